@@ -0,0 +1,100 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// defaultStatementBatchSize is the maximum number of statements in a batch when RunConfig.StatementBatchSize is zero.
+const defaultStatementBatchSize = 1000
+
+// A BatchHarness is a Harness that can execute several statements at once, in one round trip or transaction. The
+// runner executes runs of consecutive INSERT statements that expect no error in batches on such harnesses, which cuts
+// the time taken to load seed data on engines across a network. If any statement of a batch fails, the runner executes
+// the statements of the batch again one at a time to report the failure, so statements are only batched on harnesses
+// whose batches are atomic. Whether a transaction makes them atomic depends on the engine: MySQL can't roll back
+// statements on tables of non-transactional storage engines such as MyISAM.
+type BatchHarness interface {
+	Harness
+
+	// ExecuteStatements executes the statements given in order, stopping at the first that fails.
+	ExecuteStatements(ctx context.Context, statements []string) error
+	// AtomicBatches returns whether none of the statements given to ExecuteStatements take effect if any fails.
+	// Statements aren't batched on harnesses that return false.
+	AtomicBatches() bool
+}
+
+// statementBatchSize returns the maximum number of statements in a batch, or 0 if statements aren't batched for the
+// runner's harness.
+func (r *Runner) statementBatchSize() int {
+	if harness, ok := r.harness.(BatchHarness); !ok || !harness.AtomicBatches() {
+		return 0
+	}
+	// Statements executed differently from ExecuteStatement, or observed one at a time, can't be batched
+	if r.config.Reference != nil || len(r.config.Translators) > 0 || r.config.PreparedStatements ||
+		r.config.ImplicitTransactions || r.recordObserver != nil {
+		return 0
+	}
+	if r.config.StatementBatchSize == 0 {
+		return defaultStatementBatchSize
+	}
+	return r.config.StatementBatchSize
+}
+
+// isBatchable returns whether the record given can be executed in a batch: an INSERT statement expecting no error
+// on the default connection, outside any transaction, with no expectations beyond succeeding and no timeout of its own.
+func (r *Runner) isBatchable(record *parser.Record) bool {
+	if record.Type() != parser.Statement || record.ExpectError() || record.Prepared() || record.AsyncName() != "" ||
+		record.Connection() != defaultConnection || record.ExpectsWarnings() || len(record.Routes()) > 0 ||
+		len(record.MetricAssertions()) > 0 || len(record.Requires()) > 0 || record.Timeout() != 0 {
+		return false
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(record.Query())), "INSERT ") {
+		return false
+	}
+	return r.shouldExecute(r.harness, record) && !r.transactionActive(defaultConnection)
+}
+
+// executeBatch executes the statement records given in one batch and logs their success, returning whether the batch
+// succeeded. Nothing is logged for a batch that fails, which has no effect.
+func (r *Runner) executeBatch(file string, records []*parser.Record) bool {
+	ctxs := make([]context.Context, len(records))
+	statements := make([]string, len(records))
+	for i, record := range records {
+		ctx, cancel := r.newRecordContext(file, record, false)
+		defer cancel()
+		ctxs[i] = ctx
+		statements[i] = r.config.executableQuery(record)
+	}
+
+	parent := r.fileCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, r.timeout())
+	defer cancel()
+	if err := r.harness.(BatchHarness).ExecuteStatements(ctx, statements); err != nil {
+		return false
+	}
+
+	for _, ctx := range ctxs {
+		logResult(ctx, Ok, "")
+	}
+	return true
+}
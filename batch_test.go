@@ -0,0 +1,124 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchHarness records the batches of statements it executes, and the statements it executes one at a time.
+type batchHarness struct {
+	*fakeHarness
+	batchErr   error
+	nonAtomic  bool
+	batches    [][]string
+	statements []string
+}
+
+var _ BatchHarness = &batchHarness{}
+
+func (h *batchHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	h.statements = append(h.statements, statement)
+	return h.fakeHarness.ExecuteStatement(ctx, statement)
+}
+
+func (h *batchHarness) ExecuteStatements(ctx context.Context, statements []string) error {
+	h.batches = append(h.batches, statements)
+	return h.batchErr
+}
+
+func (h *batchHarness) AtomicBatches() bool {
+	return !h.nonAtomic
+}
+
+const batchTestFile = `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+statement ok
+INSERT INTO t1 VALUES(1, 2)
+
+statement ok
+insert into t1 values(3, 4)
+
+statement ok
+INSERT INTO t1 VALUES(5, 6)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+statement ok
+INSERT INTO t1 VALUES(7, 8)
+`
+
+func TestStatementBatches(t *testing.T) {
+	harness := &batchHarness{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(writeTestFile(t, batchTestFile))
+
+	assert.Equal(t, [][]string{
+		{"INSERT INTO t1 VALUES(1, 2)", "insert into t1 values(3, 4)", "INSERT INTO t1 VALUES(5, 6)"},
+	}, harness.batches)
+	assert.Equal(t, []string{"CREATE TABLE t1(a INTEGER, b INTEGER)", "INSERT INTO t1 VALUES(7, 8)"}, harness.statements)
+
+	require.Len(t, reporter.entries, 6)
+	for i, line := range []int{2, 5, 8, 11, 14, 20} {
+		assert.Equal(t, line, reporter.entries[i].LineNum)
+		assert.Equal(t, Ok, reporter.entries[i].Result)
+	}
+}
+
+func TestStatementBatchSize(t *testing.T) {
+	harness := &batchHarness{fakeHarness: newFakeHarness()}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithStatementBatchSize(2)).RunTestFiles(writeTestFile(t, batchTestFile))
+
+	assert.Equal(t, [][]string{{"INSERT INTO t1 VALUES(1, 2)", "insert into t1 values(3, 4)"}}, harness.batches)
+	assert.Equal(t, []string{
+		"CREATE TABLE t1(a INTEGER, b INTEGER)", "INSERT INTO t1 VALUES(5, 6)", "INSERT INTO t1 VALUES(7, 8)",
+	}, harness.statements)
+
+	harness = &batchHarness{fakeHarness: newFakeHarness()}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithStatementBatchSize(1)).RunTestFiles(writeTestFile(t, batchTestFile))
+	assert.Empty(t, harness.batches)
+	assert.Len(t, harness.statements, 5)
+
+	// Statements aren't batched on harnesses whose failed batches may have taken effect
+	harness = &batchHarness{fakeHarness: newFakeHarness(), nonAtomic: true}
+	NewRunner(harness, WithOutput(&bytes.Buffer{})).RunTestFiles(writeTestFile(t, batchTestFile))
+	assert.Empty(t, harness.batches)
+	assert.Len(t, harness.statements, 5)
+}
+
+func TestFailedStatementBatch(t *testing.T) {
+	harness := &batchHarness{fakeHarness: newFakeHarness(), batchErr: errors.New("deadlock")}
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(writeTestFile(t, batchTestFile))
+
+	// The statements of the failed batch are executed again one at a time
+	require.Len(t, harness.batches, 1)
+	assert.Len(t, harness.statements, 5)
+	require.Len(t, reporter.entries, 6)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A StateDependence is the outcome of bisecting the earlier records of a test file that a failing record depends on.
+type StateDependence struct {
+	TestFile string
+	LineNum  int
+	// FailureCode is the failure of the record that the earlier records must reproduce.
+	FailureCode FailureCode
+	// FailsAlone is whether the record fails without any of the earlier records, in which case it doesn't depend on
+	// their state.
+	FailsAlone bool
+	// PrefixLen is the number of earlier records in the shortest prefix of the file after which the record fails.
+	PrefixLen int
+	// Records are a minimal set of earlier records after which the record fails: without any one of them, it passes.
+	Records []*parser.Record
+	// Trials is the number of times the record was run.
+	Trials int
+}
+
+// BisectStateDependence finds the earlier records of a test file that the record on the line given depends on to
+// fail, for records that only fail when the records before them have run, e.g. because of an engine bug that leaves
+// state behind. The record is run after a binary search of the prefixes of the file for the shortest one after which
+// it fails, and then without each record of that prefix in turn, keeping the ones it doesn't fail without. Each trial
+// starts from a freshly initialized harness, and only counts as failing if the record fails with the same failure
+// code as after all of the earlier records, so that e.g. dropping the statement that creates a table isn't mistaken
+// for reproducing a wrong result. Results aren't logged to the runner's output or reporters.
+//
+// Records are identified by the line of their SQL, as in result logs. An error is returned if the test file has no
+// record on the line given, or the record doesn't fail after all of the records before it.
+func (r *Runner) BisectStateDependence(file string, line int) (*StateDependence, error) {
+	records, err := r.config.parseTestFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var earlier []*parser.Record
+	var target *parser.Record
+	for _, record := range records {
+		if record.LineNum() == line {
+			target = record
+			break
+		}
+		if record.Type() != parser.Halt {
+			earlier = append(earlier, record)
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%s: no record on line %d", file, line)
+	}
+
+	dependence := &StateDependence{TestFile: testFilePath(file), LineNum: line}
+	trial := func(prefix []*parser.Record) bool {
+		dependence.Trials++
+		result := r.runTrial(file, prefix, target)
+		return result != nil && result.Result == NotOk && result.FailureCode == dependence.FailureCode
+	}
+
+	full := r.runTrial(file, earlier, target)
+	dependence.Trials++
+	if full == nil || full.Result != NotOk {
+		return nil, fmt.Errorf("%s:%d: record doesn't fail after the records before it", file, line)
+	}
+	dependence.FailureCode = full.FailureCode
+
+	if trial(nil) {
+		dependence.FailsAlone = true
+		return dependence, nil
+	}
+
+	// The record fails after all the earlier records and passes after none, so the shortest failing prefix has
+	// between 1 and len(earlier) records
+	lo, hi := 1, len(earlier)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if trial(earlier[:mid]) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	dependence.PrefixLen = lo
+
+	// The last record of the shortest prefix is needed by definition, so only the ones before it are left out
+	needed := append([]*parser.Record(nil), earlier[:lo]...)
+	for i := len(needed) - 2; i >= 0; i-- {
+		without := append(append([]*parser.Record(nil), needed[:i]...), needed[i+1:]...)
+		if trial(without) {
+			needed = without
+		}
+	}
+	dependence.Records = needed
+	return dependence, nil
+}
+
+// runTrial runs the records given from the test file given, followed by the target record, on a freshly initialized
+// harness, and returns the result of the target record, or nil if it didn't run.
+func (r *Runner) runTrial(file string, records []*parser.Record, target *parser.Record) *ResultLogEntry {
+	var result *ResultLogEntry
+	config := r.config
+	config.Output = io.Discard
+	config.ConfirmFailures = 0
+	config.Reporters = []Reporter{reporterFunc(func(entry *ResultLogEntry) {
+		if entry.LineNum == target.LineNum() {
+			result = entry
+		}
+	})}
+	runner := &Runner{harness: r.harness, config: config, capabilities: r.capabilities}
+
+	if err := runner.initHarness(); err != nil {
+		panic(err)
+	}
+	defer runner.cleanupObjects()
+	defer runner.forgetCatalog()
+	defer runner.closeConnections()
+
+	for _, record := range append(append([]*parser.Record(nil), records...), target) {
+		ctx, cancel := runner.newRecordContext(file, record, false)
+		if res := runner.executeRecord(ctx, cancel, record); !res.cont {
+			break
+		}
+	}
+	runner.awaitAsyncStatements()
+	return result
+}
+
+// Print writes the outcome of the bisection to the writer given, with the records the failing record depends on.
+func (d *StateDependence) Print(w io.Writer) error {
+	if d.FailsAlone {
+		_, err := fmt.Fprintf(w, "%s:%d fails with %s without any earlier record (%d trials)\n", d.TestFile, d.LineNum,
+			d.FailureCode, d.Trials)
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s:%d fails with %s after the first %d records, and depends on these %d of them (%d trials):\n",
+		d.TestFile, d.LineNum, d.FailureCode, d.PrefixLen, len(d.Records), d.Trials)
+	if err != nil {
+		return err
+	}
+	for _, record := range d.Records {
+		if _, err := fmt.Fprintf(w, "%s:%d: %s\n", d.TestFile, record.LineNum(), record.Query()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pollutingHarness returns an extra row for a query after a statement that should have no effect on it.
+type pollutingHarness struct {
+	*fakeHarness
+	polluted bool
+	inits    int
+}
+
+func (h *pollutingHarness) Init() error {
+	h.polluted = false
+	h.inits++
+	return nil
+}
+
+func (h *pollutingHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	if statement == "DELETE FROM t2" {
+		h.polluted = true
+	}
+	return h.fakeHarness.ExecuteStatement(ctx, statement)
+}
+
+func (h *pollutingHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	schema, results, err := h.fakeHarness.ExecuteQuery(ctx, statement)
+	if h.polluted && statement == "SELECT a, b FROM t1" {
+		results = append(results, "3", "4")
+	}
+	return schema, results, err
+}
+
+const bisectTestFile = `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+statement ok
+CREATE TABLE t2(c INTEGER)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+statement ok
+DELETE FROM t2
+
+statement ok
+INSERT INTO t2 VALUES(1)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestBisectStateDependence(t *testing.T) {
+	path := writeTestFile(t, bisectTestFile)
+	harness := &pollutingHarness{fakeHarness: newFakeHarness()}
+	dependence, err := NewRunner(harness).BisectStateDependence(path, 20)
+	require.NoError(t, err)
+
+	assert.False(t, dependence.FailsAlone)
+	assert.Equal(t, RowCountMismatch, dependence.FailureCode)
+	assert.Equal(t, 4, dependence.PrefixLen)
+	require.Len(t, dependence.Records, 1)
+	assert.Equal(t, 14, dependence.Records[0].LineNum())
+	assert.Equal(t, harness.inits, dependence.Trials)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, dependence.Print(buf))
+	assert.Contains(t, buf.String(), "fails with RowCountMismatch after the first 4 records, and depends on these 1 of them")
+	assert.Contains(t, buf.String(), ":14: DELETE FROM t2\n")
+
+	// The first query passes after the records before it
+	_, err = NewRunner(harness).BisectStateDependence(path, 8)
+	assert.Error(t, err)
+	_, err = NewRunner(harness).BisectStateDependence(path, 3)
+	assert.Error(t, err, "there's no record on the line")
+}
+
+func TestBisectStateDependenceFailsAlone(t *testing.T) {
+	path := writeTestFile(t, bisectTestFile)
+	harness := &pollutingHarness{fakeHarness: newFakeHarness()}
+	harness.fakeHarness.queryResults["SELECT a, b FROM t1"] = fakeResult{schema: "II", results: []string{"1", "3"}}
+	dependence, err := NewRunner(harness).BisectStateDependence(path, 8)
+	require.NoError(t, err)
+	assert.True(t, dependence.FailsAlone)
+	assert.Equal(t, ValueMismatch, dependence.FailureCode)
+}
@@ -0,0 +1,153 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// blessAnswer is an answer to the prompt for accepting the new results of a record.
+type blessAnswer int
+
+const (
+	blessAccept blessAnswer = iota
+	blessSkip
+	blessQuit
+)
+
+// maxDiffCells bounds the size of the table used to diff the lines of a record. Records with larger changes are shown
+// as all of their old lines followed by all of their new ones.
+const maxDiffCells = 1 << 20
+
+// blessing returns whether generating test files prompts for accepting each change.
+func (r *Runner) blessing() bool {
+	return r.config.BlessInput != nil
+}
+
+// promptBless shows the change to the record given from the test file given, as made by the rewrite function given,
+// and asks whether to accept it. Reaching the end of the input quits.
+func (r *Runner) promptBless(testFile string, record *parser.Record, gen *generatedFile, rewrite func(*generatedFile)) blessAnswer {
+	if r.blessAnswers == nil {
+		r.blessAnswers = bufio.NewReader(r.config.BlessInput)
+	}
+	out := r.config.BlessOutput
+	if out == nil {
+		out = os.Stdout
+	}
+
+	fmt.Fprintf(out, "%s:%d: %s\n", testFile, record.LineNum(), truncateQuery(firstLine(record), true))
+	for _, line := range diffLines(gen.recordLines(record), gen.preview(record, rewrite)) {
+		fmt.Fprintln(out, line)
+	}
+
+	for {
+		fmt.Fprint(out, "Accept this change [y,n,q]? ")
+		answer, err := r.blessAnswers.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return blessAccept
+		case "n", "no":
+			return blessSkip
+		case "q", "quit":
+			return blessQuit
+		}
+		if err != nil {
+			fmt.Fprintln(out)
+			return blessQuit
+		}
+		fmt.Fprintln(out, "y - accept the new results\nn - keep the expected results\nq - keep the expected results of this "+
+			"and every remaining record, and stop")
+	}
+}
+
+// recordLines returns the lines of the test file the record given spans.
+func (g *generatedFile) recordLines(record *parser.Record) []string {
+	return g.lines[record.StartLine()-1 : record.EndLine()]
+}
+
+// preview returns the lines of the record given as rewritten by the function given, without writing them.
+func (g *generatedFile) preview(record *parser.Record, rewrite func(*generatedFile)) []string {
+	var buf bytes.Buffer
+	p := &generatedFile{lines: g.lines, next: record.StartLine(), wr: bufio.NewWriter(&buf)}
+	rewrite(p)
+	p.copyThrough(record.EndLine())
+	if err := p.flush(); err != nil {
+		panic(err)
+	}
+	return strings.Split(buf.String(), "\n")
+}
+
+// diffLines returns a diff of the lines before and after a change, with the removed lines prefixed by "-", the added
+// lines by "+", and the unchanged lines by a space.
+func diffLines(before, after []string) []string {
+	var prefix, suffix []string
+	for len(before) > 0 && len(after) > 0 && before[0] == after[0] {
+		prefix = append(prefix, " "+before[0])
+		before, after = before[1:], after[1:]
+	}
+	for len(before) > 0 && len(after) > 0 && before[len(before)-1] == after[len(after)-1] {
+		suffix = append([]string{" " + before[len(before)-1]}, suffix...)
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+
+	diff := prefix
+	if (len(before)+1)*(len(after)+1) > maxDiffCells {
+		for _, line := range before {
+			diff = append(diff, "-"+line)
+		}
+		for _, line := range after {
+			diff = append(diff, "+"+line)
+		}
+		return append(diff, suffix...)
+	}
+
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			diff = append(diff, " "+before[i])
+			i++
+			j++
+		case i < len(before) && (j == len(after) || common[i+1][j] >= common[i][j+1]):
+			diff = append(diff, "-"+before[i])
+			i++
+		default:
+			diff = append(diff, "+"+after[j])
+			j++
+		}
+	}
+	return append(diff, suffix...)
+}
@@ -0,0 +1,71 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const blessTest = `query II rowsort
+SELECT a, b FROM t1
+----
+3
+4
+
+query I
+SELECT a FROM t1 WHERE a > 1
+----
+1
+
+query II rowsort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestInteractiveBless(t *testing.T) {
+	path := writeTestFile(t, blessTest)
+	prompts := &bytes.Buffer{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}),
+		WithInteractiveBless(strings.NewReader("what\nn\ny\n"), prompts))
+	runner.GenerateTestFiles(path)
+
+	// The passing query isn't shown, and only the accepted change is written
+	assertFileContents(t, path+".generated", strings.Replace(blessTest, "query I\nSELECT a FROM t1 WHERE a > 1\n----\n1\n",
+		"query I nosort\nSELECT a FROM t1 WHERE a > 1\n----\n", 1))
+	assert.Equal(t, 3, strings.Count(prompts.String(), "Accept this change [y,n,q]? "))
+	assert.Contains(t, prompts.String(), ":2: SELECT a, b FROM t1\n query II rowsort\n SELECT a, b FROM t1\n ----\n-3\n-4\n+1\n+2\n")
+	assert.Contains(t, prompts.String(), "-query I\n+query I nosort\n SELECT a FROM t1 WHERE a > 1\n ----\n-1\n")
+
+	// Quitting, or running out of answers, keeps the rest of the file as it is
+	for _, answers := range []string{"q\n", ""} {
+		path = writeTestFile(t, blessTest)
+		runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}),
+			WithInteractiveBless(strings.NewReader(answers), &bytes.Buffer{}))
+		runner.GenerateTestFiles(path)
+		assertFileContents(t, path+".generated", blessTest)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, []string{" a", "-b", "+c", " d", "+e"}, diffLines([]string{"a", "b", "d"}, []string{"a", "c", "d", "e"}))
+	assert.Equal(t, []string{"-a", " b"}, diffLines([]string{"a", "b"}, []string{"b"}))
+	assert.Empty(t, diffLines(nil, nil))
+}
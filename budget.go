@@ -0,0 +1,69 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// A runBudget stops a run from starting test files once its configured maximum duration has passed, and records the
+// test files that were started and those that were deferred, to report them at the end of the run. Test files that
+// already started run to completion.
+type runBudget struct {
+	max   time.Duration
+	began time.Time
+	// ran and deferred are the test files started within the budget and those deferred after it ran out
+	ran, deferred []string
+}
+
+// newRunBudget returns the budget of a run starting now, which is unlimited if no maximum duration is configured.
+func (c RunConfig) newRunBudget() *runBudget {
+	return &runBudget{max: c.MaxDuration, began: time.Now()}
+}
+
+// exhausted returns whether the budget has run out.
+func (b *runBudget) exhausted() bool {
+	return b.max > 0 && time.Since(b.began) >= b.max
+}
+
+// start returns whether the test file given can start within the budget, recording it as ran or deferred.
+func (b *runBudget) start(file string) bool {
+	if b.exhausted() {
+		b.deferred = append(b.deferred, file)
+		return false
+	}
+	b.ran = append(b.ran, file)
+	return true
+}
+
+// report writes the test files that ran and those that were deferred to the writer given, if the budget ran out
+// before every test file started. Lines don't start with a timestamp, so result logs with a report can still be parsed
+// by ParseResultFile.
+func (b *runBudget) report(w io.Writer) {
+	if len(b.deferred) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Time budget of %s exhausted: ran %d test files and deferred %d\n", b.max, len(b.ran),
+		len(b.deferred))
+	for _, file := range b.ran {
+		fmt.Fprintf(w, "  ran %s\n", testFilePath(file))
+	}
+	for _, file := range b.deferred {
+		fmt.Fprintf(w, "  deferred %s\n", testFilePath(file))
+	}
+}
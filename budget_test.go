@@ -0,0 +1,78 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxDuration(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.test", "b.test", "c.test"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name),
+			[]byte("query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"), 0644))
+	}
+	harness := &slowHarness{fakeHarness: newFakeHarness(), delay: 30 * time.Millisecond}
+
+	// The first test file exhausts the budget, so the others are deferred
+	var output bytes.Buffer
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&output), WithReporters(reporter), WithMaxDuration(20*time.Millisecond)).
+		RunTestFiles(dir)
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Contains(t, output.String(), `Time budget of 20ms exhausted: ran 1 test files and deferred 2
+  ran `+testFilePath(filepath.Join(dir, "a.test"))+`
+  deferred `+testFilePath(filepath.Join(dir, "b.test"))+`
+  deferred `+testFilePath(filepath.Join(dir, "c.test"))+`
+`)
+
+	// The report doesn't get in the way of parsing the result log
+	log := filepath.Join(t.TempDir(), "results.log")
+	require.NoError(t, os.WriteFile(log, output.Bytes(), 0644))
+	entries, err := ParseResultFile(log)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Without a budget, or within it, nothing is reported
+	output.Reset()
+	NewRunner(harness, WithOutput(&output), WithMaxDuration(time.Minute)).RunTestFiles(dir)
+	assert.NotContains(t, output.String(), "Time budget")
+}
+
+func TestMaxDurationQueue(t *testing.T) {
+	dir := writeCorpus(t, "a.test", "b.test", "c.test")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.test"),
+		[]byte("query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"), 0644))
+	queue := NewQueueServer([]string{filepath.Join(dir, "a.test"), filepath.Join(dir, "b.test"),
+		filepath.Join(dir, "c.test")})
+	server := httptest.NewServer(queue)
+	defer server.Close()
+	harness := &slowHarness{fakeHarness: newFakeHarness(), delay: 30 * time.Millisecond}
+
+	// Test files left unclaimed once the budget is exhausted are left to other processes
+	var output bytes.Buffer
+	NewRunner(harness, WithOutput(&output), WithMaxDuration(20*time.Millisecond)).RunQueue(NewHTTPQueue(server.URL))
+	assert.Equal(t, 2, queue.Remaining())
+	assert.Contains(t, output.String(), "Time budget of 20ms exhausted: ran 1 test files")
+}
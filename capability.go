@@ -0,0 +1,85 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A Capability is a SQL feature that some engines don't support. Records that use such a feature declare it with a
+// require directive, and are skipped for harnesses that don't declare the capability, e.g.:
+//
+//	require window-functions
+//	query II rowsort
+//	SELECT a, ROW_NUMBER() OVER (ORDER BY a) FROM t1
+//
+// Capabilities are identified by name, so test files can require capabilities beyond the ones named by constants here.
+type Capability string
+
+const (
+	CapSubqueries       Capability = "subqueries"
+	CapCTEs             Capability = "cte"
+	CapRecursiveCTEs    Capability = "recursive-cte"
+	CapWindowFunctions  Capability = "window-functions"
+	CapFullOuterJoin    Capability = "full-outer-join"
+	CapRightJoin        Capability = "right-join"
+	CapViews            Capability = "views"
+	CapTriggers         Capability = "triggers"
+	CapStoredProcedures Capability = "stored-procedures"
+	CapTransactions     Capability = "transactions"
+	CapJSON             Capability = "json"
+	CapIntersectExcept  Capability = "intersect-except"
+	CapForeignKeys      Capability = "foreign-keys"
+	CapCheckConstraints Capability = "check-constraints"
+)
+
+// A CapabilityHarness is a Harness that declares the capabilities of its engine. Records requiring a capability the
+// harness doesn't declare are skipped. Harnesses that don't implement this interface are assumed to have every
+// capability.
+type CapabilityHarness interface {
+	Harness
+
+	// Capabilities returns the capabilities of the engine.
+	Capabilities() []Capability
+}
+
+// newCapabilitySet returns the set of capabilities declared by the harness given, or nil if it doesn't declare any.
+func newCapabilitySet(harness Harness) map[Capability]bool {
+	capabilityHarness, ok := harness.(CapabilityHarness)
+	if !ok {
+		return nil
+	}
+
+	capabilities := make(map[Capability]bool)
+	for _, c := range capabilityHarness.Capabilities() {
+		capabilities[c] = true
+	}
+	return capabilities
+}
+
+// missingCapability returns the first capability required by the record given that the harness lacks, or the empty
+// string if it has them all.
+func (r *Runner) missingCapability(record *parser.Record) Capability {
+	if r.capabilities == nil {
+		return ""
+	}
+
+	for _, required := range record.Requires() {
+		if !r.capabilities[Capability(required)] {
+			return Capability(required)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capabilityHarness supports only subqueries and CTEs.
+type capabilityHarness struct {
+	*fakeHarness
+}
+
+var _ CapabilityHarness = capabilityHarness{}
+
+func (h capabilityHarness) Capabilities() []Capability {
+	return []Capability{CapSubqueries, CapCTEs}
+}
+
+const capabilityTest = `require cte
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+require subqueries window-functions
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestCapabilities(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(capabilityHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, capabilityTest))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Skipped, reporter.entries[1].Result)
+	assert.Equal(t, "Requires unsupported capability window-functions", reporter.entries[1].ErrorMessage)
+
+	// Harnesses that don't declare capabilities are assumed to have all of them
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, capabilityTest))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+}
@@ -0,0 +1,399 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// Catalog maps the names of the tables in an engine's current schema to the names of their columns.
+type Catalog map[string][]string
+
+// A CatalogHarness is a Harness that can describe the tables in its current schema, so that the runner can verify
+// that the DDL statements of a test file had the effect they should have. See RunConfig.VerifyCatalog.
+type CatalogHarness interface {
+	Harness
+
+	// Catalog returns the base tables in the current schema and their columns. Views aren't included.
+	Catalog(ctx context.Context) (Catalog, error)
+}
+
+// ReadCatalog reads a catalog from rows of table and column names, in the order of the columns of each table, and
+// closes the rows.
+func ReadCatalog(rows *sql.Rows) (Catalog, error) {
+	defer rows.Close()
+
+	catalog := make(Catalog)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		catalog[table] = append(catalog[table], column)
+	}
+	return catalog, rows.Err()
+}
+
+// expectedTable is a table the DDL statements of the current test file should have created or dropped.
+type expectedTable struct {
+	// name is the name of the table, as written in the statement that last defined it
+	name string
+	// columns are the normalized names of the table's columns, or nil if the statements that defined them weren't
+	// understood, in which case only the table's existence is verified
+	columns []string
+	// dropped is whether the table should no longer exist
+	dropped bool
+	// record is the statement that last defined the table, which failures are reported against
+	record *parser.Record
+}
+
+var (
+	alterTableRegex  = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+(?:ONLY\s+)?(?:IF\s+EXISTS\s+)?` + objectName + `\s+(.*?)\s*;?\s*$`)
+	renameTableRegex = regexp.MustCompile(`(?is)^\s*RENAME\s+TABLES?\s+(.*?)\s*;?\s*$`)
+	renamePairRegex  = regexp.MustCompile(`(?is)^\s*` + objectName + `\s+TO\s+` + objectName + `\s*$`)
+	addColumnRegex   = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + objectName)
+	dropColumnRegex  = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?` + objectName)
+	renameToRegex    = regexp.MustCompile(`(?is)^RENAME\s+(?:TO\s+|AS\s+)?` + objectName + `$`)
+	renameColRegex   = regexp.MustCompile(`(?is)^RENAME\s+COLUMN\s+` + objectName + `\s+TO\s+` + objectName)
+	changeColRegex   = regexp.MustCompile(`(?is)^CHANGE\s+(?:COLUMN\s+)?` + objectName + `\s+` + objectName)
+	selectRegex      = regexp.MustCompile(`(?i)\bSELECT\b`)
+)
+
+// constraintKeywords begin the definitions in a CREATE TABLE or ALTER TABLE statement that aren't columns.
+var constraintKeywords = map[string]bool{
+	"PRIMARY": true, "KEY": true, "INDEX": true, "UNIQUE": true, "CONSTRAINT": true, "FOREIGN": true,
+	"CHECK": true, "FULLTEXT": true, "SPATIAL": true, "EXCLUDE": true, "PARTITION": true,
+}
+
+// trackCatalog updates the tables the current test file should have created after the statement record given executed
+// successfully, if the runner verifies the catalog. Like trackObjects, statements are recognized by their text.
+// Temporary tables and tables qualified with a schema aren't tracked, since they needn't be in the current schema.
+func (r *Runner) trackCatalog(record *parser.Record) {
+	if !r.config.VerifyCatalog || record.Type() != parser.Statement {
+		return
+	}
+
+	query := record.Query()
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+
+	if m := createTableRegex.FindStringSubmatch(query); m != nil {
+		header := strings.ToUpper(m[0])
+		if strings.Contains(header, "TEMPORARY") {
+			return
+		}
+		existing := r.expectedTable(m[1])
+		if strings.Contains(header, "EXISTS") && (existing == nil || !existing.dropped) {
+			// the table may have existed before the file ran, with different columns
+			if existing == nil {
+				r.defineTable(m[1], nil, record)
+			}
+			return
+		}
+		r.defineTable(m[1], createTableColumns(query[len(m[0]):]), record)
+	} else if m := dropRegex.FindStringSubmatch(query); m != nil && strings.EqualFold(m[1], "TABLE") {
+		for _, name := range strings.Split(m[2], ",") {
+			r.dropTable(name, record)
+		}
+	} else if m := renameTableRegex.FindStringSubmatch(query); m != nil {
+		for _, pair := range splitDefinitions(m[1]) {
+			if p := renamePairRegex.FindStringSubmatch(pair); p != nil {
+				r.renameTable(p[1], p[2], record)
+			}
+		}
+	} else if m := alterTableRegex.FindStringSubmatch(query); m != nil {
+		r.alterTable(m[1], m[2], record)
+	}
+}
+
+// expectedTable returns the tracked table with the name given, or nil if it isn't tracked. stateMux must be held.
+func (r *Runner) expectedTable(name string) *expectedTable {
+	for _, table := range r.catalog {
+		if normalizeObjectName(table.name) == normalizeObjectName(name) {
+			return table
+		}
+	}
+	return nil
+}
+
+// defineTable records that the table given should exist with the columns given. stateMux must be held.
+func (r *Runner) defineTable(name string, columns []string, record *parser.Record) {
+	name = strings.TrimSpace(name)
+	if strings.Contains(normalizeObjectName(name), ".") {
+		return
+	}
+	if table := r.expectedTable(name); table != nil {
+		table.name, table.columns, table.dropped, table.record = name, columns, false, record
+		return
+	}
+	r.catalog = append(r.catalog, &expectedTable{name: name, columns: columns, record: record})
+}
+
+// dropTable records that the table given should no longer exist. stateMux must be held.
+func (r *Runner) dropTable(name string, record *parser.Record) {
+	r.defineTable(name, nil, record)
+	if table := r.expectedTable(name); table != nil {
+		table.dropped = true
+	}
+}
+
+// renameTable records that the table given should have been renamed. stateMux must be held.
+func (r *Runner) renameTable(from, to string, record *parser.Record) {
+	var columns []string
+	if table := r.expectedTable(from); table != nil && !table.dropped {
+		columns = table.columns
+	}
+	r.dropTable(from, record)
+	r.defineTable(to, columns, record)
+}
+
+// alterTable records the changes to the columns of the table given made by the ALTER TABLE specifications given.
+// Specifications that don't add, drop or rename columns are ignored. stateMux must be held.
+func (r *Runner) alterTable(name, specs string, record *parser.Record) {
+	table := r.expectedTable(name)
+	if table == nil || table.dropped {
+		// the columns of tables created before the file ran are unknown, so only renames are tracked
+		if m := renameToRegex.FindStringSubmatch(strings.TrimSpace(specs)); m != nil {
+			r.renameTable(name, m[1], record)
+		}
+		return
+	}
+
+	columns := table.columns
+	for _, spec := range splitDefinitions(specs) {
+		keyword := strings.ToUpper(firstWord(spec))
+		second := strings.ToUpper(firstWord(strings.TrimSpace(spec[len(keyword):])))
+		switch {
+		case keyword == "ADD" && constraintKeywords[second]:
+		case keyword == "ADD" && strings.HasPrefix(strings.TrimSpace(spec[len(keyword):]), "("):
+			// MySQL's ADD (column, ...) form
+			columns = nil
+		case keyword == "ADD":
+			if m := addColumnRegex.FindStringSubmatch(spec); m != nil && columns != nil {
+				columns = append(columns, normalizeObjectName(m[1]))
+			}
+		case keyword == "DROP" && constraintKeywords[second]:
+		case keyword == "DROP":
+			if m := dropColumnRegex.FindStringSubmatch(spec); m != nil {
+				columns = removeColumn(columns, normalizeObjectName(m[1]))
+			}
+		case keyword == "RENAME":
+			if m := renameColRegex.FindStringSubmatch(spec); m != nil {
+				columns = replaceColumn(columns, normalizeObjectName(m[1]), normalizeObjectName(m[2]))
+			} else if m := renameToRegex.FindStringSubmatch(spec); m != nil {
+				table.columns, table.record = columns, record
+				r.renameTable(name, m[1], record)
+				table = r.expectedTable(m[1])
+				name, columns = m[1], table.columns
+			}
+		case keyword == "CHANGE":
+			if m := changeColRegex.FindStringSubmatch(spec); m != nil {
+				columns = replaceColumn(columns, normalizeObjectName(m[1]), normalizeObjectName(m[2]))
+			}
+		}
+	}
+	table.columns, table.record = columns, record
+}
+
+// createTableColumns returns the normalized names of the columns defined by the remainder of a CREATE TABLE statement
+// following the table name, or nil if they can't be determined, e.g. for CREATE TABLE ... AS SELECT or LIKE.
+func createTableColumns(definition string) []string {
+	definition = strings.TrimSpace(definition)
+	if !strings.HasPrefix(definition, "(") {
+		return nil
+	}
+	end := closingParen(definition)
+	if end < 0 || selectRegex.MatchString(definition[end:]) {
+		return nil
+	}
+
+	columns := []string{}
+	for _, def := range splitDefinitions(definition[1:end]) {
+		word := firstWord(def)
+		if strings.EqualFold(word, "LIKE") {
+			return nil
+		}
+		if word == "" || constraintKeywords[strings.ToUpper(word)] {
+			continue
+		}
+		columns = append(columns, normalizeObjectName(word))
+	}
+	return columns
+}
+
+// closingParen returns the index of the parenthesis closing the one that begins the string given, or -1 if it isn't
+// closed.
+func closingParen(s string) int {
+	depth := 0
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitDefinitions splits the string given at the commas that aren't in parentheses or quotes, trimming each part.
+func splitDefinitions(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// firstWord returns the first word of the string given, which ends at whitespace or a parenthesis.
+func firstWord(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t\r\n("); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// removeColumn returns the columns given without the column named.
+func removeColumn(columns []string, column string) []string {
+	if columns == nil {
+		return nil
+	}
+	remaining := []string{}
+	for _, c := range columns {
+		if c != column {
+			remaining = append(remaining, c)
+		}
+	}
+	return remaining
+}
+
+// replaceColumn returns the columns given with the column named from renamed to the one named to.
+func replaceColumn(columns []string, from, to string) []string {
+	if columns == nil {
+		return nil
+	}
+	replaced := make([]string, len(columns))
+	for i, c := range columns {
+		if c == from {
+			c = to
+		}
+		replaced[i] = c
+	}
+	return replaced
+}
+
+// forgetCatalog stops tracking the tables of the current test file.
+func (r *Runner) forgetCatalog() {
+	r.stateMux.Lock()
+	r.catalog = nil
+	r.stateMux.Unlock()
+}
+
+// verifyCatalog compares the catalog of the harness to the tables the DDL statements of the test file given should
+// have created, altered and dropped, logging a failure against the statement that last defined each table that
+// differs. Columns are compared regardless of their order. Harnesses that don't implement CatalogHarness aren't
+// verified.
+func (r *Runner) verifyCatalog(testFile string) error {
+	r.stateMux.Lock()
+	tables := r.catalog
+	r.catalog = nil
+	r.stateMux.Unlock()
+
+	harness, ok := r.harness.(CatalogHarness)
+	if !ok || len(tables) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	catalog, err := harness.Catalog(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read catalog: %w", err)
+	}
+
+	actual := make(map[string][]string, len(catalog))
+	for table, columns := range catalog {
+		normalized := make([]string, len(columns))
+		for i, column := range columns {
+			normalized[i] = normalizeObjectName(column)
+		}
+		sort.Strings(normalized)
+		actual[normalizeObjectName(table)] = normalized
+	}
+
+	var firstErr error
+	for _, table := range tables {
+		columns, exists := actual[normalizeObjectName(table.name)]
+		var format string
+		var args []interface{}
+		switch {
+		case table.dropped && exists:
+			format, args = "Expected table %s to be dropped but it exists", []interface{}{table.name}
+		case !table.dropped && !exists:
+			format, args = "Expected table %s to exist but it doesn't", []interface{}{table.name}
+		case !table.dropped && table.columns != nil:
+			expected := append([]string(nil), table.columns...)
+			sort.Strings(expected)
+			if strings.Join(expected, ",") != strings.Join(columns, ",") {
+				format, args = "Expected columns %v of table %s but got %v", []interface{}{expected, table.name, columns}
+			}
+		}
+		if format == "" {
+			continue
+		}
+
+		recordCtx, cancel := r.newRecordContext(testFile, table.record, false)
+		logFailure(recordCtx, CatalogMismatch, format, args...)
+		cancel()
+		if firstErr == nil {
+			firstErr = fmt.Errorf(strings.ToLower(format[:1])+format[1:], args...)
+		}
+	}
+	return firstErr
+}
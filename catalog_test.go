@@ -0,0 +1,119 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogHarness is a harness whose catalog is fixed, regardless of the statements it executes.
+type catalogHarness struct {
+	*fakeHarness
+	catalog Catalog
+}
+
+var _ CatalogHarness = &catalogHarness{}
+
+func (h *catalogHarness) Catalog(ctx context.Context) (Catalog, error) {
+	return h.catalog, nil
+}
+
+func TestTrackCatalog(t *testing.T) {
+	r := NewRunner(newFakeHarness(), WithCatalogVerification(true))
+	for _, statement := range []string{
+		"CREATE TABLE t1(a INTEGER, `b` VARCHAR(10) DEFAULT 'x,y', PRIMARY KEY (a, b), KEY (b))",
+		"CREATE TABLE t2(a INTEGER)",
+		"CREATE TEMPORARY TABLE t3(a INTEGER)",
+		"CREATE TABLE s.t4(a INTEGER)",
+		"CREATE TABLE t5 AS SELECT a FROM t1",
+		"CREATE TABLE IF NOT EXISTS t6(a INTEGER)",
+		"CREATE TABLE IF NOT EXISTS t1(c INTEGER)",
+		"ALTER TABLE t1 ADD COLUMN c INTEGER, ADD INDEX i1 (c), DROP COLUMN a, RENAME COLUMN b TO d",
+		"ALTER TABLE t1 CHANGE c e BIGINT",
+		"ALTER TABLE t2 RENAME TO t7",
+		"RENAME TABLE t7 TO t8",
+		"DROP TABLE IF EXISTS t5, t9",
+		"INSERT INTO t1 VALUES (1, 2)",
+	} {
+		r.trackCatalog(statementRecord(t, statement))
+	}
+
+	var tables []expectedTable
+	for _, table := range r.catalog {
+		tables = append(tables, expectedTable{name: table.name, columns: table.columns, dropped: table.dropped})
+	}
+	assert.Equal(t, []expectedTable{
+		{name: "t1", columns: []string{"d", "e"}},
+		{name: "t2", dropped: true},
+		{name: "t5", dropped: true},
+		{name: "t6"},
+		{name: "t7", dropped: true},
+		{name: "t8", columns: []string{"a"}},
+		{name: "t9", dropped: true},
+	}, tables)
+
+	// Tables aren't tracked unless the catalog is verified
+	r = NewRunner(newFakeHarness())
+	r.trackCatalog(statementRecord(t, "CREATE TABLE t1(a INTEGER)"))
+	assert.Empty(t, r.catalog)
+}
+
+const catalogTest = `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+statement ok
+CREATE TABLE t2(a INTEGER)
+
+statement ok
+ALTER TABLE t1 ADD COLUMN c INTEGER
+
+statement ok
+DROP TABLE t2
+`
+
+func TestVerifyCatalog(t *testing.T) {
+	reporter := &collectingReporter{}
+	harness := &catalogHarness{fakeHarness: newFakeHarness(), catalog: Catalog{"T1": {"C", "a", "b"}, "t3": {"a"}}}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithCatalogVerification(true))
+	runner.RunTestFiles(writeTestFile(t, catalogTest))
+	assert.Len(t, reporter.entries, 4)
+	assert.Empty(t, runner.catalog)
+
+	// The ALTER TABLE silently failed and the DROP TABLE silently succeeded without effect
+	reporter = &collectingReporter{}
+	harness.catalog = Catalog{"t1": {"a", "b"}, "t2": {"a"}}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithCatalogVerification(true))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, catalogTest))
+	})
+	require.Len(t, reporter.entries, 6)
+	assert.Equal(t, 8, reporter.entries[4].LineNum)
+	assert.Equal(t, CatalogMismatch, reporter.entries[4].FailureCode)
+	assert.Equal(t, "Expected columns [a b c] of table t1 but got [a b]", reporter.entries[4].ErrorMessage)
+	assert.Equal(t, 11, reporter.entries[5].LineNum)
+	assert.Equal(t, "Expected table t2 to be dropped but it exists", reporter.entries[5].ErrorMessage)
+	assert.Equal(t, CatalogMismatch, classifyFailure(reporter.entries[5].ErrorMessage))
+
+	// Harnesses that can't describe their catalog aren't verified
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithCatalogVerification(true))
+	runner.RunTestFiles(writeTestFile(t, catalogTest))
+	assert.Len(t, reporter.entries, 4)
+}
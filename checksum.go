@@ -0,0 +1,78 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// executeTableChecksum reads the full contents of the table of the table-checksum record given from the harness given,
+// and verifies their checksum, logging any failure. The checksum is returned for generating test files.
+func (r *Runner) executeTableChecksum(ctx context.Context, harness Harness, record *parser.Record) *R {
+	schema, results, err := harness.ExecuteQuery(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{cont: true, err: err}
+	}
+
+	checksum, err := r.config.tableChecksum(schema, results)
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Error hashing results: %v", err)
+		return &R{cont: true, err: err}
+	}
+
+	if !isGenerating(ctx) && checksum != record.Checksum() {
+		logFailure(ctx, ChecksumMismatch, "Expected checksum %s of table %s but got %s", record.Checksum(),
+			record.Table(), checksum)
+		return &R{cont: true, err: fmt.Errorf("expected checksum %s of table %s but got %s", record.Checksum(),
+			record.Table(), checksum)}
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true, checksum: checksum}
+}
+
+// tableChecksum returns the MD5 checksum of the contents of a table, given as a flat list of values with the schema
+// given. Rows are hashed in a canonical order, sorted by their values as strings column by column, so that the
+// checksum doesn't depend on the order the engine returns them in.
+func (c *RunConfig) tableChecksum(schema string, results []string) (string, error) {
+	if len(schema) == 0 || len(results)%len(schema) != 0 {
+		return "", fmt.Errorf("%d values don't form rows of %d columns", len(results), len(schema))
+	}
+
+	numCols := len(schema)
+	rows := make([][]string, len(results)/numCols)
+	for i := range rows {
+		rows[i] = c.normalizeResults(results[i*numCols:(i+1)*numCols], schema)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		for c := range rows[i] {
+			if rows[i][c] != rows[j][c] {
+				return rows[i][c] < rows[j][c]
+			}
+		}
+		return false
+	})
+
+	values := make([]string, 0, len(results))
+	for _, row := range rows {
+		values = append(values, row...)
+	}
+	return hashResults(MD5, values)
+}
@@ -0,0 +1,91 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableChecksum(t *testing.T) {
+	config := DefaultRunConfig()
+	sorted, err := config.tableChecksum("IT", []string{"1", "a", "2", "b"})
+	require.NoError(t, err)
+	unsorted, err := config.tableChecksum("IT", []string{"2", "b", "1", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, sorted, unsorted)
+
+	other, err := config.tableChecksum("IT", []string{"1", "b", "2", "a"})
+	require.NoError(t, err)
+	assert.NotEqual(t, sorted, other)
+
+	_, err = config.tableChecksum("IT", []string{"1", "a", "2"})
+	assert.Error(t, err)
+}
+
+// checksumHarness returns the rows of table t1 out of order.
+func checksumHarness() *fakeHarness {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT * FROM t1"] = fakeResult{schema: "II", results: []string{"3", "4", "1", "2"}}
+	return harness
+}
+
+// t1Checksum is the checksum of the rows of t1 returned by checksumHarness, in order.
+const t1Checksum = "302c28003d487124d97c242de94da856"
+
+func TestTableChecksumRecords(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(checksumHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "statement ok\nINSERT INTO t1 VALUES (1, 2)\n\ntable-checksum t1 "+t1Checksum+"\n"))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(checksumHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "table-checksum t1 00000000000000000000000000000000\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ChecksumMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Expected checksum 00000000000000000000000000000000 of table t1 but got "+t1Checksum,
+		reporter.entries[0].ErrorMessage)
+	assert.Equal(t, ChecksumMismatch, classifyFailure(reporter.entries[0].ErrorMessage))
+}
+
+func TestGenerateTableChecksum(t *testing.T) {
+	test := "table-checksum t1\n\nstatement ok\nINSERT INTO t1 VALUES (1, 2)\n\n# the checksum after the insert\ntable-checksum t1 stale\n\nskipif fake\ntable-checksum t1 kept\n"
+	path := writeTestFile(t, test)
+	runner := NewRunner(checksumHarness(), WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, "table-checksum t1 "+t1Checksum+"\n\nstatement ok\nINSERT INTO t1 VALUES (1, 2)\n\n# the checksum after the insert\ntable-checksum t1 "+t1Checksum+"\n\nskipif fake\ntable-checksum t1 kept\n", string(generated))
+}
+
+func TestRegenerateFailingTableChecksum(t *testing.T) {
+	test := "table-checksum   t1 " + t1Checksum + "\n\ntable-checksum t1 stale\n"
+	path := writeTestFile(t, test)
+	runner := NewRunner(checksumHarness(), WithOutput(&bytes.Buffer{}), WithRegenerateFailing(true))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, "table-checksum   t1 "+t1Checksum+"\n\ntable-checksum t1 "+t1Checksum+"\n", string(generated))
+}
@@ -0,0 +1,166 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// ObjectKind is the kind of a schema object created by a test file.
+type ObjectKind string
+
+const (
+	// ObjectTable is a table, including temporary tables.
+	ObjectTable ObjectKind = "TABLE"
+	// ObjectView is a view.
+	ObjectView ObjectKind = "VIEW"
+	// ObjectIndex is an index, which belongs to a table.
+	ObjectIndex ObjectKind = "INDEX"
+)
+
+// SchemaObject is a schema object created by a test file, as written in the statement that created it.
+type SchemaObject struct {
+	Kind ObjectKind
+	// Name is the name of the object, including any schema qualifier and quotes it was written with.
+	Name string
+	// Table is the table of an index, as written in the statement that created it.
+	Table string
+}
+
+// An ObjectCleanupHarness is a Harness that drops the objects created by a test file itself, for engines whose
+// dialect can't drop them with the MySQL statements the runner uses otherwise. See RunConfig.CleanupObjects.
+type ObjectCleanupHarness interface {
+	Harness
+
+	// DropObject drops the object given if it still exists.
+	DropObject(ctx context.Context, object SchemaObject) error
+}
+
+// objectName matches a possibly qualified and quoted object name
+const objectName = "((?:[`\"\\[]?[\\w$]+[`\"\\]]?\\.)?[`\"\\[]?[\\w$]+[`\"\\]]?)"
+
+var (
+	createTableRegex = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:TEMPORARY\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + objectName)
+	createViewRegex  = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?` +
+		`(?:(?:ALGORITHM|DEFINER|SQL\s+SECURITY)\s*=?\s*\S+\s+)*VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?` + objectName)
+	createIndexRegex = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:UNIQUE\s+|FULLTEXT\s+|SPATIAL\s+)?INDEX\s+` +
+		`(?:IF\s+NOT\s+EXISTS\s+)?` + objectName + `\s+(?:USING\s+\w+\s+)?ON\s+` + objectName)
+	dropRegex      = regexp.MustCompile(`(?i)^\s*DROP\s+(?:TEMPORARY\s+)?(TABLE|VIEW)\s+(?:IF\s+EXISTS\s+)?(.*?)\s*(?:CASCADE|RESTRICT)?\s*;?\s*$`)
+	dropIndexRegex = regexp.MustCompile(`(?i)^\s*DROP\s+INDEX\s+(?:IF\s+EXISTS\s+)?` + objectName)
+)
+
+// normalizeObjectName returns the name given without quotes and in lower case, for comparing names written
+// differently.
+func normalizeObjectName(name string) string {
+	return strings.ToLower(strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(strings.TrimSpace(name)))
+}
+
+// sameObject returns whether the objects given are the same object.
+func sameObject(a, b SchemaObject) bool {
+	return a.Kind == b.Kind && normalizeObjectName(a.Name) == normalizeObjectName(b.Name)
+}
+
+// trackObjects updates the objects created by the current test file after the statement record given executed
+// successfully, if the runner cleans them up. Statements are recognized by their text, so objects created or dropped
+// in other ways, such as by stored procedures or renames, aren't tracked.
+func (r *Runner) trackObjects(record *parser.Record) {
+	if !r.config.CleanupObjects || record.Type() != parser.Statement {
+		return
+	}
+
+	query := record.Query()
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+
+	var created *SchemaObject
+	if m := createTableRegex.FindStringSubmatch(query); m != nil {
+		created = &SchemaObject{Kind: ObjectTable, Name: m[1]}
+	} else if m := createViewRegex.FindStringSubmatch(query); m != nil {
+		created = &SchemaObject{Kind: ObjectView, Name: m[1]}
+	} else if m := createIndexRegex.FindStringSubmatch(query); m != nil {
+		created = &SchemaObject{Kind: ObjectIndex, Name: m[1], Table: m[2]}
+	} else if m := dropRegex.FindStringSubmatch(query); m != nil {
+		for _, name := range strings.Split(m[2], ",") {
+			r.forgetObject(SchemaObject{Kind: ObjectKind(strings.ToUpper(m[1])), Name: name})
+		}
+	} else if m := dropIndexRegex.FindStringSubmatch(query); m != nil {
+		r.forgetObject(SchemaObject{Kind: ObjectIndex, Name: m[1]})
+	}
+
+	if created != nil {
+		// CREATE OR REPLACE and CREATE IF NOT EXISTS can name an object that's already tracked
+		for _, object := range r.objects {
+			if sameObject(object, *created) {
+				return
+			}
+		}
+		r.objects = append(r.objects, *created)
+	}
+}
+
+// forgetObject stops tracking the object given, and the indexes of a table. stateMux must be held.
+func (r *Runner) forgetObject(dropped SchemaObject) {
+	objects := r.objects[:0]
+	for _, object := range r.objects {
+		if sameObject(object, dropped) ||
+			(dropped.Kind == ObjectTable && object.Kind == ObjectIndex &&
+				normalizeObjectName(object.Table) == normalizeObjectName(dropped.Name)) {
+			continue
+		}
+		objects = append(objects, object)
+	}
+	r.objects = objects
+}
+
+// cleanupObjects drops the objects created by the current test file that still exist, in the reverse order of their
+// creation so that views are dropped before the tables they select from. Cleanup is best effort: errors are ignored,
+// since objects may have been dropped or renamed in ways that weren't tracked.
+func (r *Runner) cleanupObjects() {
+	r.stateMux.Lock()
+	objects := r.objects
+	r.objects = nil
+	r.stateMux.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	for i := len(objects) - 1; i >= 0; i-- {
+		r.dropObject(ctx, objects[i])
+	}
+}
+
+// dropObject drops the object given, with the harness if it's an ObjectCleanupHarness and otherwise by executing a
+// MySQL DROP statement, translated by the runner's translators.
+func (r *Runner) dropObject(ctx context.Context, object SchemaObject) error {
+	if harness, ok := r.harness.(ObjectCleanupHarness); ok {
+		return harness.DropObject(ctx, object)
+	}
+
+	statement := fmt.Sprintf("DROP %s IF EXISTS %s", object.Kind, object.Name)
+	if object.Kind == ObjectIndex {
+		statement = fmt.Sprintf("DROP INDEX %s ON %s", object.Name, object.Table)
+	}
+	for _, t := range r.config.Translators {
+		var err error
+		if statement, err = t.Translate(statement); err != nil {
+			return err
+		}
+	}
+	return r.harness.ExecuteStatement(ctx, statement)
+}
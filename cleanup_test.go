@@ -0,0 +1,96 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statementRecord returns a statement record for the statement given.
+func statementRecord(t *testing.T, statement string) *parser.Record {
+	records, err := parser.ParseTestFile(writeTestFile(t, "statement ok\n"+statement+"\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	return records[0]
+}
+
+func TestTrackObjects(t *testing.T) {
+	r := NewRunner(newFakeHarness(), WithObjectCleanup(true))
+	for _, statement := range []string{
+		"CREATE TABLE t1(a INTEGER, b INTEGER)",
+		"create temporary table if not exists `s`.`t2` (a int)",
+		"CREATE TABLE t3(a INTEGER)",
+		"CREATE UNIQUE INDEX i1 ON t1(a)",
+		"CREATE INDEX i3 USING BTREE ON t3(a)",
+		"CREATE OR REPLACE ALGORITHM=MERGE VIEW v1 AS SELECT a FROM t1",
+		"CREATE VIEW v2 AS SELECT a FROM t1",
+		"CREATE TABLE IF NOT EXISTS T1(a INTEGER)",
+		"DROP VIEW v2",
+		"DROP TABLE IF EXISTS t3, t4",
+		"INSERT INTO t1 VALUES (1, 2)",
+	} {
+		r.trackObjects(statementRecord(t, statement))
+	}
+
+	assert.Equal(t, []SchemaObject{
+		{Kind: ObjectTable, Name: "t1"},
+		{Kind: ObjectTable, Name: "`s`.`t2`"},
+		{Kind: ObjectIndex, Name: "i1", Table: "t1"},
+		{Kind: ObjectView, Name: "v1"},
+	}, r.objects)
+
+	// Objects aren't tracked unless they're cleaned up
+	r = NewRunner(newFakeHarness())
+	r.trackObjects(statementRecord(t, "CREATE TABLE t1(a INTEGER)"))
+	assert.Empty(t, r.objects)
+}
+
+func TestObjectCleanup(t *testing.T) {
+	harness := &statementRecorder{fakeHarness: newFakeHarness()}
+	harness.statementErrors["CREATE TABLE missing(a INTEGER)"] = errors.New("already exists")
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithObjectCleanup(true))
+	runner.RunTestFiles(writeTestFile(t, `statement ok
+CREATE TABLE t1(a INTEGER)
+
+statement ok
+CREATE INDEX i1 ON t1(a)
+
+statement ok
+CREATE VIEW v1 AS SELECT a FROM t1
+
+statement error
+CREATE TABLE missing(a INTEGER)
+`))
+
+	require.Len(t, reporter.entries, 4)
+	assert.Equal(t, []string{
+		"CREATE TABLE t1(a INTEGER)",
+		"CREATE INDEX i1 ON t1(a)",
+		"CREATE VIEW v1 AS SELECT a FROM t1",
+		"CREATE TABLE missing(a INTEGER)",
+		"DROP VIEW IF EXISTS v1",
+		"DROP INDEX i1 ON t1",
+		"DROP TABLE IF EXISTS t1",
+	}, harness.statements)
+	assert.Empty(t, runner.objects)
+}
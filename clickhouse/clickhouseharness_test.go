@@ -0,0 +1,83 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaType(t *testing.T) {
+	tests := map[string]byte{
+		"Int32":                            'I',
+		"Nullable(UInt64)":                 'I',
+		"Bool":                             'I',
+		"Nullable(Nothing)":                'I',
+		"Float64":                          'R',
+		"Nullable(Decimal(10, 2))":         'R',
+		"String":                           'T',
+		"LowCardinality(Nullable(String))": 'T',
+		"Date":                             'T',
+		"DateTime64(3)":                    'T',
+	}
+
+	for typeName, expected := range tests {
+		assert.Equal(t, string(expected), string(schemaType(typeName)), typeName)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	f := logictest.NewValueFormatter()
+	assert.Equal(t, "2.250", formatValue(f, "2.25", 'R', "Decimal(10, 2)"))
+	assert.Equal(t, "2.25", formatValue(f, "2.25", 'T', "String"))
+	assert.Equal(t, "-5", formatValue(f, int32(-5), 'I', "Int32"))
+	assert.Equal(t, "18446744073709551615", formatValue(f, uint64(18446744073709551615), 'I', "UInt64"))
+	assert.Equal(t, "1", formatValue(f, true, 'I', "Bool"))
+	assert.Equal(t, "1.500", formatValue(f, float32(1.5), 'R', "Float32"))
+	assert.Equal(t, "NULL", formatValue(f, nil, 'I', "Nullable(Int32)"))
+	assert.Equal(t, "170141183460469231731687303715884105727", formatValue(f, new(big.Int).SetBytes([]byte{
+		0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), 'I', "Int128"))
+
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, "2020-01-02", formatValue(f, tm, 'T', "Nullable(Date32)"))
+	assert.Equal(t, "2020-01-02 03:04:05", formatValue(f, tm, 'T', "DateTime"))
+}
+
+func TestOptions(t *testing.T) {
+	opts, err := options("clickhouse://default@127.0.0.1:9000/sqllogictest?join_use_nulls=0")
+	require.NoError(t, err)
+	assert.Equal(t, "sqllogictest", opts.Auth.Database)
+	assert.Equal(t, "Memory", opts.Settings["default_table_engine"])
+	assert.EqualValues(t, 1, opts.Settings["data_type_default_nullable"])
+	assert.EqualValues(t, 0, opts.Settings["join_use_nulls"])
+
+	// Settings in one harness's DSN don't leak into the defaults
+	assert.EqualValues(t, 1, compatibilitySettings["join_use_nulls"])
+}
+
+func TestClassifyError(t *testing.T) {
+	h := &ClickHouseHarness{}
+	assert.Equal(t, logictest.ErrorClass{Category: logictest.CategoryNotFound}, h.ClassifyError(&clickhouse.Exception{Code: 60}))
+	assert.Equal(t, logictest.ErrorClass{Category: logictest.CategorySyntax}, h.ClassifyError(&clickhouse.Exception{Code: 62}))
+	assert.Equal(t, logictest.ErrorClass{}, h.ClassifyError(&clickhouse.Exception{Code: 999}))
+	assert.Equal(t, logictest.ErrorClass{}, h.ClassifyError(errors.New("connection refused")))
+}
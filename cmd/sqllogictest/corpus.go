@@ -0,0 +1,50 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/andyyu2004/sqllogictest/corpus"
+)
+
+// fetchCorpus fetches the corpus of the source given into the cache directory given, or the default one if it's
+// empty, and returns the paths given resolved against the corpus's root, or the root alone if none are given.
+func fetchCorpus(source, checksum, cacheDir string, paths []string) ([]string, error) {
+	src, err := corpus.ParseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	src.SHA256 = checksum
+
+	fetcher, err := corpus.NewFetcher(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	root, err := fetcher.Fetch(context.Background(), src)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return []string{root}, nil
+	}
+	resolved := make([]string, len(paths))
+	for i, path := range paths {
+		resolved[i] = filepath.Join(root, path)
+	}
+	return resolved, nil
+}
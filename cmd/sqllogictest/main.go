@@ -0,0 +1,991 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// General purpose test runner, for running test files against any engine without writing Go. The engine is chosen with
+// --harness: one of the harnesses of this module, which connect as the engine's own runner does; sql, which runs
+// records with any database/sql driver linked into this binary (mysql, pgx, sqlite3, duckdb or clickhouse); plugin,
+// which starts an external process speaking the line protocol of the subprocess package; or remote, which connects
+// to a server of the gRPC service of the remote package.
+//
+// Thirteen commands, controlled by the first argument:
+//
+//	run: Runs the test files given, outputting a pass / fail line to STDOUT for each test record. All other arguments
+//	  are interpreted as test files or directories, which are descended recursively for files with the .test extension,
+//	  or .test.gz and .test.zst for compressed ones.
+//	verify: Runs tests as run does, except that failures don't stop the run, and exits with status 1 and the number of
+//	  failed test records if any failed, for use in CI.
+//	generate: Runs tests as run does, but also produces a new version of each test file, named $testfile.generated,
+//	  with the results of this test run.
+//	flaky: Runs the test files given several times, as run does except that failures don't stop the run, and prints the
+//	  records whose outcome or results varied across runs, with each outcome and a sample of its results, to isolate
+//	  nondeterministic engine behavior. Exits with status 1 if any record is flaky.
+//	bisect: Finds the earlier records of a test file that a failing record depends on to fail, given as file:line with
+//	  the line of its SQL, as in result logs. Prints the shortest prefix of the file after which the record fails, and a
+//	  minimal set of its records, to diagnose engine bugs that leave state behind. Accepts the options of run.
+//	parse: Parses the test files given without running them, printing the number of records in each file, or the
+//	  error that prevented parsing it, followed by warnings about problems that don't prevent parsing it, such as queries
+//	  with several rows of results that give no sort mode. Exits with status 1 if any file couldn't be parsed, but not
+//	  for warnings.
+//	validate: Parses the test files given and checks their records for inconsistencies without running them, such as
+//	  expected results that don't fit the schema of their query, or aren't in the order of its sort mode. Prints every
+//	  problem found, and exits with status 1 if there are any, for use as a pre-commit check of corpus changes.
+//	list: Lists the test files given with the number of records of each, broken down into statements, queries with
+//	  hashed results and queries with enumerated results, and the engines named by the skipif and onlyif conditions of
+//	  its records, with the number of records naming each. Totals are listed last. With --json, the list is written as
+//	  a JSON array instead.
+//	features: Classifies the queries of the test files given by the SQL features they use, such as joins, aggregates
+//	  and subqueries, and prints the number of queries using each, to find the features a corpus doesn't cover. With
+//	  --results, the arguments are instead the results of runs, as result logs or JSON reports, optionally prefixed with
+//	  the engine they ran against (ENGINE=PATH), and a compatibility matrix of the pass rate of each feature on each
+//	  engine is printed.
+//	compare: Compares the results of two runs, given as result logs or JSON reports in the order the runs happened, and
+//	  prints the records that are newly failing, newly passing and newly skipped. With --slowdown=FACTOR, records whose
+//	  duration grew or shrank by at least that factor are printed too, if they took at least --min-duration=DURATION in
+//	  either run. Exits with status 1 if any record is newly failing, for use in a nightly regression check.
+//	trend: Prints the pass rate of each run in the results store in the directory given, oldest first, optionally only
+//	  the runs against an engine (--engine=ENGINE) or version of it (--version=VERSION). Runs are added to a results
+//	  store by the run and verify commands with --store=DIR, and --engine-version=VERSION to record the engine version.
+//	fmt: Rewrites the test files given in canonical form and prints the files it changed. With --check, files are left
+//	  as they are, and the command exits with status 1 if any isn't in canonical form.
+//	serve-queue: Serves the test files given over HTTP on the address given by --addr=ADDR (:8080 by default) until it's
+//	  interrupted, for the run and verify commands of processes on other machines to claim with --queue=URL.
+//
+// Test files can be given as glob patterns, e.g. 'test/select*.test', which are expanded to the files and directories
+// they match. Directories are walked following symlinks, leaving out the paths ignored by any .sltignore file in them,
+// which lists gitignore-style patterns. Commands exit with an error for paths that don't exist and patterns that match
+// nothing.
+//
+// The run, verify and generate commands accept options before the test files:
+//
+//	--harness=NAME: The harness to run records with, sqlite by default.
+//	--dsn=DSN: The data source name of the engine, the command line of a plugin, or the address of a remote server.
+//	  Builtin harnesses read it from the same environment variable as the engine's own runner when not given.
+//	--driver=NAME: The database/sql driver of the sql harness.
+//	--engine=NAME: The engine string that skipif and onlyif conditions are evaluated against.
+//	--timeout=DURATION: The timeout for executing each record, e.g. 30s.
+//	--float-epsilon=EPSILON: Compares R-typed values numerically, equal if they differ by no more than the relative
+//	  tolerance given, e.g. 1e-9, for records that don't give their own with a float-epsilon directive.
+//	--strict-schemas: Fails queries with integer columns where their schema expects R, unless they're preceded by an
+//	  ambiguous-schema directive.
+//	--truncate-queries: Truncates long queries in the result log, as does setting the SQLLOGICTEST_TRUNCATE_QUERIES
+//	  environment variable.
+//	--bisect-hashes: Reports the first diverging row of queries whose results hash differently than expected, as
+//	  located by the checkpoint hashes that follow their hash lines.
+//	--bisect-reference=HARNESS: Locates the first diverging row of hash mismatches without checkpoint hashes by
+//	  replaying the test file on the builtin harness given, with its default data source name, and enumerating its
+//	  results for the query. Implies --bisect-hashes.
+//	--batch-size=N: The maximum number of consecutive INSERT statements executed at once on harnesses that support it,
+//	  1000 by default. 1 executes every statement on its own.
+//	--spill-threshold=BYTES: Writes the results of queries larger than the size given to temporary files to verify them,
+//	  on harnesses that stream results, rather than holding them in memory.
+//	--normalize=NORMALIZER,...: Normalizes expected and actual values before comparing them, with any of the
+//	  normalizers trim-trailing-zeros, collapse-whitespace and lowercase-keywords, in the order given.
+//	--normalize-errors=NORMALIZER,...: Normalizes the text of errors before matching it against the patterns of
+//	  expect-error-message directives, with any of the normalizers strip-object-ids, strip-line-numbers and
+//	  strip-addresses, in the order given.
+//	--normalize-queries=NORMALIZER,...: Normalizes the text of statements and queries as written with any of the
+//	  normalizers strip-comments, join-lines and collapse-newlines, in the order given, instead of stripping comments
+//	  and joining lines. With an empty list, e.g. --normalize-queries=, they're executed verbatim, so that engines can
+//	  see comments such as optimizer hints.
+//	--large-value-threshold=BYTES: Truncates text and binary values longer than the size given, in results verified
+//	  and generated alike, or hashes them with --large-value-policy=hash.
+//	--cache-preludes: Restores the leading CREATE and INSERT statements of test files from the state saved after an
+//	  earlier file with the same ones, on harnesses that support it, instead of executing them again.
+//	--parallel=N: Runs N test files concurrently, each on its own harness. Any {i} in the data source name is replaced
+//	  with the index of the harness, so that each can be given its own database. Not supported by generate.
+//	--stress=N: Runs each test file on N harnesses at once, each with its own database as with --parallel, to shake
+//	  out races in the engine. Implies --parallel=N unless a higher parallelism is given.
+//	--shard=I/N: Only runs the test files of the Ith of N shards of the files found, e.g. --shard=3/10, to spread a
+//	  corpus across CI machines. Files are assigned to shards by a hash of their paths.
+//	--shard-by-size: Balances shards by the total size of their files instead.
+//	--exclude=PATTERN: Leaves out the test files matched by the glob pattern given, e.g. --exclude='slow_*.test', or
+//	  all the files under directories named by it. Can be given more than once.
+//	--corpus=SOURCE: Fetches the corpus of test files from the archive at the URL given, or the git ref of a GitHub
+//	  repository given as github:owner/name@ref, e.g. github:gregrahn/sqllogictest@master, and caches it locally.
+//	  The paths given are then relative to the root of the corpus, which is run entirely if none are given.
+//	--corpus-sha256=CHECKSUM: The SHA-256 checksum the corpus's archive must have.
+//	--corpus-cache=DIR: The directory corpora are cached in, sqllogictest in the user's cache directory by default.
+//	--config=FILE: Reads the paths to run and any of the options above from a YAML config file, along with condition
+//	  engines, reporters and expected failures; see logictest.ConfigFile. Options given as flags take precedence, and
+//	  the paths in the file are only run when none are given.
+//
+// The run and verify commands also accept --store=DIR, which adds the results of the run to the results store in the
+// directory given, --engine-version=VERSION, the version of the engine to record with them, and --html=FILE, which
+// writes an HTML report of the run to the file given, e.g. to publish as a CI artifact, and --metrics-addr=ADDR, which
+// serves Prometheus metrics of the records run, failed and their durations at /metrics on the address given while the
+// run lasts; see the metrics package. With --manifest=FILE, they run the test files listed in the manifest file given
+// in its order, with its per-file timeout and engine overrides, instead of test files given as arguments; see
+// logictest.Manifest. With --queue=FILE or --queue=URL, they run the test files they claim from a work queue shared
+// with other processes, so that a corpus is spread across machines without sharding it: a queue file on a shared file
+// system, created from the test files given by the first process to run, or the URL of a serve-queue command. With
+// --max-duration=DURATION, e.g. 30m, they stop starting test files once the duration given has passed, and list the
+// test files that ran and those that were deferred, for smoke tests of large corpora within a CI time limit. With
+// --confirm-failures=N, each failing record is rerun N times after the records before it in its test file once the
+// file has run, and reported as a consistent or flaky failure; the run command still stops at its first failure. With --warmup=M, the first
+// M queries of each test file are executed once without verification before they're run and timed, and with
+// --warmup-script=FILE, the queries of the SQL script given are executed before each test file, to warm up engines
+// that compile or cache plans.
+//
+// The flaky command also accepts --iterations=N, the number of times to run the test files (10 by default), --shuffle,
+// which runs them in a different random order each time, and --seed=N, which seeds the order.
+//
+// The generate command also accepts the options of generating test files shared by every runner of this module
+// (--in-place, --backup, --output-dir=DIR, --failing-only, --interactive, --column-names and --fix-schemas, which only
+// rewrites the schemas of queries to the column types the engine returns, e.g. against a reference engine); see
+// logictest.ParseGenerateOptions. It also accepts --filter, which leaves out the records that fail from the generated
+// files.
+//
+// Usage: sqllogictest (run|verify|generate|flaky|bisect|parse|validate|list|features|compare|trend|fmt|serve-queue) [options] [path1 path2 ...]
+func main() {
+	if len(os.Args) < 2 {
+		exitWithUsage()
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "run", "verify", "generate", "flaky", "bisect":
+		runCommand(command, args)
+	case "parse":
+		parseCommand(args)
+	case "list":
+		listCommand(args)
+	case "validate":
+		validateCommand(args)
+	case "features":
+		featuresCommand(args)
+	case "compare":
+		compareCommand(args)
+	case "trend":
+		trendCommand(args)
+	case "fmt":
+		fmtCommand(args)
+	case "serve-queue":
+		serveQueueCommand(args)
+	default:
+		exitWithUsage()
+	}
+}
+
+// runCommand runs the run, verify and generate commands with the arguments given.
+func runCommand(command string, args []string) {
+	var harnessOpts harnessOptions
+	var opts []logictest.RunOption
+	if _, ok := os.LookupEnv("SQLLOGICTEST_TRUNCATE_QUERIES"); ok {
+		opts = append(opts, logictest.WithTruncateQueries(true))
+	}
+	var configPath string
+	var parallelism, stressCopies int
+	var shard logictest.Shard
+	var filter bool
+	// generateArgs are the options of the generate command parsed by logictest.ParseGenerateOptions
+	var generateArgs []string
+	var storeDir, engineVersion, htmlPath, metricsAddr, manifestPath, queueSource string
+	var corpusSource, corpusChecksum, corpusCache string
+	var largeValueThreshold int
+	var largeValuePolicy logictest.LargeValuePolicy
+	flakeOpts := logictest.FlakeOptions{Iterations: 10}
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		arg := args[0]
+		args = args[1:]
+
+		name, value, _ := strings.Cut(arg, "=")
+		switch name {
+		case "--config":
+			configPath = value
+			continue
+		case "--harness":
+			harnessOpts.name = value
+			continue
+		case "--dsn":
+			harnessOpts.dsn = value
+			continue
+		case "--driver":
+			harnessOpts.driver = value
+			continue
+		case "--engine":
+			harnessOpts.engine = value
+			opts = append(opts, logictest.WithEngine(value))
+			continue
+		case "--timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				exitWithError(fmt.Errorf("invalid timeout %q: %w", value, err))
+			}
+			opts = append(opts, logictest.WithTimeout(timeout))
+			continue
+		case "--float-epsilon":
+			epsilon, err := strconv.ParseFloat(value, 64)
+			if err != nil || epsilon < 0 {
+				exitWithError(fmt.Errorf("invalid float epsilon %q", value))
+			}
+			opts = append(opts, logictest.WithFloatEpsilon(epsilon))
+			continue
+		case "--batch-size":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				exitWithError(fmt.Errorf("invalid batch size %q", value))
+			}
+			opts = append(opts, logictest.WithStatementBatchSize(n))
+			continue
+		case "--spill-threshold":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 1 {
+				exitWithError(fmt.Errorf("invalid spill threshold %q", value))
+			}
+			opts = append(opts, logictest.WithSpillThreshold(n))
+			continue
+		case "--large-value-threshold":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				exitWithError(fmt.Errorf("invalid large value threshold %q", value))
+			}
+			largeValueThreshold = n
+			continue
+		case "--large-value-policy":
+			policy, err := logictest.ParseLargeValuePolicy(value)
+			if err != nil {
+				exitWithError(err)
+			}
+			largeValuePolicy = policy
+			continue
+		case "--normalize":
+			for _, name := range strings.Split(value, ",") {
+				normalizer, err := logictest.LookupNormalizer(name)
+				if err != nil {
+					exitWithError(err)
+				}
+				opts = append(opts, logictest.WithNormalizers(normalizer))
+			}
+			continue
+		case "--normalize-errors":
+			for _, name := range strings.Split(value, ",") {
+				normalizer, err := logictest.LookupErrorNormalizer(name)
+				if err != nil {
+					exitWithError(err)
+				}
+				opts = append(opts, logictest.WithErrorNormalizers(normalizer))
+			}
+			continue
+		case "--normalize-queries":
+			var normalizers []logictest.QueryNormalizer
+			for _, name := range strings.Split(value, ",") {
+				if name == "" {
+					continue
+				}
+				normalizer, err := logictest.LookupQueryNormalizer(name)
+				if err != nil {
+					exitWithError(err)
+				}
+				normalizers = append(normalizers, normalizer)
+			}
+			opts = append(opts, logictest.WithQueryNormalizers(normalizers...))
+			continue
+		case "--strict-schemas":
+			opts = append(opts, logictest.WithStrictSchemas(true))
+			continue
+		case "--truncate-queries":
+			opts = append(opts, logictest.WithTruncateQueries(true))
+			continue
+		case "--bisect-hashes":
+			opts = append(opts, logictest.WithHashBisection(true))
+			continue
+		case "--bisect-reference":
+			reference, _, err := newHarness(harnessOptions{name: value})
+			if err != nil {
+				exitWithError(err)
+			}
+			opts = append(opts, logictest.WithHashBisection(true), logictest.WithHashBisectionReference(reference))
+			continue
+		case "--exclude":
+			if _, err := filepath.Match(value, ""); err != nil {
+				exitWithError(fmt.Errorf("invalid pattern %s: %w", value, err))
+			}
+			opts = append(opts, logictest.WithExcludes(value))
+			continue
+		case "--cache-preludes":
+			opts = append(opts, logictest.WithPreludeCache(true))
+			continue
+		case "--corpus":
+			corpusSource = value
+			continue
+		case "--corpus-sha256":
+			corpusChecksum = value
+			continue
+		case "--corpus-cache":
+			corpusCache = value
+			continue
+		case "--shard":
+			parsed, err := logictest.ParseShard(value)
+			if err != nil {
+				exitWithError(err)
+			}
+			shard.Index, shard.Count = parsed.Index, parsed.Count
+			continue
+		case "--shard-by-size":
+			shard.BySize = true
+			continue
+		case "--parallel":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				exitWithError(fmt.Errorf("invalid parallelism %q", value))
+			}
+			parallelism = n
+			continue
+		case "--stress":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 2 {
+				exitWithError(fmt.Errorf("invalid number of stress copies %q", value))
+			}
+			stressCopies = n
+			continue
+		}
+
+		if command == "flaky" {
+			switch name {
+			case "--iterations":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 2 {
+					exitWithError(fmt.Errorf("invalid iterations %q", value))
+				}
+				flakeOpts.Iterations = n
+			case "--shuffle":
+				flakeOpts.Shuffle = true
+			case "--seed":
+				seed, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					exitWithError(fmt.Errorf("invalid seed %q: %w", value, err))
+				}
+				flakeOpts.Seed = seed
+			default:
+				exitWithUsage()
+			}
+			continue
+		}
+		if command != "generate" {
+			switch name {
+			case "--store":
+				storeDir = value
+			case "--engine-version":
+				engineVersion = value
+			case "--html":
+				htmlPath = value
+			case "--metrics-addr":
+				metricsAddr = value
+			case "--manifest":
+				manifestPath = value
+			case "--queue":
+				queueSource = value
+			case "--warmup":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 {
+					exitWithError(fmt.Errorf("invalid number of warm-up queries %q", value))
+				}
+				opts = append(opts, logictest.WithWarmupRecords(n))
+			case "--warmup-script":
+				queries, err := logictest.ReadSetupScript(value)
+				if err != nil {
+					exitWithError(err)
+				}
+				opts = append(opts, logictest.WithWarmupQueries(queries...))
+			case "--confirm-failures":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 {
+					exitWithError(fmt.Errorf("invalid number of reruns %q", value))
+				}
+				opts = append(opts, logictest.WithConfirmFailures(n))
+			case "--max-duration":
+				maxDuration, err := time.ParseDuration(value)
+				if err != nil || maxDuration <= 0 {
+					exitWithError(fmt.Errorf("invalid max duration %q", value))
+				}
+				opts = append(opts, logictest.WithMaxDuration(maxDuration))
+			default:
+				exitWithUsage()
+			}
+			continue
+		}
+		if name == "--filter" {
+			filter = true
+		} else {
+			generateArgs = append(generateArgs, arg)
+		}
+	}
+	if len(generateArgs) > 0 {
+		generateOpts, rest, err := logictest.ParseGenerateOptions(generateArgs)
+		if err != nil {
+			exitWithError(err)
+		} else if len(rest) > 0 {
+			exitWithUsage()
+		}
+		opts = append(opts, generateOpts...)
+	}
+	if largeValuePolicy != "" && largeValueThreshold == 0 {
+		exitWithUsage()
+	} else if largeValueThreshold > 0 {
+		opts = append(opts, logictest.WithLargeValues(largeValueThreshold, largeValuePolicy))
+	}
+
+	// Options given as flags take precedence over the ones in the config file, so its run options are applied first
+	var reporterFiles io.Closer
+	if configPath != "" {
+		config, err := logictest.LoadConfigFile(configPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		configOpts, closer, err := config.RunOptions()
+		if err != nil {
+			exitWithError(err)
+		}
+		reporterFiles = closer
+		opts = append(configOpts, opts...)
+
+		harnessOpts = harnessOpts.withDefaults(harnessOptions{
+			name:   config.Harness,
+			dsn:    config.DSN,
+			driver: config.Driver,
+			engine: config.Engine,
+		})
+		if parallelism == 0 {
+			parallelism = config.Parallelism
+		}
+		if len(args) == 0 && manifestPath == "" {
+			args = config.Paths
+		}
+	}
+	harnessOpts = harnessOpts.withDefaults(harnessOptions{name: "sqlite"})
+	if stressCopies > 0 {
+		parallelism = max(parallelism, stressCopies)
+		opts = append(opts, logictest.WithStressCopies(stressCopies))
+	}
+	if corpusSource != "" {
+		paths, err := fetchCorpus(corpusSource, corpusChecksum, corpusCache, args)
+		if err != nil {
+			exitWithError(err)
+		}
+		args = paths
+	}
+	var manifest *logictest.Manifest
+	if manifestPath != "" {
+		if len(args) > 0 || corpusSource != "" || parallelism > 1 || command == "bisect" {
+			exitWithError(fmt.Errorf("--manifest can't be combined with test file paths, --corpus, --parallel or bisect"))
+		}
+		var err error
+		manifest, err = logictest.ReadManifest(manifestPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		args = manifest.Paths()
+	}
+	var queue logictest.WorkQueue
+	if queueSource != "" {
+		if manifestPath != "" || shard.Count > 0 || parallelism > 1 || command == "bisect" {
+			exitWithError(fmt.Errorf("--queue can't be combined with --manifest, --shard, --parallel or bisect"))
+		}
+		queue = openQueue(queueSource, args)
+	}
+	if len(args) == 0 && queue == nil {
+		exitWithUsage()
+	}
+	if parallelism > 1 && command == "generate" {
+		exitWithError(fmt.Errorf("test files can't be generated in parallel"))
+	}
+	if parallelism > 1 && (command == "flaky" || command == "bisect") {
+		exitWithError(fmt.Errorf("the %s command can't run in parallel", command))
+	}
+	if command == "bisect" && len(args) != 1 {
+		exitWithUsage()
+	}
+	if command != "bisect" {
+		if _, err := logictest.FindTestFiles(args...); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if shard.Count > 0 {
+		opts = append(opts, logictest.WithShard(shard))
+	}
+	if command == "flaky" {
+		opts = append(opts, logictest.WithOutput(io.Discard))
+	}
+	failures := &failureCounter{}
+	var flakyRecords int
+	if command == "verify" {
+		// Failures don't stop the run, so that every failure is reported and counted for the exit status
+		opts = append(opts, logictest.WithReporters(failures), logictest.WithContinueOnFailure(true))
+	}
+	if metricsAddr != "" {
+		reporter, err := serveMetrics(metricsAddr)
+		if err != nil {
+			exitWithError(err)
+		}
+		opts = append(opts, logictest.WithReporters(reporter))
+	}
+	var htmlReport *logictest.HTMLReporter
+	if htmlPath != "" {
+		file, err := os.Create(htmlPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		defer file.Close()
+		htmlReport = logictest.NewHTMLReporter(file)
+		opts = append(opts, logictest.WithReporters(htmlReport))
+	}
+	var results *resultCollector
+	if storeDir != "" {
+		results = &resultCollector{}
+		opts = append(opts, logictest.WithReporters(results))
+	}
+
+	if parallelism > 1 {
+		pool, runOpts, err := newHarnessPool(parallelism, harnessOpts)
+		if err != nil {
+			exitWithError(err)
+		}
+		logictest.NewParallelRunner(pool, append(runOpts, opts...)...).RunTestFiles(args...)
+		pool.Close()
+	} else {
+		harness, runOpts, err := newHarness(harnessOpts)
+		if err != nil {
+			exitWithError(err)
+		}
+		runner := logictest.NewRunner(harness, append(runOpts, opts...)...)
+		switch {
+		case command == "bisect":
+			bisect(runner, args[0])
+		case command == "flaky":
+			flaky := runner.DetectFlakes(flakeOpts, args...)
+			if err := logictest.PrintFlakes(os.Stdout, flaky); err != nil {
+				exitWithError(err)
+			}
+			flakyRecords = len(flaky)
+		case manifest != nil:
+			runner.RunManifest(manifest)
+		case queue != nil:
+			runner.RunQueue(queue)
+		case command != "generate":
+			runner.RunTestFiles(args...)
+		case filter:
+			runner.GenerateTestFilesWithFailedTestsExcluded(args...)
+		default:
+			runner.GenerateTestFiles(args...)
+		}
+	}
+
+	if reporterFiles != nil {
+		if err := reporterFiles.Close(); err != nil {
+			exitWithError(err)
+		}
+	}
+	if htmlReport != nil {
+		if err := htmlReport.Close(); err != nil {
+			exitWithError(err)
+		}
+	}
+	if results != nil {
+		store, err := logictest.OpenResultsStore(storeDir)
+		if err != nil {
+			exitWithError(err)
+		}
+		engine := harnessOpts.engine
+		if engine == "" {
+			engine = harnessOpts.name
+		}
+		run := &logictest.StoredRun{Engine: engine, Version: engineVersion, Results: results.entries}
+		if err := store.AddRun(run); err != nil {
+			exitWithError(err)
+		}
+	}
+	if failures.count > 0 {
+		fmt.Fprintf(os.Stderr, "%d test records failed\n", failures.count)
+		os.Exit(1)
+	}
+	if flakyRecords > 0 {
+		os.Exit(1)
+	}
+}
+
+// bisect bisects the state dependence of the failing record at the file:line location given.
+func bisect(runner *logictest.Runner, location string) {
+	i := strings.LastIndex(location, ":")
+	if i < 0 {
+		exitWithUsage()
+	}
+	line, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		exitWithError(fmt.Errorf("invalid line number in %q", location))
+	}
+
+	dependence, err := runner.BisectStateDependence(location[:i], line)
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := dependence.Print(os.Stdout); err != nil {
+		exitWithError(err)
+	}
+}
+
+// failureCounter is a reporter that counts failed records.
+type failureCounter struct {
+	count int
+}
+
+// See Reporter.Report
+func (c *failureCounter) Report(entry *logictest.ResultLogEntry) {
+	if entry.Result == logictest.NotOk {
+		c.count++
+	}
+}
+
+// resultCollector is a reporter that keeps every result, to add the run to a results store.
+type resultCollector struct {
+	mu      sync.Mutex
+	entries []*logictest.ResultLogEntry
+}
+
+// See Reporter.Report
+func (c *resultCollector) Report(entry *logictest.ResultLogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+}
+
+// parseCommand runs the parse command with the arguments given.
+func parseCommand(args []string) {
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	paths, err := logictest.FindTestFiles(args...)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	failed := false
+	for _, path := range paths {
+		records, warnings, err := parser.ParseTestFileWithWarnings(path)
+		if err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %d records\n", path, len(records))
+		for _, warning := range warnings {
+			fmt.Printf("%s:%d: warning: %s\n", path, warning.Line, warning.Message)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// validateCommand runs the validate command with the arguments given.
+func validateCommand(args []string) {
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	if _, err := logictest.FindTestFiles(args...); err != nil {
+		exitWithError(err)
+	}
+
+	problems := logictest.ValidateTestFiles(args...)
+	files := make(map[string]bool)
+	for _, problem := range problems {
+		fmt.Println(problem)
+		files[problem.File] = true
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems in %d test files\n", len(problems), len(files))
+		os.Exit(1)
+	}
+}
+
+// listCommand runs the list command with the arguments given.
+func listCommand(args []string) {
+	asJSON := len(args) > 0 && args[0] == "--json"
+	if asJSON {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	summaries, err := logictest.SummarizeTestFiles(args...)
+	if err != nil {
+		exitWithError(err)
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summaries); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "file\trecords\tstatements\tqueries\thashed\tenumerated\tengines")
+	total := logictest.FileSummary{Path: "total"}
+	for _, summary := range summaries {
+		writeSummary(w, summary)
+		total.Add(summary)
+	}
+	writeSummary(w, total)
+	w.Flush()
+}
+
+// writeSummary writes a line of the list command for the summary given.
+func writeSummary(w io.Writer, summary logictest.FileSummary) {
+	var engines []string
+	for engine, n := range summary.Engines {
+		engines = append(engines, fmt.Sprintf("%s=%d", engine, n))
+	}
+	sort.Strings(engines)
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n", summary.Path, summary.Records, summary.Statements, summary.Queries,
+		summary.HashedQueries, summary.EnumeratedQueries, strings.Join(engines, ","))
+}
+
+// featuresCommand runs the features command with the arguments given.
+func featuresCommand(args []string) {
+	results := len(args) > 0 && args[0] == "--results"
+	if results {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	if results {
+		var coverages []logictest.FeatureCoverage
+		for _, arg := range args {
+			engine, path, ok := strings.Cut(arg, "=")
+			if !ok {
+				path = arg
+				engine = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+			entries, err := logictest.ReadResults(path)
+			if err != nil {
+				exitWithError(err)
+			}
+			coverages = append(coverages, logictest.NewFeatureCoverage(engine, entries))
+		}
+		if err := logictest.PrintCompatibilityMatrix(os.Stdout, coverages); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	stats, err := logictest.AnalyzeFeatures(args...)
+	if err != nil {
+		exitWithError(err)
+	}
+	stats.Print(os.Stdout)
+}
+
+// compareCommand runs the compare command with the arguments given.
+func compareCommand(args []string) {
+	var opts logictest.CompareOptions
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, _ := strings.Cut(args[0], "=")
+		args = args[1:]
+
+		var err error
+		switch name {
+		case "--slowdown":
+			opts.SlowdownFactor, err = strconv.ParseFloat(value, 64)
+		case "--min-duration":
+			opts.MinDuration, err = time.ParseDuration(value)
+		default:
+			exitWithUsage()
+		}
+		if err != nil {
+			exitWithError(fmt.Errorf("invalid value for %s: %w", name, err))
+		}
+	}
+	if len(args) != 2 {
+		exitWithUsage()
+	}
+
+	before, err := logictest.ReadResults(args[0])
+	if err != nil {
+		exitWithError(err)
+	}
+	after, err := logictest.ReadResults(args[1])
+	if err != nil {
+		exitWithError(err)
+	}
+
+	comparison := logictest.CompareRuns(before, after, opts)
+	if err := comparison.Print(os.Stdout); err != nil {
+		exitWithError(err)
+	}
+	if comparison.HasRegressions() {
+		os.Exit(1)
+	}
+}
+
+// openQueue returns the work queue at the URL or path given. A queue file that doesn't exist yet is created with the
+// test files found under the paths given, and one that does is used as it is, while paths must be given to create it.
+func openQueue(source string, paths []string) logictest.WorkQueue {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return logictest.NewHTTPQueue(source)
+	}
+
+	if len(paths) == 0 {
+		queue, err := logictest.OpenFileQueue(source)
+		if err != nil {
+			exitWithError(err)
+		}
+		return queue
+	}
+	files, err := logictest.FindTestFiles(paths...)
+	if err != nil {
+		exitWithError(err)
+	}
+	queue, err := logictest.CreateFileQueue(source, files)
+	if err != nil {
+		exitWithError(err)
+	}
+	return queue
+}
+
+// serveQueueCommand runs the serve-queue command with the arguments given.
+func serveQueueCommand(args []string) {
+	addr := ":8080"
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, _ := strings.Cut(args[0], "=")
+		args = args[1:]
+
+		switch name {
+		case "--addr":
+			addr = value
+		default:
+			exitWithUsage()
+		}
+	}
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	files, err := logictest.FindTestFiles(args...)
+	if err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Serving %d test files on %s\n", len(files), addr)
+	exitWithError(http.ListenAndServe(addr, logictest.NewQueueServer(files)))
+}
+
+// trendCommand runs the trend command with the arguments given.
+func trendCommand(args []string) {
+	var engine, version string
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, _ := strings.Cut(args[0], "=")
+		args = args[1:]
+
+		switch name {
+		case "--engine":
+			engine = value
+		case "--version":
+			version = value
+		default:
+			exitWithUsage()
+		}
+	}
+	if len(args) != 1 {
+		exitWithUsage()
+	}
+
+	store, err := logictest.OpenResultsStore(args[0])
+	if err != nil {
+		exitWithError(err)
+	}
+	trend, err := store.Trend(engine, version)
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := logictest.PrintTrend(os.Stdout, trend); err != nil {
+		exitWithError(err)
+	}
+}
+
+// fmtCommand runs the fmt command with the arguments given.
+func fmtCommand(args []string) {
+	check := len(args) > 0 && args[0] == "--check"
+	if check {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	changed, err := logictest.FormatTestFiles(check, args...)
+	if err != nil {
+		exitWithError(err)
+	}
+	for _, path := range changed {
+		fmt.Println(path)
+	}
+	if check && len(changed) > 0 {
+		os.Exit(1)
+	}
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func exitWithUsage() {
+	fmt.Printf("Usage: sqllogictest (run|verify|generate|flaky) [--config=FILE] [--harness=%s] [--dsn=DSN] [--driver=NAME] "+
+		"[--engine=NAME] [--timeout=DURATION] [--strict-schemas] [--batch-size=N] [--cache-preludes] "+
+		"[--spill-threshold=BYTES] "+
+		"[--normalize=NORMALIZER,...] [--normalize-errors=NORMALIZER,...] "+
+		"[--normalize-queries=[NORMALIZER,...]] "+
+		"[--large-value-threshold=BYTES [--large-value-policy=truncate|hash]] "+
+		"[--corpus=SOURCE [--corpus-sha256=CHECKSUM] [--corpus-cache=DIR]] [--parallel=N] [--stress=N] "+
+		"[--shard=I/N [--shard-by-size]] "+
+		"[--exclude=PATTERN ...] "+
+		"[generate options] [path1 path2 ...]\n", harnessNames())
+	fmt.Println("       sqllogictest (run|verify) [run options] --manifest=FILE")
+	fmt.Println("       sqllogictest (run|verify) [run options] --queue=(FILE|URL) [path1 path2 ...]")
+	fmt.Println("       sqllogictest serve-queue [--addr=ADDR] path1 [path2 ...]")
+	fmt.Println("       sqllogictest bisect [run options] file:line")
+	fmt.Println("       sqllogictest (parse|validate|features) path1 [path2 ...]")
+	fmt.Println("       sqllogictest features --results [engine1=]results1 [[engine2=]results2 ...]")
+	fmt.Println("       sqllogictest list [--json] path1 [path2 ...]")
+	fmt.Println("       sqllogictest compare [--slowdown=FACTOR [--min-duration=DURATION]] before.log after.log")
+	fmt.Println("       sqllogictest trend [--engine=ENGINE] [--version=VERSION] dir")
+	fmt.Println("       sqllogictest fmt [--check] path1 [path2 ...]")
+	os.Exit(1)
+}
@@ -0,0 +1,122 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commandEnv is set for the test binary to run as the command, with the arguments it's given.
+const commandEnv = "SQLLOGICTEST_TEST_COMMAND"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(commandEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command with the arguments given in a subprocess, returning its output and exit status.
+func runMain(t *testing.T, args ...string) (string, int) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), commandEnv+"=1")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out.String(), exitErr.ExitCode()
+	}
+	require.NoError(t, err)
+	return out.String(), 0
+}
+
+const passingTest = `statement ok
+CREATE TABLE t1(a INTEGER)
+
+statement ok
+INSERT INTO t1 VALUES(1)
+
+query I nosort
+SELECT a FROM t1
+----
+1
+`
+
+const failingTest = `statement ok
+CREATE TABLE t1(a INTEGER)
+
+statement ok
+INSERT INTO missing VALUES(1)
+
+query I nosort
+SELECT 1
+----
+2
+
+query I nosort
+SELECT 3
+----
+3
+`
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	passing := filepath.Join(dir, "passing.test")
+	failing := filepath.Join(dir, "failing.test")
+	require.NoError(t, os.WriteFile(passing, []byte(passingTest), 0644))
+	require.NoError(t, os.WriteFile(failing, []byte(failingTest), 0644))
+
+	out, status := runMain(t, "verify", passing)
+	assert.Equal(t, 0, status, out)
+
+	// Every record of a failing file runs, and the failures are counted rather than crashing the command
+	out, status = runMain(t, "verify", failing, passing)
+	assert.Equal(t, 1, status, out)
+	assert.NotContains(t, out, "panic")
+	assert.Contains(t, out, "2 test records failed")
+	assert.Equal(t, 5, strings.Count(out, " ok\n"), out)
+}
+
+func TestGenerateOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stale.test")
+	stale := "query I\nSELECT 1\n----\n2\n"
+	require.NoError(t, os.WriteFile(path, []byte(stale), 0644))
+
+	out, status := runMain(t, "generate", "--in-place", "--backup", "--failing-only", path)
+	require.Equal(t, 0, status, out)
+
+	generated, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "query I nosort\nSELECT 1\n----\n1\n", string(generated))
+	backup, err := os.ReadFile(filepath.Join(filepath.Dir(path), "stale.test.bak"))
+	require.NoError(t, err)
+	assert.Equal(t, stale, string(backup))
+
+	_, status = runMain(t, "generate", "--bogus", path)
+	assert.Equal(t, 1, status)
+}
@@ -0,0 +1,177 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// isGlobPattern returns whether the path given is a glob pattern rather than a literal path.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandPattern returns the paths matched by the path given with the glob function given if it's a glob pattern, or
+// just the path given otherwise. Returns an error for malformed patterns and patterns that match nothing, which are
+// most likely mistyped.
+func expandPattern(path string, glob func(pattern string) ([]string, error)) ([]string, error) {
+	if !isGlobPattern(path) {
+		return []string{path}, nil
+	}
+
+	matches, err := glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", path, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no test files match %s", path)
+	}
+	return matches, nil
+}
+
+// excludeTestFiles returns the test files given, in order, leaving out those matched by any of the configured exclude
+// patterns.
+func (c RunConfig) excludeTestFiles(files []string) []string {
+	if len(c.Excludes) == 0 {
+		return files
+	}
+
+	var included []string
+	for _, file := range files {
+		excluded := false
+		for _, pattern := range c.Excludes {
+			if isExcluded(pattern, file) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			included = append(included, file)
+		}
+	}
+	return included
+}
+
+// isExcluded returns whether the exclude pattern given matches the path given, or any of its trailing parts or those of
+// its directories, so that select*.test excludes test/select1.test and evidence excludes the files under any directory
+// named evidence. Patterns are matched against slash-separated paths. Malformed patterns match nothing.
+func isExcluded(pattern, file string) bool {
+	elems := strings.Split(filepath.ToSlash(file), "/")
+	for end := len(elems); end > 0; end-- {
+		for start := 0; start < end; start++ {
+			if matched, _ := path.Match(pattern, strings.Join(elems[start:end], "/")); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// A testFileWalker collects the test files under directories, on disk or in an fs.FS. Directories are walked in
+// lexical order, skipping the files and directories ignored by the ignore files in the directories walked, and
+// following symlinks to directories. Each directory is only walked the first time it's reached, so that symlinks
+// that loop back to a directory being walked don't recurse forever.
+type testFileWalker struct {
+	readDir  func(dir string) ([]fs.DirEntry, error)
+	readFile func(path string) ([]byte, error)
+	stat     func(path string) (fs.FileInfo, error)
+	join     func(elem ...string) string
+	// walked are the directories walked so far
+	walked []fs.FileInfo
+	files  []string
+}
+
+// newTestFileWalker returns a testFileWalker for directories on disk.
+func newTestFileWalker() *testFileWalker {
+	return &testFileWalker{readDir: os.ReadDir, readFile: os.ReadFile, stat: os.Stat, join: filepath.Join}
+}
+
+// newTestFileWalkerFS returns a testFileWalker for directories in the file system given.
+func newTestFileWalkerFS(fsys fs.FS) *testFileWalker {
+	return &testFileWalker{
+		readDir:  func(dir string) ([]fs.DirEntry, error) { return fs.ReadDir(fsys, dir) },
+		readFile: func(path string) ([]byte, error) { return fs.ReadFile(fsys, path) },
+		stat:     func(path string) (fs.FileInfo, error) { return fs.Stat(fsys, path) },
+		join:     path.Join,
+	}
+}
+
+// walk adds the test files under the directory given to the walker's files, under the ignore files of the directories
+// above it that were walked.
+func (w *testFileWalker) walk(dir string, levels []ignoreLevel) error {
+	info, err := w.stat(dir)
+	if err != nil {
+		return err
+	}
+	for _, walked := range w.walked {
+		if os.SameFile(info, walked) {
+			return nil
+		}
+	}
+	w.walked = append(w.walked, info)
+
+	contents, err := w.readFile(w.join(dir, IgnoreFileName))
+	if err == nil {
+		levels = append(levels[:len(levels):len(levels)], ignoreLevel{rules: parseIgnoreFile(string(contents))})
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	entries, err := w.readDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		file := w.join(dir, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err := w.stat(file)
+			if errors.Is(err, fs.ErrNotExist) {
+				// Dangling symlinks are skipped like any other file that isn't a test file
+				continue
+			} else if err != nil {
+				return err
+			}
+			isDir = info.IsDir()
+		}
+
+		if isIgnored(levels, entry.Name(), isDir) {
+			continue
+		}
+		if !isDir {
+			if parser.IsTestFile(file) {
+				w.files = append(w.files, file)
+			}
+			continue
+		}
+
+		sublevels := make([]ignoreLevel, len(levels))
+		for i, level := range levels {
+			sublevels[i] = ignoreLevel{rules: level.rules, rel: path.Join(level.rel, entry.Name())}
+		}
+		if err := w.walk(file, sublevels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
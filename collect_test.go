@@ -0,0 +1,162 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCorpus writes test files at the paths given, relative to a new temporary directory, and returns the directory.
+func writeCorpus(t *testing.T, paths ...string) string {
+	dir := t.TempDir()
+	for _, path := range paths {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("statement ok\nINSERT INTO t1 VALUES(1, 2)\n"), 0644))
+	}
+	return dir
+}
+
+func TestFindTestFilesGlobs(t *testing.T) {
+	dir := writeCorpus(t, "select1.test", "select2.test.gz", "select.txt", "index/a.test", "index/b.test",
+		"insert.test")
+
+	files, err := FindTestFiles(filepath.Join(dir, "select*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "select1.test"), filepath.Join(dir, "select2.test.gz")}, files)
+
+	// Directories matched are descended
+	files, err = FindTestFiles(filepath.Join(dir, "ind?x"), filepath.Join(dir, "insert.test"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "index", "a.test"), filepath.Join(dir, "index", "b.test"),
+		filepath.Join(dir, "insert.test")}, files)
+}
+
+func TestFindTestFilesErrors(t *testing.T) {
+	dir := writeCorpus(t, "select1.test")
+
+	for _, path := range []string{"missing", "missing*.test", "[a-"} {
+		_, err := FindTestFiles(filepath.Join(dir, path))
+		assert.Error(t, err, path)
+		assert.Panics(t, func() { CollectTestFiles(filepath.Join(dir, path)) }, path)
+	}
+
+	_, err := SummarizeTestFiles(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestIsExcluded(t *testing.T) {
+	for _, tt := range []struct {
+		pattern  string
+		path     string
+		excluded bool
+	}{
+		{"slow_*.test", "test/slow_1.test", true},
+		{"slow_*.test", "test/slow_1.test.gz", false},
+		{"slow_*.test*", "test/slow_1.test.gz", true},
+		{"evidence/*.test", "test/evidence/in1.test", true},
+		{"evidence", "test/evidence/in1.test", true},
+		{"test/evidence", "/corpus/test/evidence/sub/in1.test", true},
+		{"evidence", "test/evidence_in1.test", false},
+		{"select*", "test/index/a.test", false},
+		{"[a-", "test/a.test", false},
+	} {
+		assert.Equal(t, tt.excluded, isExcluded(tt.pattern, tt.path), "%s %s", tt.pattern, tt.path)
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	dir := writeCorpus(t, "select1.test", "slow_select.test", "evidence/in1.test", "index/a.test")
+
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithExcludes("slow_*.test", "evidence")).RunTestFiles(dir)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, "a.test", filepath.Base(reporter.entries[0].TestFile))
+	assert.Equal(t, "select1.test", filepath.Base(reporter.entries[1].TestFile))
+
+	// Excluded files aren't generated either
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithExcludes("slow_*.test")).GenerateTestFiles(dir)
+	assert.FileExists(t, filepath.Join(dir, "select1.test.generated"))
+	assert.NoFileExists(t, filepath.Join(dir, "slow_select.test.generated"))
+}
+
+func TestCollectTestFilesFSGlobs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"corpus/select1.test":  {Data: []byte("halt\n")},
+		"corpus/select2.test":  {Data: []byte("halt\n")},
+		"corpus/select.txt":    {Data: []byte("not a test file")},
+		"corpus/index/a.test":  {Data: []byte("halt\n")},
+		"corpus/slow_sel.test": {Data: []byte("halt\n")},
+	}
+	assert.Equal(t, []string{"corpus/select1.test", "corpus/select2.test"},
+		collectTestFilesFS(fsys, []string{"corpus/select*"}))
+	assert.Equal(t, []string{"corpus/index/a.test", "corpus/select1.test", "corpus/select2.test"},
+		NewRunner(newFakeHarness(), WithFS(fsys), WithExcludes("slow_*")).Config().testFiles([]string{"corpus"}))
+	assert.Panics(t, func() { collectTestFilesFS(fsys, []string{"corpus/missing*"}) })
+}
+
+func TestFindTestFilesIgnoreFiles(t *testing.T) {
+	dir := writeCorpus(t, "select1.test", "slow_select.test", "evidence/in1.test", "index/a.test",
+		"index/slow_index.test", "index/b.test")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("# slow\nslow_*.test\nevidence/\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index", IgnoreFileName), []byte("!slow_index.test\nb.test\n"),
+		0644))
+
+	files, err := FindTestFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "index", "a.test"), filepath.Join(dir, "index", "slow_index.test"),
+		filepath.Join(dir, "select1.test")}, files)
+
+	// Test files given directly aren't ignored
+	files, err = FindTestFiles(filepath.Join(dir, "slow_select.test"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "slow_select.test")}, files)
+}
+
+func TestFindTestFilesSymlinks(t *testing.T) {
+	dir := writeCorpus(t, "corpus/a.test", "shared/b.test")
+	require.NoError(t, os.Symlink(filepath.Join(dir, "shared"), filepath.Join(dir, "corpus", "linked")))
+	// A symlink to a directory being walked, which would otherwise recurse forever
+	require.NoError(t, os.Symlink(filepath.Join(dir, "corpus"), filepath.Join(dir, "corpus", "loop")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "corpus", "dangling")))
+
+	files, err := FindTestFiles(filepath.Join(dir, "corpus"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "corpus", "a.test"), filepath.Join(dir, "corpus", "linked", "b.test")},
+		files)
+
+	// Symlinked directories given directly are walked too
+	files, err = FindTestFiles(filepath.Join(dir, "corpus", "linked"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "corpus", "linked", "b.test")}, files)
+}
+
+func TestCollectTestFilesFSIgnoreFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"corpus/" + IgnoreFileName: {Data: []byte("slow_*.test\n")},
+		"corpus/a.test":            {Data: []byte("halt\n")},
+		"corpus/slow_a.test":       {Data: []byte("halt\n")},
+		"corpus/sub/slow_b.test":   {Data: []byte("halt\n")},
+	}
+	assert.Equal(t, []string{"corpus/a.test"}, collectTestFilesFS(fsys, []string{"corpus"}))
+}
@@ -0,0 +1,95 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// columnNamesDirective is the directive that gives the names of the columns of a query's results.
+const columnNamesDirective = "colnames"
+
+// A ColumnNameHarness is a Harness that reports the names of the columns of a query's results, from the engine's
+// result metadata. Query records preceded by a colnames directive verify their column names with it, and generated
+// test files can include the names. See RunConfig.GenerateColumnNames.
+type ColumnNameHarness interface {
+	Harness
+
+	// ExecuteQueryColumns executes the query given like ExecuteQuery, and also returns the names of its columns.
+	ExecuteQueryColumns(ctx context.Context, statement string) (schema string, columns []string, results []string, err error)
+}
+
+// wantsColumnNames returns whether the query record given needs the names of its columns, because it asserts them or
+// because they're written to the test file being generated.
+func (r *Runner) wantsColumnNames(ctx context.Context, record *parser.Record) bool {
+	return record.ColumnNames() != nil || (isGenerating(ctx) && r.config.GenerateColumnNames)
+}
+
+// executeColumnsQuery executes the query record given with a harness that reports its column names, and verifies its
+// column names, schema and results, logging any failure. Column names aren't verified while generating test files,
+// since they're rewritten, unless only failing records are regenerated.
+func (r *Runner) executeColumnsQuery(ctx context.Context, harness ColumnNameHarness, record *parser.Record) *R {
+	schema, columns, results, err := harness.ExecuteQueryColumns(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{err: err}
+	}
+
+	for i, column := range columns {
+		columns[i] = compactColumnName(column)
+	}
+
+	expected := record.ColumnNames()
+	if expected != nil && (!isGenerating(ctx) || r.config.RegenerateFailing) && !sameColumnNames(expected, columns) {
+		logFailure(ctx, ColumnNameMismatch, "Expected column names %s but got %s", strings.Join(expected, " "),
+			strings.Join(columns, " "))
+		return &R{schema: schema, results: results, columns: columns, mismatched: true,
+			err: fmt.Errorf("expected column names %s but got %s", strings.Join(expected, " "), strings.Join(columns, " "))}
+	}
+
+	res := r.verifyQuery(ctx, record, schema, results)
+	res.columns = columns
+	return res
+}
+
+// compactColumnName returns the column name given without whitespace, so that it's a single field of a colnames
+// directive.
+func compactColumnName(name string) string {
+	return strings.Join(strings.Fields(name), "")
+}
+
+// sameColumnNames returns whether the column names given are the same, ignoring case, since engines differ in the
+// case of the names they give to expressions.
+func sameColumnNames(expected, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if !strings.EqualFold(expected[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isColumnNamesLine returns whether the test file line given is a colnames directive.
+func isColumnNamesLine(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 0 && fields[0] == columnNamesDirective
+}
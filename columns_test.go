@@ -0,0 +1,102 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// columnHarness is a harness that reports the same column names for every query.
+type columnHarness struct {
+	*fakeHarness
+	columns []string
+}
+
+var _ ColumnNameHarness = &columnHarness{}
+
+func (h *columnHarness) ExecuteQueryColumns(ctx context.Context, statement string) (string, []string, []string, error) {
+	schema, results, err := h.ExecuteQuery(ctx, statement)
+	return schema, append([]string(nil), h.columns...), results, err
+}
+
+const columnNamesTest = `colnames A b
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestColumnNames(t *testing.T) {
+	reporter := &collectingReporter{}
+	harness := &columnHarness{fakeHarness: newFakeHarness(), columns: []string{"a", "b"}}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, columnNamesTest))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	reporter = &collectingReporter{}
+	harness.columns = []string{"a", "b + 1"}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, columnNamesTest))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ColumnNameMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Expected column names A b but got a b+1", reporter.entries[0].ErrorMessage)
+	assert.Equal(t, ColumnNameMismatch, classifyFailure(reporter.entries[0].ErrorMessage))
+
+	// Harnesses that can't report column names don't verify them
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, columnNamesTest))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
+
+func TestGenerateColumnNames(t *testing.T) {
+	harness := &columnHarness{fakeHarness: newFakeHarness(), columns: []string{"a", "b + 1"}}
+
+	// Existing colnames directives are rewritten
+	path := writeTestFile(t, "# the columns\n"+columnNamesTest)
+	NewRunner(harness, WithOutput(&bytes.Buffer{})).GenerateTestFiles(path)
+	assertFileContents(t, path+".generated", `# the columns
+colnames a b+1
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`)
+
+	// Other queries only have them added when configured
+	test := "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"
+	path = writeTestFile(t, test)
+	NewRunner(harness, WithOutput(&bytes.Buffer{})).GenerateTestFiles(path)
+	assertFileContents(t, path+".generated", test)
+
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithGenerateColumnNames(true)).GenerateTestFiles(path)
+	assertFileContents(t, path+".generated", "colnames a b+1\n"+test)
+
+	// When only failing records are regenerated, only wrong column names are rewritten
+	path = writeTestFile(t, columnNamesTest)
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithRegenerateFailing(true)).GenerateTestFiles(path)
+	assertFileContents(t, path+".generated", "colnames a b+1\n"+test)
+}
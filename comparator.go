@@ -0,0 +1,112 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ValueComparator returns whether an actual result value returned by an engine matches the expected value from a
+// test file. Comparators are only consulted for values that are not identical.
+type ValueComparator func(expected, actual string) bool
+
+// comparators holds the registered comparators, keyed by schema type character, which are the default comparators of
+// new runners. comparatorsMux guards them.
+var (
+	comparators    = map[rune]ValueComparator{}
+	comparatorsMux sync.Mutex
+)
+
+// RegisterComparator registers the comparator given for result values in columns of the schema type given, e.g. 'T',
+// for runners created after it's registered. Registered comparators take precedence over the built-in comparison rules
+// for that type, including float epsilons. See WithComparator to configure a single runner.
+func RegisterComparator(typ rune, comparator ValueComparator) {
+	comparatorsMux.Lock()
+	defer comparatorsMux.Unlock()
+	comparators[typ] = comparator
+}
+
+// UnregisterComparator removes the comparator registered for the schema type given, if any.
+func UnregisterComparator(typ rune) {
+	comparatorsMux.Lock()
+	defer comparatorsMux.Unlock()
+	delete(comparators, typ)
+}
+
+// registeredComparators returns a copy of the registered comparators.
+func registeredComparators() map[rune]ValueComparator {
+	comparatorsMux.Lock()
+	defer comparatorsMux.Unlock()
+	if len(comparators) == 0 {
+		return nil
+	}
+	registered := make(map[rune]ValueComparator, len(comparators))
+	for typ, comparator := range comparators {
+		registered[typ] = comparator
+	}
+	return registered
+}
+
+// CaseInsensitiveComparator compares values without regard to case.
+func CaseInsensitiveComparator(expected, actual string) bool {
+	return strings.EqualFold(expected, actual)
+}
+
+// JSONComparator compares values as JSON documents, so that values that differ only in formatting or the order of
+// object keys are equal. Values that aren't valid JSON are never equal.
+func JSONComparator(expected, actual string) bool {
+	var e, a interface{}
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(actual), &a); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(e, a)
+}
+
+// NewTimestampComparator returns a comparator that parses values with any of the layouts given and considers them equal
+// if they differ by no more than the tolerance given. Values that can't be parsed are never equal.
+func NewTimestampComparator(tolerance time.Duration, layouts ...string) ValueComparator {
+	parse := func(s string) (time.Time, bool) {
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	return func(expected, actual string) bool {
+		e, ok := parse(expected)
+		if !ok {
+			return false
+		}
+		a, ok := parse(actual)
+		if !ok {
+			return false
+		}
+
+		diff := e.Sub(a)
+		if diff < 0 {
+			diff = -diff
+		}
+		return diff <= tolerance
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparators(t *testing.T) {
+	assert.True(t, CaseInsensitiveComparator("Hello", "hELLO"))
+	assert.False(t, CaseInsensitiveComparator("Hello", "world"))
+
+	assert.True(t, JSONComparator(`{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":1}`))
+	assert.False(t, JSONComparator(`{"a": 1}`, `{"a": 2}`))
+	assert.False(t, JSONComparator(`{"a": 1}`, `not json`))
+
+	ts := NewTimestampComparator(time.Second, "2006-01-02 15:04:05.999999", time.RFC3339)
+	assert.True(t, ts("2020-01-01 10:00:00", "2020-01-01 10:00:00.5"))
+	assert.True(t, ts("2020-01-01 10:00:00", "2020-01-01T10:00:01Z"))
+	assert.False(t, ts("2020-01-01 10:00:00", "2020-01-01 10:00:02"))
+	assert.False(t, ts("2020-01-01 10:00:00", "yesterday"))
+}
+
+func TestRegisterComparator(t *testing.T) {
+	config := DefaultRunConfig()
+	assert.False(t, config.valuesEqual("abc", "ABC", 'T', 0))
+
+	RegisterComparator('T', CaseInsensitiveComparator)
+	defer UnregisterComparator('T')
+	config = DefaultRunConfig()
+	assert.True(t, config.valuesEqual("abc", "ABC", 'T', 0))
+	assert.False(t, config.valuesEqual("abc", "ABC", 'I', 0))
+}
+
+func TestWithComparator(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT t FROM t3"] = fakeResult{schema: "T", results: []string{"ABC"}}
+	path := writeTestFile(t, "query T nosort\nSELECT t FROM t3\n----\nabc\n")
+
+	assert.Panics(t, func() {
+		NewRunner(harness, WithOutput(&bytes.Buffer{})).RunTestFiles(path)
+	})
+
+	// Comparators only apply to the runner they're configured for
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithComparator('T', CaseInsensitiveComparator))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Empty(t, DefaultRunConfig().Comparators)
+}
@@ -0,0 +1,221 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// ReadResults reads the results of a run from the file given, which is either a result log or a report written by a
+// JSONReporter, told apart by its contents.
+func ReadResults(path string) ([]*ResultLogEntry, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(contents), []byte("{")) {
+		return ParseResultFile(path)
+	}
+
+	var entries []*ResultLogEntry
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	for {
+		entry := &ResultLogEntry{}
+		if err := decoder.Decode(entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// CompareOptions configure CompareRuns.
+type CompareOptions struct {
+	// SlowdownFactor is the factor by which the duration of a record must change to be reported as slower or faster,
+	// e.g. 2 for records that take at least twice or at most half as long. Zero disables timing comparisons.
+	SlowdownFactor float64
+	// MinDuration is the duration a record must take in either run for changes in its duration to be reported, so
+	// that the noise in the timing of fast records isn't.
+	MinDuration time.Duration
+}
+
+// A ResultChange is a record whose result differs between two runs. Before is nil for records that only the second
+// run reported, and After for records that only the first did.
+type ResultChange struct {
+	TestFile string
+	LineNum  int
+	Before   *ResultLogEntry
+	After    *ResultLogEntry
+}
+
+// Query returns the query of the record that changed.
+func (c ResultChange) Query() string {
+	if c.After != nil {
+		return c.After.Query
+	}
+	return c.Before.Query
+}
+
+// A RunComparison categorizes the differences between the results of two runs of the same test files, e.g. a nightly
+// run and the one before it. Records that failed, timed out or didn't report a result in one run are considered
+// failing, skipped or not run in it accordingly; records only reported by one run are considered skipped in the
+// other.
+type RunComparison struct {
+	// NewlyFailing are the records that fail in the second run but didn't in the first, regressions.
+	NewlyFailing []ResultChange
+	// NewlyPassing are the records that pass in the second run but failed or were skipped in the first, progressions.
+	NewlyPassing []ResultChange
+	// NewlySkipped are the records that were skipped or didn't run in the second run but ran in the first.
+	NewlySkipped []ResultChange
+	// Slower and Faster are the records that ran in both runs and whose duration changed beyond the threshold given
+	// by CompareOptions.
+	Slower []ResultChange
+	Faster []ResultChange
+}
+
+// outcome classifies a result for comparisons: whether it passed, failed or didn't run.
+type outcome int
+
+const (
+	outcomeSkipped outcome = iota
+	outcomePassed
+	outcomeFailed
+)
+
+func outcomeOf(entry *ResultLogEntry) outcome {
+	if entry == nil {
+		return outcomeSkipped
+	}
+	switch entry.Result {
+	case Ok:
+		return outcomePassed
+	case NotOk, Timeout:
+		return outcomeFailed
+	default:
+		return outcomeSkipped
+	}
+}
+
+// CompareRuns compares the results of two runs, given in the order the runs happened. Records are matched by their
+// test file and line number; if a run reported a record more than once, its last result is used. Changes are ordered
+// by test file and line number.
+func CompareRuns(before, after []*ResultLogEntry, opts CompareOptions) RunComparison {
+	type recordKey struct {
+		file string
+		line int
+	}
+	changes := make(map[recordKey]*ResultChange)
+	change := func(entry *ResultLogEntry) *ResultChange {
+		key := recordKey{entry.TestFile, entry.LineNum}
+		if changes[key] == nil {
+			changes[key] = &ResultChange{TestFile: entry.TestFile, LineNum: entry.LineNum}
+		}
+		return changes[key]
+	}
+	for _, entry := range before {
+		change(entry).Before = entry
+	}
+	for _, entry := range after {
+		change(entry).After = entry
+	}
+
+	var sorted []*ResultChange
+	for _, c := range changes {
+		sorted = append(sorted, c)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].TestFile != sorted[j].TestFile {
+			return sorted[i].TestFile < sorted[j].TestFile
+		}
+		return sorted[i].LineNum < sorted[j].LineNum
+	})
+
+	var comparison RunComparison
+	for _, c := range sorted {
+		was, is := outcomeOf(c.Before), outcomeOf(c.After)
+		switch {
+		case is == outcomeFailed && was != outcomeFailed:
+			comparison.NewlyFailing = append(comparison.NewlyFailing, *c)
+		case is == outcomePassed && was != outcomePassed:
+			comparison.NewlyPassing = append(comparison.NewlyPassing, *c)
+		case is == outcomeSkipped && was != outcomeSkipped:
+			comparison.NewlySkipped = append(comparison.NewlySkipped, *c)
+		case is != outcomeSkipped && opts.SlowdownFactor > 0:
+			beforeDuration, afterDuration := c.Before.Duration, c.After.Duration
+			if beforeDuration < opts.MinDuration && afterDuration < opts.MinDuration {
+				continue
+			}
+			if float64(afterDuration) >= float64(beforeDuration)*opts.SlowdownFactor && afterDuration > beforeDuration {
+				comparison.Slower = append(comparison.Slower, *c)
+			} else if float64(beforeDuration) >= float64(afterDuration)*opts.SlowdownFactor && beforeDuration > afterDuration {
+				comparison.Faster = append(comparison.Faster, *c)
+			}
+		}
+	}
+	return comparison
+}
+
+// HasRegressions returns whether any record fails in the second run that didn't in the first.
+func (c RunComparison) HasRegressions() bool {
+	return len(c.NewlyFailing) > 0
+}
+
+// Print writes this comparison to the writer given, with a section for each category of changes that isn't empty.
+func (c RunComparison) Print(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d newly failing, %d newly passing, %d newly skipped, %d slower, %d faster\n",
+		len(c.NewlyFailing), len(c.NewlyPassing), len(c.NewlySkipped), len(c.Slower), len(c.Faster))
+
+	printSection := func(title string, changes []ResultChange, detail func(ResultChange) string) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(bw, "\n%s:\n", title)
+		for _, change := range changes {
+			fmt.Fprintf(bw, "%s:%d: %s: %s\n", change.TestFile, change.LineNum, change.Query(), detail(change))
+		}
+	}
+	transition := func(change ResultChange) string {
+		return fmt.Sprintf("%s -> %s", resultString(change.Before), resultString(change.After))
+	}
+	timing := func(change ResultChange) string {
+		return fmt.Sprintf("%v -> %v", change.Before.Duration, change.After.Duration)
+	}
+
+	printSection("Newly failing", c.NewlyFailing, func(change ResultChange) string {
+		return transition(change) + ": " + change.After.ErrorMessage
+	})
+	printSection("Newly passing", c.NewlyPassing, transition)
+	printSection("Newly skipped", c.NewlySkipped, transition)
+	printSection("Slower", c.Slower, timing)
+	printSection("Faster", c.Faster, timing)
+	return bw.Flush()
+}
+
+// resultString returns the result of the entry given for printing, or "missing" for records a run didn't report.
+func resultString(entry *ResultLogEntry) string {
+	if entry == nil {
+		return "missing"
+	}
+	return entry.Result.String()
+}
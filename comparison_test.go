@@ -0,0 +1,108 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resultEntry(file string, line int, result ResultType, duration time.Duration) *ResultLogEntry {
+	return &ResultLogEntry{TestFile: file, LineNum: line, Query: "SELECT 1", Result: result, Duration: duration}
+}
+
+func TestCompareRuns(t *testing.T) {
+	before := []*ResultLogEntry{
+		resultEntry("a.test", 1, Ok, time.Millisecond),
+		resultEntry("a.test", 5, NotOk, time.Millisecond),
+		resultEntry("a.test", 9, Ok, 100*time.Millisecond),
+		resultEntry("a.test", 13, Ok, 100*time.Millisecond),
+		resultEntry("a.test", 17, Ok, time.Millisecond),
+		resultEntry("b.test", 1, Skipped, 0),
+		resultEntry("b.test", 5, Ok, 0),
+	}
+	after := []*ResultLogEntry{
+		resultEntry("b.test", 1, Ok, 0),
+		resultEntry("a.test", 1, Timeout, time.Millisecond),
+		resultEntry("a.test", 5, Ok, time.Millisecond),
+		resultEntry("a.test", 9, Ok, 300*time.Millisecond),
+		resultEntry("a.test", 13, Ok, 40*time.Millisecond),
+		resultEntry("a.test", 17, Ok, 3*time.Millisecond),
+		resultEntry("c.test", 1, NotOk, 0),
+	}
+
+	comparison := CompareRuns(before, after, CompareOptions{SlowdownFactor: 2, MinDuration: 10 * time.Millisecond})
+	lines := func(changes []ResultChange) []string {
+		var keys []string
+		for _, c := range changes {
+			keys = append(keys, fmt.Sprintf("%s:%d", c.TestFile, c.LineNum))
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"a.test:1", "c.test:1"}, lines(comparison.NewlyFailing))
+	assert.Equal(t, []string{"a.test:5", "b.test:1"}, lines(comparison.NewlyPassing))
+	assert.Equal(t, []string{"b.test:5"}, lines(comparison.NewlySkipped))
+	assert.Equal(t, []string{"a.test:9"}, lines(comparison.Slower))
+	assert.Equal(t, []string{"a.test:13"}, lines(comparison.Faster))
+	assert.True(t, comparison.HasRegressions())
+	assert.Nil(t, comparison.NewlySkipped[0].After)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, comparison.Print(buf))
+	assert.Contains(t, buf.String(), "2 newly failing, 2 newly passing, 1 newly skipped, 1 slower, 1 faster\n")
+	assert.Contains(t, buf.String(), "\nNewly failing:\na.test:1: SELECT 1: ok -> timeout: \n")
+	assert.Contains(t, buf.String(), "\nNewly skipped:\nb.test:5: SELECT 1: ok -> missing\n")
+	assert.Contains(t, buf.String(), "\nSlower:\na.test:9: SELECT 1: 100ms -> 300ms\n")
+
+	// Timing isn't compared by default
+	comparison = CompareRuns(before, after, CompareOptions{})
+	assert.Empty(t, comparison.Slower)
+	assert.Empty(t, comparison.Faster)
+}
+
+func TestReadResults(t *testing.T) {
+	path := writeTestFile(t, "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n")
+	dir := t.TempDir()
+
+	logPath := filepath.Join(dir, "results.log")
+	jsonPath := filepath.Join(dir, "results.json")
+	logFile, err := os.Create(logPath)
+	require.NoError(t, err)
+	jsonFile, err := os.Create(jsonPath)
+	require.NoError(t, err)
+	NewRunner(newFakeHarness(), WithOutput(logFile), WithReporters(NewJSONReporter(jsonFile))).RunTestFiles(path)
+	require.NoError(t, logFile.Close())
+	require.NoError(t, jsonFile.Close())
+
+	fromLog, err := ReadResults(logPath)
+	require.NoError(t, err)
+	fromJSON, err := ReadResults(jsonPath)
+	require.NoError(t, err)
+	require.Len(t, fromLog, 1)
+	require.Len(t, fromJSON, 1)
+	assert.Equal(t, fromLog[0].TestFile, fromJSON[0].TestFile)
+	assert.Equal(t, 2, fromJSON[0].LineNum)
+	assert.Equal(t, Ok, fromJSON[0].Result)
+
+	_, err = ReadResults(filepath.Join(dir, "missing.log"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,603 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RunConfig configures a Runner. Embedding programs construct a Runner with NewRunner and the RunOptions that set its
+// fields.
+type RunConfig struct {
+	// Output is the destination of the text result log, STDOUT by default.
+	Output io.Writer
+	// Reporters receive a structured entry for every result, in addition to the text result log.
+	Reporters []Reporter
+	// TruncateQueries truncates long queries in result logs.
+	TruncateQueries bool
+	// Timeout is the timeout for executing each record. When zero, the timeout returned by the harness's GetTimeout
+	// method is used, or a default of 20 minutes if that is zero as well.
+	Timeout time.Duration
+	// HashThreshold, when non-zero, overrides the hash-threshold of test files when generating results.
+	HashThreshold int
+	// FloatEpsilon is the tolerance for comparing R-typed values numerically, for records that don't specify their own
+	// with a float-epsilon directive. The default of 0 compares values as strings.
+	FloatEpsilon float64
+	// HashAlgorithm is the algorithm used to hash results when generating test files, MD5 by default. Verification
+	// always uses the algorithm recorded in the test file.
+	HashAlgorithm HashAlgorithm
+	// HashCheckpointInterval is the number of rows between checkpoint hashes written after each hash line when
+	// generating test files. The default of 0 writes no checkpoints.
+	HashCheckpointInterval int
+	// BisectHashMismatches reports the first diverging row of hash mismatches, using checkpoint hashes, or
+	// HashBisectionReference for records without them.
+	BisectHashMismatches bool
+	// HashBisectionReference, when set, locates the first diverging row of hash mismatches of records without
+	// checkpoint hashes. The records of the test file before the mismatched query are replayed on this harness, and its
+	// results for the query are enumerated and compared to the actual results if they hash to the expected hash.
+	HashBisectionReference Harness
+	// SkipAfterSetupFailure reports the remaining records of a file as not run after a setup statement (such as a
+	// CREATE TABLE or an INSERT) fails, rather than failing the run. The failed statement is reported as the root cause.
+	SkipAfterSetupFailure bool
+	// PreparedStatements executes every statement and query as a prepared statement, for harnesses that implement
+	// PreparedStatementHarness. Other harnesses skip all statements and queries.
+	PreparedStatements bool
+	// Reference, when set, runs test files in differential mode: every statement and query is also executed on this
+	// harness, and the results of the harness under test are compared to the reference's rather than to the expected
+	// results in the test file. Differential mode isn't supported by ParallelRunner.
+	Reference Harness
+	// Translators rewrite every statement and query in turn before it's executed by the harness under test.
+	Translators []Translator
+	// SetupStatements are executed on the harness after it's initialized for each test file, e.g. to create schemas or
+	// set session modes the test files depend on. A failing setup statement fails the run.
+	SetupStatements []string
+	// AsyncStatementDelay is how long the runner waits after starting an async statement before executing the next
+	// record, so that the statement reaches the engine and blocks on any locks it needs first. This orders interleaved
+	// statements, e.g. to provoke a deadlock. When zero, a default of 100 milliseconds is used.
+	AsyncStatementDelay time.Duration
+	// CleanupObjects drops the tables, views and indexes created by each test file after it runs, so that a database
+	// shared between test files isn't contaminated by earlier files. Objects are tracked by recognizing the CREATE
+	// and DROP statements the file executes. See ObjectCleanupHarness for engines that drop objects themselves.
+	CleanupObjects bool
+	// VerifyCatalog compares the tables and columns in the catalog after each test file runs to the ones its CREATE,
+	// ALTER, RENAME and DROP TABLE statements should have produced, catching DDL statements that succeeded without
+	// effect. Only harnesses that implement CatalogHarness are verified.
+	VerifyCatalog bool
+	// ImplicitTransactions executes each statement and query in a transaction that's rolled back after it, so that
+	// records can't change the data seen by later records, e.g. to verify expected results repeatedly against a fixed
+	// dataset loaded by SetupStatements. Records aren't wrapped on a connection that has a transaction active already,
+	// or for harnesses that declare capabilities without CapTransactions.
+	ImplicitTransactions bool
+	// DirectiveHandlers execute the custom records of each directive registered with parser.RegisterDirective, by
+	// the directive's name. Custom records without a handler are skipped.
+	DirectiveHandlers map[string]DirectiveHandler
+	// Engine, when set, is the engine identifier that skipif and onlyif conditions are evaluated against, instead of
+	// the one returned by the harness's EngineStr method.
+	Engine string
+	// ConditionEngines are additional engine identifiers that skipif and onlyif conditions match, e.g. mysql for an
+	// engine that should run the records written for MySQL as well as its own.
+	ConditionEngines []string
+	// ExpectedFailures are the records that are known to fail, as paths of test files and line numbers in the form of
+	// result logs, e.g. "index/random/10/slt_good_0.test:535". Their failures are reported as skipped, with the
+	// failure message prefixed with "expected failure: ", and don't stop the run, so that a run only fails for new
+	// failures. See ReadExpectedFailures.
+	ExpectedFailures map[string]bool
+	// Shard, when its Count is non-zero, restricts runs to the test files of one shard of the files found under the
+	// paths given.
+	Shard Shard
+	// GenerateInPlace replaces each test file with the file generated from it, rather than writing the generated file
+	// beside it with the .generated suffix.
+	GenerateInPlace bool
+	// GenerateBackups keeps the original of each test file regenerated in place, with the .bak suffix.
+	GenerateBackups bool
+	// GenerateOutputDir, when set, is the directory generated files are written to, mirroring the directories the
+	// test files were found in. It takes precedence over GenerateInPlace.
+	GenerateOutputDir string
+	// RegenerateFailing only rewrites the expected results of queries whose results differ from them when generating
+	// test files, copying every other record unchanged, so that blessing new engine behavior changes as little of each
+	// file as possible. Queries with multiple result sets and procedure calls are never rewritten in this mode.
+	RegenerateFailing bool
+	// GenerateColumnNames writes a colnames directive with the names of its columns before every query of generated
+	// test files, for harnesses that implement ColumnNameHarness. Queries with a colnames directive already have it
+	// rewritten regardless.
+	GenerateColumnNames bool
+	// BlessInput, when set, makes generating test files interactive: the change to each query or table checksum whose
+	// results differ from the expected ones is shown on BlessOutput, or STDOUT if that's nil, and only the changes
+	// accepted by the answers read from BlessInput are written. It should be used with RegenerateFailing, so that every
+	// other record is copied unchanged.
+	BlessInput  io.Reader
+	BlessOutput io.Writer
+	// Tracer, when set, traces runs with a span for each test file and a child span for each of its records, with the
+	// record's result and the failure message of failing records.
+	Tracer trace.Tracer
+	// StatementBatchSize is the maximum number of consecutive INSERT statements executed at once on harnesses that
+	// implement BatchHarness with atomic batches, 1000 if it's zero. Setting it to 1 executes every statement on its own.
+	StatementBatchSize int
+	// CachePreludes saves the state of the database after the prelude of each test file, its leading run of CREATE
+	// and INSERT statements, on harnesses that implement PreludeHarness, and restores it instead of executing the
+	// statements again for later files with the same prelude.
+	CachePreludes bool
+	// SpillThreshold, when positive, is the size in bytes beyond which the results of a query from a StreamingHarness
+	// are written to temporary files rather than held in memory, and then sorted, hashed and compared as they're read
+	// back, so that a query with pathologically large results can't exhaust the runner's memory. Expected results
+	// are held in memory regardless, as parsed from the test file.
+	SpillThreshold int64
+	// FS, when set, is the file system test files are found and read in, such as an embed.FS, rather than the
+	// operating system's. Paths to run are then slash-separated names in it. Generating test files isn't supported
+	// from a file system, which can't be written to.
+	FS fs.FS
+	// Comparators compare the expected and actual values of result columns of the schema types they're keyed by, e.g.
+	// 'T', in place of the built-in comparison rules for those types, including FloatEpsilon. They're only consulted
+	// for values that aren't identical.
+	Comparators map[rune]ValueComparator
+	// Formatter renders values in the format of test files. It's given to harnesses that implement FormattingHarness,
+	// and its conventions are applied when normalizing their results, e.g. the trailing zeros of integers in R columns.
+	Formatter *ValueFormatter
+	// Normalizers are applied in order to both the expected and actual values of query results and the messages of
+	// warnings before they're compared, e.g. TrimTrailingZeros, so that engines with cosmetic formatting differences
+	// can run a shared corpus. Hashed results aren't normalized, since the values their hashes were computed from
+	// aren't known.
+	Normalizers []Normalizer
+	// ErrorNormalizers are applied in order to the text of errors before it's matched against the pattern of an
+	// expect-error-message directive, after the harness's own normalization if it's an ErrorNormalizingHarness, e.g.
+	// StripObjectIDs, so that assertions on error text are stable from run to run.
+	ErrorNormalizers []ErrorNormalizer
+	// QueryNormalizers, when not nil, are applied in order to the raw text of statements and queries in place of the
+	// parser's DefaultQueryNormalizers, so that engines for which comments are significant, e.g. optimizer hints, can
+	// execute them as written. An empty slice executes the raw text verbatim.
+	QueryNormalizers []QueryNormalizer
+	// LargeValueThreshold, when positive, is the length in bytes beyond which text and binary result values are
+	// rewritten by LargeValuePolicy, both when verifying results and when generating test files, so that expected
+	// results record large values compactly.
+	LargeValueThreshold int
+	// LargeValuePolicy is how values longer than LargeValueThreshold are rewritten, TruncateLargeValues if it's empty.
+	LargeValuePolicy LargeValuePolicy
+	// StrictSchemas reports queries whose results have integer columns where their schema expects R, which are
+	// otherwise accepted because MySQL's typing of numeric expressions is hard to replicate. This commonly hides
+	// type regressions, e.g. in empty results, whose column types harnesses often have to guess. Queries preceded by
+	// an ambiguous-schema directive are still accepted.
+	StrictSchemas bool
+	// FixSchemas only rewrites the schemas of queries when generating test files, to the schemas the engine returns
+	// for their results, copying every other record and every expected result unchanged. A query's schema is only
+	// rewritten if its expected results still match the engine's results with the new schema, e.g. to modernize the
+	// inaccurate I and R columns of corpora derived from MySQL in bulk against a reference engine.
+	FixSchemas bool
+	// Excludes are glob patterns of test files to leave out of runs and generation, such as slow_*.test or
+	// evidence/*.test. A pattern excludes the test files whose slash-separated paths, or any trailing part of them or
+	// of their directories, it matches, so that a directory name excludes all the test files under it.
+	Excludes []string
+	// MaxDuration, when set, is the time budget of a run: once it has passed, no further test files are started, and
+	// the test files that ran and those that were deferred are listed on Output at the end of the run. Test files that
+	// already started run to completion, so runs can last longer. Useful for smoke tests on large corpora.
+	MaxDuration time.Duration
+	// StressCopies, when greater than 1, makes a ParallelRunner run each test file on this many harnesses of its pool at
+	// once, rather than a different file on each, to shake out races in the engine under test. Each copy runs on its
+	// own database, so the copies only interfere with each other through the engine itself. The results of the copies
+	// are interleaved in the result log.
+	StressCopies int
+	// ConfirmFailures, when set, is the number of times to rerun each record that fails, in isolation after the
+	// records before it in its test file, to triage its failure as consistent or flaky. Failures are reported with
+	// their Confirmation once their test file has run, or once the first one has been rerun if it stops the run, as
+	// every failure does unless ContinueOnFailure is set.
+	ConfirmFailures int
+	// ContinueOnFailure runs every record of every test file regardless of failures, which are only reported, rather
+	// than stopping the run at the first one, e.g. to count the failures of a run.
+	ContinueOnFailure bool
+	// WarmupRecords is the number of queries at the start of each test file that are executed once without
+	// verification right before they're run, so that engines that compile or cache plans aren't penalized on the
+	// first records timed.
+	WarmupRecords int
+	// WarmupQueries are executed once on the harness after it's initialized for each test file, before any of its
+	// records, discarding their results and errors.
+	WarmupQueries []string
+}
+
+// A RunOption sets an option of a RunConfig.
+type RunOption func(*RunConfig)
+
+// DefaultRunConfig returns the config used by the package-level functions such as RunTestFiles, and as the starting
+// point for NewRunner.
+func DefaultRunConfig() RunConfig {
+	return RunConfig{
+		Output:        os.Stdout,
+		HashAlgorithm: MD5,
+		Comparators:   registeredComparators(),
+		Formatter:     NewValueFormatter(),
+	}
+}
+
+// WithOutput sets the destination of the text result log.
+func WithOutput(w io.Writer) RunOption {
+	return func(c *RunConfig) {
+		c.Output = w
+	}
+}
+
+// WithReporters adds reporters to receive a structured entry for every result.
+func WithReporters(reporters ...Reporter) RunOption {
+	return func(c *RunConfig) {
+		c.Reporters = append(c.Reporters, reporters...)
+	}
+}
+
+// WithTruncateQueries sets whether long queries are truncated in result logs.
+func WithTruncateQueries(truncate bool) RunOption {
+	return func(c *RunConfig) {
+		c.TruncateQueries = truncate
+	}
+}
+
+// WithTimeout sets the timeout for executing each record, overriding the harness's timeout.
+func WithTimeout(timeout time.Duration) RunOption {
+	return func(c *RunConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// WithHashThreshold overrides the hash-threshold of test files when generating results.
+func WithHashThreshold(threshold int) RunOption {
+	return func(c *RunConfig) {
+		c.HashThreshold = threshold
+	}
+}
+
+// WithFloatEpsilon sets the tolerance for comparing R-typed values, for records that don't specify their own.
+func WithFloatEpsilon(epsilon float64) RunOption {
+	return func(c *RunConfig) {
+		c.FloatEpsilon = epsilon
+	}
+}
+
+// WithHashAlgorithm sets the algorithm used to hash results when generating test files.
+func WithHashAlgorithm(algorithm HashAlgorithm) RunOption {
+	return func(c *RunConfig) {
+		c.HashAlgorithm = algorithm
+	}
+}
+
+// WithHashCheckpointInterval sets the number of rows between checkpoint hashes written when generating test files.
+func WithHashCheckpointInterval(rows int) RunOption {
+	return func(c *RunConfig) {
+		c.HashCheckpointInterval = rows
+	}
+}
+
+// WithHashBisection sets whether the first diverging row of hash mismatches is reported.
+func WithHashBisection(bisect bool) RunOption {
+	return func(c *RunConfig) {
+		c.BisectHashMismatches = bisect
+	}
+}
+
+// WithHashBisectionReference sets the harness that enumerates the expected results of hash mismatches without
+// checkpoint hashes, to locate their first diverging row. Hash bisection must be enabled with WithHashBisection.
+func WithHashBisectionReference(reference Harness) RunOption {
+	return func(c *RunConfig) {
+		c.HashBisectionReference = reference
+	}
+}
+
+// WithSkipAfterSetupFailure sets whether the remaining records of a file are skipped after a setup statement fails.
+func WithSkipAfterSetupFailure(skip bool) RunOption {
+	return func(c *RunConfig) {
+		c.SkipAfterSetupFailure = skip
+	}
+}
+
+// WithPreparedStatements sets whether every statement and query is executed as a prepared statement.
+func WithPreparedStatements(prepared bool) RunOption {
+	return func(c *RunConfig) {
+		c.PreparedStatements = prepared
+	}
+}
+
+// WithReference runs test files in differential mode against the reference harness given. See RunConfig.Reference.
+func WithReference(reference Harness) RunOption {
+	return func(c *RunConfig) {
+		c.Reference = reference
+	}
+}
+
+// WithTranslators adds translators to rewrite every statement and query before it's executed.
+func WithTranslators(translators ...Translator) RunOption {
+	return func(c *RunConfig) {
+		c.Translators = append(c.Translators, translators...)
+	}
+}
+
+// WithAsyncStatementDelay sets how long the runner waits after starting an async statement before executing the next
+// record.
+func WithAsyncStatementDelay(delay time.Duration) RunOption {
+	return func(c *RunConfig) {
+		c.AsyncStatementDelay = delay
+	}
+}
+
+// WithObjectCleanup sets whether the tables, views and indexes created by each test file are dropped after it runs.
+func WithObjectCleanup(cleanup bool) RunOption {
+	return func(c *RunConfig) {
+		c.CleanupObjects = cleanup
+	}
+}
+
+// WithCatalogVerification sets whether the catalog is compared to the tables each test file should have created after
+// it runs.
+func WithCatalogVerification(verify bool) RunOption {
+	return func(c *RunConfig) {
+		c.VerifyCatalog = verify
+	}
+}
+
+// WithImplicitTransactions sets whether each statement and query executes in a transaction that's rolled back after it.
+func WithImplicitTransactions(implicit bool) RunOption {
+	return func(c *RunConfig) {
+		c.ImplicitTransactions = implicit
+	}
+}
+
+// WithDirectiveHandler sets the handler that executes the custom records of the directive named.
+func WithDirectiveHandler(directive string, handler DirectiveHandler) RunOption {
+	return func(c *RunConfig) {
+		handlers := make(map[string]DirectiveHandler, len(c.DirectiveHandlers)+1)
+		for name, h := range c.DirectiveHandlers {
+			handlers[name] = h
+		}
+		handlers[directive] = handler
+		c.DirectiveHandlers = handlers
+	}
+}
+
+// WithGenerateInPlace sets whether generated files replace the test files they're generated from, and whether the
+// originals are kept with the .bak suffix.
+func WithGenerateInPlace(inPlace, backups bool) RunOption {
+	return func(c *RunConfig) {
+		c.GenerateInPlace = inPlace
+		c.GenerateBackups = backups
+	}
+}
+
+// WithGenerateOutputDir sets the directory generated files are written to, mirroring the directories the test files
+// were found in.
+func WithGenerateOutputDir(dir string) RunOption {
+	return func(c *RunConfig) {
+		c.GenerateOutputDir = dir
+	}
+}
+
+// WithRegenerateFailing sets whether generating test files only rewrites the expected results of queries that fail.
+func WithRegenerateFailing(failingOnly bool) RunOption {
+	return func(c *RunConfig) {
+		c.RegenerateFailing = failingOnly
+	}
+}
+
+// WithGenerateColumnNames sets whether generated test files give the names of the columns of every query.
+func WithGenerateColumnNames(names bool) RunOption {
+	return func(c *RunConfig) {
+		c.GenerateColumnNames = names
+	}
+}
+
+// WithInteractiveBless makes generating test files interactive: the change to each record whose results differ from
+// the expected ones is shown on out, and only the changes accepted by the answers read from in are written. Every other
+// record is copied unchanged, as with WithRegenerateFailing, which it enables.
+func WithInteractiveBless(in io.Reader, out io.Writer) RunOption {
+	return func(c *RunConfig) {
+		c.BlessInput = in
+		c.BlessOutput = out
+		c.RegenerateFailing = true
+	}
+}
+
+// WithSetupStatements adds statements to execute on the harness after it's initialized for each test file. See
+// ReadSetupScript to read them from a SQL script.
+func WithSetupStatements(statements ...string) RunOption {
+	return func(c *RunConfig) {
+		c.SetupStatements = append(c.SetupStatements, statements...)
+	}
+}
+
+// WithEngine sets the engine identifier that skipif and onlyif conditions are evaluated against.
+func WithEngine(engine string) RunOption {
+	return func(c *RunConfig) {
+		c.Engine = engine
+	}
+}
+
+// WithConditionEngines adds engine identifiers that skipif and onlyif conditions match, in addition to the engine's own.
+func WithConditionEngines(engines ...string) RunOption {
+	return func(c *RunConfig) {
+		c.ConditionEngines = append(c.ConditionEngines, engines...)
+	}
+}
+
+// WithExpectedFailures adds records that are known to fail, given as test file paths and line numbers, e.g.
+// "index/random/10/slt_good_0.test:535". Paths are matched as they're written in result logs, and longer paths are
+// shortened the same way first.
+func WithExpectedFailures(records ...string) RunOption {
+	return func(c *RunConfig) {
+		if c.ExpectedFailures == nil {
+			c.ExpectedFailures = make(map[string]bool)
+		}
+		for _, record := range records {
+			c.ExpectedFailures[expectedFailureKey(record)] = true
+		}
+	}
+}
+
+// WithShard restricts runs to the test files of the shard given.
+func WithShard(shard Shard) RunOption {
+	return func(c *RunConfig) {
+		c.Shard = shard
+	}
+}
+
+// WithTracer traces runs with the OpenTelemetry tracer given.
+func WithTracer(tracer trace.Tracer) RunOption {
+	return func(c *RunConfig) {
+		c.Tracer = tracer
+	}
+}
+
+// WithStatementBatchSize sets the maximum number of consecutive INSERT statements executed at once on harnesses that
+// implement BatchHarness.
+func WithStatementBatchSize(size int) RunOption {
+	return func(c *RunConfig) {
+		c.StatementBatchSize = size
+	}
+}
+
+// WithPreludeCache sets whether the preludes of test files are saved and restored for later files with the same
+// prelude, on harnesses that implement PreludeHarness.
+func WithPreludeCache(cache bool) RunOption {
+	return func(c *RunConfig) {
+		c.CachePreludes = cache
+	}
+}
+
+// WithSpillThreshold sets the size in bytes beyond which the results of a query from a StreamingHarness are spilled to
+// temporary files.
+func WithSpillThreshold(bytes int64) RunOption {
+	return func(c *RunConfig) {
+		c.SpillThreshold = bytes
+	}
+}
+
+// WithFS finds and reads test files in the file system given rather than the operating system's.
+func WithFS(fsys fs.FS) RunOption {
+	return func(c *RunConfig) {
+		c.FS = fsys
+	}
+}
+
+// WithComparator sets the comparator of the values of result columns of the schema type given.
+func WithComparator(typ rune, comparator ValueComparator) RunOption {
+	return func(c *RunConfig) {
+		comparators := make(map[rune]ValueComparator, len(c.Comparators)+1)
+		for t, cmp := range c.Comparators {
+			comparators[t] = cmp
+		}
+		comparators[typ] = comparator
+		c.Comparators = comparators
+	}
+}
+
+// WithFormatter sets the formatter used to render values. See RunConfig.Formatter.
+func WithFormatter(formatter *ValueFormatter) RunOption {
+	return func(c *RunConfig) {
+		c.Formatter = formatter
+	}
+}
+
+// WithNormalizers adds normalizers applied to expected and actual values before they're compared.
+func WithNormalizers(normalizers ...Normalizer) RunOption {
+	return func(c *RunConfig) {
+		c.Normalizers = append(c.Normalizers, normalizers...)
+	}
+}
+
+// WithErrorNormalizers adds normalizers applied to the text of errors before it's matched against expected patterns.
+func WithErrorNormalizers(normalizers ...ErrorNormalizer) RunOption {
+	return func(c *RunConfig) {
+		c.ErrorNormalizers = append(c.ErrorNormalizers, normalizers...)
+	}
+}
+
+// WithQueryNormalizers normalizes the raw text of statements and queries with the normalizers given, in place of the
+// parser's normalization. With none, statements and queries are executed verbatim, comments and line breaks included.
+func WithQueryNormalizers(normalizers ...QueryNormalizer) RunOption {
+	return func(c *RunConfig) {
+		c.QueryNormalizers = append(append([]QueryNormalizer{}, c.QueryNormalizers...), normalizers...)
+	}
+}
+
+// WithLargeValues rewrites text and binary result values longer than the threshold given with the policy given.
+func WithLargeValues(threshold int, policy LargeValuePolicy) RunOption {
+	return func(c *RunConfig) {
+		c.LargeValueThreshold = threshold
+		c.LargeValuePolicy = policy
+	}
+}
+
+// WithStrictSchemas reports integer result columns where queries expect R, unless their schemas are ambiguous.
+func WithStrictSchemas(strict bool) RunOption {
+	return func(c *RunConfig) {
+		c.StrictSchemas = strict
+	}
+}
+
+// WithFixSchemas only rewrites the schemas of queries when generating test files.
+func WithFixSchemas(fix bool) RunOption {
+	return func(c *RunConfig) {
+		c.FixSchemas = fix
+	}
+}
+
+// WithExcludes leaves the test files matched by any of the glob patterns given out of runs and generation.
+func WithExcludes(patterns ...string) RunOption {
+	return func(c *RunConfig) {
+		c.Excludes = append(c.Excludes, patterns...)
+	}
+}
+
+// WithMaxDuration sets the time budget of runs, after which no further test files are started.
+func WithMaxDuration(d time.Duration) RunOption {
+	return func(c *RunConfig) {
+		c.MaxDuration = d
+	}
+}
+
+// WithStressCopies sets the number of harnesses a ParallelRunner runs each test file on at once, to stress the engine
+// under test with concurrent runs of the same file. It must not be more than the size of the runner's pool.
+func WithStressCopies(copies int) RunOption {
+	return func(c *RunConfig) {
+		c.StressCopies = copies
+	}
+}
+
+// WithConfirmFailures reruns each record that fails the number of times given to confirm its failure. Combine it with
+// WithContinueOnFailure to confirm every failure of a run rather than only the first.
+func WithConfirmFailures(reruns int) RunOption {
+	return func(c *RunConfig) {
+		c.ConfirmFailures = reruns
+	}
+}
+
+// WithContinueOnFailure sets whether failures are only reported rather than stopping the run.
+func WithContinueOnFailure(cont bool) RunOption {
+	return func(c *RunConfig) {
+		c.ContinueOnFailure = cont
+	}
+}
+
+// WithWarmupRecords executes the first queries of each test file, up to the number given, once without verification
+// before they're run.
+func WithWarmupRecords(n int) RunOption {
+	return func(c *RunConfig) {
+		c.WarmupRecords = n
+	}
+}
+
+// WithWarmupQueries adds queries to execute once on the harness before each test file, discarding their results.
+func WithWarmupQueries(queries ...string) RunOption {
+	return func(c *RunConfig) {
+		c.WarmupQueries = append(c.WarmupQueries, queries...)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRunnerOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(),
+		WithOutput(buf),
+		WithReporters(reporter),
+		WithTruncateQueries(true),
+		WithTimeout(time.Second),
+		WithHashThreshold(4),
+		WithFloatEpsilon(0.01),
+		WithHashAlgorithm(XXH64),
+		WithHashCheckpointInterval(10),
+		WithHashBisection(true),
+	)
+
+	config := runner.Config()
+	assert.Equal(t, buf, config.Output)
+	assert.Equal(t, []Reporter{reporter}, config.Reporters)
+	assert.True(t, config.TruncateQueries)
+	assert.Equal(t, time.Second, config.Timeout)
+	assert.Equal(t, time.Second, runner.timeout())
+	assert.Equal(t, 4, config.HashThreshold)
+	assert.Equal(t, 0.01, config.FloatEpsilon)
+	assert.Equal(t, XXH64, config.HashAlgorithm)
+	assert.Equal(t, 10, config.HashCheckpointInterval)
+	assert.True(t, config.BisectHashMismatches)
+
+	runner.RunTestFiles("testdata/basic.test")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Len(t, reporter.entries, 5)
+}
+
+func TestDefaultRunConfig(t *testing.T) {
+	runner := NewRunner(newFakeHarness())
+	assert.Equal(t, defaultTimeout, runner.timeout())
+	assert.Equal(t, MD5, runner.Config().HashAlgorithm)
+	assert.Equal(t, 0, runner.Config().HashThreshold)
+}
+
+func TestTranslators(t *testing.T) {
+	rename := TranslatorFunc(func(query string) (string, error) {
+		return strings.ReplaceAll(query, "renamed", "t1"), nil
+	})
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithTranslators(rename))
+	runner.RunTestFiles(writeTestFile(t, "query II nosort\nSELECT a, b FROM renamed\n----\n1\n2\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, "SELECT a, b FROM renamed", reporter.entries[0].Query)
+}
@@ -0,0 +1,199 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A ConfigFile is a run configuration read from a YAML file, so that the options of complex runs, e.g. in CI, needn't
+// be spelled out as flags. For example:
+//
+//	paths:
+//	  - test/select
+//	  - test/index
+//	engine: dolt
+//	condition-engines: [mysql]
+//	timeout: 30s
+//	parallelism: 4
+//	reporters:
+//	  - format: json
+//	    path: results.json
+//	expected-failures:
+//	  - known-failures.txt
+//	exclude:
+//	  - slow_*.test
+//
+// Relative paths are resolved relative to the directory of the file.
+type ConfigFile struct {
+	// Paths are the test files and directories to run.
+	Paths []string `yaml:"paths"`
+	// Harness, DSN and Driver select the harness of the sqllogictest command, see cmd/sqllogictest. They're ignored by
+	// RunOptions.
+	Harness string `yaml:"harness"`
+	DSN     string `yaml:"dsn"`
+	Driver  string `yaml:"driver"`
+	// Engine sets RunConfig.Engine.
+	Engine string `yaml:"engine"`
+	// ConditionEngines sets RunConfig.ConditionEngines.
+	ConditionEngines []string `yaml:"condition-engines"`
+	// Timeout sets RunConfig.Timeout, written as a duration like 30s.
+	Timeout time.Duration `yaml:"timeout"`
+	// Parallelism is the number of test files to run concurrently, for programs that run them with a ParallelRunner.
+	Parallelism int `yaml:"parallelism"`
+	// Reporters are the reporters to add to RunConfig.Reporters.
+	Reporters []ReporterConfig `yaml:"reporters"`
+	// ExpectedFailures are expected failures files, read by ReadExpectedFailures, listing the records to add to
+	// RunConfig.ExpectedFailures.
+	ExpectedFailures []string `yaml:"expected-failures"`
+	// Exclude are glob patterns of test files to leave out, setting RunConfig.Excludes.
+	Exclude []string `yaml:"exclude"`
+}
+
+// ReporterConfig configures a reporter of a ConfigFile.
+type ReporterConfig struct {
+	// Format is the format of the reporter: text, for a TextReporter, json, for a JSONReporter, or html, for an
+	// HTMLReporter.
+	Format string `yaml:"format"`
+	// Path is the file the reporter writes to, or STDOUT if it's empty or "-".
+	Path string `yaml:"path"`
+}
+
+// LoadConfigFile reads the run configuration in the YAML file given. Unknown keys are an error, so that misspelled
+// options aren't silently ignored.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ConfigFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if config.Parallelism < 0 {
+		return nil, fmt.Errorf("%s: parallelism must not be negative, got %d", path, config.Parallelism)
+	}
+	for _, reporter := range config.Reporters {
+		if reporter.Format != "text" && reporter.Format != "json" && reporter.Format != "html" {
+			return nil, fmt.Errorf("%s: unknown reporter format %q, expected text, json or html", path, reporter.Format)
+		}
+	}
+
+	for _, pattern := range config.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid exclude pattern %s: %w", path, pattern, err)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	for i := range config.Paths {
+		config.Paths[i] = resolvePath(dir, config.Paths[i])
+	}
+	for i := range config.ExpectedFailures {
+		config.ExpectedFailures[i] = resolvePath(dir, config.ExpectedFailures[i])
+	}
+	for i := range config.Reporters {
+		if config.Reporters[i].Path != "" && config.Reporters[i].Path != "-" {
+			config.Reporters[i].Path = resolvePath(dir, config.Reporters[i].Path)
+		}
+	}
+	return config, nil
+}
+
+// resolvePath returns the path given, resolved relative to the directory given if it's relative.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// RunOptions returns the run options configured by this file, and a closer for the files its reporters write to, which
+// should be closed once the run is done.
+func (c *ConfigFile) RunOptions() ([]RunOption, io.Closer, error) {
+	var opts []RunOption
+	if c.Engine != "" {
+		opts = append(opts, WithEngine(c.Engine))
+	}
+	if len(c.ConditionEngines) > 0 {
+		opts = append(opts, WithConditionEngines(c.ConditionEngines...))
+	}
+	if c.Timeout != 0 {
+		opts = append(opts, WithTimeout(c.Timeout))
+	}
+	if len(c.Exclude) > 0 {
+		opts = append(opts, WithExcludes(c.Exclude...))
+	}
+
+	for _, path := range c.ExpectedFailures {
+		records, err := ReadExpectedFailures(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithExpectedFailures(records...))
+	}
+
+	// HTML reports are written when closed, so they're closed before the files they're written to
+	var reports, files closers
+	for _, reporter := range c.Reporters {
+		var w io.Writer = os.Stdout
+		if reporter.Path != "" && reporter.Path != "-" {
+			file, err := os.Create(reporter.Path)
+			if err != nil {
+				files.Close()
+				return nil, nil, err
+			}
+			files = append(files, file)
+			w = file
+		}
+
+		switch reporter.Format {
+		case "json":
+			opts = append(opts, WithReporters(NewJSONReporter(w)))
+		case "html":
+			report := NewHTMLReporter(w)
+			reports = append(reports, report)
+			opts = append(opts, WithReporters(report))
+		default:
+			opts = append(opts, WithReporters(NewTextReporter(w)))
+		}
+	}
+
+	return opts, append(reports, files...), nil
+}
+
+// closers closes all of a list of files, returning the first error.
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var firstErr error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,165 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sqllogictest.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "failures.txt"), []byte("# known\nselect/t.test:12 wrong rounding\n\n"), 0644))
+	require.NoError(t, os.WriteFile(path, []byte(`paths:
+  - select
+  - /abs/index
+harness: sql
+driver: sqlite3
+engine: dolt
+condition-engines: [mysql]
+timeout: 30s
+parallelism: 4
+reporters:
+  - format: json
+    path: results.json
+  - format: text
+  - format: html
+    path: report.html
+expected-failures:
+  - failures.txt
+exclude:
+  - slow_*.test
+`), 0644))
+
+	config, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "select"), "/abs/index"}, config.Paths)
+	assert.Equal(t, "sql", config.Harness)
+	assert.Equal(t, "sqlite3", config.Driver)
+	assert.Equal(t, 30*time.Second, config.Timeout)
+	assert.Equal(t, 4, config.Parallelism)
+	assert.Equal(t, []ReporterConfig{{Format: "json", Path: filepath.Join(dir, "results.json")}, {Format: "text"},
+		{Format: "html", Path: filepath.Join(dir, "report.html")}}, config.Reporters)
+
+	opts, closer, err := config.RunOptions()
+	require.NoError(t, err)
+	runConfig := NewRunner(newFakeHarness(), opts...).Config()
+	assert.Equal(t, "dolt", runConfig.Engine)
+	assert.Equal(t, []string{"mysql"}, runConfig.ConditionEngines)
+	assert.Equal(t, 30*time.Second, runConfig.Timeout)
+	assert.Len(t, runConfig.Reporters, 3)
+	assert.Equal(t, map[string]bool{"select/t.test:12": true}, runConfig.ExpectedFailures)
+	assert.Equal(t, []string{"slow_*.test"}, runConfig.Excludes)
+	assert.FileExists(t, filepath.Join(dir, "results.json"))
+
+	require.NoError(t, closer.Close())
+	report, err := os.ReadFile(filepath.Join(dir, "report.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "<h1>sqllogictest report</h1>")
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, contents := range []string{
+		"paths: [a]\ntimeuot: 30s\n",
+		"reporters:\n  - format: xml\n",
+		"parallelism: -1\n",
+		"exclude: ['[a-']\n",
+	} {
+		path := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		_, err := LoadConfigFile(path)
+		assert.Error(t, err, contents)
+	}
+
+	_, err := LoadConfigFile(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestConditionEngines(t *testing.T) {
+	path := writeTestFile(t, `onlyif mysql
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+skipif fake
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`)
+
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+	assert.Equal(t, Skipped, reporter.entries[1].Result)
+
+	// The onlyif record runs for an engine treated as mysql, and the skipif record runs when the engine string is
+	// overridden
+	reporter = &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithEngine("other"),
+		WithConditionEngines("mysql")).RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+}
+
+func TestExpectedFailures(t *testing.T) {
+	path := writeTestFile(t, `query II nosort
+SELECT a, b FROM t1
+----
+1
+3
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`)
+
+	reporter := &collectingReporter{}
+	buf := &bytes.Buffer{}
+	NewRunner(newFakeHarness(), WithOutput(buf), WithReporters(reporter), WithExpectedFailures(path+":2")).RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+	assert.True(t, strings.HasPrefix(reporter.entries[0].ErrorMessage, "expected failure: "), reporter.entries[0].ErrorMessage)
+	assert.NotContains(t, strings.Split(buf.String(), "\n")[0], "not ok")
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+}
+
+func TestReadExpectedFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failures.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a/b.test:3\n  # comment\nc.test:10: reason\n"), 0644))
+	records, err := ReadExpectedFailures(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b.test:3", "c.test:10"}, records)
+
+	require.NoError(t, os.WriteFile(path, []byte("a/b.test\n"), 0644))
+	_, err = ReadExpectedFailures(path)
+	assert.Error(t, err)
+}
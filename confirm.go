@@ -0,0 +1,114 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A Confirmation is the outcome of rerunning a failed record to confirm its failure.
+type Confirmation string
+
+const (
+	// ConsistentFailure is the confirmation of a record that failed the same way in every rerun.
+	ConsistentFailure Confirmation = "consistent"
+	// FlakyFailure is the confirmation of a record that passed, or failed differently, in any rerun.
+	FlakyFailure Confirmation = "flaky"
+)
+
+// A heldFailure is a failure of a record held back from the result log until it's confirmed.
+type heldFailure struct {
+	record *parser.Record
+	entry  *ResultLogEntry
+}
+
+// holdFailure holds back the failure given of the record given, to report it once it's confirmed at the end of its
+// test file. Returns false if failures aren't confirmed by this runner, in which case the failure should be reported
+// as it is.
+func (r *Runner) holdFailure(record *parser.Record, entry *ResultLogEntry) bool {
+	if r.config.ConfirmFailures <= 0 {
+		return false
+	}
+
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	r.heldFailures = append(r.heldFailures, heldFailure{record: record, entry: entry})
+	return true
+}
+
+// confirmFailures reruns each record of the test file given that failed the configured number of times in isolation,
+// i.e. after the records before it in the file on a freshly initialized harness, as BisectStateDependence does. Each
+// failure is then reported, confirmed as consistent if the record failed with the same failure code in every rerun,
+// and flaky otherwise.
+func (r *Runner) confirmFailures(file string) {
+	r.stateMux.Lock()
+	failures := r.heldFailures
+	r.heldFailures = nil
+	r.stateMux.Unlock()
+	if len(failures) == 0 {
+		return
+	}
+
+	// Failures are still reported if the test file can't be parsed again, without a confirmation
+	records, err := r.config.parseTestFile(file)
+	for _, failure := range failures {
+		if err == nil {
+			failure.entry.Confirmation = r.confirmFailure(file, records, failure)
+		}
+		emitLogEntry(r.config, failure.entry)
+	}
+}
+
+// confirmFailure reruns the failed record given from the test file with the records given, and returns the
+// confirmation of its failure.
+func (r *Runner) confirmFailure(file string, records []*parser.Record, failure heldFailure) Confirmation {
+	var earlier []*parser.Record
+	for _, record := range records {
+		if record.LineNum() == failure.record.LineNum() {
+			break
+		}
+		if record.Type() != parser.Halt {
+			earlier = append(earlier, record)
+		}
+	}
+
+	for i := 0; i < r.config.ConfirmFailures; i++ {
+		result := r.runTrial(file, earlier, failure.record)
+		if result == nil || result.Result != NotOk || result.FailureCode != failure.entry.FailureCode {
+			return FlakyFailure
+		}
+	}
+	return ConsistentFailure
+}
+
+// confirmationSuffix returns the suffix of the error message of failures with the confirmation given in text result
+// logs.
+func confirmationSuffix(confirmation Confirmation) string {
+	return fmt.Sprintf(" (%s)", confirmation)
+}
+
+// splitConfirmation splits the confirmation of a failure from its error message in a text result log, returning the
+// error message without it.
+func splitConfirmation(message string) (string, Confirmation) {
+	for _, confirmation := range []Confirmation{ConsistentFailure, FlakyFailure} {
+		if strings.HasSuffix(message, confirmationSuffix(confirmation)) {
+			return strings.TrimSuffix(message, confirmationSuffix(confirmation)), confirmation
+		}
+	}
+	return message, ""
+}
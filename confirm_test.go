@@ -0,0 +1,112 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const confirmTest = `statement ok
+INSERT INTO missing VALUES(1, 2)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+query R nosort
+SELECT 1.0 / 3
+----
+0.333
+
+statement ok
+INSERT INTO t1 VALUES (1)
+`
+
+func TestConfirmFailures(t *testing.T) {
+	path := writeTestFile(t, confirmTest)
+	// The query fails the first time it runs, and passes when it's rerun
+	harness := &alternatingHarness{fakeHarness: newFakeHarness(), query: "SELECT a, b FROM t1", calls: 1}
+
+	var output bytes.Buffer
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&output), WithReporters(reporter), WithConfirmFailures(2),
+		WithContinueOnFailure(true)).RunTestFiles(path)
+
+	// Failures are reported once the test file has run
+	require.Len(t, reporter.entries, 4)
+	assert.Equal(t, 16, reporter.entries[0].LineNum)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	for i, expected := range []struct {
+		line         int
+		code         FailureCode
+		confirmation Confirmation
+	}{
+		{2, UnexpectedError, ConsistentFailure},
+		{5, ValueMismatch, FlakyFailure},
+		{11, ValueMismatch, ConsistentFailure},
+	} {
+		entry := reporter.entries[i+1]
+		assert.Equal(t, expected.line, entry.LineNum)
+		assert.Equal(t, NotOk, entry.Result)
+		assert.Equal(t, expected.code, entry.FailureCode)
+		assert.Equal(t, expected.confirmation, entry.Confirmation)
+	}
+
+	// Confirmations are written to text result logs, and parsed back
+	assert.Contains(t, output.String(), "not ok: Unexpected error table not found: missing (consistent)\n")
+	log := filepath.Join(t.TempDir(), "results.log")
+	require.NoError(t, os.WriteFile(log, output.Bytes(), 0644))
+	entries, err := ParseResultFile(log)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "Unexpected error table not found: missing", entries[1].ErrorMessage)
+	assert.Equal(t, ConsistentFailure, entries[1].Confirmation)
+	assert.Equal(t, FlakyFailure, entries[2].Confirmation)
+	assert.Equal(t, ValueMismatch, entries[3].FailureCode)
+}
+
+func TestConfirmFailuresStopRun(t *testing.T) {
+	path := writeTestFile(t, confirmTest)
+	harness := newFakeHarness()
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithConfirmFailures(2))
+
+	// Without ContinueOnFailure, the first failure stops the run once it has been rerun and reported
+	assert.Panics(t, func() { runner.RunTestFiles(path) })
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, 2, reporter.entries[0].LineNum)
+	assert.Equal(t, UnexpectedError, reporter.entries[0].FailureCode)
+	assert.Equal(t, ConsistentFailure, reporter.entries[0].Confirmation)
+}
+
+func TestConfirmFailuresExpected(t *testing.T) {
+	path := writeTestFile(t, confirmTest)
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithConfirmFailures(2),
+		WithExpectedFailures(path+":2"), WithContinueOnFailure(true)).RunTestFiles(path)
+
+	// Expected failures aren't rerun
+	require.Len(t, reporter.entries, 4)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+	assert.Empty(t, reporter.entries[0].Confirmation)
+}
@@ -0,0 +1,283 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// MultiConnectionHarness is a Harness that can open additional connections to the database it tests, for records
+// that run on a named connection, such as interleaved statements in isolation tests. Harnesses that don't implement
+// this interface skip records on named connections.
+type MultiConnectionHarness interface {
+	Harness
+	// NewConnection opens a new connection to the database under test, sharing its state with the harness but not its
+	// session. The connection is closed at the end of the test file if it implements io.Closer.
+	NewConnection() (Harness, error)
+}
+
+// defaultAsyncStatementDelay is how long the runner waits after starting an async statement by default.
+const defaultAsyncStatementDelay = 100 * time.Millisecond
+
+// errNoConnections is returned when a record needs a named connection but the harness can't open one.
+var errNoConnections = errors.New("harness doesn't support multiple connections")
+
+// asyncStatement is a statement executing in the background until it is awaited.
+type asyncStatement struct {
+	name   string
+	record *parser.Record
+	// harness is the connection the statement executes on
+	harness Harness
+	// ctx is the context the statement executes and logs in
+	ctx    context.Context
+	cancel context.CancelFunc
+	// finished is closed once the statement finishes, after its warnings and error are set
+	finished chan struct{}
+	warnings []Warning
+	err      error
+}
+
+// wait waits for the statement to finish and returns whether it did, or false if it timed out first.
+func (s *asyncStatement) wait() bool {
+	select {
+	case <-s.finished:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// connection returns the harness to execute records on the connection named, opening it on first use. The default
+// connection is the runner's harness.
+func (r *Runner) connection(name string) (Harness, error) {
+	if name == defaultConnection {
+		return r.harness, nil
+	}
+
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	if conn, ok := r.connections[name]; ok {
+		return conn, nil
+	}
+
+	multiHarness, ok := r.harness.(MultiConnectionHarness)
+	if !ok {
+		return nil, errNoConnections
+	}
+	conn, err := multiHarness.NewConnection()
+	if err != nil {
+		return nil, err
+	}
+	r.config.setFormatter(conn)
+	if r.connections == nil {
+		r.connections = make(map[string]Harness)
+	}
+	r.connections[name] = conn
+	return conn, nil
+}
+
+// closeConnections cancels any async statement still executing and closes the named connections opened by the current
+// test file.
+func (r *Runner) closeConnections() {
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+
+	for _, statement := range r.asyncStatements {
+		statement.cancel()
+	}
+	r.asyncStatements = nil
+
+	for _, conn := range r.connections {
+		if closer, ok := conn.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	r.connections = nil
+}
+
+// startAsyncStatement starts executing the statement record given in the background on the harness given, to be
+// verified by a later awaitstatement record. The statement has its own timeout, starting now.
+func (r *Runner) startAsyncStatement(ctx context.Context, harness Harness, preparedHarness PreparedStatementHarness, record *parser.Record) *R {
+	name := record.AsyncName()
+	if r.pendingStatement(name, false) != nil {
+		logFailure(ctx, UnexpectedError, "Async statement %s is already pending", name)
+		return &R{cont: true, err: fmt.Errorf("async statement %s is already pending", name)}
+	}
+
+	// The statement outlives the record's context, but logs its result as the same record
+	asyncCtx, cancel := context.WithTimeout(context.Background(), r.recordTimeout(record))
+	asyncCtx = context.WithValue(asyncCtx, "lock", ctx.Value("lock"))
+	statement := &asyncStatement{
+		name:     name,
+		record:   record,
+		harness:  harness,
+		ctx:      asyncCtx,
+		cancel:   cancel,
+		finished: make(chan struct{}),
+	}
+
+	r.stateMux.Lock()
+	r.asyncStatements = append(r.asyncStatements, statement)
+	r.stateMux.Unlock()
+
+	go func() {
+		defer close(statement.finished)
+		defer func() {
+			if p := recover(); p != nil {
+				statement.err = fmt.Errorf("panic executing async statement: %v", p)
+			}
+		}()
+		statement.warnings, statement.err = executeStatement(asyncCtx, harness, preparedHarness, record)
+	}()
+
+	select {
+	case <-statement.finished:
+	case <-time.After(r.asyncStatementDelay()):
+	}
+	return &R{cont: true}
+}
+
+// asyncStatementDelay returns how long to wait after starting an async statement before executing the next record.
+func (r *Runner) asyncStatementDelay() time.Duration {
+	if r.config.AsyncStatementDelay != 0 {
+		return r.config.AsyncStatementDelay
+	}
+	return defaultAsyncStatementDelay
+}
+
+// awaitStatement waits for the async statement named by the awaitstatement record given to finish, and verifies and
+// logs its result.
+func (r *Runner) awaitStatement(ctx context.Context, record *parser.Record) *R {
+	statement := r.pendingStatement(record.AsyncName(), true)
+	if statement == nil {
+		logFailure(ctx, UnexpectedError, "No pending async statement named %s", record.AsyncName())
+		return &R{cont: true, err: fmt.Errorf("no pending async statement named %s", record.AsyncName())}
+	}
+	return r.finishAsyncStatement(statement)
+}
+
+// awaitAsyncStatements waits for the async statements of the current test file that were never awaited, in the order
+// they started, and returns the first error verifying them.
+func (r *Runner) awaitAsyncStatements() error {
+	r.stateMux.Lock()
+	statements := r.asyncStatements
+	r.asyncStatements = nil
+	r.stateMux.Unlock()
+
+	var firstErr error
+	for _, statement := range statements {
+		if res := r.finishAsyncStatement(statement); res.err != nil && firstErr == nil {
+			firstErr = res.err
+		}
+	}
+	return firstErr
+}
+
+// finishAsyncStatement waits for the async statement given to finish or time out, and verifies and logs its result.
+func (r *Runner) finishAsyncStatement(statement *asyncStatement) *R {
+	defer statement.cancel()
+
+	if !statement.wait() {
+		logResult(statement.ctx, Timeout, "")
+		return &R{cont: true, err: testTimeoutError}
+	}
+	return r.verifyStatement(statement.ctx, statement.harness, statement.record, statement.warnings, statement.err)
+}
+
+// awaitConflict waits for all the async statements named by the awaitdeadlock or awaitlocktimeout record given to
+// finish, and verifies that one of them failed with the deadlock or lock wait timeout the record expects. That
+// statement is logged as ok, and the others are verified as usual. A deadlock victim's transaction is rolled back by
+// the engine, and so is no longer active.
+func (r *Runner) awaitConflict(ctx context.Context, record *parser.Record) *R {
+	for _, name := range record.AsyncNames() {
+		if r.pendingStatement(name, false) == nil {
+			logFailure(ctx, UnexpectedError, "No pending async statement named %s", name)
+			return &R{cont: true, err: fmt.Errorf("no pending async statement named %s", name)}
+		}
+	}
+
+	statements := make([]*asyncStatement, len(record.AsyncNames()))
+	finished := make([]bool, len(statements))
+	for i, name := range record.AsyncNames() {
+		statements[i] = r.pendingStatement(name, true)
+		defer statements[i].cancel()
+	}
+	for i, statement := range statements {
+		finished[i] = statement.wait()
+	}
+
+	expected := record.ExpectedError()
+	victim := -1
+	for i, statement := range statements {
+		if finished[i] && statement.err != nil && r.isError(statement.err, expected) {
+			victim = i
+			break
+		}
+	}
+
+	res := &R{cont: true}
+	for i, statement := range statements {
+		var sres *R
+		switch {
+		case i == victim:
+			if expected == parser.ConflictDeadlock {
+				r.setTransactionActive(statement.record.Connection(), false)
+			}
+			logResult(statement.ctx, Ok, "")
+			continue
+		case !finished[i]:
+			logResult(statement.ctx, Timeout, "")
+			sres = &R{cont: true, err: testTimeoutError}
+		default:
+			sres = r.verifyStatement(statement.ctx, statement.harness, statement.record, statement.warnings, statement.err)
+		}
+		if sres.err != nil && res.err == nil {
+			res.err = sres.err
+		}
+	}
+
+	if victim < 0 {
+		names := strings.Join(record.AsyncNames(), ", ")
+		logFailure(ctx, MissingExpectedError, "Expected %s from one of %s but got none", expected, names)
+		if res.err == nil {
+			res.err = fmt.Errorf("expected %s from one of %s but got none", expected, names)
+		}
+	}
+	return res
+}
+
+// pendingStatement returns the pending async statement with the name given, or nil if there is none, removing it from
+// the pending statements if remove is true.
+func (r *Runner) pendingStatement(name string, remove bool) *asyncStatement {
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+
+	for i, statement := range r.asyncStatements {
+		if statement.name == name {
+			if remove {
+				r.asyncStatements = append(r.asyncStatements[:i:i], r.asyncStatements[i+1:]...)
+			}
+			return statement
+		}
+	}
+	return nil
+}
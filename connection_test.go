@@ -0,0 +1,277 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockingHarness is a harness with a single table lock shared by all its connections, held by a connection between
+// its LOCK and UNLOCK statements. A LOCK statement blocks while another connection holds the lock. A DEADLOCK
+// statement fails as the victim of a deadlock, releasing the lock.
+type lockingHarness struct {
+	*statementRecorder
+	lock   chan struct{}
+	mu     sync.Mutex
+	closed int
+}
+
+var _ MultiConnectionHarness = &lockingHarness{}
+var _ ErrorClassifier = &lockingHarness{}
+
+var errDeadlock = errors.New("deadlock found when trying to get lock")
+
+func newLockingHarness() *lockingHarness {
+	return &lockingHarness{
+		statementRecorder: &statementRecorder{fakeHarness: newFakeHarness()},
+		lock:              make(chan struct{}, 1),
+	}
+}
+
+func (h *lockingHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	switch statement {
+	case "LOCK":
+		select {
+		case h.lock <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case "UNLOCK":
+		<-h.lock
+	case "FAIL":
+		return errors.New("statement failed")
+	case "DEADLOCK":
+		<-h.lock
+		return errDeadlock
+	}
+	return h.statementRecorder.ExecuteStatement(ctx, statement)
+}
+
+func (h *lockingHarness) ClassifyError(err error) ErrorClass {
+	if err == errDeadlock {
+		return ErrorClass{SQLState: "40001", Category: CategoryDeadlock}
+	}
+	return ErrorClass{}
+}
+
+func (h *lockingHarness) NewConnection() (Harness, error) {
+	return &lockingConnection{lockingHarness: h}, nil
+}
+
+// lockingConnection is a named connection of a lockingHarness.
+type lockingConnection struct {
+	*lockingHarness
+}
+
+func (c *lockingConnection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed++
+	return nil
+}
+
+const asyncTest = `connection c1
+statement ok
+LOCK
+
+connection c2
+statement async l ok
+LOCK
+
+connection c1
+statement ok
+INSERT INTO t1 VALUES (1)
+
+connection c1
+statement ok
+UNLOCK
+
+awaitstatement l
+
+connection c2
+statement ok
+UNLOCK
+`
+
+func TestAsyncStatements(t *testing.T) {
+	harness := newLockingHarness()
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, asyncTest))
+
+	require.Len(t, reporter.entries, 5)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.Query)
+	}
+	// The async statement only executes once the first connection releases the lock
+	assert.Equal(t, []string{"LOCK", "INSERT INTO t1 VALUES (1)", "UNLOCK", "LOCK", "UNLOCK"}, harness.statements)
+	assert.Equal(t, 2, harness.closed)
+}
+
+func TestAsyncStatementErrors(t *testing.T) {
+	t.Run("unawaited statement is verified at end of file", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "connection c1\nstatement async s ok\nFAIL\n"))
+		})
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, UnexpectedError, reporter.entries[0].FailureCode)
+		assert.Equal(t, 3, reporter.entries[0].LineNum)
+	})
+
+	t.Run("unknown statement", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "awaitstatement s\n"))
+		})
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, "No pending async statement named s", reporter.entries[0].ErrorMessage)
+	})
+
+	t.Run("expected error", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		runner.RunTestFiles(writeTestFile(t, "connection c1\nstatement async s error\nFAIL\n\nawaitstatement s\n"))
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, Ok, reporter.entries[0].Result)
+	})
+
+	t.Run("record timeout", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+			WithTimeout(10*time.Second), WithAsyncStatementDelay(time.Millisecond))
+		start := time.Now()
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t,
+				"connection c1\nstatement ok\nLOCK\n\nconnection c2\ntimeout 50ms\nstatement async s ok\nLOCK\n\nawaitstatement s\n"))
+		})
+		require.Len(t, reporter.entries, 2)
+		assert.Equal(t, Timeout, reporter.entries[1].Result)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestNamedConnectionsUnsupported(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "connection c1\nstatement ok\nINSERT INTO t1 VALUES (1)\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+}
+
+func TestGenerateAsyncStatements(t *testing.T) {
+	path := writeTestFile(t, asyncTest)
+	runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, asyncTest, string(generated))
+}
+
+const deadlockTest = `connection c1
+txn begin
+
+connection c1
+statement ok
+LOCK
+
+connection c2
+statement async a ok
+LOCK
+
+connection c1
+statement async b ok
+DEADLOCK
+
+awaitdeadlock a b
+
+connection c1
+txn inactive
+
+connection c2
+statement ok
+UNLOCK
+`
+
+func TestAwaitDeadlock(t *testing.T) {
+	harness := newLockingHarness()
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithAsyncStatementDelay(time.Millisecond))
+	runner.RunTestFiles(writeTestFile(t, deadlockTest))
+
+	require.Len(t, reporter.entries, 6)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.Query)
+	}
+	assert.Equal(t, []string{"BEGIN", "LOCK", "LOCK", "UNLOCK"}, harness.statements)
+}
+
+func TestAwaitConflictErrors(t *testing.T) {
+	t.Run("no conflict", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "connection c1\nstatement async a ok\nLOCK\n\nawaitlocktimeout a\n"))
+		})
+		require.Len(t, reporter.entries, 2)
+		assert.Equal(t, Ok, reporter.entries[0].Result)
+		assert.Equal(t, MissingExpectedError, reporter.entries[1].FailureCode)
+		assert.Equal(t, "Expected lock-timeout from one of a but got none", reporter.entries[1].ErrorMessage)
+		assert.Equal(t, MissingExpectedError, classifyFailure(reporter.entries[1].ErrorMessage))
+	})
+
+	t.Run("wrong conflict", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "statement ok\nLOCK\n\nconnection c1\nstatement async a ok\nDEADLOCK\n\nawaitlocktimeout a\n"))
+		})
+		require.Len(t, reporter.entries, 3)
+		assert.Equal(t, UnexpectedError, reporter.entries[1].FailureCode)
+		assert.Equal(t, MissingExpectedError, reporter.entries[2].FailureCode)
+	})
+
+	t.Run("unknown statement", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "connection c1\nstatement async a ok\nLOCK\n\nawaitdeadlock a b\n"))
+		})
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, "No pending async statement named b", reporter.entries[0].ErrorMessage)
+	})
+}
+
+func TestGenerateAwaitConflict(t *testing.T) {
+	path := writeTestFile(t, deadlockTest)
+	runner := NewRunner(newLockingHarness(), WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, deadlockTest, string(generated))
+}
@@ -0,0 +1,294 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convert converts plain SQL scripts, together with the expected results of their queries captured as CSV
+// files, into sqllogictest test files.
+package convert
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// Options configure the conversion of a script.
+type Options struct {
+	// Header is whether the first row of each CSV file holds the column names of the query. When set, the names are
+	// written as a colnames directive for the query.
+	Header bool
+	// NullString is the CSV value that represents NULL. Defaults to the empty string, in which case empty strings
+	// cannot be represented.
+	NullString string
+	// SortMode is the sort mode written for every query. When empty, queries with an ORDER BY clause are written with
+	// nosort, and all others with rowsort.
+	SortMode parser.SortMode
+	// Formatter renders the values of expected results. Defaults to logictest.NewValueFormatter.
+	Formatter *logictest.ValueFormatter
+}
+
+// A ResultSource opens the CSV file holding the expected results of the query with the index given, counting the
+// queries of the script from 1.
+type ResultSource func(query int) (io.ReadCloser, error)
+
+// queryKeywords are the leading keywords of the statements that return rows, and are converted into query records.
+var queryKeywords = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"VALUES":   true,
+	"TABLE":    true,
+	"SHOW":     true,
+	"EXPLAIN":  true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"PRAGMA":   true,
+}
+
+var orderByRegex = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+// ConvertFiles converts the SQL script at the path given into a test file written to w. The expected results of the
+// Nth query of the script are read from the file N.csv in resultsDir.
+func ConvertFiles(w io.Writer, scriptPath, resultsDir string, opts Options) error {
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	return Convert(w, string(script), func(query int) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(resultsDir, strconv.Itoa(query)+".csv"))
+	}, opts)
+}
+
+// Convert converts the SQL script given into a test file written to w. Statements are separated by semicolons, and
+// lines starting with -- are comments. Statements that return rows become query records with the results read from
+// the source given, with schema letters inferred from the values; all others become statement ok records.
+func Convert(w io.Writer, script string, results ResultSource, opts Options) error {
+	if opts.Formatter == nil {
+		opts.Formatter = logictest.NewValueFormatter()
+	}
+	wr := bufio.NewWriter(w)
+	queries := 0
+	for _, statement := range logictest.SplitStatements(script) {
+		statement, err := FormatStatement(statement)
+		if err != nil {
+			return err
+		}
+		if !IsQuery(statement) {
+			wr.WriteString("statement ok\n" + statement + "\n\n")
+			continue
+		}
+
+		queries++
+		rc, err := results(queries)
+		if err != nil {
+			return fmt.Errorf("unable to open results of query %d: %v", queries, err)
+		}
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("invalid results of query %d: %v", queries, err)
+		}
+		if err := writeQuery(wr, statement, rows, opts); err != nil {
+			return fmt.Errorf("invalid results of query %d: %v", queries, err)
+		}
+	}
+	return wr.Flush()
+}
+
+// writeQuery writes a query record for the statement given, with the expected results given as CSV rows.
+func writeQuery(wr *bufio.Writer, statement string, rows [][]string, opts Options) error {
+	var columns []string
+	if opts.Header {
+		if len(rows) == 0 {
+			return fmt.Errorf("missing header")
+		}
+		columns, rows = rows[0], rows[1:]
+	}
+
+	numCols := len(columns)
+	if len(rows) > 0 {
+		numCols = len(rows[0])
+	}
+	if numCols == 0 {
+		return fmt.Errorf("unable to infer the columns of a query without rows or header")
+	}
+
+	types := inferTypes(rows, numCols, opts.NullString)
+	values := make([]string, 0, len(rows)*numCols)
+	for _, row := range rows {
+		for i, value := range row {
+			values = append(values, formatValue(opts.Formatter, value, types[i], opts.NullString))
+		}
+	}
+
+	sortMode := opts.SortMode
+	if sortMode == "" {
+		sortMode = DefaultSortMode(statement)
+	}
+	values = SortValues(sortMode, values, numCols)
+
+	if columns != nil {
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = strings.Join(strings.Fields(column), "")
+			if names[i] == "" {
+				names[i] = "?column?"
+			}
+		}
+		wr.WriteString("colnames " + strings.Join(names, " ") + "\n")
+	}
+	wr.WriteString("query " + string(types) + " " + string(sortMode) + "\n")
+	wr.WriteString(statement + "\n----\n")
+	for _, value := range values {
+		wr.WriteString(parser.EscapeResult(logictest.EscapeLiteral(value)) + "\n")
+	}
+	wr.WriteString("\n")
+	return nil
+}
+
+// inferTypes returns the schema letters of the columns of the rows given: I for columns whose values are all integers,
+// R for columns whose values are all numbers, and T for all others. Columns with only NULL values are T.
+func inferTypes(rows [][]string, numCols int, nullString string) []byte {
+	types := make([]byte, numCols)
+	for i := range types {
+		types[i] = 'I'
+	}
+	nonNull := make([]bool, numCols)
+	for _, row := range rows {
+		for i, value := range row {
+			if value == nullString {
+				continue
+			}
+			nonNull[i] = true
+			switch {
+			case types[i] == 'I' && isInteger(value):
+			case types[i] != 'T' && isNumber(value):
+				types[i] = 'R'
+			default:
+				types[i] = 'T'
+			}
+		}
+	}
+	for i := range types {
+		if !nonNull[i] {
+			types[i] = 'T'
+		}
+	}
+	return types
+}
+
+// formatValue renders the CSV value given, of a column with the schema letter given, as an expected result value with
+// the formatter given.
+func formatValue(f *logictest.ValueFormatter, value string, typ byte, nullString string) string {
+	switch {
+	case value == nullString:
+		return f.FormatNull()
+	case typ == 'I':
+		i, _ := strconv.ParseInt(value, 10, 64)
+		return f.FormatInt(i)
+	case typ == 'R':
+		n, _ := strconv.ParseFloat(value, 64)
+		return f.FormatFloat(n)
+	default:
+		return f.FormatString(value)
+	}
+}
+
+func isInteger(value string) bool {
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err == nil
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// IsQuery returns whether the statement given returns rows, and so is written as a query record.
+func IsQuery(statement string) bool {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return false
+	}
+	return queryKeywords[strings.ToUpper(strings.TrimLeft(fields[0], "("))]
+}
+
+// DefaultSortMode returns the sort mode written for the query given when none is configured: nosort for queries with
+// an ORDER BY clause, and rowsort for all others, whose rows an engine may return in any order.
+func DefaultSortMode(query string) parser.SortMode {
+	if orderByRegex.MatchString(query) {
+		return parser.NoSort
+	}
+	return parser.Rowsort
+}
+
+// FormatStatement formats the statement given for a record of a test file. Test files concatenate the lines of a
+// record's statement as they are, so the statement is joined into a single line to keep its tokens apart. Returns an
+// error for statements containing # outside quotes, which test files treat as the start of a comment.
+func FormatStatement(statement string) (string, error) {
+	lines := strings.Split(statement, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	statement = strings.Join(kept, " ")
+	if parser.StripComment(statement) != statement {
+		return "", fmt.Errorf("unable to convert statement %q: test files treat # as the start of a comment", statement)
+	}
+	return statement, nil
+}
+
+// SortValues sorts the result values given, which have the number of columns given, the way the runner sorts results
+// for the sort mode given.
+func SortValues(mode parser.SortMode, values []string, numCols int) []string {
+	switch mode {
+	case parser.Rowsort:
+		if numCols == 0 {
+			return values
+		}
+		rows := make([][]string, len(values)/numCols)
+		for i := range rows {
+			rows[i] = values[i*numCols : (i+1)*numCols]
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k := range rows[i] {
+				if rows[i][k] != rows[j][k] {
+					return rows[i][k] < rows[j][k]
+				}
+			}
+			return false
+		})
+		sorted := make([]string, 0, len(values))
+		for _, row := range rows {
+			sorted = append(sorted, row...)
+		}
+		return sorted
+	case parser.ValueSort:
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		return sorted
+	default:
+		return values
+	}
+}
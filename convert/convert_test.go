@@ -0,0 +1,133 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `-- Fixtures
+CREATE TABLE t1(a INTEGER, b REAL, c TEXT);
+
+INSERT INTO t1 VALUES (1, 1.5, 'x;y'), (2, NULL, 'z');
+
+SELECT a, b, c
+FROM t1;
+
+select c, a * 2 from t1 order by a desc;
+`
+
+var testResults = []string{
+	"a,b,c\n2,,z\n1,1.5,x;y\n",
+	"c,a * 2\nz,4\nx;y,2\n",
+}
+
+func testResultSource(results []string) ResultSource {
+	return func(query int) (io.ReadCloser, error) {
+		if query > len(results) {
+			return nil, os.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader(results[query-1])), nil
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Convert(&out, testScript, testResultSource(testResults), Options{Header: true}))
+	assert.Equal(t, `statement ok
+CREATE TABLE t1(a INTEGER, b REAL, c TEXT)
+
+statement ok
+INSERT INTO t1 VALUES (1, 1.5, 'x;y'), (2, NULL, 'z')
+
+colnames a b c
+query IRT rowsort
+SELECT a, b, c FROM t1
+----
+1
+1.500
+x;y
+2
+NULL
+z
+
+colnames c a*2
+query TI nosort
+select c, a * 2 from t1 order by a desc
+----
+z
+4
+x;y
+2
+
+`, out.String())
+
+	// The converted file passes against the engine the results were captured from
+	path := filepath.Join(t.TempDir(), "converted.test")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0644))
+	output := &bytes.Buffer{}
+	logictest.NewRunner(sqlite.NewSqliteHarness(":memory:"), logictest.WithOutput(output)).RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Equal(t, 4, strings.Count(output.String(), " ok"))
+}
+
+func TestConvertOptions(t *testing.T) {
+	var out bytes.Buffer
+	results := []string{"1,\\N\n0,\n"}
+	opts := Options{NullString: "\\N", SortMode: "valuesort"}
+	require.NoError(t, Convert(&out, "SELECT a, b FROM t1", testResultSource(results), opts))
+	assert.Equal(t, "query IT valuesort\nSELECT a, b FROM t1\n----\n\n0\n1\nNULL\n\n", out.String())
+}
+
+func TestConvertErrors(t *testing.T) {
+	script := "CREATE TABLE t1(a INTEGER); SELECT a FROM t1; SELECT a, a FROM t1;"
+	for name, results := range map[string][]string{
+		"missing results": {"1\n"},
+		"no columns":      {"1\n", ""},
+		"ragged rows":     {"1\n", "1,1\n2\n"},
+	} {
+		err := Convert(&bytes.Buffer{}, script, testResultSource(results), Options{})
+		assert.Error(t, err, name)
+	}
+
+	err := Convert(&bytes.Buffer{}, "SELECT 1 # one", testResultSource([]string{"1\n"}), Options{})
+	assert.Error(t, err, "comment character")
+}
+
+func TestConvertCommentCharacters(t *testing.T) {
+	var out bytes.Buffer
+	results := []string{"#,a # b\n"}
+	require.NoError(t, Convert(&out, "SELECT '#', 'a # b'", testResultSource(results), Options{}))
+	assert.Equal(t, "query TT rowsort\nSELECT '#', 'a # b'\n----\n\\#\na # b\n\n", out.String())
+
+	// Values containing # pass against the engine
+	path := filepath.Join(t.TempDir(), "converted.test")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0644))
+	output := &bytes.Buffer{}
+	logictest.NewRunner(sqlite.NewSqliteHarness(":memory:"), logictest.WithOutput(output)).RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Equal(t, 1, strings.Count(output.String(), " ok"))
+}
@@ -0,0 +1,63 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/convert"
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// Converter for existing regression suites. Reads a SQL script and the expected results of its queries, captured as
+// CSV files named after the position of each query in the script (1.csv for the first query, 2.csv for the second,
+// and so on), and writes the equivalent test file to STDOUT.
+//
+// Usage: go run main.go [--header] [--null=STRING] [--sort=MODE] script.sql results-dir > script.test
+func main() {
+	var opts convert.Options
+	args := os.Args[1:]
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		switch arg := args[0]; {
+		case arg == "--header":
+			opts.Header = true
+		case strings.HasPrefix(arg, "--null="):
+			opts.NullString = strings.TrimPrefix(arg, "--null=")
+		case strings.HasPrefix(arg, "--sort="):
+			opts.SortMode = parser.SortMode(strings.TrimPrefix(arg, "--sort="))
+			if opts.SortMode != parser.NoSort && opts.SortMode != parser.Rowsort && opts.SortMode != parser.ValueSort {
+				exitWithUsage()
+			}
+		default:
+			exitWithUsage()
+		}
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		exitWithUsage()
+	}
+
+	if err := convert.ConvertFiles(os.Stdout, args[0], args[1], opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func exitWithUsage() {
+	fmt.Println("Usage: convert [--header] [--null=STRING] [--sort=nosort|rowsort|valuesort] script.sql results-dir")
+	os.Exit(1)
+}
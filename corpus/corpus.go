@@ -0,0 +1,339 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package corpus fetches corpora of test files from remote archives, such as a git ref of a repository on GitHub, and
+// caches them locally, so that projects can run a large corpus without vendoring it.
+package corpus
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSource is the corpus the sqllogictest format originates from, as mirrored on GitHub.
+const DefaultSource = "github:gregrahn/sqllogictest@master"
+
+// A Source is a remote archive of a corpus.
+type Source struct {
+	// URL is the address of a gzipped tar or zip archive of the corpus.
+	URL string
+	// SHA256, when set, is the hex-encoded SHA-256 checksum the archive must have. Archives that don't match aren't
+	// used, whether downloaded or cached.
+	SHA256 string
+}
+
+// GitHubSource returns the source of the archive of the git ref given, a branch, tag or commit, of the GitHub
+// repository given in the form owner/name.
+func GitHubSource(repo, ref string) Source {
+	return Source{URL: fmt.Sprintf("https://codeload.github.com/%s/tar.gz/%s", repo, ref)}
+}
+
+// ParseSource parses a source written as the URL of an archive, or as github:owner/name@ref for a git ref of a GitHub
+// repository, e.g. DefaultSource. The ref defaults to HEAD.
+func ParseSource(s string) (Source, error) {
+	if repo, ok := strings.CutPrefix(s, "github:"); ok {
+		repo, ref, found := strings.Cut(repo, "@")
+		if !found {
+			ref = "HEAD"
+		}
+		if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || ref == "" {
+			return Source{}, fmt.Errorf("invalid GitHub source %q, expected github:owner/name@ref", s)
+		}
+		return GitHubSource(repo, ref), nil
+	}
+	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+		return Source{}, fmt.Errorf("invalid corpus source %q, expected a URL or github:owner/name@ref", s)
+	}
+	return Source{URL: s}, nil
+}
+
+// A Fetcher downloads corpora and caches them in a directory, one subdirectory per source URL holding the archive, its
+// checksum and the files extracted from it.
+type Fetcher struct {
+	// CacheDir is the directory corpora are cached in.
+	CacheDir string
+	// Client downloads archives, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// NewFetcher returns a fetcher that caches corpora in the directory given, or in a sqllogictest directory in the
+// user's cache directory if it's empty.
+func NewFetcher(cacheDir string) (*Fetcher, error) {
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir = filepath.Join(userCache, "sqllogictest")
+	}
+	return &Fetcher{CacheDir: cacheDir}, nil
+}
+
+const (
+	archiveName  = "archive"
+	checksumName = "archive.sha256"
+	corpusName   = "corpus"
+)
+
+// Fetch returns the directory holding the files of the corpus of the source given, downloading and extracting its
+// archive unless it's cached already. The path can be given to the runner as the test files to run. A single
+// directory at the root of the archive, as in the archives GitHub serves, is left out.
+func (f *Fetcher) Fetch(ctx context.Context, src Source) (string, error) {
+	key := sha256.Sum256([]byte(src.URL))
+	dir := filepath.Join(f.CacheDir, hex.EncodeToString(key[:8]))
+	corpusDir := filepath.Join(dir, corpusName)
+
+	// The recorded checksum is written last, so a cache entry with one is complete
+	if recorded, err := os.ReadFile(filepath.Join(dir, checksumName)); err == nil {
+		sum := strings.TrimSpace(string(recorded))
+		if src.SHA256 == "" || strings.EqualFold(src.SHA256, sum) {
+			if actual, err := fileChecksum(filepath.Join(dir, archiveName)); err == nil && actual == sum {
+				return corpusDir, nil
+			}
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	sum, err := f.download(ctx, src.URL, filepath.Join(dir, archiveName))
+	if err != nil {
+		return "", err
+	}
+	if src.SHA256 != "" && !strings.EqualFold(src.SHA256, sum) {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("checksum of %s is %s, expected %s", src.URL, sum, src.SHA256)
+	}
+
+	if err := extract(filepath.Join(dir, archiveName), corpusDir); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("extracting %s: %w", src.URL, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, checksumName), []byte(sum+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return corpusDir, nil
+}
+
+// download downloads the URL given to the path given, returning its hex-encoded SHA-256 checksum.
+func (f *Fetcher) download(ctx context.Context, url, dest string) (string, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	file, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), resp.Body); err != nil {
+		file.Close()
+		return "", fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fileChecksum returns the hex-encoded SHA-256 checksum of the file at the path given.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extract extracts the regular files of the gzipped tar or zip archive at the path given into the directory given,
+// leaving out a single directory at the root of the archive.
+func extract(archive, dest string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	magic, err := bufio.NewReader(file).Peek(4)
+	if err != nil {
+		return fmt.Errorf("unrecognized archive: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return extractTar(file, dest)
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		return extractZip(file, stat.Size(), dest)
+	default:
+		return fmt.Errorf("unrecognized archive, expected a gzipped tar or zip archive")
+	}
+}
+
+// extractTar extracts the regular files of the gzipped tar archive read from the reader given, as extract does. Since
+// tar archives can only be read once, the files are extracted with their paths as they are, and the single root
+// directory is moved into place afterwards if there is one.
+func extractTar(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tmp := dest + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		names = append(names, header.Name)
+		if err := writeEntry(tmp, header.Name, tr); err != nil {
+			return err
+		}
+	}
+	if len(names) == 0 {
+		return os.MkdirAll(dest, 0755)
+	}
+
+	root := tmp
+	if prefix := commonRoot(names); prefix != "" {
+		root = filepath.Join(tmp, prefix)
+	}
+	return os.Rename(root, dest)
+}
+
+// extractZip extracts the regular files of the zip archive read from the reader given, as extract does.
+func extractZip(r io.ReaderAt, size int64, dest string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, file := range zr.File {
+		if file.Mode().IsRegular() {
+			names = append(names, file.Name)
+		}
+	}
+	prefix := commonRoot(names)
+
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeEntry(dest, strings.TrimPrefix(path.Clean(file.Name), prefix+"/"), r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(dest, 0755)
+}
+
+// commonRoot returns the single directory at the root of the archive with the files named, or an empty string if
+// the files aren't all in one.
+func commonRoot(names []string) string {
+	root := ""
+	for _, name := range names {
+		dir, _, found := strings.Cut(path.Clean(name), "/")
+		if !found || (root != "" && dir != root) {
+			return ""
+		}
+		root = dir
+	}
+	return root
+}
+
+// writeEntry writes the contents of the archive entry with the name given into the directory given, rejecting entries
+// whose names would place them outside of it.
+func writeEntry(dir, name string, r io.Reader) error {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("archive entry %q is outside of the archive", name)
+	}
+
+	dest := filepath.Join(dir, filepath.FromSlash(clean))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
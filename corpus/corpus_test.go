@@ -0,0 +1,164 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corpus
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectTest = "query I nosort\nSELECT 1\n----\n1\n"
+
+func tarArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// serve serves the archive given, counting the requests for it.
+func serve(t *testing.T, archive []byte, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetch(t *testing.T) {
+	for name, archive := range map[string][]byte{
+		"tar": tarArchive(t, map[string]string{"sqllogictest-master/test/select1.test": selectTest, "sqllogictest-master/README": "corpus"}),
+		"zip": zipArchive(t, map[string]string{"sqllogictest-master/test/select1.test": selectTest, "sqllogictest-master/README": "corpus"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var requests int
+			server := serve(t, archive, &requests)
+			fetcher := &Fetcher{CacheDir: t.TempDir()}
+			src := Source{URL: server.URL + "/corpus", SHA256: checksum(archive)}
+
+			dir, err := fetcher.Fetch(context.Background(), src)
+			require.NoError(t, err)
+			contents, err := os.ReadFile(filepath.Join(dir, "test", "select1.test"))
+			require.NoError(t, err)
+			assert.Equal(t, selectTest, string(contents))
+
+			// The corpus is served from the cache after the first fetch
+			cached, err := fetcher.Fetch(context.Background(), src)
+			require.NoError(t, err)
+			assert.Equal(t, dir, cached)
+			assert.Equal(t, 1, requests)
+
+			// A cached archive that no longer matches its checksum is downloaded again
+			require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(dir), archiveName), []byte("corrupt"), 0644))
+			_, err = fetcher.Fetch(context.Background(), src)
+			require.NoError(t, err)
+			assert.Equal(t, 2, requests)
+		})
+	}
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	var requests int
+	server := serve(t, tarArchive(t, map[string]string{"select1.test": selectTest}), &requests)
+	fetcher := &Fetcher{CacheDir: t.TempDir()}
+
+	_, err := fetcher.Fetch(context.Background(), Source{URL: server.URL, SHA256: checksum([]byte("other"))})
+	assert.ErrorContains(t, err, "checksum")
+
+	// Without an expected checksum, the archive is used as it is, here without a root directory to leave out
+	dir, err := fetcher.Fetch(context.Background(), Source{URL: server.URL})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "select1.test"))
+}
+
+func TestFetchRejectsEntriesOutsideArchive(t *testing.T) {
+	var requests int
+	server := serve(t, tarArchive(t, map[string]string{"../escaped.test": selectTest}), &requests)
+	cacheDir := t.TempDir()
+
+	_, err := (&Fetcher{CacheDir: cacheDir}).Fetch(context.Background(), Source{URL: server.URL})
+	assert.ErrorContains(t, err, "outside of the archive")
+
+	// Nothing is left in the cache after a failed fetch
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFetchHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := (&Fetcher{CacheDir: t.TempDir()}).Fetch(context.Background(), Source{URL: server.URL})
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestParseSource(t *testing.T) {
+	src, err := ParseSource(DefaultSource)
+	require.NoError(t, err)
+	assert.Equal(t, "https://codeload.github.com/gregrahn/sqllogictest/tar.gz/master", src.URL)
+
+	src, err = ParseSource("github:owner/repo")
+	require.NoError(t, err)
+	assert.Equal(t, "https://codeload.github.com/owner/repo/tar.gz/HEAD", src.URL)
+
+	src, err = ParseSource("https://example.com/corpus.zip")
+	require.NoError(t, err)
+	assert.Equal(t, Source{URL: "https://example.com/corpus.zip"}, src)
+
+	for _, invalid := range []string{"github:repo", "github:owner/repo@", "corpus.tar.gz"} {
+		_, err := ParseSource(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
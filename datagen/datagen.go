@@ -0,0 +1,255 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package datagen generates reproducible datasets for test files, as preludes of CREATE TABLE and bulk INSERT
+// statement records. Each column draws its values from a distribution, with a share of NULLs, using a random number
+// generator seeded by the spec, so that the same spec always produces the same prelude.
+package datagen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ColumnType is the SQL type of a generated column.
+type ColumnType string
+
+const (
+	// TypeInteger columns hold integers between the column's Min and Max
+	TypeInteger ColumnType = "INTEGER"
+	// TypeReal columns hold numbers between the column's Min and Max, with two decimal places
+	TypeReal ColumnType = "REAL"
+	// TypeText columns hold lower case words, which encode integers between the column's Min and Max so that
+	// distributions apply to them too
+	TypeText ColumnType = "TEXT"
+)
+
+// Distribution is the distribution a column's values are drawn from.
+type Distribution string
+
+const (
+	// Uniform draws every value in the column's range with equal probability. It's the default.
+	Uniform Distribution = "uniform"
+	// Sequential takes the values of the column's range in order, starting again from Min after Max.
+	Sequential Distribution = "sequential"
+	// Normal draws values around the middle of the column's range, with a standard deviation of a sixth of the range,
+	// clamped to the range.
+	Normal Distribution = "normal"
+	// Zipf draws values with a Zipf distribution, so that Min is the most common value and larger ones are
+	// increasingly rare.
+	Zipf Distribution = "zipf"
+)
+
+// defaultBatchSize is the number of rows inserted by each INSERT statement when a table doesn't give one.
+const defaultBatchSize = 100
+
+// zipfExponent is the exponent of the Zipf distribution, which must be greater than 1.
+const zipfExponent = 1.1
+
+// Spec describes the tables of a dataset.
+type Spec struct {
+	// Seed seeds the generator, so that the same spec always produces the same dataset
+	Seed int64 `json:"seed"`
+	// Tables are the tables of the dataset, created and filled in order
+	Tables []Table `json:"tables"`
+}
+
+// Table describes a generated table.
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+	// Rows is the number of rows in the table
+	Rows int `json:"rows"`
+	// BatchSize is the number of rows inserted by each INSERT statement, or 0 for the default of 100
+	BatchSize int `json:"batch_size"`
+}
+
+// Column describes a column of a generated table and the values it holds.
+type Column struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type"`
+	// Distribution is the distribution the column's values are drawn from, or empty for Uniform
+	Distribution Distribution `json:"distribution"`
+	// Min and Max bound the column's values, inclusively. For TEXT columns they bound the integers encoded as words.
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+	// NullRatio is the share of the column's values that are NULL, between 0 and 1
+	NullRatio float64 `json:"null_ratio"`
+	// PrimaryKey makes the column the table's primary key, which requires a sequential distribution with a range at
+	// least as large as the number of rows, and no NULLs
+	PrimaryKey bool `json:"primary_key"`
+}
+
+// Generate writes the prelude of statement records that creates and fills the tables of the spec given.
+func Generate(w io.Writer, spec Spec) error {
+	for _, table := range spec.Tables {
+		if err := table.validate(); err != nil {
+			return err
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(spec.Seed))
+	wr := bufio.NewWriter(w)
+	for _, table := range spec.Tables {
+		writeStatement(wr, table.createStatement())
+
+		batchSize := table.BatchSize
+		if batchSize == 0 {
+			batchSize = defaultBatchSize
+		}
+		values := make([]valueGenerator, len(table.Columns))
+		for i, column := range table.Columns {
+			values[i] = column.generator(rnd)
+		}
+
+		for start := 0; start < table.Rows; start += batchSize {
+			sb := strings.Builder{}
+			sb.WriteString("INSERT INTO " + table.Name + " VALUES ")
+			for row := start; row < start+batchSize && row < table.Rows; row++ {
+				if row > start {
+					sb.WriteString(", ")
+				}
+				sb.WriteString("(")
+				for i, value := range values {
+					if i > 0 {
+						sb.WriteString(", ")
+					}
+					sb.WriteString(value(row))
+				}
+				sb.WriteString(")")
+			}
+			writeStatement(wr, sb.String())
+		}
+	}
+	return wr.Flush()
+}
+
+// writeStatement writes a statement record for the statement given.
+func writeStatement(wr *bufio.Writer, statement string) {
+	wr.WriteString("statement ok\n" + statement + "\n\n")
+}
+
+// validate returns an error describing the first problem with the table, if any.
+func (t Table) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("table has no name")
+	}
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("table %s has no columns", t.Name)
+	}
+	if t.Rows < 0 || t.BatchSize < 0 {
+		return fmt.Errorf("table %s has a negative number of rows or batch size", t.Name)
+	}
+
+	for _, c := range t.Columns {
+		switch {
+		case c.Name == "":
+			return fmt.Errorf("table %s has a column with no name", t.Name)
+		case c.Type != TypeInteger && c.Type != TypeReal && c.Type != TypeText:
+			return fmt.Errorf("column %s.%s has unknown type %q", t.Name, c.Name, c.Type)
+		case c.Distribution != "" && c.Distribution != Uniform && c.Distribution != Sequential &&
+			c.Distribution != Normal && c.Distribution != Zipf:
+			return fmt.Errorf("column %s.%s has unknown distribution %q", t.Name, c.Name, c.Distribution)
+		case c.Max < c.Min:
+			return fmt.Errorf("column %s.%s has max %d less than min %d", t.Name, c.Name, c.Max, c.Min)
+		case c.NullRatio < 0 || c.NullRatio > 1:
+			return fmt.Errorf("column %s.%s has null ratio %v outside 0 to 1", t.Name, c.Name, c.NullRatio)
+		case c.PrimaryKey && (c.Distribution != Sequential || c.NullRatio != 0 || c.Max-c.Min+1 < int64(t.Rows)):
+			return fmt.Errorf("primary key %s.%s must be sequential without NULLs, with a range of at least %d values",
+				t.Name, c.Name, t.Rows)
+		}
+	}
+	return nil
+}
+
+// createStatement returns the CREATE TABLE statement for the table.
+func (t Table) createStatement() string {
+	columns := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		columns[i] = c.Name + " " + string(c.Type)
+		if c.PrimaryKey {
+			columns[i] += " PRIMARY KEY"
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s(%s)", t.Name, strings.Join(columns, ", "))
+}
+
+// A valueGenerator returns the SQL literal of a column's value in the row given.
+type valueGenerator func(row int) string
+
+// generator returns the generator of the column's values, drawing from the random number generator given.
+func (c Column) generator(rnd *rand.Rand) valueGenerator {
+	span := c.Max - c.Min + 1
+	var draw func(row int) float64
+	switch c.Distribution {
+	case Sequential:
+		draw = func(row int) float64 {
+			return float64(c.Min + int64(row)%span)
+		}
+	case Normal:
+		mean, stddev := float64(c.Min)+float64(c.Max-c.Min)/2, float64(c.Max-c.Min)/6
+		draw = func(int) float64 {
+			return math.Max(float64(c.Min), math.Min(float64(c.Max), rnd.NormFloat64()*stddev+mean))
+		}
+	case Zipf:
+		zipf := rand.NewZipf(rnd, zipfExponent, 1, uint64(c.Max-c.Min))
+		draw = func(int) float64 {
+			return float64(c.Min + int64(zipf.Uint64()))
+		}
+	default:
+		draw = func(int) float64 {
+			if c.Type == TypeReal {
+				return float64(c.Min) + rnd.Float64()*float64(c.Max-c.Min)
+			}
+			return float64(c.Min + rnd.Int63n(span))
+		}
+	}
+
+	return func(row int) string {
+		// NULLs are drawn first, so that a column's values don't depend on whether its NULL ratio is zero
+		isNull := rnd.Float64() < c.NullRatio
+		value := draw(row)
+		if isNull {
+			return "NULL"
+		}
+
+		switch c.Type {
+		case TypeReal:
+			return strconv.FormatFloat(value, 'f', 2, 64)
+		case TypeText:
+			return "'" + word(int64(math.Round(value))) + "'"
+		default:
+			return strconv.FormatInt(int64(math.Round(value)), 10)
+		}
+	}
+}
+
+// word encodes the integer given as a word of lower case letters, in bijective base 26 so that every integer has a
+// distinct word, prefixed with "minus" for negative integers.
+func word(n int64) string {
+	if n < 0 {
+		return "minus" + word(-n)
+	}
+
+	var letters []byte
+	for n++; n > 0; n = (n - 1) / 26 {
+		letters = append([]byte{byte('a' + (n-1)%26)}, letters...)
+	}
+	return string(letters)
+}
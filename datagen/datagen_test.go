@@ -0,0 +1,115 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datagen
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/parser"
+	"github.com/andyyu2004/sqllogictest/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpec() Spec {
+	return Spec{
+		Seed: 42,
+		Tables: []Table{{
+			Name:      "t1",
+			Rows:      250,
+			BatchSize: 100,
+			Columns: []Column{
+				{Name: "id", Type: TypeInteger, Distribution: Sequential, Min: 1, Max: 250, PrimaryKey: true},
+				{Name: "price", Type: TypeReal, Distribution: Normal, Min: 0, Max: 100, NullRatio: 0.2},
+				{Name: "category", Type: TypeText, Distribution: Zipf, Min: 0, Max: 20},
+				{Name: "qty", Type: TypeInteger, Min: -5, Max: 5},
+			},
+		}},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Generate(&out, testSpec()))
+
+	// The same spec always produces the same prelude
+	var again bytes.Buffer
+	require.NoError(t, Generate(&again, testSpec()))
+	assert.Equal(t, out.String(), again.String())
+
+	path := filepath.Join(t.TempDir(), "prelude.test")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0644))
+	records, err := parser.ParseTestFile(path)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "CREATE TABLE t1(id INTEGER PRIMARY KEY, price REAL, category TEXT, qty INTEGER)",
+		records[0].Query())
+	assert.True(t, strings.HasPrefix(records[1].Query(), "INSERT INTO t1 VALUES (1, "))
+	assert.True(t, strings.HasPrefix(records[3].Query(), "INSERT INTO t1 VALUES (201, "))
+
+	// The prelude executes, and its values follow the spec
+	h := sqlite.NewSqliteHarness(":memory:")
+	output := &bytes.Buffer{}
+	logictest.NewRunner(h, logictest.WithOutput(output)).RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+
+	ctx := context.Background()
+	_, results, err := h.ExecuteQuery(ctx, "SELECT COUNT(*), COUNT(price), MIN(price) >= 0, MAX(price) <= 100, "+
+		"MIN(qty), MAX(qty), COUNT(DISTINCT category) <= 21 FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, "250", results[0])
+	nonNull, err := strconv.Atoi(results[1])
+	require.NoError(t, err)
+	assert.InDelta(t, 200, nonNull, 25)
+	assert.Equal(t, []string{"1", "1", "-5", "5", "1"}, results[2:])
+
+	// The most common category is the smallest
+	_, results, err = h.ExecuteQuery(ctx, "SELECT category FROM t1 GROUP BY category ORDER BY COUNT(*) DESC LIMIT 1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, results)
+}
+
+func TestGenerateInvalidSpecs(t *testing.T) {
+	for name, modify := range map[string]func(*Table){
+		"no name":            func(t *Table) { t.Name = "" },
+		"no columns":         func(t *Table) { t.Columns = nil },
+		"unknown type":       func(t *Table) { t.Columns[1].Type = "BLOB" },
+		"unknown dist":       func(t *Table) { t.Columns[1].Distribution = "poisson" },
+		"empty range":        func(t *Table) { t.Columns[1].Max = -1 },
+		"null ratio":         func(t *Table) { t.Columns[1].NullRatio = 1.5 },
+		"random key":         func(t *Table) { t.Columns[0].Distribution = Uniform },
+		"small key range":    func(t *Table) { t.Columns[0].Max = 10 },
+		"negative row count": func(t *Table) { t.Rows = -1 },
+	} {
+		spec := testSpec()
+		modify(&spec.Tables[0])
+		assert.Error(t, Generate(&bytes.Buffer{}, spec), name)
+	}
+}
+
+func TestWord(t *testing.T) {
+	assert.Equal(t, "a", word(0))
+	assert.Equal(t, "z", word(25))
+	assert.Equal(t, "aa", word(26))
+	assert.Equal(t, "minusb", word(-1))
+}
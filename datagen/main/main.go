@@ -0,0 +1,70 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest/datagen"
+)
+
+// Data generator. Reads a spec of the tables of a dataset as JSON and writes the prelude of statement records that
+// creates and fills them to STDOUT, e.g.:
+//
+//	{
+//	  "seed": 42,
+//	  "tables": [{
+//	    "name": "t1",
+//	    "rows": 1000,
+//	    "columns": [
+//	      {"name": "id", "type": "INTEGER", "distribution": "sequential", "min": 1, "max": 1000, "primary_key": true},
+//	      {"name": "price", "type": "REAL", "distribution": "normal", "min": 0, "max": 100, "null_ratio": 0.1},
+//	      {"name": "category", "type": "TEXT", "distribution": "zipf", "min": 0, "max": 20}
+//	    ]
+//	  }]
+//	}
+//
+// Usage: go run main.go spec.json > prelude.test
+func main() {
+	if len(os.Args) != 2 {
+		exitWithUsage()
+	}
+
+	contents, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var spec datagen.Spec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		exitWithError(fmt.Errorf("invalid spec %s: %v", os.Args[1], err))
+	}
+
+	if err := datagen.Generate(os.Stdout, spec); err != nil {
+		exitWithError(err)
+	}
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func exitWithUsage() {
+	fmt.Println("Usage: datagen spec.json")
+	os.Exit(1)
+}
@@ -0,0 +1,181 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A Duplicate is a query record that repeats a query record of an earlier test file.
+type Duplicate struct {
+	// File and Line locate the duplicate record
+	File string
+	Line int
+	// OriginalFile and OriginalLine locate the first record it repeats
+	OriginalFile string
+	OriginalLine int
+}
+
+// FindDuplicates returns the query records in the test files found under the paths given that repeat a query record of
+// an earlier file, in the order of the files and their records. Records repeat each other when their normalized queries
+// are the same, along with their directives, headers and expected results, ignoring comments and spacing. Queries
+// repeated within a file are kept, since they commonly verify that a statement between them had no effect.
+func FindDuplicates(paths ...string) ([]Duplicate, error) {
+	type occurrence struct {
+		file string
+		line int
+	}
+	originals := make(map[string]occurrence)
+
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []Duplicate
+	for _, file := range files {
+		contents, err := parser.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		records, err := parser.Parse(bytes.NewReader(contents))
+		if err != nil {
+			return nil, err
+		}
+
+		lines := strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n")
+		for _, record := range records {
+			if record.Type() != parser.Query {
+				continue
+			}
+			key := duplicateKey(record, lines)
+			original, ok := originals[key]
+			switch {
+			case !ok:
+				originals[key] = occurrence{file: file, line: record.LineNum()}
+			case original.file != file:
+				duplicates = append(duplicates, Duplicate{
+					File:         file,
+					Line:         record.LineNum(),
+					OriginalFile: original.file,
+					OriginalLine: original.line,
+				})
+			}
+		}
+	}
+	return duplicates, nil
+}
+
+// RemoveDuplicates removes the records found by FindDuplicates from their test files, along with the blank lines ending
+// them, and returns them.
+func RemoveDuplicates(paths ...string) ([]Duplicate, error) {
+	duplicates, err := FindDuplicates(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]map[int]bool)
+	var files []string
+	for _, d := range duplicates {
+		if lines[d.File] == nil {
+			lines[d.File] = make(map[int]bool)
+			files = append(files, d.File)
+		}
+		lines[d.File][d.Line] = true
+	}
+
+	for _, file := range files {
+		contents, err := parser.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		records, err := parser.Parse(bytes.NewReader(contents))
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		gen := newGeneratedFile(contents, &buf)
+		for _, record := range records {
+			if lines[file][record.LineNum()] {
+				gen.dropRecord(record)
+			}
+		}
+		gen.copyRest()
+		if err := gen.flush(); err != nil {
+			return nil, err
+		}
+		if err := parser.WriteFile(file, buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return duplicates, nil
+}
+
+// duplicateKey returns the key of the query record given, from the test file with the lines given, that's shared by the
+// records that repeat it.
+func duplicateKey(record *parser.Record, lines []string) string {
+	var key strings.Builder
+	for n := record.StartLine(); n <= record.EndLine(); n++ {
+		if n >= record.LineNum() && (record.SeparatorLine() == 0 || n < record.SeparatorLine()) {
+			continue
+		}
+		line := lines[n-1]
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if n < record.LineNum() {
+			line, _, _ = strings.Cut(line, "#")
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			key.WriteString(strings.Join(fields, " ") + "\n")
+		}
+	}
+	key.WriteString(normalizeQuery(record.Query()))
+	return key.String()
+}
+
+// normalizeQuery returns the query given with runs of whitespace outside of quotes collapsed to single spaces, letters
+// outside of quotes lowercased, and any trailing semicolon removed.
+func normalizeQuery(query string) string {
+	var sb strings.Builder
+	var quote rune
+	space := false
+	for _, c := range strings.TrimRight(strings.TrimSpace(query), "; \t") {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+		default:
+			c = unicode.ToLower(c)
+		}
+		if space {
+			sb.WriteRune(' ')
+			space = false
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,61 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest"
+)
+
+// Corpus deduplicator. Reports the query records of the test files given that repeat a query record of an earlier file,
+// with the same normalized query, directives and expected results. With --remove, also removes them from their files.
+//
+// Usage: go run main.go [--remove] testfile1 [testfile2 ...]
+func main() {
+	args := os.Args[1:]
+	remove := len(args) > 0 && args[0] == "--remove"
+	if remove {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	find := logictest.FindDuplicates
+	if remove {
+		find = logictest.RemoveDuplicates
+	}
+	duplicates, err := find(args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, d := range duplicates {
+		fmt.Printf("%s:%d: duplicate of %s:%d\n", d.File, d.Line, d.OriginalFile, d.OriginalLine)
+	}
+	action := "found"
+	if remove {
+		action = "removed"
+	}
+	fmt.Printf("%d duplicate records %s\n", len(duplicates), action)
+}
+
+func exitWithUsage() {
+	fmt.Println("Usage: dedup [--remove] testfile1 [testfile2 ...]")
+	os.Exit(1)
+}
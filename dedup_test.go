@@ -0,0 +1,109 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dedupOriginal = `statement ok
+CREATE TABLE t1(a INTEGER)
+
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+2
+
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+2
+`
+
+const dedupRepeats = `statement ok
+CREATE TABLE t1(a INTEGER)
+
+# Repeats the first file's query with different spacing, case and comments
+query I rowsort  # comment
+select a
+  FROM t1 where a > 1;
+----
+2
+
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+3
+
+skipif mysql
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+2
+
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+2
+`
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "a.test")
+	repeats := filepath.Join(dir, "b.test")
+	require.NoError(t, os.WriteFile(original, []byte(dedupOriginal), 0644))
+	require.NoError(t, os.WriteFile(repeats, []byte(dedupRepeats), 0644))
+
+	// Statements, queries repeated within a file, and queries with other results or directives aren't duplicates
+	duplicates, err := FindDuplicates(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []Duplicate{
+		{File: repeats, Line: 6, OriginalFile: original, OriginalLine: 5},
+		{File: repeats, Line: 23, OriginalFile: original, OriginalLine: 5},
+	}, duplicates)
+
+	removed, err := RemoveDuplicates(dir)
+	require.NoError(t, err)
+	assert.Equal(t, duplicates, removed)
+	assertFileContents(t, original, dedupOriginal)
+	assertFileContents(t, repeats, `statement ok
+CREATE TABLE t1(a INTEGER)
+
+# Repeats the first file's query with different spacing, case and comments
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+3
+
+skipif mysql
+query I rowsort
+SELECT a FROM t1 WHERE a > 1
+----
+2
+`)
+
+	duplicates, err = FindDuplicates(dir)
+	require.NoError(t, err)
+	assert.Empty(t, duplicates)
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	assert.Equal(t, "select 'A  B' from t1 where \"C\" = 1", normalizeQuery("SELECT  'A  B'\n FROM t1\tWHERE \"C\" = 1;"))
+}
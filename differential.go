@@ -0,0 +1,145 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// executeDifferential executes the statement or query record given on both the harness under test and the reference
+// harness, and compares their outcomes to each other rather than to the expected results in the test file, logging
+// any difference.
+func (r *Runner) executeDifferential(ctx context.Context, record *parser.Record) *R {
+	reference := r.config.Reference
+
+	// Only the harness under test executes translated queries
+	translated, err := r.translate(record)
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unable to translate query: %v", err)
+		return &R{cont: true, err: err}
+	}
+
+	if record.Type() == parser.Statement {
+		err := r.harness.ExecuteStatement(ctx, translated.Query())
+		refErr := reference.ExecuteStatement(ctx, record.Query())
+		if err := r.compareErrors(ctx, err, refErr); err != nil {
+			return &R{cont: true, err: err}
+		}
+		logResult(ctx, Ok, "")
+		return &R{cont: true}
+	}
+
+	schema, results, err := r.harness.ExecuteQuery(ctx, translated.Query())
+	refSchema, refResults, refErr := reference.ExecuteQuery(ctx, record.Query())
+	if err != nil || refErr != nil {
+		if err := r.compareErrors(ctx, err, refErr); err != nil {
+			return &R{cont: true, err: err}
+		}
+		logResult(ctx, Ok, "")
+		return &R{cont: true}
+	}
+
+	if len(schema) != len(refSchema) {
+		logFailure(ctx, SchemaMismatch, "Schemas differ from reference engine. Expected %s, got %s", refSchema, schema)
+		return &R{cont: true, err: fmt.Errorf("schemas differ from reference engine: expected %s, got %s", refSchema, schema)}
+	}
+	for i, c := range refSchema {
+		compatible := compatibleSchemaTypes(c, rune(schema[i])) || compatibleSchemaTypes(rune(schema[i]), c)
+		if !compatible || (r.config.StrictSchemas && !record.AmbiguousSchema() && c != rune(schema[i])) {
+			logFailure(ctx, SchemaMismatch, "Schemas differ from reference engine. Expected %s, got %s", refSchema, schema)
+			return &R{cont: true, err: fmt.Errorf("schemas differ from reference engine: expected %s, got %s", refSchema, schema)}
+		}
+	}
+
+	if len(results) != len(refResults) {
+		logFailure(ctx, RowCountMismatch, "Incorrect number of results compared to reference engine. Expected %v, got %v", len(refResults), len(results))
+		return &R{cont: true, err: fmt.Errorf("incorrect number of results compared to reference engine: expected %v, got %v", len(refResults), len(results))}
+	}
+
+	// Normalize both sides with the reference schema, so that integer results in float columns compare equal
+	numCols := len(refSchema)
+	results = r.config.normalizeAll(r.config.normalizeResults(results, refSchema), refSchema)
+	refResults = r.config.normalizeAll(r.config.normalizeResults(refResults, refSchema), refSchema)
+	results = sortValues(record.SortMode(), results, numCols)
+	refResults = sortValues(record.SortMode(), refResults, numCols)
+	if record.SortMode() == parser.PartialSort {
+		results = record.SortResults(results)
+		refResults = record.SortResults(refResults)
+	}
+
+	epsilon := r.config.floatEpsilon(record)
+	for i := range refResults {
+		if !r.config.valuesEqual(refResults[i], results[i], refSchema[i%numCols], epsilon) {
+			row, col := i/numCols, i%numCols
+			logFailure(ctx, ValueMismatch, "Incorrect result at position %d compared to reference engine. Expected %v, got %v, at row %d column %d. Expected row %v, got %v",
+				i, refResults[i], results[i], row, col, rowAt(refResults, row, numCols), rowAt(results, row, numCols))
+			return &R{cont: true, err: fmt.Errorf("incorrect result at position %d compared to reference engine, expected `%v`, got `%v`", i, refResults[i], results[i])}
+		}
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true}
+}
+
+// compareErrors compares the errors returned by the harness under test and the reference harness for the same
+// record, logging a failure if only one of them failed.
+func (r *Runner) compareErrors(ctx context.Context, err, refErr error) error {
+	switch {
+	case err != nil && refErr == nil:
+		logFailure(ctx, UnexpectedError, "Unexpected error %s (reference engine succeeded)", r.describeError(err))
+		return err
+	case err == nil && refErr != nil:
+		logFailure(ctx, MissingExpectedError, "Expected error but didn't get one (reference engine failed with: %v)", refErr)
+		return fmt.Errorf("expected error from reference engine: %v", refErr)
+	}
+	return nil
+}
+
+// sortValues sorts the result values given, which have the number of columns given, according to the sort mode given.
+func sortValues(mode parser.SortMode, values []string, numCols int) []string {
+	switch mode {
+	case parser.Rowsort:
+		if numCols == 0 {
+			return values
+		}
+		rows := make([][]string, len(values)/numCols)
+		for i := range rows {
+			rows[i] = values[i*numCols : (i+1)*numCols]
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k := range rows[i] {
+				if rows[i][k] != rows[j][k] {
+					return rows[i][k] < rows[j][k]
+				}
+			}
+			return false
+		})
+		sorted := make([]string, 0, len(values))
+		for _, row := range rows {
+			sorted = append(sorted, row...)
+		}
+		return sorted
+	case parser.ValueSort:
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		return sorted
+	default:
+		return values
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// The expected results in this file are stale, and only the live results of the harnesses are compared.
+const differentialTest = `statement ok
+INSERT INTO missing VALUES(1, 2)
+
+query II rowsort
+SELECT a, b FROM t1
+----
+5
+6
+`
+
+func TestDifferential(t *testing.T) {
+	reference := newFakeHarness()
+	reference.queryResults["SELECT a, b FROM t1"] = fakeResult{schema: "II", results: []string{"1", "2"}}
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithReference(reference))
+	runner.RunTestFiles(writeTestFile(t, differentialTest))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+
+	reference.queryResults["SELECT a, b FROM t1"] = fakeResult{schema: "II", results: []string{"1", "3"}}
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithReference(reference))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, differentialTest))
+	})
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, ValueMismatch, reporter.entries[1].FailureCode)
+	assert.Contains(t, reporter.entries[1].ErrorMessage, "compared to reference engine. Expected 3, got 2")
+
+	reference.statementErrors = nil
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithReference(reference))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, differentialTest))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, UnexpectedError, reporter.entries[0].FailureCode)
+}
+
+func TestSortValues(t *testing.T) {
+	values := []string{"2", "a", "1", "b", "1", "a"}
+	assert.Equal(t, []string{"1", "a", "1", "b", "2", "a"}, sortValues(parser.Rowsort, values, 2))
+	assert.Equal(t, []string{"1", "1", "2", "a", "a", "b"}, sortValues(parser.ValueSort, values, 2))
+	assert.Equal(t, values, sortValues(parser.NoSort, values, 2))
+}
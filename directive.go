@@ -0,0 +1,53 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A DirectiveHandler executes a custom record, of a directive registered with parser.RegisterDirective, on the harness
+// for the record's connection. Handlers implement engine-specific control records, such as backups or replication
+// failovers, so that they execute in the runner's loop and are reported like other records. A handler returns nil if
+// the record succeeded, ErrSkipDirective if the harness doesn't support it, or an error describing its failure.
+type DirectiveHandler func(ctx context.Context, harness Harness, record *parser.Record) error
+
+// ErrSkipDirective is returned by a DirectiveHandler to report its record as skipped, e.g. for a harness that doesn't
+// support the directive.
+var ErrSkipDirective = errors.New("directive skipped")
+
+// executeCustom executes the custom record given with the handler for its directive, logging its result. Records of
+// directives without a handler are skipped.
+func (r *Runner) executeCustom(ctx context.Context, harness Harness, record *parser.Record) *R {
+	handler, ok := r.config.DirectiveHandlers[record.Directive()]
+	if !ok {
+		logResult(ctx, Skipped, "Unsupported directive %s", record.Directive())
+		return &R{skipped: true, cont: true}
+	}
+
+	if err := handler(ctx, harness, record); err == ErrSkipDirective {
+		logResult(ctx, Skipped, "")
+		return &R{skipped: true, cont: true}
+	} else if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error from %s: %v", record.Directive(), err)
+		return &R{cont: true, err: err}
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true}
+}
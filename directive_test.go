@@ -0,0 +1,80 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectiveHandlers(t *testing.T) {
+	var backups [][]string
+	backup := func(ctx context.Context, harness Harness, record *parser.Record) error {
+		if harness.EngineStr() != "fake" {
+			return ErrSkipDirective
+		}
+		if record.DirectiveArgs()[0] == "broken" {
+			return errors.New("backup failed")
+		}
+		backups = append(backups, record.DirectiveBody())
+		return harness.ExecuteStatement(ctx, "BACKUP")
+	}
+
+	path := writeTestFile(t, customRecordTest)
+	harness := &statementRecorder{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithDirectiveHandler("test_backup", backup))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, 1, reporter.entries[0].LineNum)
+	assert.Equal(t, [][]string{{"t1"}}, backups)
+	assert.Equal(t, []string{"BACKUP", "INSERT INTO t1 VALUES (1)"}, harness.statements)
+
+	// Handlers execute while generating too, and their records are copied unchanged
+	runner.GenerateTestFiles(path)
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, customRecordTest, string(generated))
+	assert.Len(t, backups, 2)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithDirectiveHandler("test_backup", backup))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "test_backup broken\nt1\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, NotOk, reporter.entries[0].Result)
+	assert.Equal(t, "Unexpected error from test_backup: backup failed", reporter.entries[0].ErrorMessage)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(capabilityHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithDirectiveHandler("test_backup", func(ctx context.Context, harness Harness, record *parser.Record) error {
+			return ErrSkipDirective
+		}))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+}
@@ -0,0 +1,123 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dolt provides a harness for Dolt, the primary consumer of this runner. Dolt is run as a sql-server, which
+// speaks the MySQL protocol, so the harness builds on the MySQL harness and overrides only what differs for Dolt.
+package dolt
+
+import (
+	"context"
+	"strings"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/mysql"
+)
+
+// DoltHarness is a harness for a Dolt sql-server. Besides the behavior it inherits from MysqlHarness, it reports its
+// engine as dolt and explains queries with Dolt's plan output.
+type DoltHarness struct {
+	*mysql.MysqlHarness
+}
+
+// compile check for interface compliance
+var _ logictest.Harness = &DoltHarness{}
+var _ logictest.StreamingHarness = &DoltHarness{}
+var _ logictest.MultiResultHarness = &DoltHarness{}
+var _ logictest.ProcedureHarness = &DoltHarness{}
+var _ logictest.ErrorClassifier = &DoltHarness{}
+var _ logictest.PreparedStatementHarness = &DoltHarness{}
+var _ logictest.CapabilityHarness = &DoltHarness{}
+var _ logictest.ExplainHarness = &DoltHarness{}
+var _ logictest.MultiConnectionHarness = &DoltHarness{}
+var _ logictest.SnapshotHarness = &DoltHarness{}
+var _ logictest.BatchHarness = &DoltHarness{}
+
+// NewDoltHarness returns a new Dolt test harness for the data source name of a running sql-server given, in the form
+// accepted by the MySQL driver. Panics if it cannot open a connection using the DSN. See StartServer to run a server.
+func NewDoltHarness(dsn string) *DoltHarness {
+	return &DoltHarness{MysqlHarness: mysql.NewMysqlHarness(dsn)}
+}
+
+// See MultiConnectionHarness.NewConnection
+func (h *DoltHarness) NewConnection() (logictest.Harness, error) {
+	session, err := h.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	return &DoltHarness{MysqlHarness: session}, nil
+}
+
+// See BatchHarness.AtomicBatches. Unlike MySQL's, all of Dolt's tables are transactional.
+func (h *DoltHarness) AtomicBatches() bool {
+	return true
+}
+
+// See Harness.EngineStr
+func (h *DoltHarness) EngineStr() string {
+	return "dolt"
+}
+
+// See ExplainHarness.ExplainQuery. Dolt doesn't support MySQL's EXPLAIN FORMAT=TREE, but its plain EXPLAIN output is
+// already a tree with one node per row.
+func (h *DoltHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	rows, err := h.DB().QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if rows.Err() != nil {
+		return "", rows.Err()
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// snapshotTagPrefix prefixes the names of the tags that hold snapshots, to keep them apart from tags of the database.
+const snapshotTagPrefix = "sqllogictest-snapshot-"
+
+// See SnapshotHarness.Snapshot. The working set is committed, including any new tables, and the commit is tagged with
+// the snapshot name. Restoring resets the branch to the tag, which is cheap since Dolt shares storage between commits.
+func (h *DoltHarness) Snapshot(ctx context.Context, name string) error {
+	tag := snapshotTagPrefix + name
+	if _, err := h.DB().ExecContext(ctx, "CALL DOLT_COMMIT('-Am', ?, '--allow-empty')", "snapshot "+name); err != nil {
+		return err
+	}
+	// Replace any earlier snapshot with the same name
+	var exists bool
+	if err := h.DB().QueryRowContext(ctx, "SELECT COUNT(*) > 0 FROM dolt_tags WHERE tag_name = ?", tag).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		if _, err := h.DB().ExecContext(ctx, "CALL DOLT_TAG('-d', ?)", tag); err != nil {
+			return err
+		}
+	}
+	_, err := h.DB().ExecContext(ctx, "CALL DOLT_TAG(?)", tag)
+	return err
+}
+
+// See SnapshotHarness.Restore
+func (h *DoltHarness) Restore(ctx context.Context, name string) error {
+	_, err := h.DB().ExecContext(ctx, "CALL DOLT_RESET('--hard', ?)", snapshotTagPrefix+name)
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dolt
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Server is a dolt sql-server process started by StartServer.
+type Server struct {
+	cmd *exec.Cmd
+	// done is closed once the process exits, after which err holds its exit error
+	done chan struct{}
+	err  error
+	// DSN is the data source name for connecting to the server as the root user, suitable for NewDoltHarness.
+	DSN string
+}
+
+// StartServer starts a dolt sql-server listening on the local port given, serving the database in the directory dir,
+// using the dolt binary at the path given (or found on the PATH if only a name is given). If dir isn't yet a Dolt
+// database, it is initialized with dolt init. Returns once the server accepts connections, or with an error if it
+// exits or ctx is done first.
+func StartServer(ctx context.Context, doltPath, dir string, port int) (*Server, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".dolt")); os.IsNotExist(err) {
+		init := exec.CommandContext(ctx, doltPath, "init", "--name", "sqllogictest", "--email", "sqllogictest@dolthub.com")
+		init.Dir = dir
+		if out, err := init.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("dolt init failed: %v: %s", err, out)
+		}
+	}
+
+	cmd := exec.Command(doltPath, "sql-server", "--host", "127.0.0.1", "--port", strconv.Itoa(port))
+	cmd.Dir = dir
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cmd:  cmd,
+		done: make(chan struct{}),
+		DSN:  fmt.Sprintf("root@tcp(127.0.0.1:%d)/%s", port, filepath.Base(dir)),
+	}
+	go func() {
+		s.err = cmd.Wait()
+		close(s.done)
+	}()
+
+	if err := s.waitForConnections(ctx, fmt.Sprintf("127.0.0.1:%d", port)); err != nil {
+		s.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// waitForConnections polls the address given until it accepts connections, the server exits or ctx is done.
+func (s *Server) waitForConnections(ctx context.Context, addr string) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			return conn.Close()
+		}
+
+		select {
+		case <-s.done:
+			return fmt.Errorf("dolt sql-server exited before accepting connections: %v", s.err)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop kills the server, if it's still running, and waits for it to exit.
+func (s *Server) Stop() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	if err := s.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-s.done
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dolt
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDoltEnv is set in the environment of the test binary when it's run as a fake dolt binary.
+const fakeDoltEnv = "SQLLOGICTEST_FAKE_DOLT"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeDoltEnv); mode != "" {
+		fakeDolt(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeDolt implements the dolt commands used by StartServer: init creates the .dolt directory, and sql-server listens
+// on the port given until killed, or exits immediately if mode is "exit".
+func fakeDolt(mode string) {
+	switch os.Args[1] {
+	case "init":
+		if err := os.Mkdir(".dolt", 0755); err != nil {
+			os.Exit(1)
+		}
+	case "sql-server":
+		if mode == "exit" {
+			os.Exit(1)
+		}
+		l, err := net.Listen("tcp", "127.0.0.1:"+os.Args[len(os.Args)-1])
+		if err != nil {
+			os.Exit(1)
+		}
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				os.Exit(1)
+			}
+			conn.Close()
+		}
+	}
+}
+
+// freePort returns a local port that's free to listen on.
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestStartServer(t *testing.T) {
+	t.Setenv(fakeDoltEnv, "serve")
+	dir := filepath.Join(t.TempDir(), "sqllogictest")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	port := freePort(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server, err := StartServer(ctx, os.Args[0], dir, port)
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dir, ".dolt"))
+	assert.Contains(t, server.DSN, "/sqllogictest")
+
+	conn, err := net.Dial("tcp", server.DSN[len("root@tcp("):len(server.DSN)-len(")/sqllogictest")])
+	require.NoError(t, err)
+	conn.Close()
+
+	require.NoError(t, server.Stop())
+	require.NoError(t, server.Stop())
+}
+
+func TestStartServerExits(t *testing.T) {
+	t.Setenv(fakeDoltEnv, "exit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := StartServer(ctx, os.Args[0], t.TempDir(), freePort(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exited before accepting connections")
+}
+
+func TestEngineStr(t *testing.T) {
+	assert.Equal(t, "dolt", NewDoltHarness("root@tcp(127.0.0.1:3306)/sqllogictest").EngineStr())
+}
@@ -0,0 +1,331 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqldb"
+	"github.com/andyyu2004/sqllogictest/translate"
+	"github.com/marcboeker/go-duckdb"
+)
+
+// sqllogictest harness for DuckDB, which runs the engine in-process. Like SqliteHarness, it's fast enough to serve as a
+// local reference engine for differential runs. MySQL-isms in the corpus are translated with translate.MySQLToDuckDB
+// before execution. Don't also configure the runner with that translator.
+type DuckDBHarness struct {
+	db         *sql.DB
+	translator logictest.Translator
+	formatter  *logictest.ValueFormatter
+}
+
+// compile check for interface compliance
+var _ logictest.Harness = &DuckDBHarness{}
+var _ logictest.ErrorClassifier = &DuckDBHarness{}
+var _ logictest.CapabilityHarness = &DuckDBHarness{}
+var _ logictest.ExplainHarness = &DuckDBHarness{}
+var _ logictest.SeedHarness = &DuckDBHarness{}
+var _ logictest.ObjectCleanupHarness = &DuckDBHarness{}
+var _ logictest.CatalogHarness = &DuckDBHarness{}
+var _ logictest.BatchHarness = &DuckDBHarness{}
+var _ logictest.FormattingHarness = &DuckDBHarness{}
+
+// NewDuckDBHarness returns a new DuckDB test harness for the data source name given, e.g. the path of a database file
+// or the empty string for an in-memory database. Panics if it cannot open the database.
+func NewDuckDBHarness(dsn string) *DuckDBHarness {
+	db, err := sql.Open("duckdb", dsn)
+	if err != nil {
+		panic(err)
+	}
+	return &DuckDBHarness{db: db, translator: translate.MySQLToDuckDB, formatter: logictest.NewValueFormatter()}
+}
+
+// See FormattingHarness.SetFormatter
+func (h *DuckDBHarness) SetFormatter(formatter *logictest.ValueFormatter) {
+	h.formatter = formatter
+}
+
+// See Harness.EngineStr
+func (h *DuckDBHarness) EngineStr() string {
+	return "duckdb"
+}
+
+// See CapabilityHarness.Capabilities. DuckDB has no triggers or stored procedures.
+func (h *DuckDBHarness) Capabilities() []logictest.Capability {
+	return []logictest.Capability{
+		logictest.CapSubqueries,
+		logictest.CapCTEs,
+		logictest.CapRecursiveCTEs,
+		logictest.CapWindowFunctions,
+		logictest.CapFullOuterJoin,
+		logictest.CapRightJoin,
+		logictest.CapViews,
+		logictest.CapTransactions,
+		logictest.CapJSON,
+		logictest.CapIntersectExcept,
+		logictest.CapForeignKeys,
+		logictest.CapCheckConstraints,
+	}
+}
+
+// See Harness.Init
+func (h *DuckDBHarness) Init() error {
+	// Lift the limit of SetSeed, which doesn't carry over to the next test file
+	h.db.SetMaxOpenConns(0)
+
+	if err := h.dropAll("VIEW"); err != nil {
+		return err
+	}
+
+	return h.dropAll("BASE TABLE")
+}
+
+// See SeedHarness.SetSeed. DuckDB seeds are per session, so the harness is limited to a single connection, the seeded
+// one, for the rest of the test file.
+func (h *DuckDBHarness) SetSeed(ctx context.Context, seed int64) error {
+	h.db.SetMaxOpenConns(1)
+	_, err := h.db.ExecContext(ctx, "SELECT setseed($1)", logictest.SeedFraction(seed))
+	return err
+}
+
+// See ObjectCleanupHarness.DropObject. DuckDB indexes are dropped by name alone.
+func (h *DuckDBHarness) DropObject(ctx context.Context, object logictest.SchemaObject) error {
+	return h.ExecuteStatement(ctx, fmt.Sprintf("DROP %s IF EXISTS %s CASCADE", object.Kind, object.Name))
+}
+
+// See CatalogHarness.Catalog
+func (h *DuckDBHarness) Catalog(ctx context.Context) (logictest.Catalog, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT c.table_name, c.column_name FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	return logictest.ReadCatalog(rows)
+}
+
+// See Harness.ExecuteStatement
+func (h *DuckDBHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	statement, err := h.translator.Translate(statement)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.db.ExecContext(ctx, statement)
+	return err
+}
+
+// See BatchHarness.ExecuteStatements. The statements are translated and execute in a transaction, as by
+// sqldb.ExecuteStatements.
+func (h *DuckDBHarness) ExecuteStatements(ctx context.Context, statements []string) error {
+	return sqldb.ExecuteStatements(ctx, h.db, h.translator, statements)
+}
+
+// See BatchHarness.AtomicBatches
+func (h *DuckDBHarness) AtomicBatches() bool {
+	return true
+}
+
+// See Harness.ExecuteQuery
+func (h *DuckDBHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
+	statement, err = h.translator.Translate(statement)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sb := strings.Builder{}
+	for _, columnType := range types {
+		sb.WriteByte(schemaType(columnType.DatabaseTypeName()))
+	}
+
+	row := make([]interface{}, len(types))
+	scanArgs := make([]interface{}, len(types))
+	for i := range row {
+		scanArgs[i] = &row[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return "", nil, err
+		}
+		for i, v := range row {
+			results = append(results, formatValue(h.formatter, v, types[i].DatabaseTypeName()))
+		}
+	}
+
+	if rows.Err() != nil {
+		return "", nil, rows.Err()
+	}
+
+	return sb.String(), results, nil
+}
+
+// See ExplainHarness.ExplainQuery
+func (h *DuckDBHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	query, err := h.translator.Translate(query)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := h.db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var plans []string
+	for rows.Next() {
+		var key, plan string
+		if err := rows.Scan(&key, &plan); err != nil {
+			return "", err
+		}
+		plans = append(plans, plan)
+	}
+	if rows.Err() != nil {
+		return "", rows.Err()
+	}
+
+	return strings.Join(plans, "\n"), nil
+}
+
+// duckdbErrorCategories map the types of DuckDB errors to error categories. Catalog and binder errors are categorized
+// by their messages instead, since they cover both missing and existing objects.
+var duckdbErrorCategories = map[duckdb.ErrorType]logictest.ErrorCategory{
+	duckdb.ErrorTypeParser:         logictest.CategorySyntax,
+	duckdb.ErrorTypeSyntax:         logictest.CategorySyntax,
+	duckdb.ErrorTypeConstraint:     logictest.CategoryConstraint,
+	duckdb.ErrorTypeOutOfRange:     logictest.CategoryData,
+	duckdb.ErrorTypeConversion:     logictest.CategoryData,
+	duckdb.ErrorTypeDivideByZero:   logictest.CategoryData,
+	duckdb.ErrorTypeTransaction:    logictest.CategoryTransaction,
+	duckdb.ErrorTypeNotImplemented: logictest.CategoryUnsupported,
+	duckdb.ErrorTypePermission:     logictest.CategoryPermission,
+}
+
+// See ErrorClassifier.ClassifyError. DuckDB doesn't report SQLSTATEs, so only the category of errors is known.
+func (h *DuckDBHarness) ClassifyError(err error) logictest.ErrorClass {
+	var duckErr *duckdb.Error
+	if !errors.As(err, &duckErr) {
+		return logictest.ErrorClass{}
+	}
+
+	if category, ok := duckdbErrorCategories[duckErr.Type]; ok {
+		return logictest.ErrorClass{Category: category}
+	}
+
+	if duckErr.Type == duckdb.ErrorTypeCatalog || duckErr.Type == duckdb.ErrorTypeBinder {
+		switch {
+		case strings.Contains(duckErr.Msg, "does not exist"), strings.Contains(duckErr.Msg, "not found"):
+			return logictest.ErrorClass{Category: logictest.CategoryNotFound}
+		case strings.Contains(duckErr.Msg, "already exists"):
+			return logictest.ErrorClass{Category: logictest.CategoryAlreadyExists}
+		}
+	}
+
+	return logictest.ErrorClass{}
+}
+
+func (h *DuckDBHarness) GetTimeout() int64 {
+	return 0
+}
+
+// dropAll drops every table of the type given, as named in information_schema.tables (BASE TABLE or VIEW).
+func (h *DuckDBHarness) dropAll(tableType string) error {
+	rows, err := h.db.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = ?", tableType)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	kind := "TABLE"
+	if tableType == "VIEW" {
+		kind = "VIEW"
+	}
+	for _, name := range names {
+		drop := fmt.Sprintf(`DROP %s IF EXISTS "%s" CASCADE`, kind, strings.ReplaceAll(name, `"`, `""`))
+		if _, err := h.db.Exec(drop); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// schemaType returns the schema type of a column with the DuckDB type name given. Integer and boolean types are I,
+// floating point and decimal types are R, and all other types are T.
+func schemaType(typeName string) byte {
+	switch {
+	case strings.HasPrefix(typeName, "DECIMAL"), typeName == "FLOAT", typeName == "DOUBLE":
+		return 'R'
+	case strings.HasSuffix(typeName, "INT"), strings.HasSuffix(typeName, "INTEGER"), typeName == "BOOLEAN":
+		// TINYINT, SMALLINT, INTEGER, BIGINT, HUGEINT and their unsigned variants
+		return 'I'
+	default:
+		return 'T'
+	}
+}
+
+// formatValue renders a value returned by the driver for a column with the DuckDB type name given, with the formatter
+// given.
+func formatValue(f *logictest.ValueFormatter, v interface{}, typeName string) string {
+	switch v := v.(type) {
+	case duckdb.Decimal:
+		return f.FormatFloat(v.Float64())
+	case *big.Int:
+		if v == nil {
+			return f.FormatNull()
+		}
+		return f.FormatString(v.String())
+	case time.Time:
+		if typeName == "DATE" {
+			return f.FormatString(v.Format("2006-01-02"))
+		}
+		return f.FormatString(v.Format("2006-01-02 15:04:05"))
+	default:
+		return f.FormatValue(v)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package duckdb
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuckDBHarness(t *testing.T) {
+	output := &bytes.Buffer{}
+	runner := logictest.NewRunner(NewDuckDBHarness(""), logictest.WithOutput(output))
+	runner.RunTestFiles("testdata/basic.test")
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Contains(t, output.String(), " ok")
+}
+
+func TestDuckDBHarnessInit(t *testing.T) {
+	ctx := context.Background()
+	h := NewDuckDBHarness("")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER)"))
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE VIEW v1 AS SELECT a FROM t1"))
+	require.NoError(t, h.Init())
+
+	schema, results, err := h.ExecuteQuery(ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema()")
+	require.NoError(t, err)
+	assert.Equal(t, "T", schema)
+	assert.Empty(t, results)
+}
+
+func TestDuckDBHarnessExplain(t *testing.T) {
+	ctx := context.Background()
+	h := NewDuckDBHarness("")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER)"))
+
+	plan, err := h.ExplainQuery(ctx, "SELECT a FROM t1")
+	require.NoError(t, err)
+	assert.Contains(t, plan, "t1")
+}
+
+func TestDuckDBHarnessSetSeed(t *testing.T) {
+	ctx := context.Background()
+	h := NewDuckDBHarness("")
+
+	random := func() []string {
+		_, results, err := h.ExecuteQuery(ctx, "SELECT random(), random()")
+		require.NoError(t, err)
+		return results
+	}
+
+	require.NoError(t, h.SetSeed(ctx, 42))
+	first := random()
+	require.NoError(t, h.SetSeed(ctx, 42))
+	assert.Equal(t, first, random())
+	require.NoError(t, h.SetSeed(ctx, 7))
+	assert.NotEqual(t, first, random())
+	require.NoError(t, h.Init())
+}
+
+func TestDuckDBHarnessObjectCleanup(t *testing.T) {
+	h := NewDuckDBHarness("")
+	output := &bytes.Buffer{}
+	path := filepath.Join(t.TempDir(), "cleanup.test")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\nCREATE TABLE t1(a INTEGER)\n\n"+
+		"statement ok\nCREATE INDEX i1 ON t1(a)\n\nstatement ok\nCREATE VIEW v1 AS SELECT a FROM t1\n"), 0644))
+
+	// Init only drops objects before each file, so without cleanup they would remain afterwards
+	runner := logictest.NewRunner(h, logictest.WithOutput(output), logictest.WithObjectCleanup(true))
+	runner.RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+
+	_, results, err := h.ExecuteQuery(context.Background(), "SELECT table_name FROM information_schema.tables")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestDuckDBHarnessCatalog(t *testing.T) {
+	ctx := context.Background()
+	h := NewDuckDBHarness("")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER, b VARCHAR)"))
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE VIEW v1 AS SELECT a FROM t1"))
+
+	catalog, err := h.Catalog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, logictest.Catalog{"t1": {"a", "b"}}, catalog)
+}
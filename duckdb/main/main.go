@@ -0,0 +1,38 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/duckdb"
+)
+
+// DuckDB test runner. Runs DuckDB in-process against an in-memory database, unless the path of a database file is
+// given in the SQLLOGICTEST_DUCKDB_DSN environment variable, in which case all tables in that database are dropped
+// routinely.
+//
+// The modes and options of the runner are those of logictest.RunEngineMain.
+func main() {
+	harness := duckdb.NewDuckDBHarness(os.Getenv("SQLLOGICTEST_DUCKDB_DSN"))
+
+	if err := logictest.RunEngineMain(harness, os.Args[1:]); err != nil {
+		fmt.Println(err)
+		fmt.Println("Usage: go run main.go " + logictest.EngineMainUsage)
+		os.Exit(1)
+	}
+}
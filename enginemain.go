@@ -0,0 +1,70 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+)
+
+// EngineMainUsage describes the arguments of RunEngineMain, for the usage messages of the runners of each engine.
+const EngineMainUsage = "(analyze|filter|generate|verify) [generate options] testfile1 [testfile2 ...], where the " +
+	"generate options, for the generate and filter modes, are " + GenerateUsage
+
+// RunEngineMain runs the mode given by the first of the command line arguments given on the harness given, as the
+// runners of each engine of this module do, and returns an error for arguments that don't match EngineMainUsage. The
+// modes are:
+//
+//	verify: Runs the test files given, outputting a pass / fail line to STDOUT for each test record. All arguments
+//	  after the first are interpreted as test files or directories, which contain tests to be run. For directory
+//	  arguments, directories are descended recursively, and all files with the .test extension will be added to the
+//	  list of tests.
+//	generate: Runs tests as verify does, but also produces a new version of each test file, named
+//	  $testfile.generated, with the results of this test run.
+//	filter: Runs the tests and produces a new version of each test file, just like generate, but any tests that fail
+//	  are filtered out and not included in the generated files. This mode is useful when validating a new batch of
+//	  fuzzed statements against a test oracle to filter out statements that don't execute correctly.
+//	analyze: Analyzes all test statements in the specified test files and prints out a usage count for various
+//	  statement types (e.g. SELECT, CREATE TABLE, CREATE INDEX).
+//
+// The generate and filter modes accept the options of ParseGenerateOptions before the test files, e.g. --in-place to
+// replace each test file with the generated file, or --failing-only to only rewrite the expected results of queries
+// that fail.
+func RunEngineMain(harness Harness, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing mode")
+	}
+
+	mode, args := args[0], args[1:]
+	switch mode {
+	case "verify":
+		RunTestFiles(harness, args...)
+	case "generate", "filter":
+		opts, files, err := ParseGenerateOptions(args)
+		if err != nil {
+			return err
+		}
+		runner := NewRunner(harness, opts...)
+		if mode == "filter" {
+			runner.GenerateTestFilesWithFailedTestsExcluded(files...)
+		} else {
+			runner.GenerateTestFiles(files...)
+		}
+	case "analyze":
+		AnalyzeStatements(harness, args...)
+	default:
+		return fmt.Errorf("unknown mode %s", mode)
+	}
+	return nil
+}
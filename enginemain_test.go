@@ -0,0 +1,28 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunEngineMainUsage(t *testing.T) {
+	assert.EqualError(t, RunEngineMain(newFakeHarness(), nil), "missing mode")
+	assert.EqualError(t, RunEngineMain(newFakeHarness(), []string{"run", "a.test"}), "unknown mode run")
+	assert.EqualError(t, RunEngineMain(newFakeHarness(), []string{"generate", "--in-palce", "a.test"}),
+		"unrecognized option --in-palce")
+}
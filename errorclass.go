@@ -0,0 +1,218 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// ErrorCategory is an engine-independent category of errors, used to write tests that expect a particular kind of
+// error without depending on the error codes or messages of any one engine.
+type ErrorCategory string
+
+const (
+	// CategoryUnknown is the category of errors that can't be classified.
+	CategoryUnknown ErrorCategory = ""
+	// CategorySyntax is the category of syntax errors and other malformed statements.
+	CategorySyntax ErrorCategory = "syntax"
+	// CategoryNotFound is the category of errors referencing a table, column or other object that doesn't exist.
+	CategoryNotFound ErrorCategory = "not-found"
+	// CategoryAlreadyExists is the category of errors creating an object that already exists.
+	CategoryAlreadyExists ErrorCategory = "already-exists"
+	// CategoryConstraint is the category of integrity constraint violations, such as duplicate keys.
+	CategoryConstraint ErrorCategory = "constraint"
+	// CategoryData is the category of errors in data values, such as overflows and division by zero.
+	CategoryData ErrorCategory = "data"
+	// CategoryPermission is the category of authorization errors.
+	CategoryPermission ErrorCategory = "permission"
+	// CategoryTransaction is the category of transaction errors, such as deadlocks and serialization failures.
+	CategoryTransaction ErrorCategory = "transaction"
+	// CategoryDeadlock is the category of errors for a transaction chosen as the victim of a deadlock. Deadlocks are
+	// transaction errors too.
+	CategoryDeadlock ErrorCategory = "deadlock"
+	// CategoryLockTimeout is the category of errors for a statement that timed out waiting for a lock. Lock timeouts
+	// are transaction errors too.
+	CategoryLockTimeout ErrorCategory = "lock-timeout"
+	// CategoryUnsupported is the category of errors for features the engine doesn't support.
+	CategoryUnsupported ErrorCategory = "unsupported"
+)
+
+// ErrorClass is the portable classification of an engine error.
+type ErrorClass struct {
+	// SQLState is the five character SQLSTATE of the error, or empty if unknown.
+	SQLState string
+	// Category is the category of the error.
+	Category ErrorCategory
+	// Code is the engine's own code for the error, such as a MySQL error number or SQLite extended result code, or
+	// empty if unknown. The runner maps codes to portable SQLSTATEs with PortableSQLState.
+	Code string
+
+	// portableSQLState is the portable SQLSTATE of the error's code, if the built-in mapping knows it
+	portableSQLState string
+}
+
+// Matches returns whether this class matches the expected error given, as written in a test file after "statement
+// error". The expected error is either a SQLSTATE or the name of an error category, compared case-insensitively. A
+// SQLSTATE matches both the one the engine reported and the portable one its error code maps to, and one whose
+// subclass is 000, such as 23000, matches every SQLSTATE in its class.
+func (c ErrorClass) Matches(expected string) bool {
+	if sqlStateMatches(c.SQLState, expected) || sqlStateMatches(c.portableSQLState, expected) {
+		return true
+	}
+	for category := c.Category; category != CategoryUnknown; category = categoryParents[category] {
+		if strings.EqualFold(string(category), expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryParents map error categories to the broader categories they are part of.
+var categoryParents = map[ErrorCategory]ErrorCategory{
+	CategoryDeadlock:    CategoryTransaction,
+	CategoryLockTimeout: CategoryTransaction,
+}
+
+func (c ErrorClass) String() string {
+	sqlState := c.SQLState
+	if c.portableSQLState != "" && !strings.EqualFold(c.portableSQLState, sqlState) {
+		if sqlState == "" {
+			sqlState = c.portableSQLState
+		} else {
+			sqlState += "/" + c.portableSQLState
+		}
+	}
+
+	switch {
+	case sqlState != "" && c.Category != CategoryUnknown:
+		return fmt.Sprintf("%s (%s)", sqlState, c.Category)
+	case sqlState != "":
+		return sqlState
+	case c.Category != CategoryUnknown:
+		return string(c.Category)
+	default:
+		return "unknown"
+	}
+}
+
+// An ErrorClassifier maps the errors returned by a harness's engine to portable error classes. Harnesses that implement
+// this interface can run statements that expect a particular error, written e.g.:
+//
+//	statement error 23000
+//	INSERT INTO t1 VALUES (1)
+//
+//	statement error not-found
+//	SELECT * FROM missing
+//
+// Harnesses that report their engine's own error codes in ErrorClass.Code can also run statements expecting the
+// portable SQLSTATEs those codes map to, so that e.g. "statement error 23505" expects a duplicate key on MySQL,
+// Postgres and SQLite alike. For harnesses that don't implement this interface, any error satisfies such statements.
+type ErrorClassifier interface {
+	// ClassifyError returns the class of the error given, which was returned by the harness.
+	ClassifyError(err error) ErrorClass
+}
+
+// sqlStateCategories map SQLSTATEs and SQLSTATE classes (their first two characters) to error categories. Full
+// SQLSTATEs take precedence over classes.
+var sqlStateCategories = map[string]ErrorCategory{
+	"0A":    CategoryUnsupported,
+	"22":    CategoryData,
+	"23":    CategoryConstraint,
+	"28":    CategoryPermission,
+	"40":    CategoryTransaction,
+	"42":    CategorySyntax,
+	"42501": CategoryPermission,
+	"42S01": CategoryAlreadyExists,
+	"42S02": CategoryNotFound,
+	"42S11": CategoryAlreadyExists,
+	"42S12": CategoryNotFound,
+	"42S21": CategoryAlreadyExists,
+	"42S22": CategoryNotFound,
+	"40P01": CategoryDeadlock,
+	"42P01": CategoryNotFound,
+	"42P07": CategoryAlreadyExists,
+	"42701": CategoryAlreadyExists,
+	"42804": CategoryData,
+	"3D000": CategoryNotFound,
+	"25006": CategoryPermission,
+	"42703": CategoryNotFound,
+	"42883": CategoryNotFound,
+	"55P03": CategoryLockTimeout,
+}
+
+// CategoryForSQLState returns the category of errors with the SQLSTATE given, or CategoryUnknown if it has none.
+// Harnesses for engines that report SQLSTATEs can use this to implement ErrorClassifier.
+func CategoryForSQLState(sqlState string) ErrorCategory {
+	sqlState = strings.ToUpper(sqlState)
+	if category, ok := sqlStateCategories[sqlState]; ok {
+		return category
+	}
+	if len(sqlState) >= 2 {
+		return sqlStateCategories[sqlState[:2]]
+	}
+	return CategoryUnknown
+}
+
+// verifyError verifies that the error returned for the statement record given is the one it expects, if the record
+// names one and the harness can classify errors, and that its text matches the pattern the record expects, if it gives
+// one, logging any failure.
+func (r *Runner) verifyError(ctx context.Context, record *parser.Record, err error) error {
+	if expected := record.ExpectedError(); expected != "" {
+		if class, ok := r.classifyError(err); ok && !class.Matches(expected) {
+			logFailure(ctx, ErrorMismatch, "Expected error %s but got %s: %v", expected, class, err)
+			return fmt.Errorf("expected error %s but got %s: %v", expected, class, err)
+		}
+	}
+	return r.verifyErrorMessage(ctx, record, err)
+}
+
+// isError returns whether the error given is the one expected, a SQLSTATE or error category. Any error is the one
+// expected if the harness can't classify errors.
+func (r *Runner) isError(err error, expected string) bool {
+	class, ok := r.classifyError(err)
+	return !ok || class.Matches(expected)
+}
+
+// describeError returns a description of the error given for failure messages, which includes its class if the
+// harness can classify errors.
+func (r *Runner) describeError(err error) string {
+	if class, ok := r.classifyError(err); ok && class != (ErrorClass{}) {
+		return fmt.Sprintf("%v [%s]", err, class)
+	}
+	return err.Error()
+}
+
+// classifyError returns the class of the error given, with the portable SQLSTATE of its code if the built-in mapping
+// knows it, or false if the harness can't classify errors. Errors the harness doesn't categorize are categorized by
+// their portable SQLSTATE.
+func (r *Runner) classifyError(err error) (ErrorClass, bool) {
+	classifier, ok := r.harness.(ErrorClassifier)
+	if !ok {
+		return ErrorClass{}, false
+	}
+
+	class := classifier.ClassifyError(err)
+	if class.Code != "" {
+		class.portableSQLState = PortableSQLState(r.harness.EngineStr(), class.Code)
+		if class.Category == CategoryUnknown {
+			class.Category = CategoryForSQLState(class.portableSQLState)
+		}
+	}
+	return class, true
+}
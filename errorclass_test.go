@@ -0,0 +1,100 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// classifyingHarness classifies every error as a missing table.
+type classifyingHarness struct {
+	*fakeHarness
+}
+
+var _ ErrorClassifier = classifyingHarness{}
+
+func (h classifyingHarness) ClassifyError(err error) ErrorClass {
+	return ErrorClass{SQLState: "42S02", Category: CategoryNotFound}
+}
+
+func TestCategoryForSQLState(t *testing.T) {
+	assert.Equal(t, CategoryConstraint, CategoryForSQLState("23000"))
+	assert.Equal(t, CategoryConstraint, CategoryForSQLState("23505"))
+	assert.Equal(t, CategoryNotFound, CategoryForSQLState("42s02"))
+	assert.Equal(t, CategorySyntax, CategoryForSQLState("42000"))
+	assert.Equal(t, CategoryUnknown, CategoryForSQLState("HY000"))
+	assert.Equal(t, CategoryUnknown, CategoryForSQLState(""))
+}
+
+func TestErrorClassMatches(t *testing.T) {
+	class := ErrorClass{SQLState: "42S02", Category: CategoryNotFound}
+	assert.True(t, class.Matches("42S02"))
+	assert.True(t, class.Matches("42s02"))
+	assert.True(t, class.Matches("not-found"))
+	assert.False(t, class.Matches("23000"))
+	assert.False(t, ErrorClass{}.Matches(""))
+	assert.Equal(t, "42S02 (not-found)", class.String())
+}
+
+func TestErrorClassMatchesParentCategory(t *testing.T) {
+	class := ErrorClass{SQLState: "40P01", Category: CategoryForSQLState("40P01")}
+	assert.True(t, class.Matches("deadlock"))
+	assert.True(t, class.Matches("transaction"))
+	assert.False(t, class.Matches("lock-timeout"))
+	assert.True(t, ErrorClass{Category: CategoryForSQLState("40001")}.Matches("transaction"))
+	assert.False(t, ErrorClass{Category: CategoryForSQLState("40001")}.Matches("deadlock"))
+}
+
+func TestExpectedStatementErrors(t *testing.T) {
+	test := "statement error not-found\nINSERT INTO missing VALUES(1, 2)\n\nstatement error 42S02\nINSERT INTO missing VALUES(1, 2)\n"
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(classifyingHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, test))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(classifyingHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "statement error 23000\nINSERT INTO missing VALUES(1, 2)\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ErrorMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Expected error 23000 but got 42S02 (not-found): table not found: missing", reporter.entries[0].ErrorMessage)
+
+	// Without a classifier, any error satisfies the statement
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "statement error 23000\nINSERT INTO missing VALUES(1, 2)\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
+
+func TestUnexpectedErrorClassDescribed(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(classifyingHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "statement ok\nINSERT INTO missing VALUES(1, 2)\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, UnexpectedError, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Unexpected error table not found: missing [42S02 (not-found)]", reporter.entries[0].ErrorMessage)
+}
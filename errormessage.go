@@ -0,0 +1,115 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// An ErrorNormalizer rewrites the text of an error before it's matched against the pattern of an expect-error-message
+// directive, e.g. to remove details that vary from run to run, such as object IDs.
+type ErrorNormalizer func(message string) string
+
+// An ErrorNormalizingHarness is a Harness that normalizes the text of its engine's errors before it's matched against
+// expected error patterns, e.g. to remove the engine's own decorations. Its normalization is applied before the
+// runner's ErrorNormalizers.
+type ErrorNormalizingHarness interface {
+	Harness
+
+	// NormalizeError returns the text of an error returned by the harness, normalized.
+	NormalizeError(message string) string
+}
+
+var (
+	objectIDRegex   = regexp.MustCompile(`(?i)\b(oid|id|xid|txid)(\s*[:=#]?\s*)\d+`)
+	uuidRegex       = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	lineNumberRegex = regexp.MustCompile(`(?i)\b(line|column|col|position|pos|offset|char)(\s*[:#]?\s*)\d+`)
+	addressRegex    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`)
+)
+
+// StripObjectIDs replaces the numbers of object IDs in error text with N, e.g. "relation with OID N does not exist",
+// and UUIDs with UUID.
+func StripObjectIDs(message string) string {
+	message = objectIDRegex.ReplaceAllString(message, "${1}${2}N")
+	return uuidRegex.ReplaceAllString(message, "UUID")
+}
+
+// StripLineNumbers replaces the numbers of the lines, columns and positions that error text points to with N, e.g.
+// "syntax error at line N".
+func StripLineNumbers(message string) string {
+	return lineNumberRegex.ReplaceAllString(message, "${1}${2}N")
+}
+
+// StripAddresses replaces the hexadecimal memory addresses in error text with 0xADDR.
+func StripAddresses(message string) string {
+	return addressRegex.ReplaceAllString(message, "0xADDR")
+}
+
+// errorNormalizers are the built-in error normalizers, by the names they're given on the command line.
+var errorNormalizers = map[string]ErrorNormalizer{
+	"strip-object-ids":   StripObjectIDs,
+	"strip-line-numbers": StripLineNumbers,
+	"strip-addresses":    StripAddresses,
+}
+
+// LookupErrorNormalizer returns the built-in error normalizer with the name given, e.g. strip-object-ids.
+func LookupErrorNormalizer(name string) (ErrorNormalizer, error) {
+	normalizer, ok := errorNormalizers[name]
+	if !ok {
+		names := make([]string, 0, len(errorNormalizers))
+		for name := range errorNormalizers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown error normalizer %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return normalizer, nil
+}
+
+// normalizeError returns the text of the error given, normalized by the harness and then by the runner's error
+// normalizers.
+func (r *Runner) normalizeError(err error) string {
+	message := err.Error()
+	if harness, ok := r.harness.(ErrorNormalizingHarness); ok {
+		message = harness.NormalizeError(message)
+	}
+	for _, normalizer := range r.config.ErrorNormalizers {
+		message = normalizer(message)
+	}
+	return message
+}
+
+// verifyErrorMessage verifies that the normalized text of the error returned for the statement record given matches
+// the pattern it expects, if it gives one, logging any failure.
+func (r *Runner) verifyErrorMessage(ctx context.Context, record *parser.Record, err error) error {
+	pattern := record.ExpectedErrorPattern()
+	if pattern == "" {
+		return nil
+	}
+
+	// The parser has already checked that the pattern compiles
+	message := r.normalizeError(err)
+	if !regexp.MustCompile(pattern).MatchString(message) {
+		logFailure(ctx, ErrorMismatch, "Expected error matching %s but got %s", pattern, message)
+		return fmt.Errorf("expected error matching %s but got %s", pattern, message)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixingHarness decorates the text of its errors with a prefix it removes again when normalizing them.
+type prefixingHarness struct {
+	*fakeHarness
+}
+
+var _ ErrorNormalizingHarness = prefixingHarness{}
+
+func (h prefixingHarness) NormalizeError(message string) string {
+	return strings.TrimPrefix(message, "engine: ")
+}
+
+func TestErrorNormalizers(t *testing.T) {
+	assert.Equal(t, "relation with OID N does not exist", StripObjectIDs("relation with OID 16384 does not exist"))
+	assert.Equal(t, "table id: N, session UUID", StripObjectIDs("table id: 42, session 123e4567-e89b-12d3-a456-426614174000"))
+	assert.Equal(t, "valid 12 rows", StripObjectIDs("valid 12 rows"))
+	assert.Equal(t, "syntax error at line N, column N", StripLineNumbers("syntax error at line 3, column 17"))
+	assert.Equal(t, "LINE N: SELECT", StripLineNumbers("LINE 1: SELECT"))
+	assert.Equal(t, "nil pointer at 0xADDR", StripAddresses("nil pointer at 0xc000123abc"))
+
+	normalizer, err := LookupErrorNormalizer("strip-addresses")
+	require.NoError(t, err)
+	assert.Equal(t, "0xADDR", normalizer("0x1f"))
+	_, err = LookupErrorNormalizer("strip-everything")
+	assert.Error(t, err)
+}
+
+const errorMessageTest = `expect-error-message ^table not found: [a-z]+$
+statement error
+INSERT INTO missing VALUES(1, 2)
+
+expect-error-message relation with OID N does not exist
+statement error
+DROP TABLE t1
+`
+
+func TestExpectedErrorMessages(t *testing.T) {
+	harness := newFakeHarness()
+	harness.statementErrors["DROP TABLE t1"] = errors.New("relation with OID 16384 does not exist")
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithErrorNormalizers(StripObjectIDs))
+	runner.RunTestFiles(writeTestFile(t, errorMessageTest))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+
+	// Without the normalizer, the object ID fails the second record
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, errorMessageTest))
+	})
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, ErrorMismatch, reporter.entries[1].FailureCode)
+	assert.Equal(t, "Expected error matching relation with OID N does not exist but got relation with OID 16384 does not "+
+		"exist", reporter.entries[1].ErrorMessage)
+}
+
+func TestHarnessNormalizesErrors(t *testing.T) {
+	harness := newFakeHarness()
+	harness.statementErrors["INSERT INTO missing VALUES(1, 2)"] = errors.New("engine: table not found: missing")
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(prefixingHarness{harness}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "expect-error-message ^table not found\nstatement error\n"+
+		"INSERT INTO missing VALUES(1, 2)\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
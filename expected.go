@@ -0,0 +1,73 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadExpectedFailures reads the records listed in an expected failures file, for WithExpectedFailures. The file has
+// one record per line, as a test file path and line number separated by a colon, optionally followed by a reason.
+// Blank lines and lines starting with # are ignored.
+func ReadExpectedFailures(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []string
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		record := strings.TrimSuffix(strings.Fields(line)[0], ":")
+		i := strings.LastIndex(record, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("%s:%d: expected a test file and line number, got %q", path, lineNum, line)
+		}
+		if _, err := strconv.Atoi(record[i+1:]); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid line number in %q", path, lineNum, line)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// expectedFailureKey returns the key of the record given, a test file path and line number, in the ExpectedFailures
+// of a RunConfig: its path as it's written in result logs, and its line number.
+func expectedFailureKey(record string) string {
+	i := strings.LastIndex(record, ":")
+	if i < 0 {
+		return record
+	}
+	return testFilePath(filepath.FromSlash(record[:i])) + ":" + record[i+1:]
+}
+
+// isExpectedFailure returns whether the failure of the record at the line given of the test file given is expected.
+func (c *RunConfig) isExpectedFailure(testFile string, line int) bool {
+	return c.ExpectedFailures[testFilePath(testFile)+":"+strconv.Itoa(line)]
+}
@@ -0,0 +1,118 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// An ExplainHarness is a Harness that can describe the plan its engine uses to execute a query. When a query returns
+// the wrong results, the runner attaches the plan to the failure reported in ResultLogEntry.Plan, so that engine
+// developers can see which plan produced them. Harnesses that implement this interface can also run queries that
+// assert the shape of their plan, written e.g.:
+//
+//	expect-plan-contains IndexScan
+//	expect-plan-not-contains Sort
+//	query I nosort
+//	SELECT id FROM customer WHERE id = 1 ORDER BY id
+//
+// For harnesses that don't implement this interface, plan assertions are ignored.
+type ExplainHarness interface {
+	Harness
+
+	// ExplainQuery returns a description of the plan for the query given, e.g. the output of EXPLAIN.
+	ExplainQuery(ctx context.Context, query string) (string, error)
+}
+
+// planFailureCodes are the failure codes of queries that executed but returned the wrong results, or used the wrong
+// plan.
+var planFailureCodes = map[FailureCode]bool{
+	SchemaMismatch:   true,
+	RowCountMismatch: true,
+	ValueMismatch:    true,
+	HashMismatch:     true,
+	PlanMismatch:     true,
+}
+
+// explainFailure returns the plan for the query of the record given, which failed with the code given, or the empty
+// string if the harness can't explain it or the failure isn't one of wrong results.
+func (r *Runner) explainFailure(record *parser.Record, code FailureCode) string {
+	harness, ok := r.harness.(ExplainHarness)
+	if !ok || record.Type() != parser.Query || !planFailureCodes[code] {
+		return ""
+	}
+
+	translated, err := r.translate(record)
+	if err != nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	plan, err := harness.ExplainQuery(ctx, translated.Query())
+	if err != nil {
+		return "error explaining query: " + err.Error()
+	}
+	return plan
+}
+
+// verifyPlan verifies that the plan of the query record given, which just executed successfully, does or doesn't
+// contain the operators its plan assertions name, if it has any and the harness it executed on can explain queries,
+// logging the first failure. The plan itself is attached to the failure by explainFailure.
+func (r *Runner) verifyPlan(ctx context.Context, harness Harness, record *parser.Record) error {
+	if len(record.PlanAssertions()) == 0 {
+		return nil
+	}
+
+	explainHarness, ok := harness.(ExplainHarness)
+	if !ok {
+		return nil
+	}
+
+	plan, err := explainHarness.ExplainQuery(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unable to explain query: %v", err)
+		return err
+	}
+
+	for _, assertion := range record.PlanAssertions() {
+		if planContains(plan, assertion.Operator) == assertion.Contains {
+			continue
+		}
+		expectation := "to contain"
+		if !assertion.Contains {
+			expectation = "not to contain"
+		}
+		logFailure(ctx, PlanMismatch, "Expected plan %s %s", expectation, assertion.Operator)
+		return fmt.Errorf("expected plan %s %s", expectation, assertion.Operator)
+	}
+	return nil
+}
+
+// planContains returns whether the plan given contains the operator given as a whole word or phrase, ignoring case,
+// so that e.g. "Scan" doesn't match "IndexScan", but "index scan" matches "-> Index Scan using idx on t".
+func planContains(plan, operator string) bool {
+	words := strings.Fields(operator)
+	for i := range words {
+		words[i] = regexp.QuoteMeta(words[i])
+	}
+	pattern := `(?i)(^|[^\pL\pN_])` + strings.Join(words, `\s+`) + `($|[^\pL\pN_])`
+	return regexp.MustCompile(pattern).MatchString(plan)
+}
@@ -0,0 +1,134 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// explainHarness explains every query with a fixed plan.
+type explainHarness struct {
+	*fakeHarness
+}
+
+var _ ExplainHarness = explainHarness{}
+
+func (h explainHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	return "Table scan on t1 for " + query, nil
+}
+
+func TestPlanCapturedOnFailure(t *testing.T) {
+	reporter := &collectingReporter{}
+	output := &bytes.Buffer{}
+	runner := NewRunner(explainHarness{newFakeHarness()}, WithOutput(output), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Empty(t, reporter.entries[0].Plan)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(explainHarness{newFakeHarness()}, WithOutput(output), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query II nosort\nSELECT a, b FROM t1\n----\n1\n3\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, "Table scan on t1 for SELECT a, b FROM t1", reporter.entries[0].Plan)
+	assert.NotContains(t, output.String(), "Table scan")
+
+	// Queries that fail with errors aren't explained
+	reporter = &collectingReporter{}
+	runner = NewRunner(explainHarness{newFakeHarness()}, WithOutput(output), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query I nosort\nSELECT missing\n----\n1\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Empty(t, reporter.entries[0].Plan)
+}
+
+// indexPlanHarness explains queries with a WHERE clause as an index lookup, and every other query as a table scan.
+type indexPlanHarness struct {
+	*fakeHarness
+}
+
+var _ ExplainHarness = indexPlanHarness{}
+
+func (h indexPlanHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	if strings.Contains(query, "WHERE") {
+		return "Project\n -> IndexScan on t1 using a_idx", nil
+	}
+	return "Project\n -> Table Scan on t1", nil
+}
+
+const planTest = `expect-plan-contains IndexScan
+expect-plan-not-contains table scan
+query II nosort
+SELECT a, b FROM t1 WHERE a = 1
+----
+1
+2
+
+expect-plan-contains Table Scan
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+expect-plan-contains IndexScan
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestPlanAssertions(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT a, b FROM t1 WHERE a = 1"] = harness.queryResults["SELECT a, b FROM t1"]
+	reporter := &collectingReporter{}
+	runner := NewRunner(indexPlanHarness{harness}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, planTest))
+	})
+	require.Len(t, reporter.entries, 3)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+	assert.Equal(t, NotOk, reporter.entries[2].Result)
+	assert.Equal(t, PlanMismatch, reporter.entries[2].FailureCode)
+	assert.Equal(t, "Expected plan to contain IndexScan", reporter.entries[2].ErrorMessage)
+	assert.Equal(t, "Project\n -> Table Scan on t1", reporter.entries[2].Plan)
+
+	// Harnesses that can't explain queries ignore plan assertions
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, planTest))
+	require.Len(t, reporter.entries, 3)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
+
+func TestPlanContains(t *testing.T) {
+	assert.True(t, planContains("-> IndexScan on t1", "IndexScan"))
+	assert.True(t, planContains("-> Index Scan using idx on t", "index scan"))
+	assert.True(t, planContains("IndexScan", "indexscan"))
+	assert.False(t, planContains("-> IndexScan on t1", "Scan"))
+	assert.False(t, planContains("-> Table Scan on t1", "Index Scan"))
+}
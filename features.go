@@ -0,0 +1,269 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// A Feature is a category of SQL features a query can use.
+type Feature string
+
+const (
+	// FeatureJoin is a join of several tables, explicit or with a comma-separated FROM list.
+	FeatureJoin Feature = "join"
+	// FeatureAggregate is an aggregate function, GROUP BY or HAVING.
+	FeatureAggregate Feature = "aggregate"
+	// FeatureSubquery is a subquery, in any clause.
+	FeatureSubquery Feature = "subquery"
+	// FeatureCase is a CASE expression.
+	FeatureCase Feature = "case"
+	// FeatureSetOperation is a UNION, INTERSECT or EXCEPT.
+	FeatureSetOperation Feature = "set-operation"
+	// FeatureWindow is a window function.
+	FeatureWindow Feature = "window"
+	// FeatureCTE is a common table expression.
+	FeatureCTE Feature = "cte"
+	// FeatureDistinct is SELECT DISTINCT or an aggregate of distinct values.
+	FeatureDistinct Feature = "distinct"
+)
+
+// Features are all features recognized by QueryFeatures, in the order they're reported.
+var Features = []Feature{
+	FeatureJoin,
+	FeatureAggregate,
+	FeatureSubquery,
+	FeatureCase,
+	FeatureSetOperation,
+	FeatureWindow,
+	FeatureCTE,
+	FeatureDistinct,
+}
+
+// featurePatterns match the upper-cased text of queries, without literals, that use each feature.
+var featurePatterns = map[Feature][]*regexp.Regexp{
+	FeatureJoin: {
+		regexp.MustCompile(`\bJOIN\b`),
+		regexp.MustCompile(`\bFROM\s+[\w.]+(?:\s+(?:AS\s+)?\w+)?\s*,`),
+	},
+	FeatureAggregate: {
+		regexp.MustCompile(`\b(?:COUNT|SUM|AVG|MIN|MAX|TOTAL|GROUP_CONCAT|STRING_AGG|ARRAY_AGG|STDDEV|VARIANCE)\s*\(`),
+		regexp.MustCompile(`\bGROUP\s+BY\b`),
+		regexp.MustCompile(`\bHAVING\b`),
+	},
+	FeatureSubquery: {
+		regexp.MustCompile(`\(\s*SELECT\b`),
+	},
+	FeatureCase: {
+		regexp.MustCompile(`\bCASE\b`),
+	},
+	FeatureSetOperation: {
+		regexp.MustCompile(`\b(?:UNION|INTERSECT|EXCEPT)\b`),
+	},
+	FeatureWindow: {
+		regexp.MustCompile(`\bOVER\s*\(`),
+		regexp.MustCompile(`\bOVER\s+\w`),
+	},
+	FeatureCTE: {
+		regexp.MustCompile(`^\s*WITH\b`),
+	},
+	FeatureDistinct: {
+		regexp.MustCompile(`\bDISTINCT\b`),
+	},
+}
+
+// literalRegex matches string literals and quoted identifiers, whose contents could look like keywords.
+var literalRegex = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"|` + "`[^`]*`")
+
+// QueryFeatures returns the features used by the query given, in the order of Features. Features are recognized by
+// patterns of keywords rather than by parsing, so unusual queries can be misclassified, but the statistics of a
+// corpus are reliable enough to find the features it doesn't cover.
+func QueryFeatures(query string) []Feature {
+	query = strings.ToUpper(literalRegex.ReplaceAllString(query, "''"))
+
+	var features []Feature
+	for _, feature := range Features {
+		for _, pattern := range featurePatterns[feature] {
+			if pattern.MatchString(query) {
+				features = append(features, feature)
+				break
+			}
+		}
+	}
+	return features
+}
+
+// FeatureStats are the numbers of queries in a corpus that use each feature.
+type FeatureStats struct {
+	// Queries is the number of queries analyzed.
+	Queries int
+	// Counts is the number of queries that use each feature.
+	Counts map[Feature]int
+	// Plain is the number of queries that use none of the features.
+	Plain int
+}
+
+// AnalyzeFeatures classifies the queries of the test files found under any of the paths given by the features they
+// use, as QueryFeatures does.
+func AnalyzeFeatures(paths ...string) (FeatureStats, error) {
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return FeatureStats{}, err
+	}
+
+	stats := FeatureStats{Counts: make(map[Feature]int)}
+	for _, file := range files {
+		records, err := parser.ParseTestFile(file)
+		if err != nil {
+			return FeatureStats{}, fmt.Errorf("%s: %w", file, err)
+		}
+
+		for _, record := range records {
+			if record.Type() != parser.Query {
+				continue
+			}
+			stats.Queries++
+			features := QueryFeatures(record.Query())
+			for _, feature := range features {
+				stats.Counts[feature]++
+			}
+			if len(features) == 0 {
+				stats.Plain++
+			}
+		}
+	}
+	return stats, nil
+}
+
+// Print writes these statistics to the writer given, with the number and percentage of queries using each feature.
+func (s FeatureStats) Print(w io.Writer) {
+	p := message.NewPrinter(language.English)
+	p.Fprintf(w, "Feature Coverage (%d queries):\n", s.Queries)
+	for _, feature := range Features {
+		p.Fprintf(w, " -  %-25s: %12d %6.1f%%\n", feature, s.Counts[feature], s.percent(s.Counts[feature]))
+	}
+	p.Fprintf(w, " -  %-25s: %12d %6.1f%%\n", "(none)", s.Plain, s.percent(s.Plain))
+}
+
+func (s FeatureStats) percent(n int) float64 {
+	if s.Queries == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(s.Queries)
+}
+
+// FeatureOutcomes are the numbers of records of a run using a feature that passed, failed and were skipped.
+type FeatureOutcomes struct {
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+func (o *FeatureOutcomes) add(entry *ResultLogEntry) {
+	switch outcomeOf(entry) {
+	case outcomePassed:
+		o.Passed++
+	case outcomeFailed:
+		o.Failed++
+	default:
+		o.Skipped++
+	}
+}
+
+// PassRate returns the percentage of the records that ran that passed, or zero if none ran.
+func (o FeatureOutcomes) PassRate() float64 {
+	if o.Passed+o.Failed == 0 {
+		return 0
+	}
+	return 100 * float64(o.Passed) / float64(o.Passed+o.Failed)
+}
+
+// FeatureCoverage is the outcomes of the records of a run against an engine by the features they use, as classified
+// by QueryFeatures, showing the features the engine supports.
+type FeatureCoverage struct {
+	Engine string
+	// Outcomes are the outcomes of the records using each feature.
+	Outcomes map[Feature]FeatureOutcomes
+	// Plain are the outcomes of the records using none of the features.
+	Plain FeatureOutcomes
+	// Total are the outcomes of all records.
+	Total FeatureOutcomes
+}
+
+// NewFeatureCoverage classifies the results of a run against the engine given by the features of their records.
+// Statements are classified along with queries, e.g. an INSERT with a subquery. Queries truncated in result logs with
+// WithTruncateQueries can be misclassified.
+func NewFeatureCoverage(engine string, results []*ResultLogEntry) FeatureCoverage {
+	coverage := FeatureCoverage{Engine: engine, Outcomes: make(map[Feature]FeatureOutcomes)}
+	for _, entry := range results {
+		coverage.Total.add(entry)
+		features := QueryFeatures(entry.Query)
+		for _, feature := range features {
+			outcomes := coverage.Outcomes[feature]
+			outcomes.add(entry)
+			coverage.Outcomes[feature] = outcomes
+		}
+		if len(features) == 0 {
+			coverage.Plain.add(entry)
+		}
+	}
+	return coverage
+}
+
+// PrintCompatibilityMatrix writes a table of the feature coverage of each engine given to the writer given, with a row
+// for each feature and a column for each engine. Each cell is the number of records using the feature that passed out
+// of the ones that ran, and the pass rate.
+func PrintCompatibilityMatrix(w io.Writer, coverages []FeatureCoverage) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "FEATURE")
+	for _, coverage := range coverages {
+		fmt.Fprintf(tw, "\t%s", coverage.Engine)
+	}
+	fmt.Fprintln(tw)
+
+	row := func(name string, outcomes func(FeatureCoverage) FeatureOutcomes) {
+		fmt.Fprint(tw, name)
+		for _, coverage := range coverages {
+			o := outcomes(coverage)
+			if o.Passed+o.Failed == 0 {
+				fmt.Fprint(tw, "\t-")
+			} else {
+				fmt.Fprintf(tw, "\t%d/%d %.1f%%", o.Passed, o.Passed+o.Failed, o.PassRate())
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	for _, feature := range Features {
+		feature := feature
+		row(string(feature), func(c FeatureCoverage) FeatureOutcomes {
+			return c.Outcomes[feature]
+		})
+	}
+	row("(none)", func(c FeatureCoverage) FeatureOutcomes {
+		return c.Plain
+	})
+	row("(all)", func(c FeatureCoverage) FeatureOutcomes {
+		return c.Total
+	})
+	return tw.Flush()
+}
@@ -0,0 +1,117 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFeatures(t *testing.T) {
+	tests := []struct {
+		query    string
+		features []Feature
+	}{
+		{"SELECT a FROM t1", nil},
+		{"SELECT a FROM t1, t2 WHERE t1.a = t2.b", []Feature{FeatureJoin}},
+		{"SELECT x.a FROM t1 AS x , t2", []Feature{FeatureJoin}},
+		{"SELECT * FROM t1 LEFT OUTER JOIN t2 ON a = b", []Feature{FeatureJoin}},
+		{"SELECT count(*) FROM t1", []Feature{FeatureAggregate}},
+		{"SELECT a FROM t1 GROUP BY a", []Feature{FeatureAggregate}},
+		{"SELECT a FROM t1 WHERE a IN (SELECT b FROM t2)", []Feature{FeatureSubquery}},
+		{"SELECT CASE WHEN a < 0 THEN 1 ELSE 2 END FROM t1", []Feature{FeatureCase}},
+		{"SELECT a FROM t1 UNION ALL SELECT b FROM t2", []Feature{FeatureSetOperation}},
+		{"SELECT row_number() OVER (ORDER BY a) FROM t1", []Feature{FeatureWindow}},
+		// The body of a CTE looks like a subquery
+		{"WITH c AS (SELECT 1) SELECT * FROM c", []Feature{FeatureSubquery, FeatureCTE}},
+		{"SELECT DISTINCT a FROM t1", []Feature{FeatureDistinct}},
+		{"SELECT sum(DISTINCT a) FROM t1 WHERE a IN (SELECT b FROM t2 UNION SELECT c FROM t3)",
+			[]Feature{FeatureAggregate, FeatureSubquery, FeatureSetOperation, FeatureDistinct}},
+		// Keywords in literals and quoted identifiers don't count
+		{"SELECT 'a join b', \"case\" FROM t1 WHERE b = 'union'", nil},
+		{"SELECT a FROM t1 WHERE b OVERLAPS c", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.features, QueryFeatures(tt.query), tt.query)
+	}
+}
+
+func TestAnalyzeFeatures(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+query I nosort
+SELECT count(*) FROM t1, t1 AS t2
+----
+0
+
+query I nosort
+SELECT a FROM t1
+----
+`)
+
+	stats, err := AnalyzeFeatures(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Queries)
+	assert.Equal(t, map[Feature]int{FeatureJoin: 1, FeatureAggregate: 1}, stats.Counts)
+	assert.Equal(t, 1, stats.Plain)
+
+	buf := &bytes.Buffer{}
+	stats.Print(buf)
+	assert.Contains(t, buf.String(), "Feature Coverage (2 queries):")
+	assert.Contains(t, buf.String(), "join")
+	assert.Contains(t, buf.String(), "50.0%")
+}
+
+func TestFeatureCoverage(t *testing.T) {
+	entry := func(query string, result ResultType) *ResultLogEntry {
+		return &ResultLogEntry{TestFile: "a.test", Query: query, Result: result}
+	}
+	sqlite := NewFeatureCoverage("sqlite", []*ResultLogEntry{
+		entry("CREATE TABLE t1(a INTEGER)", Ok),
+		entry("SELECT count(*) FROM t1, t2", Ok),
+		entry("SELECT a FROM t1 GROUP BY a", NotOk),
+		entry("SELECT row_number() OVER (ORDER BY a) FROM t1", Skipped),
+	})
+	assert.Equal(t, FeatureOutcomes{Passed: 1, Failed: 1}, sqlite.Outcomes[FeatureAggregate])
+	assert.Equal(t, 50.0, sqlite.Outcomes[FeatureAggregate].PassRate())
+	assert.Equal(t, FeatureOutcomes{Passed: 1}, sqlite.Outcomes[FeatureJoin])
+	assert.Equal(t, FeatureOutcomes{Skipped: 1}, sqlite.Outcomes[FeatureWindow])
+	assert.Equal(t, FeatureOutcomes{Passed: 1}, sqlite.Plain)
+	assert.Equal(t, FeatureOutcomes{Passed: 2, Failed: 1, Skipped: 1}, sqlite.Total)
+
+	mysql := NewFeatureCoverage("mysql", []*ResultLogEntry{
+		entry("SELECT row_number() OVER (ORDER BY a) FROM t1", Timeout),
+	})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, PrintCompatibilityMatrix(buf, []FeatureCoverage{sqlite, mysql}))
+	lines := strings.Split(buf.String(), "\n")
+	require.Len(t, lines, 12)
+	row := func(i int) string {
+		return strings.Join(strings.Fields(lines[i]), " ")
+	}
+	assert.Equal(t, "FEATURE sqlite mysql", row(0))
+	assert.Equal(t, "join 1/1 100.0% -", row(1))
+	assert.Equal(t, "aggregate 1/2 50.0% -", row(2))
+	assert.Equal(t, "window - 0/1 0.0%", row(6))
+	assert.Equal(t, "(none) 1/1 100.0% -", row(9))
+	assert.Equal(t, "(all) 2/3 66.7% 0/1 0.0%", row(10))
+}
@@ -0,0 +1,200 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// flakeSampleSize is the number of result values kept in each sample of a flaky record's results.
+const flakeSampleSize = 10
+
+// FlakeOptions configure DetectFlakes.
+type FlakeOptions struct {
+	// Iterations is the number of times the test files are run.
+	Iterations int
+	// Shuffle runs the test files in a different random order in each iteration, to find records whose outcome
+	// depends on the files run before them.
+	Shuffle bool
+	// Seed seeds the random order of the test files when they're shuffled, so that a detection can be repeated.
+	Seed int64
+}
+
+// A FlakyRecord is a record whose outcome varied across the iterations of DetectFlakes.
+type FlakyRecord struct {
+	TestFile string
+	LineNum  int
+	Query    string
+	// Outcomes are the distinct outcomes of the record, in the order they first occurred.
+	Outcomes []*FlakeOutcome
+}
+
+// A FlakeOutcome is one of the outcomes of a flaky record, with the iterations it occurred in.
+type FlakeOutcome struct {
+	Result       ResultType
+	ErrorMessage string
+	// Results are the first values of the results of a query, sorted by its sort mode, when it returned any.
+	Results []string
+	// Iterations are the iterations the outcome occurred in, counted from 1.
+	Iterations []int
+}
+
+// flakeRun accumulates the outcomes of a record across iterations.
+type flakeRun struct {
+	record   *FlakyRecord
+	outcomes map[string]*FlakeOutcome
+	// entry and results are the result reported for the record in the current iteration, and the results it returned
+	entry   *ResultLogEntry
+	results []string
+}
+
+// DetectFlakes runs the test files found under any of the paths given the number of times given by the options,
+// and returns the records whose outcome, error message or results varied across iterations, ordered by test file
+// and line number, to isolate nondeterministic engine behavior. Failures don't stop the run, so that every record
+// runs in every iteration. The results are logged to the runner's output and reporters as for RunTestFiles.
+func (r *Runner) DetectFlakes(opts FlakeOptions, paths ...string) []*FlakyRecord {
+	files := r.config.testFiles(paths)
+	random := rand.New(rand.NewSource(opts.Seed))
+
+	runs := make(map[string]*flakeRun)
+	var order []*flakeRun
+	runOf := func(testFile string, line int) *flakeRun {
+		key := fmt.Sprintf("%s:%d", testFile, line)
+		run, ok := runs[key]
+		if !ok {
+			run = &flakeRun{
+				record:   &FlakyRecord{TestFile: testFile, LineNum: line},
+				outcomes: make(map[string]*FlakeOutcome),
+			}
+			runs[key] = run
+			order = append(order, run)
+		}
+		return run
+	}
+
+	for i := 1; i <= opts.Iterations; i++ {
+		if opts.Shuffle {
+			random.Shuffle(len(files), func(i, j int) {
+				files[i], files[j] = files[j], files[i]
+			})
+		}
+
+		collector := reporterFunc(func(entry *ResultLogEntry) {
+			run := runOf(entry.TestFile, entry.LineNum)
+			run.record.Query = entry.Query
+			run.entry = entry
+		})
+		config := r.config
+		config.Reporters = append(append([]Reporter(nil), r.config.Reporters...), collector)
+		runner := &Runner{harness: r.harness, config: config, capabilities: r.capabilities}
+		runner.recordObserver = func(file string, record *parser.Record, res *R) {
+			if res.results != nil {
+				results := r.config.normalizeResults(res.results, record.Schema())
+				runOf(testFilePath(file), record.LineNum()).results = record.SortResults(results)
+			}
+		}
+
+		for _, file := range files {
+			if !runner.runTestFile(file) {
+				break
+			}
+		}
+		for _, run := range order {
+			run.endIteration(i)
+		}
+	}
+
+	var flaky []*FlakyRecord
+	for _, run := range order {
+		if len(run.record.Outcomes) > 1 {
+			flaky = append(flaky, run.record)
+		}
+	}
+	sort.SliceStable(flaky, func(i, j int) bool {
+		if flaky[i].TestFile != flaky[j].TestFile {
+			return flaky[i].TestFile < flaky[j].TestFile
+		}
+		return flaky[i].LineNum < flaky[j].LineNum
+	})
+	return flaky
+}
+
+// endIteration records the outcome of the record in the iteration given, with the results it returned, if any.
+func (r *flakeRun) endIteration(iteration int) {
+	entry, results := r.entry, r.results
+	r.entry, r.results = nil, nil
+	if entry == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%s\x00%s\x00%s", entry.Result, entry.ErrorMessage, strings.Join(results, "\x00"))
+	outcome, ok := r.outcomes[key]
+	if !ok {
+		if len(results) > flakeSampleSize {
+			results = results[:flakeSampleSize]
+		}
+		outcome = &FlakeOutcome{Result: entry.Result, ErrorMessage: entry.ErrorMessage, Results: results}
+		r.outcomes[key] = outcome
+		r.record.Outcomes = append(r.record.Outcomes, outcome)
+	}
+	outcome.Iterations = append(outcome.Iterations, iteration)
+}
+
+// reporterFunc adapts a function to a Reporter.
+type reporterFunc func(entry *ResultLogEntry)
+
+func (f reporterFunc) Report(entry *ResultLogEntry) {
+	f(entry)
+}
+
+// PrintFlakes writes the flaky records given to the writer given, with each of their outcomes and the iterations
+// they occurred in.
+func PrintFlakes(w io.Writer, flaky []*FlakyRecord) error {
+	if _, err := fmt.Fprintf(w, "%d flaky records\n", len(flaky)); err != nil {
+		return err
+	}
+	for _, record := range flaky {
+		if _, err := fmt.Fprintf(w, "\n%s:%d: %s\n", record.TestFile, record.LineNum, record.Query); err != nil {
+			return err
+		}
+		for _, outcome := range record.Outcomes {
+			line := fmt.Sprintf("  %s in iterations %s", outcome.Result, joinInts(outcome.Iterations))
+			if outcome.ErrorMessage != "" {
+				line += ": " + outcome.ErrorMessage
+			}
+			if len(outcome.Results) > 0 {
+				line += fmt.Sprintf("\n    results: %s", strings.Join(outcome.Results, " "))
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinInts(ints []int) string {
+	strs := make([]string, len(ints))
+	for i, n := range ints {
+		strs[i] = fmt.Sprint(n)
+	}
+	return strings.Join(strs, ",")
+}
@@ -0,0 +1,79 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alternatingHarness returns different results for a query every other time it's executed.
+type alternatingHarness struct {
+	*fakeHarness
+	query string
+	calls int
+}
+
+func (h *alternatingHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	if statement == h.query {
+		h.calls++
+		if h.calls%2 == 0 {
+			return "II", []string{"2", "1"}, nil
+		}
+	}
+	return h.fakeHarness.ExecuteQuery(ctx, statement)
+}
+
+func TestDetectFlakes(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+INSERT INTO missing VALUES(1, 2)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+query R nosort
+SELECT 1.0 / 3
+----
+0.333
+`)
+	harness := &alternatingHarness{fakeHarness: newFakeHarness(), query: "SELECT a, b FROM t1"}
+	flaky := NewRunner(harness, WithOutput(&bytes.Buffer{})).DetectFlakes(FlakeOptions{Iterations: 3}, path)
+
+	// The failing statement fails consistently and doesn't stop the run, and the first query alternates between
+	// passing and failing
+	require.Len(t, flaky, 1)
+	first := flaky[0]
+	assert.Equal(t, 5, first.LineNum)
+	assert.Equal(t, "SELECT a, b FROM t1", first.Query)
+	require.Len(t, first.Outcomes, 2)
+	assert.Equal(t, Ok, first.Outcomes[0].Result)
+	assert.Equal(t, []int{1, 3}, first.Outcomes[0].Iterations)
+	assert.Equal(t, []string{"1", "2"}, first.Outcomes[0].Results)
+	assert.Equal(t, NotOk, first.Outcomes[1].Result)
+	assert.Equal(t, []int{2}, first.Outcomes[1].Iterations)
+	assert.Equal(t, []string{"2", "1"}, first.Outcomes[1].Results)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, PrintFlakes(buf, flaky))
+	assert.Contains(t, buf.String(), "1 flaky records\n")
+	assert.Contains(t, buf.String(), "  ok in iterations 1,3\n    results: 1 2\n")
+}
@@ -0,0 +1,234 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// lineKind is the part of a test file a line belongs to, which determines how it's formatted.
+type lineKind int
+
+const (
+	triviaLine lineKind = iota
+	directiveLine
+	queryLine
+	separatorLine
+	resultLine
+)
+
+// looseHashRegex matches hash lines with any spacing and casing, which FormatTestFile rewrites in the canonical form.
+var looseHashRegex = regexp.MustCompile(`(?i)^\s*(\d+)\s+values\s+hashing\s+to\s+(?:([a-z0-9]+):)?([0-9a-f]+)\s*$`)
+
+// canonicalDirectives are the directives whose fields FormatTestFile separates by single spaces. The others, such as
+// warning and bind, can have arguments in which spacing is significant, and only have trailing whitespace removed.
+var canonicalDirectives = map[string]bool{
+	"statement": true, "query": true, "procedure": true, "halt": true, "hash-threshold": true, "skipif": true,
+	"onlyif": true, "float-epsilon": true, "timeout": true, "ambiguous-schema": true, "prepared": true, "require": true,
+	"route": true, "txn": true, "connection": true, "awaitstatement": true, "awaitdeadlock": true,
+	"awaitlocktimeout": true, "warnings": true, "table-checksum": true, "snapshot": true, "restore": true,
+	"set-seed": true, columnNamesDirective: true, "expect-plan-contains": true, "expect-plan-not-contains": true,
+	"expect-metric": true,
+}
+
+// FormatTestFile returns the contents of the test file at the path given in canonical form:
+//   - directive lines have their fields separated by single spaces, engine names and sort modes in lower case, and
+//     schemas in upper case
+//   - separator lines and hash lines are written exactly as the runner generates them
+//   - records are separated by a single blank line, with no blank lines at the start or end of the file
+//   - trailing whitespace is removed outside of queries and results, and lines end with a newline alone
+//
+// The lines of queries and results are otherwise kept as they are, as are comments. Returns an error if the test file
+// doesn't parse, or if its canonical form has different records.
+func FormatTestFile(path string) ([]byte, error) {
+	contents, err := parser.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	records, err := parser.Parse(bytes.NewReader(contents))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n")
+	formatted := []byte(strings.Join(formatLines(lines, records), "\n"))
+	if len(formatted) > 0 {
+		formatted = append(formatted, '\n')
+	}
+
+	formattedRecords, err := parser.Parse(bytes.NewReader(formatted))
+	if err != nil {
+		return nil, fmt.Errorf("%s: formatted file doesn't parse: %v", path, err)
+	}
+	if len(formattedRecords) != len(records) {
+		return nil, fmt.Errorf("%s: formatted file has %d records rather than %d", path, len(formattedRecords), len(records))
+	}
+	for i, record := range records {
+		if formattedRecords[i].Type() != record.Type() || formattedRecords[i].Query() != record.Query() {
+			return nil, fmt.Errorf("%s: formatting changes the record on line %d", path, record.LineNum())
+		}
+	}
+	return formatted, nil
+}
+
+// FormatTestFiles rewrites the test files found under the paths given in canonical form, as given by FormatTestFile,
+// and returns the paths of the files that weren't in canonical form. When check is set, the files are left as they are.
+func FormatTestFiles(check bool, paths ...string) ([]string, error) {
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, path := range files {
+		formatted, err := FormatTestFile(path)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := parser.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(contents, formatted) {
+			continue
+		}
+
+		changed = append(changed, path)
+		if !check {
+			if err := parser.WriteFile(path, formatted); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changed, nil
+}
+
+// formatLines returns the lines given, of a test file with the records given, in canonical form.
+func formatLines(lines []string, records []*parser.Record) []string {
+	kinds := make([]lineKind, len(lines))
+	owners := make([]*parser.Record, len(lines))
+	starts := make(map[int]bool)
+	for _, record := range records {
+		starts[record.StartLine()] = true
+		for n := record.StartLine(); n <= record.EndLine(); n++ {
+			owners[n-1] = record
+			switch {
+			case strings.HasPrefix(lines[n-1], "#"):
+				kinds[n-1] = triviaLine
+			case n <= record.HeaderLine():
+				kinds[n-1] = directiveLine
+			case record.SeparatorLine() == 0 || n < record.SeparatorLine():
+				kinds[n-1] = queryLine
+			case n == record.SeparatorLine() || len(record.ResultSets()) > 1 && strings.TrimSpace(lines[n-1]) == parser.Separator:
+				kinds[n-1] = separatorLine
+			default:
+				kinds[n-1] = resultLine
+			}
+		}
+	}
+
+	var formatted []string
+	// blank is whether a blank line separates the line to write from the last one written
+	blank := false
+	for i, line := range lines {
+		n := i + 1
+		if starts[n] && len(formatted) > 0 && owners[i-1] != nil && owners[i-1] != owners[i] {
+			blank = true
+		}
+
+		switch kinds[i] {
+		case triviaLine:
+			line = strings.TrimRight(line, " \t\r")
+			if line == "" {
+				blank = len(formatted) > 0
+				continue
+			}
+		case directiveLine:
+			line = formatDirective(line)
+		case separatorLine:
+			line = parser.Separator
+		case resultLine:
+			line = formatResult(line, owners[i])
+		}
+
+		if blank {
+			formatted = append(formatted, "")
+			blank = false
+		}
+		formatted = append(formatted, line)
+	}
+	return formatted
+}
+
+// formatDirective returns the directive line given in canonical form.
+func formatDirective(line string) string {
+	content, comment, hasComment := strings.Cut(line, "#")
+	fields := strings.Fields(content)
+	if len(fields) == 0 || !canonicalDirectives[fields[0]] {
+		return strings.TrimRight(line, " \t\r")
+	}
+
+	switch fields[0] {
+	case "skipif", "onlyif":
+		if len(fields) > 1 {
+			fields[1] = strings.ToLower(fields[1])
+		}
+	case "query", "procedure":
+		if len(fields) > 1 && fields[1] != "ok" && fields[1] != "error" {
+			fields[1] = strings.ToUpper(fields[1])
+		}
+		if len(fields) > 2 {
+			switch mode := parser.SortMode(strings.ToLower(fields[2])); mode {
+			case parser.NoSort, parser.Rowsort, parser.ValueSort:
+				fields[2] = string(mode)
+			default:
+				if strings.HasPrefix(string(mode), string(parser.PartialSort)+"(") {
+					fields[2] = string(mode)
+				}
+			}
+		}
+	}
+
+	formatted := strings.Join(fields, " ")
+	if hasComment {
+		formatted += " #" + strings.TrimRight(comment, " \t\r")
+	}
+	return formatted
+}
+
+// formatResult returns the result line given, of the record given, in canonical form. Only hash lines change, and only
+// when all of the record's results are hash lines.
+func formatResult(line string, record *parser.Record) string {
+	match := looseHashRegex.FindStringSubmatch(line)
+	if match == nil {
+		return line
+	}
+	for _, result := range record.Result() {
+		if !looseHashRegex.MatchString(result) {
+			return line
+		}
+	}
+
+	hash := strings.ToLower(match[3])
+	if match[2] != "" {
+		hash = strings.ToLower(match[2]) + ":" + hash
+	}
+	return fmt.Sprintf("%s values hashing to %s", match[1], hash)
+}
@@ -0,0 +1,55 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest"
+)
+
+// Test file formatter. Rewrites the test files given in canonical form, with consistent spacing, directive casing,
+// separator lines and hash lines, and prints the files it changed. With --check, the files are left as they are, and
+// the formatter exits with status 1 if any isn't in canonical form.
+//
+// Usage: go run main.go [--check] testfile1 [testfile2 ...]
+func main() {
+	args := os.Args[1:]
+	check := len(args) > 0 && args[0] == "--check"
+	if check {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		exitWithUsage()
+	}
+
+	changed, err := logictest.FormatTestFiles(check, args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, path := range changed {
+		fmt.Println(path)
+	}
+	if check && len(changed) > 0 {
+		os.Exit(1)
+	}
+}
+
+func exitWithUsage() {
+	fmt.Println("Usage: format [--check] testfile1 [testfile2 ...]")
+	os.Exit(1)
+}
@@ -0,0 +1,103 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unformattedTest = "\n\n# Leading comment   \r\n" + `statement  ok
+CREATE TABLE t1(a INTEGER, b TEXT)
+
+
+
+skipif MySQL   # trailing comment   
+query  it   ROWSORT label-1
+SELECT a,
+  b FROM t1 
+----  
+1
+x 
+
+warning 1265  Data  truncated
+statement ok
+INSERT INTO t1 VALUES (2, 'y')
+ 
+halt
+query I nosort
+SELECT a FROM t1
+----
+   30   VALUES hashing to  3C13DEE48D9356AE19AF2515E05E6B54  
+
+
+`
+
+const formattedTest = `# Leading comment
+statement ok
+CREATE TABLE t1(a INTEGER, b TEXT)
+
+skipif mysql # trailing comment
+query IT rowsort label-1
+SELECT a,
+  b FROM t1 
+----
+1
+x 
+
+warning 1265  Data  truncated
+statement ok
+INSERT INTO t1 VALUES (2, 'y')
+
+halt
+
+query I nosort
+SELECT a FROM t1
+----
+30 values hashing to 3c13dee48d9356ae19af2515e05e6b54
+`
+
+func TestFormatTestFile(t *testing.T) {
+	path := writeTestFile(t, unformattedTest)
+	formatted, err := FormatTestFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, formattedTest, string(formatted))
+
+	// Formatting is idempotent
+	path = writeTestFile(t, formattedTest)
+	formatted, err = FormatTestFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, formattedTest, string(formatted))
+
+	_, err = FormatTestFile(writeTestFile(t, "statement maybe\nSELECT 1\n"))
+	assert.Error(t, err)
+}
+
+func TestFormatTestFiles(t *testing.T) {
+	unformatted := writeTestFile(t, unformattedTest)
+	formatted := writeTestFile(t, formattedTest)
+
+	changed, err := FormatTestFiles(true, unformatted, formatted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{unformatted}, changed)
+	assertFileContents(t, unformatted, unformattedTest)
+
+	changed, err = FormatTestFiles(false, unformatted, formatted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{unformatted}, changed)
+	assertFileContents(t, unformatted, formattedTest)
+}
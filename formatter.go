@@ -0,0 +1,196 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A ValueFormatter renders values returned by an engine as result strings in the format used by test files. Harnesses
+// should implement FormattingHarness and render their results with the formatter they're given, so that they stay
+// consistent with the normalization the runner applies when verifying results.
+type ValueFormatter struct {
+	// FloatPrecision is the number of digits rendered after the decimal point of floating point values.
+	FloatPrecision int
+	// NullString is the string used to render NULL values.
+	NullString string
+	// EmptyString is the string used to render empty string values. The original sqllogictest uses "(empty)", but the
+	// corpus commonly used with this runner expects empty strings to be rendered as-is.
+	EmptyString string
+}
+
+// NewValueFormatter returns a formatter with the conventions of the sqllogictest corpus: floats rendered as if by
+// printf("%.3f"), and NULL values rendered as "NULL".
+func NewValueFormatter() *ValueFormatter {
+	return &ValueFormatter{
+		FloatPrecision: 3,
+		NullString:     "NULL",
+	}
+}
+
+// A FormattingHarness is a Harness that renders its results with a ValueFormatter. Runners give it the formatter of
+// their RunConfig before executing any records on it.
+type FormattingHarness interface {
+	Harness
+
+	// SetFormatter sets the formatter used to render the results of the harness.
+	SetFormatter(formatter *ValueFormatter)
+}
+
+// setFormatter gives the formatter of this config to the harness given, if it renders its results with one.
+func (c *RunConfig) setFormatter(harness Harness) {
+	if harness, ok := harness.(FormattingHarness); ok {
+		harness.SetFormatter(c.Formatter)
+	}
+}
+
+// FormatNull returns the rendering of a NULL value.
+func (f *ValueFormatter) FormatNull() string {
+	return f.NullString
+}
+
+// FormatInt renders an integer value as if by printf("%d").
+func (f *ValueFormatter) FormatInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+// FormatUint renders an unsigned integer value as if by printf("%d").
+func (f *ValueFormatter) FormatUint(i uint64) string {
+	return strconv.FormatUint(i, 10)
+}
+
+// FormatFloat renders a floating point value with FloatPrecision digits after the decimal point.
+func (f *ValueFormatter) FormatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', f.FloatPrecision, 64)
+}
+
+// FormatBool renders a boolean value as an integer, 1 for true and 0 for false.
+func (f *ValueFormatter) FormatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// FormatString renders a string value.
+func (f *ValueFormatter) FormatString(s string) string {
+	if s == "" && f.EmptyString != "" {
+		return f.EmptyString
+	}
+	return s
+}
+
+// EmptyBytes is the rendering of empty binary values, which can't be rendered as an empty line in test files.
+const EmptyBytes = "(empty)"
+
+// FormatBytes renders a binary value, such as the value of a BLOB column, in the canonical encoding of B-typed
+// columns: its bytes in lowercase hex, e.g. 0aff, or EmptyBytes if it has none.
+func (f *ValueFormatter) FormatBytes(b []byte) string {
+	if b == nil {
+		return f.FormatNull()
+	} else if len(b) == 0 {
+		return EmptyBytes
+	}
+	return hex.EncodeToString(b)
+}
+
+// FormatValue renders a value of any of the types commonly returned by database/sql drivers, including the sql.Null*
+// types and pointers to any of these types. Panics on unhandled types. Byte slices are rendered as text, as drivers
+// return them for text columns, except when scanned into a sql.Null[[]byte], which is rendered by FormatBytes.
+func (f *ValueFormatter) FormatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return f.FormatNull()
+	case *sql.NullBool:
+		if !v.Valid {
+			return f.FormatNull()
+		}
+		return f.FormatBool(v.Bool)
+	case *sql.NullInt64:
+		if !v.Valid {
+			return f.FormatNull()
+		}
+		return f.FormatInt(v.Int64)
+	case *sql.NullInt32:
+		if !v.Valid {
+			return f.FormatNull()
+		}
+		return f.FormatInt(int64(v.Int32))
+	case *sql.NullFloat64:
+		if !v.Valid {
+			return f.FormatNull()
+		}
+		return f.FormatFloat(v.Float64)
+	case *sql.NullString:
+		if !v.Valid {
+			return f.FormatNull()
+		}
+		return f.FormatString(v.String)
+	case *sql.Null[[]byte]:
+		if !v.Valid {
+			return f.FormatNull()
+		}
+		return f.FormatBytes(append([]byte{}, v.V...))
+	case *interface{}:
+		return f.FormatValue(*v)
+	case bool:
+		return f.FormatBool(v)
+	case int:
+		return f.FormatInt(int64(v))
+	case int8:
+		return f.FormatInt(int64(v))
+	case int16:
+		return f.FormatInt(int64(v))
+	case int32:
+		return f.FormatInt(int64(v))
+	case int64:
+		return f.FormatInt(v)
+	case uint:
+		return f.FormatUint(uint64(v))
+	case uint8:
+		return f.FormatUint(uint64(v))
+	case uint16:
+		return f.FormatUint(uint64(v))
+	case uint32:
+		return f.FormatUint(uint64(v))
+	case uint64:
+		return f.FormatUint(v)
+	case float32:
+		return f.FormatFloat(float64(v))
+	case float64:
+		return f.FormatFloat(v)
+	case string:
+		return f.FormatString(v)
+	case []byte:
+		if v == nil {
+			return f.FormatNull()
+		}
+		return f.FormatString(string(v))
+	default:
+		panic(fmt.Sprintf("unhandled type %T for value %v", v, v))
+	}
+}
+
+// formatIntAsFloat renders an integer result string as a float with FloatPrecision trailing zeros.
+func (f *ValueFormatter) formatIntAsFloat(s string) string {
+	if f.FloatPrecision == 0 {
+		return s
+	}
+	return s + "." + strings.Repeat("0", f.FloatPrecision)
+}
@@ -0,0 +1,83 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueFormatter(t *testing.T) {
+	f := NewValueFormatter()
+	assert.Equal(t, "NULL", f.FormatValue(nil))
+	assert.Equal(t, "NULL", f.FormatValue(&sql.NullInt64{}))
+	assert.Equal(t, "12", f.FormatValue(&sql.NullInt64{Int64: 12, Valid: true}))
+	assert.Equal(t, "1.500", f.FormatValue(1.5))
+	assert.Equal(t, "1", f.FormatValue(true))
+	assert.Equal(t, "abc", f.FormatValue([]byte("abc")))
+	assert.Equal(t, "", f.FormatValue(""))
+	assert.Equal(t, "10.000", f.formatIntAsFloat("10"))
+	assert.Equal(t, "00ff7f", f.FormatBytes([]byte{0x00, 0xff, 0x7f}))
+	assert.Equal(t, "NULL", f.FormatBytes(nil))
+	assert.Equal(t, "(empty)", f.FormatBytes([]byte{}))
+	assert.Equal(t, "0aff", f.FormatValue(&sql.Null[[]byte]{V: []byte{0x0a, 0xff}, Valid: true}))
+	assert.Equal(t, "NULL", f.FormatValue(&sql.Null[[]byte]{}))
+
+	f.FloatPrecision = 1
+	f.NullString = "null"
+	f.EmptyString = "(empty)"
+	assert.Equal(t, "null", f.FormatValue(&sql.NullString{}))
+	assert.Equal(t, "1.5", f.FormatValue(&sql.NullFloat64{Float64: 1.5, Valid: true}))
+	assert.Equal(t, "(empty)", f.FormatValue(""))
+	assert.Equal(t, "10.0", f.formatIntAsFloat("10"))
+}
+
+// formattingHarness renders the results of its queries with the formatter it's given.
+type formattingHarness struct {
+	*fakeHarness
+	formatter *ValueFormatter
+}
+
+var _ FormattingHarness = &formattingHarness{}
+
+func (h *formattingHarness) SetFormatter(formatter *ValueFormatter) {
+	h.formatter = formatter
+}
+
+func (h *formattingHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	return "RR", []string{h.formatter.FormatFloat(1.5), "1"}, nil
+}
+
+func TestWithFormatter(t *testing.T) {
+	formatter := NewValueFormatter()
+	formatter.FloatPrecision = 1
+	for precision, expected := range map[int]string{1: "1.5\n1.0\n", 3: "1.500\n1.000\n"} {
+		var opts []RunOption
+		if precision == 1 {
+			opts = append(opts, WithFormatter(formatter))
+		}
+		reporter := &collectingReporter{}
+		runner := NewRunner(&formattingHarness{fakeHarness: newFakeHarness()},
+			append(opts, WithOutput(&bytes.Buffer{}), WithReporters(reporter))...)
+		runner.RunTestFiles(writeTestFile(t, "query RR nosort\nSELECT 1.5, 1\n----\n"+expected))
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, Ok, reporter.entries[0].Result, reporter.entries[0].ErrorMessage)
+	}
+}
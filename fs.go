@@ -0,0 +1,85 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"io/fs"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// RunTestFilesFS runs the test files found under any of the paths given in the file system given, such as an embed.FS
+// holding a corpus compiled into a test binary, as RunTestFiles does for paths on disk. Paths are slash-separated
+// names in the file system, with "." for its root.
+func RunTestFilesFS(harness Harness, fsys fs.FS, paths ...string) {
+	NewRunner(harness, WithFS(fsys)).RunTestFiles(paths...)
+}
+
+// testFiles returns the test files of the configured shard found under the paths given, in the configured file system
+// if there is one, leaving out the excluded ones.
+func (c RunConfig) testFiles(paths []string) []string {
+	if c.FS == nil {
+		return c.Shard.Select(c.excludeTestFiles(collectTestFiles(paths)))
+	}
+	return c.Shard.Select(c.excludeTestFiles(collectTestFilesFS(c.FS, paths)))
+}
+
+// openTestFile opens the test file given to read its records, from the configured file system if there is one.
+func (c RunConfig) openTestFile(file string) (*parser.RecordReader, error) {
+	if c.FS == nil {
+		return parser.OpenTestFile(file)
+	}
+	return parser.OpenTestFileFS(c.FS, file)
+}
+
+// parseTestFile parses the test file given, from the configured file system if there is one.
+func (c RunConfig) parseTestFile(file string) ([]*parser.Record, error) {
+	if c.FS == nil {
+		return parser.ParseTestFile(file)
+	}
+	return parser.ParseTestFileFS(c.FS, file)
+}
+
+// collectTestFilesFS returns the test files found under any of the paths given in the file system given, as
+// collectTestFiles does for paths on disk. Panics for paths that don't exist and patterns that match nothing.
+func collectTestFilesFS(fsys fs.FS, paths []string) []string {
+	var testFiles []string
+	for _, arg := range paths {
+		matches, err := expandPattern(arg, func(pattern string) ([]string, error) { return fs.Glob(fsys, pattern) })
+		if err != nil {
+			panic(err)
+		}
+
+		for _, path := range matches {
+			stat, err := fs.Stat(fsys, path)
+			if err != nil {
+				panic(err)
+			}
+
+			if !stat.IsDir() {
+				if path == arg || parser.IsTestFile(path) {
+					testFiles = append(testFiles, path)
+				}
+				continue
+			}
+			walker := newTestFileWalkerFS(fsys)
+			if err := walker.walk(path, nil); err != nil {
+				panic(err)
+			}
+			testFiles = append(testFiles, walker.files...)
+		}
+	}
+	return testFiles
+}
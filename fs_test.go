@@ -0,0 +1,80 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/basic.test
+var embeddedCorpus embed.FS
+
+func TestRunTestFilesEmbedded(t *testing.T) {
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithFS(embeddedCorpus)).
+		RunTestFiles("testdata")
+
+	require.Len(t, reporter.entries, 5)
+	assert.Equal(t, "testdata/basic.test", reporter.entries[0].TestFile)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
+
+func TestRunTestFilesFS(t *testing.T) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write([]byte("statement ok\nINSERT INTO t1 VALUES(1, 2)\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	fsys := fstest.MapFS{
+		"corpus/a.test":       {Data: []byte("query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n")},
+		"corpus/sub/b.test":   {Data: []byte("statement ok\nCREATE TABLE t1(a INTEGER, b INTEGER)\n")},
+		"corpus/notes.txt":    {Data: []byte("not a test file")},
+		"corpus/seed.test.gz": {Data: compressed.Bytes()},
+		"other/c.test":        {Data: []byte("statement error\nINSERT INTO missing VALUES(1, 2)\n")},
+		"other/ignored.test":  {Data: []byte("halt\n")},
+	}
+	paths := []string{"corpus", "other/c.test"}
+	assert.Equal(t, []string{"corpus/a.test", "corpus/seed.test.gz", "corpus/sub/b.test", "other/c.test"},
+		collectTestFilesFS(fsys, paths))
+
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithFS(fsys)).
+		RunTestFiles(paths...)
+	require.Len(t, reporter.entries, 4)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+
+	assert.Panics(t, func() { collectTestFilesFS(fsys, []string{"missing"}) })
+}
+
+func TestParseTestFileFS(t *testing.T) {
+	records, err := parser.ParseTestFileFS(embeddedCorpus, "testdata/basic.test")
+	require.NoError(t, err)
+	expected, err := parser.ParseTestFile("testdata/basic.test")
+	require.NoError(t, err)
+	assert.Equal(t, expected, records)
+}
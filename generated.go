@@ -0,0 +1,325 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// generatedSuffix is appended to the name of a test file for the file generated from it, unless generated files are
+// written in place or to an output directory. For compressed test files it goes before the suffix of the compression.
+const generatedSuffix = ".generated"
+
+// backupSuffix is appended to the name of a test file for the backup of its original contents when it's regenerated
+// in place with backups, before the suffix of the compression of compressed test files.
+const backupSuffix = ".bak"
+
+// GenerateUsage describes the options parsed by ParseGenerateOptions, for the usage messages of runners.
+const GenerateUsage = "[--in-place [--backup] | --output-dir=DIR] [--failing-only | --interactive] [--column-names] [--fix-schemas]"
+
+// ParseGenerateOptions parses the command line options of generating test files that precede the test files in the
+// arguments given, as accepted by every runner of this module, and returns the run options they set with the rest of
+// the arguments:
+//
+//	--in-place: Replaces each test file with the generated file, rather than writing $testfile.generated.
+//	--backup: With --in-place, keeps the original of each test file as $testfile.bak.
+//	--output-dir=DIR: Writes the generated files to DIR instead, mirroring the directories the test files are in.
+//	--failing-only: Only rewrites the expected results of queries that fail, leaving other records unchanged.
+//	--interactive: Like --failing-only, but shows the change to each failing query on STDOUT and asks on STDIN whether
+//	  to accept it.
+//	--column-names: Writes a colnames directive with the names of its columns before every query.
+//	--fix-schemas: Only rewrites the schemas of queries to the column types the engine returns, where their expected
+//	  results still match.
+//
+// Returns an error for any other option.
+func ParseGenerateOptions(args []string) ([]RunOption, []string, error) {
+	var inPlace, backup bool
+	var opts []RunOption
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		switch arg := args[0]; {
+		case arg == "--in-place":
+			inPlace = true
+		case arg == "--backup":
+			backup = true
+		case arg == "--column-names":
+			opts = append(opts, WithGenerateColumnNames(true))
+		case arg == "--failing-only":
+			opts = append(opts, WithRegenerateFailing(true))
+		case arg == "--fix-schemas":
+			opts = append(opts, WithFixSchemas(true))
+		case arg == "--interactive":
+			opts = append(opts, WithInteractiveBless(os.Stdin, os.Stdout))
+		case strings.HasPrefix(arg, "--output-dir="):
+			opts = append(opts, WithGenerateOutputDir(strings.TrimPrefix(arg, "--output-dir=")))
+		default:
+			return nil, nil, fmt.Errorf("unrecognized option %s", arg)
+		}
+		args = args[1:]
+	}
+	return append(opts, WithGenerateInPlace(inPlace, backup)), args, nil
+}
+
+// generateTestFiles generates the test files of the configured shard found under the paths given, leaving out the
+// excluded ones, returning early after a "halt run" record.
+func (r *Runner) generateTestFiles(paths []string, filterOutFailedTests bool) {
+	if r.config.FS != nil {
+		panic("generating test files isn't supported for test files in an fs.FS")
+	}
+
+	inShard := make(map[string]bool)
+	for _, file := range r.config.testFiles(paths) {
+		inShard[file] = true
+	}
+
+	for _, path := range paths {
+		for _, file := range collectTestFiles([]string{path}) {
+			if !inShard[file] {
+				continue
+			}
+			if !r.generateTestFile(file, r.generatedFilePath(path, file), filterOutFailedTests) {
+				return
+			}
+		}
+	}
+}
+
+// generatedFilePath returns the path to write the file generated from the test file given, found under the path given
+// to GenerateTestFiles. With an output directory, the generated file mirrors the test file's path relative to the
+// directory it was found in, or just its name for test files given directly. Otherwise the generated file is the test
+// file's sibling, even when it's regenerated in place, since it only replaces the test file once it's complete.
+func (r *Runner) generatedFilePath(path, file string) string {
+	if r.config.GenerateOutputDir == "" {
+		// The suffix goes before that of a compressed file, which the generated file is compressed like
+		base, compression := parser.SplitCompressionSuffix(file)
+		return base + generatedSuffix + compression
+	}
+
+	rel := filepath.Base(file)
+	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+		if relPath, err := filepath.Rel(path, file); err == nil {
+			rel = relPath
+		}
+	}
+	return filepath.Join(r.config.GenerateOutputDir, rel)
+}
+
+// createGeneratedFile creates the file to generate at the path given, and any directories it needs. The file is
+// compressed if its name has the suffix of a compression.
+func createGeneratedFile(path string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return parser.CreateFile(path)
+}
+
+// finishGeneratedFile replaces the test file given by the file generated from it, if test files are regenerated in
+// place, first renaming the test file to keep a backup if configured.
+func (r *Runner) finishGeneratedFile(file, generated string) error {
+	if !r.config.GenerateInPlace || r.config.GenerateOutputDir != "" {
+		return nil
+	}
+
+	if r.config.GenerateBackups {
+		base, compression := parser.SplitCompressionSuffix(file)
+		if err := os.Rename(file, base+backupSuffix+compression); err != nil {
+			return err
+		}
+	}
+	return os.Rename(generated, file)
+}
+
+// A generatedFile writes the file generated from a test file. Generation works on the lines of the test file and
+// copies them verbatim, including the comments and blank lines around records, except for the lines of the records it
+// rewrites or drops. Regenerated files therefore only differ from the originals where results changed.
+type generatedFile struct {
+	// lines are the lines of the test file, without their line endings
+	lines []string
+	// crlf is whether the lines of the test file end with carriage returns, which new lines are written with too
+	crlf bool
+	// trailingNewline is whether the test file ends with a line ending
+	trailingNewline bool
+	// next is the number of the next line of the test file to copy, counting from 1
+	next    int
+	wr      *bufio.Writer
+	written bool
+}
+
+// newGeneratedFile returns a generatedFile writing to the writer given the file generated from the test file with the
+// contents given.
+func newGeneratedFile(contents []byte, w io.Writer) *generatedFile {
+	text := string(contents)
+	g := &generatedFile{
+		crlf:            strings.HasSuffix(strings.SplitN(text, "\n", 2)[0], "\r"),
+		trailingNewline: strings.HasSuffix(text, "\n"),
+		next:            1,
+		wr:              bufio.NewWriter(w),
+	}
+	if text = strings.TrimSuffix(text, "\n"); text != "" || g.trailingNewline {
+		g.lines = strings.Split(text, "\n")
+	}
+	for i, line := range g.lines {
+		g.lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return g
+}
+
+// line returns the line of the test file with the number given.
+func (g *generatedFile) line(n int) string {
+	return g.lines[n-1]
+}
+
+// writeLine writes a line to the generated file, preceded by the line ending of the previous line.
+func (g *generatedFile) writeLine(line string) {
+	if g.written {
+		g.writeLineEnding()
+	}
+	if _, err := g.wr.WriteString(line); err != nil {
+		panic(err)
+	}
+	g.written = true
+}
+
+func (g *generatedFile) writeLineEnding() {
+	ending := "\n"
+	if g.crlf {
+		ending = "\r\n"
+	}
+	if _, err := g.wr.WriteString(ending); err != nil {
+		panic(err)
+	}
+}
+
+// copyThrough copies the lines of the test file that haven't been copied or skipped yet, up to and including the line
+// given.
+func (g *generatedFile) copyThrough(n int) {
+	for ; g.next <= n && g.next <= len(g.lines); g.next++ {
+		g.writeLine(g.line(g.next))
+	}
+}
+
+// skipThrough skips the lines of the test file that haven't been copied or skipped yet, up to and including the line
+// given.
+func (g *generatedFile) skipThrough(n int) {
+	if n >= g.next {
+		g.next = n + 1
+	}
+}
+
+// copyRest copies the rest of the test file, ending the generated file the way the test file ends.
+func (g *generatedFile) copyRest() {
+	g.copyThrough(len(g.lines))
+	if g.written && g.trailingNewline {
+		g.writeLineEnding()
+	}
+}
+
+// flush flushes the lines written to the underlying writer.
+func (g *generatedFile) flush() error {
+	return g.wr.Flush()
+}
+
+// replaceLine replaces the line of the test file given, unless the replacement only differs from it in spacing. A
+// comment at the end of the line is kept.
+func (g *generatedFile) replaceLine(n int, replacement string) {
+	g.copyThrough(n - 1)
+	g.skipThrough(n)
+
+	original := g.line(n)
+	content, comment := original, ""
+	if i := strings.Index(original, "#"); i >= 0 {
+		content = strings.TrimRight(original[:i], " \t")
+		comment = original[len(content):]
+	}
+	if slices.Equal(strings.Fields(content), strings.Fields(replacement)) {
+		g.writeLine(original)
+	} else {
+		g.writeLine(replacement + comment)
+	}
+}
+
+// dropRecord leaves the record given out of the generated file, along with the blank line ending it, or the blank line
+// before it if it ends the file.
+func (g *generatedFile) dropRecord(record *parser.Record) {
+	start, end := record.StartLine(), record.EndLine()
+	if end < len(g.lines) && strings.TrimSpace(g.line(end+1)) == "" {
+		end++
+	} else if end == len(g.lines) && start > g.next && strings.TrimSpace(g.line(start-1)) == "" {
+		start--
+	}
+	g.copyThrough(start - 1)
+	g.skipThrough(end)
+}
+
+// rewriteRecord rewrites the header and results of the record given, keeping its other lines. When columns isn't nil,
+// the record's colnames directive is rewritten with them too, or added before the header if it has none. Results that
+// are unchanged are kept as they are, along with any comments among them.
+func (g *generatedFile) rewriteRecord(record *parser.Record, columns []string, header string, results []string) {
+	if columns != nil {
+		directive := columnNamesDirective + " " + strings.Join(columns, " ")
+		found := false
+		for n := record.StartLine(); n < record.HeaderLine(); n++ {
+			if !isColumnNamesLine(g.line(n)) {
+				continue
+			}
+			if !found {
+				g.replaceLine(n, directive)
+			} else {
+				g.copyThrough(n - 1)
+				g.skipThrough(n)
+			}
+			found = true
+		}
+		if !found {
+			g.copyThrough(record.HeaderLine() - 1)
+			g.writeLine(directive)
+		}
+	}
+	g.replaceLine(record.HeaderLine(), header)
+
+	if record.SeparatorLine() == 0 {
+		g.copyThrough(record.EndLine())
+		if len(results) > 0 {
+			g.writeLine(parser.Separator)
+			for _, line := range results {
+				g.writeLine(line)
+			}
+		}
+		return
+	}
+
+	g.copyThrough(record.SeparatorLine())
+	var original []string
+	for n := record.SeparatorLine() + 1; n <= record.EndLine(); n++ {
+		if !strings.HasPrefix(g.line(n), "#") {
+			original = append(original, g.line(n))
+		}
+	}
+	if slices.Equal(original, results) {
+		g.copyThrough(record.EndLine())
+		return
+	}
+	for _, line := range results {
+		g.writeLine(line)
+	}
+	g.skipThrough(record.EndLine())
+}
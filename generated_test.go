@@ -0,0 +1,258 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staleTest is regenerated with its sort mode written explicitly, as in regeneratedTest
+const staleTest = `query II
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+const regeneratedTest = `query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestGenerateInPlace(t *testing.T) {
+	path := writeTestFile(t, staleTest)
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithGenerateInPlace(true, true))
+	runner.GenerateTestFiles(path)
+
+	assertFileContents(t, path, regeneratedTest)
+	assertFileContents(t, path+".bak", staleTest)
+	assert.NoFileExists(t, path+".generated")
+
+	path = writeTestFile(t, staleTest)
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithGenerateInPlace(true, false))
+	runner.GenerateTestFiles(path)
+
+	assertFileContents(t, path, regeneratedTest)
+	assert.NoFileExists(t, path+".bak")
+	assert.NoFileExists(t, path+".generated")
+}
+
+func TestGenerateOutputDir(t *testing.T) {
+	input := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(input, "select", "nested"), 0755))
+	for _, name := range []string{"a.test", filepath.Join("select", "nested", "b.test")} {
+		require.NoError(t, os.WriteFile(filepath.Join(input, name), []byte(staleTest), 0644))
+	}
+	single := writeTestFile(t, staleTest)
+
+	output := t.TempDir()
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithGenerateOutputDir(output),
+		WithGenerateInPlace(true, true))
+	runner.GenerateTestFiles(input, single)
+
+	assertFileContents(t, filepath.Join(output, "a.test"), regeneratedTest)
+	assertFileContents(t, filepath.Join(output, "select", "nested", "b.test"), regeneratedTest)
+	assertFileContents(t, filepath.Join(output, "test.test"), regeneratedTest)
+
+	// The test files are left as they were
+	assertFileContents(t, filepath.Join(input, "a.test"), staleTest)
+	assertFileContents(t, single, staleTest)
+	assert.NoFileExists(t, single+".bak")
+}
+
+func TestParseGenerateOptions(t *testing.T) {
+	opts, files, err := ParseGenerateOptions([]string{"--in-place", "--backup", "--failing-only", "--column-names",
+		"a.test", "--fix-schemas"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.test", "--fix-schemas"}, files)
+
+	config := NewRunner(newFakeHarness(), opts...).Config()
+	assert.True(t, config.GenerateInPlace)
+	assert.True(t, config.GenerateBackups)
+	assert.True(t, config.RegenerateFailing)
+	assert.True(t, config.GenerateColumnNames)
+	assert.False(t, config.FixSchemas)
+
+	opts, _, err = ParseGenerateOptions([]string{"--output-dir=out", "--fix-schemas"})
+	require.NoError(t, err)
+	config = NewRunner(newFakeHarness(), opts...).Config()
+	assert.Equal(t, "out", config.GenerateOutputDir)
+	assert.True(t, config.FixSchemas)
+	assert.False(t, config.GenerateInPlace)
+
+	_, _, err = ParseGenerateOptions([]string{"--in-place=yes", "a.test"})
+	assert.Error(t, err)
+}
+
+// assertFileContents asserts that the file at the path given has the contents given.
+func assertFileContents(t *testing.T, path, contents string) {
+	actual, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(actual))
+}
+
+const failingTest = `statement ok
+INSERT INTO t1 VALUES (1)
+
+query II
+SELECT a, b FROM t1
+----
+1
+2
+
+query II rowsort
+SELECT a, b FROM t1
+----
+3
+4
+
+query I
+SELECT a FROM t1 WHERE a > 1
+----
+1
+`
+
+func TestRegenerateFailing(t *testing.T) {
+	path := writeTestFile(t, failingTest)
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithRegenerateFailing(true))
+	runner.GenerateTestFiles(path)
+
+	// The passing query keeps its implicit sort mode, while the failing ones are rewritten
+	assertFileContents(t, path+".generated", `statement ok
+INSERT INTO t1 VALUES (1)
+
+query II
+SELECT a, b FROM t1
+----
+1
+2
+
+query II rowsort
+SELECT a, b FROM t1
+----
+1
+2
+
+query I nosort
+SELECT a FROM t1 WHERE a > 1
+----
+`)
+}
+
+// triviaTest has comments and spacing around and within its records, which regeneration keeps
+const triviaTest = `# Leading comment
+
+query II nosort  # header comment
+# comment between the header and the query
+SELECT a, b FROM t1
+----
+1
+# comment among the results
+2
+# comment at the end of the record
+
+
+query II   # rewritten with its sort mode
+SELECT a, b FROM t1
+----
+1
+2
+
+query I
+SELECT a FROM t1 WHERE a > 1
+
+statement ok
+INSERT INTO missing VALUES(1, 2)
+
+statement ok
+INSERT INTO t1 VALUES (1)
+# trailing comment without a newline`
+
+func TestGenerateKeepsTrivia(t *testing.T) {
+	path := writeTestFile(t, triviaTest)
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{})).GenerateTestFiles(path)
+
+	// Only the lines of the records whose results changed differ
+	assertFileContents(t, path+".generated", strings.NewReplacer(
+		"query II   # rewritten", "query II nosort   # rewritten",
+		"query I\n", "query I nosort\n",
+	).Replace(triviaTest))
+
+	// Failed records are left out along with the blank lines ending them
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{})).GenerateTestFilesWithFailedTestsExcluded(path)
+	assertFileContents(t, path+".generated", strings.NewReplacer(
+		"query II   # rewritten", "query II nosort   # rewritten",
+		"query I\n", "query I nosort\n",
+		"statement ok\nINSERT INTO missing VALUES(1, 2)\n\n", "",
+	).Replace(triviaTest))
+}
+
+func TestGenerateKeepsLineEndings(t *testing.T) {
+	path := writeTestFile(t, "query II rowsort\r\nSELECT a, b FROM t1\r\n----\r\n3\r\n4\r\n\r\n")
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithRegenerateFailing(true)).GenerateTestFiles(path)
+	assertFileContents(t, path+".generated", "query II rowsort\r\nSELECT a, b FROM t1\r\n----\r\n1\r\n2\r\n\r\n")
+}
+
+func TestGenerateCompressedInPlace(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"stale.test.gz", "stale.test.zst"} {
+		require.NoError(t, parser.WriteFile(filepath.Join(dir, name), []byte(staleTest)))
+	}
+
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(dir)
+	assert.Len(t, reporter.entries, 2)
+
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithGenerateInPlace(true, true)).GenerateTestFiles(dir)
+	for _, suffix := range []string{".gz", ".zst"} {
+		contents, err := parser.ReadFile(filepath.Join(dir, "stale.test"+suffix))
+		require.NoError(t, err)
+		assert.Equal(t, regeneratedTest, string(contents))
+
+		// Backups keep the suffix of the compression too
+		backup, err := parser.ReadFile(filepath.Join(dir, "stale.test.bak"+suffix))
+		require.NoError(t, err)
+		assert.Equal(t, staleTest, string(backup))
+	}
+	assert.Len(t, CollectTestFiles(dir), 2)
+}
+
+func TestGenerateCommentCharacters(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT '#', 'a # b', '\\#'"] = fakeResult{schema: "TTT", results: []string{"#", "a # b", `\#`}}
+	test := "query TTT nosort\nSELECT '#', 'a # b', '\\#'\n"
+	path := writeTestFile(t, test)
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithGenerateInPlace(true, false),
+		WithRegenerateFailing(true))
+	runner.GenerateTestFiles(path)
+	assertFileContents(t, path, test+"----\n\\#\na # b\n\\\\#\n")
+
+	// The generated file passes
+	reporter := &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
@@ -14,7 +14,10 @@
 
 package logictest
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // A Harness runs the queries in sqllogictest tests on an underlying SQL engine.
 type Harness interface {
@@ -46,3 +49,71 @@ type Harness interface {
 	// GetTimeout returns timeout defined in the harness. The value is in seconds.
 	GetTimeout() int64
 }
+
+// A RowIterator iterates over the rows of a query result. See StreamingHarness.
+type RowIterator interface {
+	// NextRow returns the values of the next row of the result, rendered as described by Harness.ExecuteQuery, or
+	// io.EOF when there are no more rows.
+	NextRow() ([]string, error)
+	// Close releases any resources held by the iterator.
+	Close() error
+}
+
+// A StreamingHarness is a Harness that can return the results of a query incrementally. The runner verifies hashed
+// results of unsorted queries from a StreamingHarness without materializing them, which keeps memory use flat for
+// queries with very large results. Results that must be sorted or enumerated are still materialized, in temporary
+// files rather than memory when they exceed RunConfig.SpillThreshold.
+type StreamingHarness interface {
+	Harness
+
+	// ExecuteQueryStreaming executes the query given and returns the schema of its results, as described by
+	// ExecuteQuery, and an iterator over its rows. The runner closes the iterator when it's done with it.
+	ExecuteQueryStreaming(ctx context.Context, statement string) (schema string, rows RowIterator, err error)
+}
+
+// A RowHarness is a variant of Harness that returns query results as rows rather than as a flattened slice of values.
+// Use NewRowHarnessAdapter to run tests with a RowHarness.
+type RowHarness interface {
+	// See Harness.EngineStr
+	EngineStr() string
+	// See Harness.Init
+	Init() error
+	// See Harness.ExecuteStatement
+	ExecuteStatement(ctx context.Context, statement string) error
+	// ExecuteQueryRows executes the query given and returns the schema of its results, as described by
+	// Harness.ExecuteQuery, and its rows in the order that the underlying engine returns them. Each row must have one
+	// value per column of the schema, rendered as described by Harness.ExecuteQuery.
+	ExecuteQueryRows(ctx context.Context, statement string) (schema string, rows [][]string, err error)
+	// See Harness.GetTimeout
+	GetTimeout() int64
+}
+
+// RowHarnessAdapter adapts a RowHarness to the Harness interface, flattening the rows of query results and checking
+// that each has the number of values given by the schema.
+type RowHarnessAdapter struct {
+	RowHarness
+}
+
+var _ Harness = RowHarnessAdapter{}
+
+// NewRowHarnessAdapter returns a Harness that executes queries with the RowHarness given.
+func NewRowHarnessAdapter(harness RowHarness) RowHarnessAdapter {
+	return RowHarnessAdapter{RowHarness: harness}
+}
+
+// See Harness.ExecuteQuery
+func (a RowHarnessAdapter) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	schema, rows, err := a.ExecuteQueryRows(ctx, statement)
+	if err != nil {
+		return "", nil, err
+	}
+
+	results := make([]string, 0, len(rows)*len(schema))
+	for i, row := range rows {
+		if len(row) != len(schema) {
+			return "", nil, fmt.Errorf("row %d has %d values, but schema %s has %d columns", i, len(row), schema, len(schema))
+		}
+		results = append(results, row...)
+	}
+	return schema, results, nil
+}
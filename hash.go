@@ -0,0 +1,242 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+	"github.com/cespare/xxhash/v2"
+)
+
+// HashAlgorithm identifies the algorithm used to hash query results. The algorithm is recorded in the hash line of
+// test files as a prefix of the hash, e.g. "30 values hashing to xxh64:4c2e3f5a6b7d8e9f". Hashes without a prefix are
+// MD5 hashes, for compatibility with the original sqllogictest.
+type HashAlgorithm string
+
+const (
+	// MD5 is the algorithm used by the original sqllogictest C code, and the default.
+	MD5 HashAlgorithm = "md5"
+	// XXH64 is the 64-bit xxHash algorithm, which is considerably faster than MD5.
+	XXH64 HashAlgorithm = "xxh64"
+	// SHA256 is the SHA-256 algorithm, for environments where MD5 is disallowed.
+	SHA256 HashAlgorithm = "sha256"
+)
+
+func (a HashAlgorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case MD5, "":
+		return md5.New(), nil
+	case XXH64:
+		return xxhash.New(), nil
+	case SHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", string(a))
+	}
+}
+
+// formatHash renders the hash given as it appears in test files, with the algorithm prefix for algorithms other than
+// MD5.
+func (a HashAlgorithm) formatHash(hash string) string {
+	if a == MD5 || a == "" {
+		return hash
+	}
+	return string(a) + ":" + hash
+}
+
+// resultHasher incrementally computes the hash of result values, using the same scheme as the original sqllogictest C
+// code: each value is hashed followed by a newline.
+type resultHasher struct {
+	h     hash.Hash
+	count int
+}
+
+func newResultHasher(algorithm HashAlgorithm) (*resultHasher, error) {
+	h, err := algorithm.newHash()
+	if err != nil {
+		return nil, err
+	}
+	return &resultHasher{h: h}, nil
+}
+
+// add adds the value given to the hash. Writes to a hash.Hash never return an error.
+func (h *resultHasher) add(value string) {
+	h.h.Write([]byte(value))
+	h.h.Write([]byte{'\n'})
+	h.count++
+}
+
+// sum returns the hash of the values added so far. Values can continue to be added afterwards.
+func (h *resultHasher) sum() string {
+	return fmt.Sprintf("%x", h.h.Sum(nil))
+}
+
+// hashResults computes the hash of the results given with the algorithm given.
+func hashResults(algorithm HashAlgorithm, results []string) (string, error) {
+	h, err := newResultHasher(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range results {
+		h.add(r)
+	}
+	return h.sum(), nil
+}
+
+// hashCheckpointLines returns the checkpoint hash lines for the sorted results given, one every interval rows.
+func hashCheckpointLines(algorithm HashAlgorithm, results []string, numCols int, interval int) ([]string, error) {
+	if interval <= 0 || numCols == 0 {
+		return nil, nil
+	}
+
+	var lines []string
+	step := interval * numCols
+	for n := step; n < len(results); n += step {
+		hash, err := hashResults(algorithm, results[:n])
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fmt.Sprintf("%d values hashing to %s", n, algorithm.formatHash(hash)))
+	}
+	return lines, nil
+}
+
+// locateHashDivergence compares the checkpoint hashes of the record given against the same prefixes of the sorted
+// results given, and returns the range of rows [lo, hi) that contains the first row that differs from the expected
+// results. Returns ok = false if the record has no checkpoints.
+func locateHashDivergence(record *parser.Record, results []string) (lo, hi int, ok bool, err error) {
+	checkpoints := record.HashCheckpoints()
+	if len(checkpoints) == 0 {
+		return 0, 0, false, nil
+	}
+
+	numCols := record.NumCols()
+	prev := 0
+	for _, checkpoint := range checkpoints {
+		if checkpoint.NumValues > len(results) {
+			break
+		}
+
+		hash, err := hashResults(HashAlgorithm(checkpoint.Algorithm), results[:checkpoint.NumValues])
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if hash != checkpoint.Hash {
+			return prev / numCols, (checkpoint.NumValues + numCols - 1) / numCols, true, nil
+		}
+		prev = checkpoint.NumValues
+	}
+
+	return prev / numCols, (record.NumResults() + numCols - 1) / numCols, true, nil
+}
+
+// describeHashDivergence returns a description of where the results given first diverge from the expected results
+// of the record given, or the empty string if this can't be determined.
+func describeHashDivergence(record *parser.Record, results []string) string {
+	lo, hi, ok, err := locateHashDivergence(record, results)
+	if err != nil || !ok {
+		return ""
+	}
+	return describeRowRange(lo, hi)
+}
+
+// enumerateHashDivergence returns a description of where the results given first diverge from the expected results
+// of the hashed query record given, for records without checkpoint hashes. The records of the test file before it are
+// replayed on a freshly initialized HashBisectionReference, and the reference's results for the query are taken as the
+// expected results if they hash to the record's expected hash. Returns the empty string if this can't be determined.
+func (r *Runner) enumerateHashDivergence(ctx context.Context, record *parser.Record, results []string) string {
+	if r.config.HashBisectionReference == nil {
+		return "no checkpoint hashes or bisection reference to locate the first differing row"
+	}
+
+	testFile := ctx.Value("lock").(*loggingLock).testFile
+	records, err := r.config.parseTestFile(testFile)
+	if err != nil {
+		return ""
+	}
+
+	config := r.config
+	config.Output = io.Discard
+	config.Reporters = nil
+	config.ConfirmFailures = 0
+	config.Reference = nil
+	config.Translators = nil
+	config.BisectHashMismatches = false
+	config.HashBisectionReference = nil
+	reference := &Runner{
+		harness:      r.config.HashBisectionReference,
+		config:       config,
+		capabilities: newCapabilitySet(r.config.HashBisectionReference),
+	}
+
+	if err := reference.initHarness(); err != nil {
+		return ""
+	}
+	defer reference.cleanupObjects()
+	defer reference.forgetCatalog()
+	defer reference.closeConnections()
+
+	for _, earlier := range records {
+		if earlier.LineNum() >= record.LineNum() {
+			break
+		}
+		ctx, cancel := reference.newRecordContext(testFile, earlier, false)
+		if res := reference.executeRecord(ctx, cancel, earlier); !res.cont {
+			break
+		}
+	}
+	reference.awaitAsyncStatements()
+
+	translated, err := reference.translate(record)
+	if err != nil {
+		return ""
+	}
+	queryCtx, cancel := reference.newRecordContext(testFile, record, false)
+	defer cancel()
+	_, expected, err := reference.harness.ExecuteQuery(queryCtx, translated.Query())
+	if err != nil || len(expected) != len(results) {
+		return ""
+	}
+
+	expected = config.normalizeResults(config.compactValues(expected, record.Schema()), record.Schema())
+	expected = record.SortResults(expected)
+	hash, err := hashResults(HashAlgorithm(record.HashAlgorithm()), expected)
+	if err != nil || hash != record.HashResult() {
+		return ""
+	}
+
+	numCols := record.NumCols()
+	for i := range results {
+		if results[i] != expected[i] {
+			return describeRowRange(i/numCols, i/numCols+1)
+		}
+	}
+	return ""
+}
+
+// describeRowRange describes the range of rows [lo, hi) that contains the first differing row.
+func describeRowRange(lo, hi int) string {
+	if hi-lo <= 1 {
+		return fmt.Sprintf("first differing row is %d", lo)
+	}
+	return fmt.Sprintf("first differing row is between rows %d and %d", lo, hi-1)
+}
@@ -0,0 +1,150 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashResults(t *testing.T) {
+	results := []string{"1", "2"}
+
+	hash, err := hashResults(MD5, results)
+	require.NoError(t, err)
+	assert.Equal(t, "6ddb4095eb719e2a9f0a3f95677d24e0", hash)
+	assert.Equal(t, hash, MD5.formatHash(hash))
+
+	hash, err = hashResults(SHA256, results)
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, "sha256:"+hash, SHA256.formatHash(hash))
+
+	hash, err = hashResults(XXH64, results)
+	require.NoError(t, err)
+	assert.Len(t, hash, 16)
+
+	_, err = hashResults("crc32", results)
+	assert.Error(t, err)
+}
+
+func TestVerifyHashAlgorithms(t *testing.T) {
+	for _, algorithm := range []HashAlgorithm{MD5, XXH64, SHA256} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			hash, err := hashResults(algorithm, []string{"1", "2"})
+			require.NoError(t, err)
+
+			contents := fmt.Sprintf("hash-threshold 1\nquery II nosort\nSELECT a, b FROM t1\n----\n2 values hashing to %s\n", algorithm.formatHash(hash))
+			lines := runAndCaptureOutput(t, newFakeHarness(), writeTestFile(t, contents))
+			require.Len(t, lines, 1)
+			assert.Contains(t, lines[0], "SELECT a, b FROM t1 ok")
+		})
+	}
+}
+
+func TestLocateHashDivergence(t *testing.T) {
+	expected := []string{"1", "a", "2", "b", "3", "c", "4", "d", "5", "e"}
+	hash, err := hashResults(MD5, expected)
+	require.NoError(t, err)
+	checkpoints, err := hashCheckpointLines(MD5, expected, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 4)
+	assert.Equal(t, "2 values hashing to", checkpoints[0][:19])
+
+	contents := fmt.Sprintf("query IT nosort\nSELECT * FROM t2\n----\n10 values hashing to %s\n%s\n", hash, strings.Join(checkpoints, "\n"))
+	records, err := parser.ParseTestFile(writeTestFile(t, contents))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.True(t, records[0].IsHashResult())
+	require.Len(t, records[0].HashCheckpoints(), 4)
+
+	actual := []string{"1", "a", "2", "b", "3", "x", "4", "d", "5", "e"}
+	assert.Equal(t, "first differing row is 2", describeHashDivergence(records[0], actual))
+
+	actual = []string{"1", "a", "2", "b", "3", "c", "4", "d", "5", "x"}
+	assert.Equal(t, "first differing row is 4", describeHashDivergence(records[0], actual))
+
+	checkpoints, err = hashCheckpointLines(MD5, expected, 2, 2)
+	require.NoError(t, err)
+	contents = fmt.Sprintf("query IT nosort\nSELECT * FROM t2\n----\n10 values hashing to %s\n%s\n", hash, strings.Join(checkpoints, "\n"))
+	records, err = parser.ParseTestFile(writeTestFile(t, contents))
+	require.NoError(t, err)
+
+	actual = []string{"1", "a", "2", "b", "3", "x", "4", "d", "5", "e"}
+	assert.Equal(t, "first differing row is between rows 2 and 3", describeHashDivergence(records[0], actual))
+}
+
+// insertingHarness returns the rows of t2 only once the statement that inserts them has been executed since it was
+// initialized.
+type insertingHarness struct {
+	fakeHarness
+	rows     []string
+	inserted bool
+}
+
+func (h *insertingHarness) Init() error {
+	h.inserted = false
+	return nil
+}
+
+func (h *insertingHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	h.inserted = h.inserted || statement == "INSERT INTO t2 VALUES (1, 'a'), (2, 'b'), (3, 'c')"
+	return nil
+}
+
+func (h *insertingHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	if !h.inserted {
+		return "IT", nil, nil
+	}
+	return "IT", h.rows, nil
+}
+
+func TestEnumerateHashDivergence(t *testing.T) {
+	expected := []string{"1", "a", "2", "b", "3", "c"}
+	hash, err := hashResults(MD5, expected)
+	require.NoError(t, err)
+	contents := fmt.Sprintf("statement ok\nINSERT INTO t2 VALUES (1, 'a'), (2, 'b'), (3, 'c')\n\n"+
+		"query IT rowsort\nSELECT * FROM t2\n----\n6 values hashing to %s\n", hash)
+	file := writeTestFile(t, contents)
+
+	harness := &insertingHarness{rows: []string{"3", "c", "1", "a", "2", "x"}}
+	run := func(opts ...RunOption) []string {
+		buf := &bytes.Buffer{}
+		opts = append([]RunOption{WithOutput(buf), WithContinueOnFailure(true), WithHashBisection(true)}, opts...)
+		NewRunner(harness, opts...).RunTestFiles(file)
+		return strings.Split(strings.TrimSpace(buf.String()), "\n")
+	}
+
+	lines := run()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "no checkpoint hashes or bisection reference to locate the first differing row")
+
+	lines = run(WithHashBisectionReference(&insertingHarness{rows: []string{"2", "b", "3", "c", "1", "a"}}))
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "first differing row is 1")
+
+	// A reference that doesn't produce the expected hash doesn't locate the divergence
+	lines = run(WithHashBisectionReference(&insertingHarness{rows: []string{"1", "a", "2", "y", "3", "c"}}))
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "Hash of results differ")
+	assert.NotContains(t, lines[1], "first differing row")
+}
@@ -0,0 +1,192 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// htmlSlowQueries is the number of slowest records listed in an HTML report.
+const htmlSlowQueries = 25
+
+// HTMLReporter renders the results of a run as a static HTML page, with the pass rate of each test file, the
+// failures with their messages and plans, which expand on click, and the slowest records. Since the page summarizes
+// the whole run, it's only written when the reporter is closed.
+type HTMLReporter struct {
+	w       io.Writer
+	mu      sync.Mutex
+	entries []*ResultLogEntry
+}
+
+var _ Reporter = &HTMLReporter{}
+
+// NewHTMLReporter returns a reporter that writes an HTML report of the results to the writer given when closed.
+func NewHTMLReporter(w io.Writer) *HTMLReporter {
+	return &HTMLReporter{w: w}
+}
+
+// See Reporter.Report
+func (r *HTMLReporter) Report(entry *ResultLogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Close writes the report of the results received so far.
+func (r *HTMLReporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return htmlReportTemplate.Execute(r.w, newHTMLReport(r.entries))
+}
+
+// htmlReport is the data the HTML report template renders.
+type htmlReport struct {
+	Generated time.Time
+	Total     htmlFileStats
+	Files     []*htmlFileStats
+	Failures  []*ResultLogEntry
+	Slowest   []*ResultLogEntry
+}
+
+// htmlFileStats are the outcomes of the records of one test file, or of all of them.
+type htmlFileStats struct {
+	File     string
+	Records  int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Duration time.Duration
+}
+
+func (s *htmlFileStats) add(entry *ResultLogEntry) {
+	s.Records++
+	s.Duration += entry.Duration
+	switch outcomeOf(entry) {
+	case outcomePassed:
+		s.Passed++
+	case outcomeFailed:
+		s.Failed++
+	default:
+		s.Skipped++
+	}
+}
+
+// PassRate returns the percentage of the records that ran that passed.
+func (s *htmlFileStats) PassRate() float64 {
+	if s.Passed+s.Failed == 0 {
+		return 0
+	}
+	return 100 * float64(s.Passed) / float64(s.Passed+s.Failed)
+}
+
+func newHTMLReport(entries []*ResultLogEntry) *htmlReport {
+	report := &htmlReport{Generated: time.Now()}
+	files := make(map[string]*htmlFileStats)
+	for _, entry := range entries {
+		stats, ok := files[entry.TestFile]
+		if !ok {
+			stats = &htmlFileStats{File: entry.TestFile}
+			files[entry.TestFile] = stats
+			report.Files = append(report.Files, stats)
+		}
+		stats.add(entry)
+		report.Total.add(entry)
+
+		if outcomeOf(entry) == outcomeFailed {
+			report.Failures = append(report.Failures, entry)
+		}
+		if entry.Result != Skipped && entry.Result != DidNotRun {
+			report.Slowest = append(report.Slowest, entry)
+		}
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].File < report.Files[j].File
+	})
+	sort.SliceStable(report.Failures, func(i, j int) bool {
+		a, b := report.Failures[i], report.Failures[j]
+		if a.TestFile != b.TestFile {
+			return a.TestFile < b.TestFile
+		}
+		return a.LineNum < b.LineNum
+	})
+	sort.SliceStable(report.Slowest, func(i, j int) bool {
+		return report.Slowest[i].Duration > report.Slowest[j].Duration
+	})
+	if len(report.Slowest) > htmlSlowQueries {
+		report.Slowest = report.Slowest[:htmlSlowQueries]
+	}
+	return report
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>sqllogictest report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.75em; text-align: left; }
+td.num { text-align: right; }
+tr.failing td { background: #fdd; }
+details { margin-bottom: 0.5em; }
+summary { cursor: pointer; font-family: monospace; }
+pre { background: #f6f6f6; padding: 0.5em; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>sqllogictest report</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}: {{.Total.Records}} records, {{.Total.Passed}} passed,
+{{.Total.Failed}} failed, {{.Total.Skipped}} skipped, {{printf "%.2f" .Total.PassRate}}% pass rate, in {{.Total.Duration}}.</p>
+
+<h2>Test files</h2>
+<table>
+<tr><th>File</th><th>Records</th><th>Passed</th><th>Failed</th><th>Skipped</th><th>Pass rate</th><th>Duration</th></tr>
+{{- range .Files}}
+<tr{{if .Failed}} class="failing"{{end}}><td>{{.File}}</td><td class="num">{{.Records}}</td><td class="num">{{.Passed}}</td><td class="num">{{.Failed}}</td><td class="num">{{.Skipped}}</td><td class="num">{{printf "%.2f" .PassRate}}%</td><td class="num">{{.Duration}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Failures</h2>
+{{- range .Failures}}
+<details>
+<summary>{{.TestFile}}:{{.LineNum}}: {{.Result}}{{if .Confirmation}} ({{.Confirmation}}){{end}}</summary>
+<pre>{{if .RawQuery}}{{.RawQuery}}{{else}}{{.Query}}{{end}}</pre>
+{{- if .ErrorMessage}}
+<pre>{{.ErrorMessage}}</pre>
+{{- end}}
+{{- if .Plan}}
+<pre>{{.Plan}}</pre>
+{{- end}}
+</details>
+{{- else}}
+<p>None.</p>
+{{- end}}
+
+<h2>Slowest records</h2>
+<table>
+<tr><th>Record</th><th>Query</th><th>Result</th><th>Duration</th></tr>
+{{- range .Slowest}}
+<tr><td>{{.TestFile}}:{{.LineNum}}</td><td><code>{{.Query}}</code></td><td>{{.Result}}</td><td class="num">{{.Duration}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
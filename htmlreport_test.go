@@ -0,0 +1,54 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReporter(t *testing.T) {
+	var out bytes.Buffer
+	reporter := NewHTMLReporter(&out)
+
+	failure := resultEntry("b.test", 5, NotOk, 2*time.Millisecond)
+	failure.Query = "SELECT a < b FROM t"
+	failure.ErrorMessage = "Incorrect result at position 0. Expected 1, got 0"
+	for _, entry := range []*ResultLogEntry{
+		resultEntry("b.test", 1, Ok, time.Millisecond),
+		failure,
+		resultEntry("a.test", 1, Ok, 300*time.Millisecond),
+		resultEntry("a.test", 5, Skipped, 0),
+	} {
+		reporter.Report(entry)
+	}
+	assert.Empty(t, out.String(), "the report is written on close")
+	require.NoError(t, reporter.Close())
+
+	report := out.String()
+	assert.Contains(t, report, "4 records, 2 passed,\n1 failed, 1 skipped, 66.67% pass rate")
+	assert.Contains(t, report, `<tr><td>a.test</td><td class="num">2</td><td class="num">1</td><td class="num">0</td><td class="num">1</td><td class="num">100.00%</td>`)
+	assert.Contains(t, report, `<tr class="failing"><td>b.test</td>`)
+	assert.Contains(t, report, "<summary>b.test:5: not ok</summary>")
+	assert.Contains(t, report, "<pre>SELECT a &lt; b FROM t</pre>", "queries are escaped")
+	assert.Contains(t, report, "<pre>Incorrect result at position 0. Expected 1, got 0</pre>")
+	assert.Less(t, bytes.Index(out.Bytes(), []byte("a.test:1</td>")), bytes.Index(out.Bytes(), []byte("b.test:5</td>")),
+		"the slowest records come first")
+	assert.NotContains(t, report, "a.test:5</td>", "skipped records aren't listed as slow")
+}
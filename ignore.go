@@ -0,0 +1,138 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"path"
+	"strings"
+)
+
+// IgnoreFileName is the name of the files that list the paths to leave out when collecting test files from the
+// directory they're in, with gitignore-style patterns.
+const IgnoreFileName = ".sltignore"
+
+// An ignoreRule is a pattern of an ignore file.
+type ignoreRule struct {
+	// pattern is the slash-separated glob pattern of the rule, without its leading ! or trailing /
+	pattern string
+	// negate is whether the rule re-includes the paths it matches, from a leading !
+	negate bool
+	// dirOnly is whether the rule only matches directories, from a trailing /
+	dirOnly bool
+	// anchored is whether the rule matches paths relative to the ignore file's directory, rather than the names of
+	// files and directories at any depth under it, as is the case for patterns containing a slash
+	anchored bool
+}
+
+// parseIgnoreFile returns the rules of the ignore file with the contents given. As in gitignore files, blank lines and
+// lines starting with # are skipped, a leading ! negates a pattern, a trailing / only matches directories, patterns
+// with any other slash are relative to the file's directory, and ** matches any number of directories.
+func parseIgnoreFile(contents string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			// Escapes a leading # or !
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimLeft(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matches returns whether this rule matches the path given, slash-separated and relative to the ignore file's
+// directory.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		return matchIgnorePattern(r.pattern, path.Base(rel))
+	}
+	return matchIgnorePattern(r.pattern, rel)
+}
+
+// matchIgnorePattern returns whether the slash-separated glob pattern given matches the slash-separated path given,
+// with each element of the pattern matching an element of the path, except for ** elements, which match any number of
+// them. Malformed patterns match nothing.
+func matchIgnorePattern(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchElems matches the elements of a pattern against the elements of a path, as described by matchIgnorePattern.
+func matchElems(pattern, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchElems(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], elems[0]); !matched {
+		return false
+	}
+	return matchElems(pattern[1:], elems[1:])
+}
+
+// An ignoreLevel is an ignore file in effect while walking a directory under the directory the file is in.
+type ignoreLevel struct {
+	rules []ignoreRule
+	// rel is the slash-separated path of the directory walked relative to the ignore file's directory, empty for that
+	// directory itself
+	rel string
+}
+
+// isIgnored returns whether the file or directory with the name given, in the directory walked under the ignore files
+// given, is ignored. Ignore files are given outermost first, and as in gitignore files, the last rule that matches a
+// path decides whether it's ignored, with the rules of ignore files in deeper directories coming later.
+func isIgnored(levels []ignoreLevel, name string, isDir bool) bool {
+	ignored := false
+	for _, level := range levels {
+		rel := path.Join(level.rel, name)
+		for _, rule := range level.rules {
+			if rule.matches(rel, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
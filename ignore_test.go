@@ -0,0 +1,69 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIgnoreFile(t *testing.T) {
+	rules := parseIgnoreFile("# slow tests\n\nslow_*.test\n!slow_keep.test\nevidence/\n/select/*.test  \n\\#odd.test\n")
+	assert.Equal(t, []ignoreRule{
+		{pattern: "slow_*.test"},
+		{pattern: "slow_keep.test", negate: true},
+		{pattern: "evidence", dirOnly: true},
+		{pattern: "select/*.test", anchored: true},
+		{pattern: "#odd.test"},
+	}, rules)
+}
+
+func TestIsIgnored(t *testing.T) {
+	levels := []ignoreLevel{
+		{rules: parseIgnoreFile("slow_*.test\n!slow_keep.test\nevidence/\n/index/*.test\nrandom/**/big.test\n")},
+	}
+	for _, tt := range []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"slow_1.test", false, true},
+		{"select/slow_1.test", false, true},
+		{"slow_keep.test", false, false},
+		{"evidence", true, true},
+		{"evidence", false, false},
+		{"select/evidence", true, true},
+		{"index/a.test", false, true},
+		{"select/index/a.test", false, false},
+		{"random/big.test", false, true},
+		{"random/a/b/big.test", false, true},
+		{"select/a.test", false, false},
+	} {
+		dir, name := "", tt.rel
+		if i := strings.LastIndex(tt.rel, "/"); i >= 0 {
+			dir, name = tt.rel[:i], tt.rel[i+1:]
+		}
+		levels[0].rel = dir
+		assert.Equal(t, tt.ignored, isIgnored(levels, name, tt.isDir), tt.rel)
+	}
+
+	// The rules of deeper ignore files come later
+	levels = append(levels, ignoreLevel{rules: parseIgnoreFile("!slow_2.test\n")})
+	levels[0].rel = "select"
+	assert.False(t, isIgnored(levels, "slow_2.test", false))
+	assert.True(t, isIgnored(levels, "slow_3.test", false))
+}
@@ -0,0 +1,90 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"crypto/md5"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// A LargeValuePolicy determines how text and binary result values longer than RunConfig.LargeValueThreshold are
+// written to generated test files and compared to expected results, so that a single huge value doesn't bloat test
+// files.
+type LargeValuePolicy string
+
+const (
+	// TruncateLargeValues replaces large values with their first LargeValueThreshold bytes followed by a marker with
+	// their length, e.g. "abc... (1048576 bytes)".
+	TruncateLargeValues LargeValuePolicy = "truncate"
+	// HashLargeValues replaces large values with a marker with their length and MD5 hash, e.g. "(1048576 bytes hashing
+	// to 8b1a9953c4611296a827abf8c47804d7)", so that every byte of them is still verified.
+	HashLargeValues LargeValuePolicy = "hash"
+)
+
+// ParseLargeValuePolicy parses the name of a large value policy, truncate or hash.
+func ParseLargeValuePolicy(s string) (LargeValuePolicy, error) {
+	switch policy := LargeValuePolicy(s); policy {
+	case TruncateLargeValues, HashLargeValues:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid large value policy %q, expected %s or %s", s, TruncateLargeValues, HashLargeValues)
+	}
+}
+
+var (
+	hashedValueRegex    = regexp.MustCompile(`^\(\d+ bytes hashing to [0-9a-f]{32}\)$`)
+	truncatedValueRegex = regexp.MustCompile(`\.\.\. \(\d+ bytes\)$`)
+)
+
+// isCompacted returns whether the value given has been compacted already, so that compacting values is idempotent.
+func isCompacted(value string) bool {
+	suffix := value[max(0, len(value)-32):]
+	return hashedValueRegex.MatchString(value) || truncatedValueRegex.MatchString(suffix)
+}
+
+// compactValue applies the runner's large value policy to the actual result value given, in a column of the type
+// given. Only T- and B-typed values longer than the threshold are changed.
+func (c *RunConfig) compactValue(value string, typ byte) string {
+	if c.LargeValueThreshold <= 0 || len(value) <= c.LargeValueThreshold || (typ != 'T' && typ != 'B') ||
+		isCompacted(value) {
+		return value
+	}
+
+	if c.LargeValuePolicy == HashLargeValues {
+		return fmt.Sprintf("(%d bytes hashing to %x)", len(value), md5.Sum([]byte(value)))
+	}
+
+	// Truncated values end on a character boundary, so that the prefix kept is valid UTF-8 if the value is
+	end := c.LargeValueThreshold
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", value[:end], len(value))
+}
+
+// compactValues applies the runner's large value policy to the actual result values given, which have the schema
+// given, returning the values unchanged if there's no threshold.
+func (c *RunConfig) compactValues(values []string, schema string) []string {
+	if c.LargeValueThreshold <= 0 || len(schema) == 0 {
+		return values
+	}
+	compacted := make([]string, len(values))
+	for i, value := range values {
+		compacted[i] = c.compactValue(value, schema[i%len(schema)])
+	}
+	return compacted
+}
@@ -0,0 +1,80 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactValue(t *testing.T) {
+	config := &RunConfig{LargeValueThreshold: 4}
+	assert.Equal(t, "abcd", config.compactValue("abcd", 'T'))
+	assert.Equal(t, "abcd... (6 bytes)", config.compactValue("abcdef", 'T'))
+	assert.Equal(t, "abcd... (6 bytes)", config.compactValue("abcd... (6 bytes)", 'T'))
+	assert.Equal(t, "123456", config.compactValue("123456", 'I'))
+
+	// Truncated values end on a character boundary
+	assert.Equal(t, "ab... (6 bytes)", config.compactValue("ab€c", 'T'))
+
+	config.LargeValuePolicy = HashLargeValues
+	hashed := config.compactValue("abcdef", 'B')
+	assert.Equal(t, "(6 bytes hashing to e80b5017098950fc58aad83c8c14978e)", hashed)
+	assert.Equal(t, hashed, config.compactValue(hashed, 'B'))
+
+	_, err := ParseLargeValuePolicy("drop")
+	assert.Error(t, err)
+}
+
+func TestLargeValues(t *testing.T) {
+	large := strings.Repeat("x", 100)
+	harness := newFakeHarness()
+	harness.queryResults["SELECT a, b FROM t2"] = fakeResult{schema: "IT", results: []string{"1", large, "2", "y"}}
+
+	for _, policy := range []LargeValuePolicy{TruncateLargeValues, HashLargeValues} {
+		t.Run(string(policy), func(t *testing.T) {
+			// Generated files record large values compactly
+			path := writeTestFile(t, "query IT nosort\nSELECT a, b FROM t2\n----\n1\nx\n2\ny\n")
+			NewRunner(harness, WithOutput(&bytes.Buffer{}), WithLargeValues(10, policy), WithGenerateInPlace(true, false),
+				WithRegenerateFailing(true)).GenerateTestFiles(path)
+			compacted := (&RunConfig{LargeValueThreshold: 10, LargeValuePolicy: policy}).compactValue(large, 'T')
+			assertFileContents(t, path, "query IT nosort\nSELECT a, b FROM t2\n----\n1\n"+compacted+"\n2\ny\n")
+
+			// And verify against the same values
+			reporter := &collectingReporter{}
+			NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithLargeValues(10, policy)).
+				RunTestFiles(path)
+			require.Len(t, reporter.entries, 1)
+			assert.Equal(t, Ok, reporter.entries[0].Result)
+
+			// Including when they're hashed
+			NewRunner(harness, WithOutput(&bytes.Buffer{}), WithLargeValues(10, policy), WithHashThreshold(1)).
+				GenerateTestFiles(path)
+			hashed, err := os.ReadFile(path + ".generated")
+			require.NoError(t, err)
+			require.Contains(t, string(hashed), "4 values hashing to")
+			reporter = &collectingReporter{}
+			NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithLargeValues(10, policy)).
+				RunTestFiles(path + ".generated")
+			require.Len(t, reporter.entries, 1)
+			assert.Equal(t, Ok, reporter.entries[0].Result)
+		})
+	}
+}
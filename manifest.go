@@ -0,0 +1,135 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A Manifest lists the test files of a curated suite in the order to run them, each with optional overrides of the
+// run configuration, for suites where the selection and order of test files matter. Manifest files have one entry
+// per line: a test file path, followed by any options separated by spaces, for example:
+//
+//	# setup first, then the slow tests with a longer timeout
+//	setup/schema.test
+//	select/select1.test timeout=5m
+//	mysql/ engine=mysql
+//
+// The options are timeout=DURATION, which overrides RunConfig.Timeout, and engine=NAME, which overrides
+// RunConfig.Engine, for the entry's test files. Paths can also be directories or glob patterns, as for RunTestFiles,
+// and are relative to the directory of the manifest unless absolute. Blank lines and lines starting with # are
+// ignored.
+type Manifest struct {
+	Entries []ManifestEntry
+}
+
+// A ManifestEntry is an entry of a Manifest.
+type ManifestEntry struct {
+	// Path is the test file, or the directory or glob pattern of test files, of the entry
+	Path string
+	// Timeout overrides RunConfig.Timeout for the entry's test files if it's not zero
+	Timeout time.Duration
+	// Engine overrides RunConfig.Engine for the entry's test files if it's not empty
+	Engine string
+}
+
+// ReadManifest reads the manifest file at the path given.
+func ReadManifest(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest := &Manifest{}
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		entry := ManifestEntry{Path: resolvePath(filepath.Dir(path), fields[0])}
+		for _, option := range fields[1:] {
+			name, value, _ := strings.Cut(option, "=")
+			switch name {
+			case "timeout":
+				timeout, err := time.ParseDuration(value)
+				if err != nil || timeout <= 0 {
+					return nil, fmt.Errorf("%s:%d: invalid timeout %q", path, lineNum, value)
+				}
+				entry.Timeout = timeout
+			case "engine":
+				if value == "" {
+					return nil, fmt.Errorf("%s:%d: expected an engine name", path, lineNum)
+				}
+				entry.Engine = value
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown option %q, expected timeout or engine", path, lineNum, option)
+			}
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Paths returns the paths of the manifest's entries, in order.
+func (m *Manifest) Paths() []string {
+	paths := make([]string, len(m.Entries))
+	for i, entry := range m.Entries {
+		paths[i] = entry.Path
+	}
+	return paths
+}
+
+// RunManifest runs the test files of the manifest given in the order of its entries, with the overrides of each entry
+// applied to the runner's configuration for its test files. Otherwise test files are run as by RunTestFiles, which
+// includes leaving out the excluded ones and those of other shards, and the run stops early after a "halt run"
+// record.
+func (r *Runner) RunManifest(manifest *Manifest) {
+	budget := r.config.newRunBudget()
+	for _, entry := range manifest.Entries {
+		runner := r
+		if entry.Timeout != 0 || entry.Engine != "" {
+			config := r.config
+			if entry.Timeout != 0 {
+				config.Timeout = entry.Timeout
+			}
+			if entry.Engine != "" {
+				config.Engine = entry.Engine
+			}
+			runner = &Runner{harness: r.harness, config: config, capabilities: r.capabilities}
+		}
+
+		for _, file := range r.config.testFiles([]string{entry.Path}) {
+			if !budget.start(file) {
+				continue
+			}
+			if !runner.runTestFile(file) {
+				return
+			}
+		}
+	}
+	budget.report(r.config.Output)
+}
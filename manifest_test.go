@@ -0,0 +1,91 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "suite.manifest")
+	require.NoError(t, os.WriteFile(path, []byte(`# curated suite
+setup.test
+
+select/select1.test timeout=5m
+/abs/mysql engine=mysql timeout=10s
+`), 0644))
+
+	manifest, err := ReadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestEntry{
+		{Path: filepath.Join(dir, "setup.test")},
+		{Path: filepath.Join(dir, "select", "select1.test"), Timeout: 5 * time.Minute},
+		{Path: "/abs/mysql", Timeout: 10 * time.Second, Engine: "mysql"},
+	}, manifest.Entries)
+	assert.Equal(t, []string{filepath.Join(dir, "setup.test"), filepath.Join(dir, "select", "select1.test"),
+		"/abs/mysql"}, manifest.Paths())
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, contents := range []string{
+		"a.test timeout=soon\n",
+		"a.test timeout=-1s\n",
+		"a.test engine=\n",
+		"a.test retries=3\n",
+	} {
+		path := filepath.Join(dir, "suite.manifest")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		_, err := ReadManifest(path)
+		assert.Error(t, err, contents)
+	}
+
+	_, err := ReadManifest(filepath.Join(dir, "missing.manifest"))
+	assert.Error(t, err)
+}
+
+func TestRunManifest(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"a.test": "statement ok\nINSERT INTO t1 VALUES(1, 2)\n",
+		"b.test": "onlyif mysql\nstatement ok\nINSERT INTO t1 VALUES(1, 2)\n",
+		"c.test": "halt run\n\nstatement ok\nINSERT INTO t1 VALUES(1, 2)\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+	path := filepath.Join(dir, "suite.manifest")
+	require.NoError(t, os.WriteFile(path, []byte("b.test engine=mysql\na.test\nb.test\nc.test\na.test\n"), 0644))
+	manifest, err := ReadManifest(path)
+	require.NoError(t, err)
+
+	// The test files run in the manifest's order, with the engine overridden for the first, until c.test halts the run
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunManifest(manifest)
+	require.Len(t, reporter.entries, 3)
+	assert.Equal(t, "b.test", filepath.Base(reporter.entries[0].TestFile))
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, "a.test", filepath.Base(reporter.entries[1].TestFile))
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+	assert.Equal(t, "b.test", filepath.Base(reporter.entries[2].TestFile))
+	assert.Equal(t, Skipped, reporter.entries[2].Result)
+}
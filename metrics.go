@@ -0,0 +1,86 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// The names of the execution metrics that MetricsHarness implementations report, where their engine measures them.
+// Harnesses can report other metrics under names of their own.
+const (
+	// MetricRowsExamined is the number of rows the engine read to execute a statement or query
+	MetricRowsExamined = "rows_examined"
+	// MetricMemoryUsed is the peak number of bytes of memory the engine used to execute a statement or query
+	MetricMemoryUsed = "memory_used"
+	// MetricSpills is the number of times the engine spilled intermediate results to disk
+	MetricSpills = "spills"
+)
+
+// A MetricsHarness is a Harness that can report metrics of its engine's execution of statements and queries.
+// Harnesses that implement this interface can run records that assert bounds on these metrics, so that their
+// performance characteristics are regression-tested along with their results, written e.g.:
+//
+//	expect-metric rows_examined <= 1
+//	expect-metric spills = 0
+//	query I nosort
+//	SELECT id FROM customer WHERE id = 1
+//
+// For harnesses that don't implement this interface, expect-metric directives are ignored.
+type MetricsHarness interface {
+	Harness
+
+	// Metrics returns the metrics of the execution of the statement or query given, keyed by name, e.g.
+	// MetricRowsExamined. It's called right after the statement or query executes successfully.
+	Metrics(ctx context.Context, query string) (map[string]float64, error)
+}
+
+// verifyMetrics verifies that the metrics of the record given, which just executed successfully, satisfy the bounds
+// it asserts, if it asserts any and the harness it executed on can report metrics, logging the first failure. Metrics
+// the harness doesn't report fail their assertions.
+func (r *Runner) verifyMetrics(ctx context.Context, harness Harness, record *parser.Record) error {
+	if len(record.MetricAssertions()) == 0 {
+		return nil
+	}
+
+	metricsHarness, ok := harness.(MetricsHarness)
+	if !ok {
+		return nil
+	}
+
+	metrics, err := metricsHarness.Metrics(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unable to determine metrics: %v", err)
+		return err
+	}
+
+	for _, assertion := range record.MetricAssertions() {
+		value, ok := metrics[assertion.Metric]
+		if !ok {
+			logFailure(ctx, MetricMismatch, "Expected metric %s but it wasn't reported", assertion)
+			return fmt.Errorf("expected metric %s but it wasn't reported", assertion)
+		}
+		if !assertion.Holds(value) {
+			actual := strconv.FormatFloat(value, 'g', -1, 64)
+			logFailure(ctx, MetricMismatch, "Expected metric %s but got %s", assertion, actual)
+			return fmt.Errorf("expected metric %s but got %s", assertion, actual)
+		}
+	}
+	return nil
+}
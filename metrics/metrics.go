@@ -0,0 +1,75 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exports the results of runs as Prometheus metrics, so that long-running corpus jobs can be watched
+// in dashboards as they run.
+package metrics
+
+import (
+	"github.com/andyyu2004/sqllogictest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// durationBuckets are the buckets of the record duration histogram, from a millisecond to about 4 minutes.
+var durationBuckets = prometheus.ExponentialBuckets(0.001, 4, 10)
+
+// Reporter is a logictest.Reporter that counts the records run by their result, the failures by their failure code,
+// and observes the duration of records:
+//
+//	sqllogictest_records_total{result}: the records run, e.g. result="ok" or result="not ok"
+//	sqllogictest_failures_total{code}: the failed records, e.g. code="ValueMismatch"
+//	sqllogictest_record_duration_seconds{result}: a histogram of the time taken by records
+type Reporter struct {
+	records   *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+}
+
+var _ logictest.Reporter = &Reporter{}
+
+// NewReporter returns a reporter with its metrics registered with the registerer given.
+func NewReporter(registerer prometheus.Registerer) (*Reporter, error) {
+	r := &Reporter{
+		records: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sqllogictest_records_total",
+			Help: "The number of test records run, by result.",
+		}, []string{"result"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sqllogictest_failures_total",
+			Help: "The number of test records that failed, by failure code.",
+		}, []string{"code"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "sqllogictest_record_duration_seconds",
+			Help:    "The time taken to run test records, by result.",
+			Buckets: durationBuckets,
+		}, []string{"result"}),
+	}
+
+	for _, collector := range []prometheus.Collector{r.records, r.failures, r.durations} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// See Reporter.Report
+func (r *Reporter) Report(entry *logictest.ResultLogEntry) {
+	result := entry.Result.String()
+	r.records.WithLabelValues(result).Inc()
+	r.durations.WithLabelValues(result).Observe(entry.Duration.Seconds())
+	if entry.Result == logictest.NotOk {
+		r.failures.WithLabelValues(string(entry.FailureCode)).Inc()
+	}
+}
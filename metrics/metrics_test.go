@@ -0,0 +1,58 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andyyu2004/sqllogictest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	reporter, err := NewReporter(registry)
+	require.NoError(t, err)
+
+	for _, entry := range []*logictest.ResultLogEntry{
+		{Result: logictest.Ok, Duration: 2 * time.Millisecond},
+		{Result: logictest.Ok, Duration: 20 * time.Millisecond},
+		{Result: logictest.NotOk, FailureCode: logictest.ValueMismatch, Duration: time.Millisecond},
+		{Result: logictest.Skipped},
+	} {
+		reporter.Report(entry)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(reporter.records.WithLabelValues("ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(reporter.records.WithLabelValues("not ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(reporter.records.WithLabelValues("skipped")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(reporter.failures.WithLabelValues("ValueMismatch")))
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP sqllogictest_failures_total The number of test records that failed, by failure code.
+# TYPE sqllogictest_failures_total counter
+sqllogictest_failures_total{code="ValueMismatch"} 1
+`), "sqllogictest_failures_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, testutil.CollectAndCount(reporter.durations))
+
+	_, err = NewReporter(registry)
+	assert.Error(t, err, "metrics can only be registered once")
+}
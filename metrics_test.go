@@ -0,0 +1,95 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricsHarness reports that queries with a WHERE clause examined one row, and that every other statement and query
+// examined ten rows. It reports no spills.
+type metricsHarness struct {
+	*fakeHarness
+}
+
+var _ MetricsHarness = metricsHarness{}
+
+func (h metricsHarness) Metrics(ctx context.Context, query string) (map[string]float64, error) {
+	if strings.Contains(query, "WHERE") {
+		return map[string]float64{MetricRowsExamined: 1, MetricMemoryUsed: 1024}, nil
+	}
+	return map[string]float64{MetricRowsExamined: 10, MetricMemoryUsed: 65536}, nil
+}
+
+const metricsTest = `expect-metric rows_examined <= 10
+statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+expect-metric rows_examined = 1
+expect-metric memory_used < 4096
+query II nosort
+SELECT a, b FROM t1 WHERE a = 1
+----
+1
+2
+
+expect-metric rows_examined <= 1
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestMetricAssertions(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT a, b FROM t1 WHERE a = 1"] = harness.queryResults["SELECT a, b FROM t1"]
+	reporter := &collectingReporter{}
+	runner := NewRunner(metricsHarness{harness}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, metricsTest))
+	})
+	require.Len(t, reporter.entries, 3)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+	assert.Equal(t, NotOk, reporter.entries[2].Result)
+	assert.Equal(t, MetricMismatch, reporter.entries[2].FailureCode)
+	assert.Equal(t, "Expected metric rows_examined <= 1 but got 10", reporter.entries[2].ErrorMessage)
+
+	// Metrics the harness doesn't report fail their assertions
+	reporter = &collectingReporter{}
+	runner = NewRunner(metricsHarness{harness}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "expect-metric spills = 0\nquery II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, MetricMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Expected metric spills = 0 but it wasn't reported", reporter.entries[0].ErrorMessage)
+
+	// Harnesses that don't report metrics ignore metric assertions
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, metricsTest))
+	require.Len(t, reporter.entries, 3)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A MultiResultHarness is a Harness that can execute statements that return multiple result sets, such as CALL
+// statements for stored procedures. Queries with multiple result sets are written in test files with one schema per
+// result set, separated by commas, and the results of each result set separated by a separator line:
+//
+//	query I,IT nosort
+//	CALL p()
+//	----
+//	1
+//	----
+//	2
+//	two
+//
+// Such queries are skipped for harnesses that don't implement this interface.
+type MultiResultHarness interface {
+	Harness
+
+	// ExecuteQueryResultSets executes the statement given and returns the schema and results of each result set it
+	// returns, in order, formatted as described by Harness.ExecuteQuery.
+	ExecuteQueryResultSets(ctx context.Context, statement string) (schemas []string, results [][]string, err error)
+}
+
+// executeMultiResultQuery executes the query record given, which has multiple result sets, and verifies the schema and
+// results of each result set in order, logging any failure.
+func (r *Runner) executeMultiResultQuery(ctx context.Context, record *parser.Record) *R {
+	harness, ok := r.harness.(MultiResultHarness)
+	if !ok {
+		logResult(ctx, Skipped, "")
+		return &R{skipped: true}
+	}
+
+	schemas, results, err := harness.ExecuteQueryResultSets(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{err: err}
+	}
+
+	return r.verifyResultSets(ctx, record, schemas, results)
+}
+
+// verifyResultSets verifies the number of result sets given and the schema and results of each against those expected
+// by the record given, logging any failure.
+func (r *Runner) verifyResultSets(ctx context.Context, record *parser.Record, schemas []string, results [][]string) *R {
+	if len(schemas) != record.NumResultSets() || len(results) != len(schemas) {
+		logFailure(ctx, RowCountMismatch, "Incorrect number of result sets. Expected %v, got %v", record.NumResultSets(), len(schemas))
+		return &R{err: fmt.Errorf("incorrect number of result sets. expected %v, got %v", record.NumResultSets(), len(schemas))}
+	}
+
+	res := &R{}
+	for i, set := range record.ResultSets() {
+		res.resultSets = append(res.resultSets, &R{schema: schemas[i], results: results[i]})
+		if res.err != nil {
+			continue
+		}
+
+		if err := r.verifySchema(ctx, set, schemas[i]); err != nil {
+			res.err = err
+			continue
+		}
+		res.err = r.verifyResults(ctx, set, schemas[i], results[i])
+	}
+
+	return res
+}
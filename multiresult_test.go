@@ -0,0 +1,79 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multiResultHarness returns two result sets for the statement "CALL p()".
+type multiResultHarness struct {
+	*fakeHarness
+}
+
+var _ MultiResultHarness = multiResultHarness{}
+
+func (h multiResultHarness) ExecuteQueryResultSets(ctx context.Context, statement string) ([]string, [][]string, error) {
+	return []string{"I", "IT"}, [][]string{{"1"}, {"2", "two", "3", "three"}}, nil
+}
+
+const multiResultTest = `query I,IT nosort
+CALL p()
+----
+1
+----
+2
+two
+3
+three
+`
+
+func TestMultipleResultSets(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(multiResultHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, multiResultTest))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(multiResultHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query I,IT nosort\nCALL p()\n----\n1\n----\n2\ntwo\n3\n3\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, multiResultTest))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+}
+
+func TestGenerateMultipleResultSets(t *testing.T) {
+	path := writeTestFile(t, multiResultTest+"\n")
+	runner := NewRunner(multiResultHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithHashThreshold(3))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, "query I,IT nosort\nCALL p()\n----\n1\n----\n4 values hashing to bd9d87aad3a4a3ec849a688d78535c7e\n\n", string(generated))
+}
@@ -22,8 +22,12 @@ import (
 	"github.com/andyyu2004/sqllogictest/mysql"
 )
 
-// MySQL test runner. Assumes a local MySQL with user sqllogictest, password "password". Adjust as necessary. Uses the
-// database "sqllogictest" for all operations, and will drop all tables in this database routinely.
+// defaultDSN is the data source name used when SQLLOGICTEST_MYSQL_DSN isn't set.
+const defaultDSN = "sqllogictest:password@tcp(127.0.0.1:3306)/sqllogictest"
+
+// MySQL test runner. Assumes a local MySQL with user sqllogictest, password "password", unless a different data source
+// name is given in the SQLLOGICTEST_MYSQL_DSN environment variable. Uses the database "sqllogictest" for all
+// operations, and will drop all tables in this database routinely.
 //
 // Sample setup commands:
 //
@@ -31,51 +35,17 @@ import (
 //	create user sqllogictest@localhost identified by "password";
 //	grant all on sqllogictest.* to sqllogictest@localhost;
 //
-// Three modes, controlled by the first argument:
-// verify: Runs the test files given, outputting a pass / fail line to STDOUT for each test record. All arguments after
-//
-//	the first are interpreted as test files or directories, which contain tests to be run. For directory arguments,
-//	directories are descended recursively, and all files with the .test extension will be added to the list of tests.
-//
-// generate: Runs tests as verify does, but also produces a new version of each test file, named $testfile.generated,
-//
-//	with the results of this test run.
-//
-// filter: Runs the tests and produces a new version of each test file, just like generate, but any tests that
-//
-//	fail are filtered out and not included in the generated files. This mode is useful when validating a new batch of
-//	fuzzed statements against a test oracle to filter out statements that don't execute correctly.
-//
-// analyze: Analyzes all test statements in the specified test files and prints out a usage count for various statement
-//
-//	types (e.g. SELECT, CREATE TABLE, CREATE INDEX).
-//
-// Usage: go run main.go (analyze|filter|generate|verify) testfile1 [testfile2 ...]
+// The modes and options of the runner are those of logictest.RunEngineMain.
 func main() {
-	if len(os.Args) == 0 {
-		exitWithUsage()
+	dsn := os.Getenv("SQLLOGICTEST_MYSQL_DSN")
+	if dsn == "" {
+		dsn = defaultDSN
 	}
+	harness := mysql.NewMysqlHarness(dsn)
 
-	args := os.Args[1:]
-
-	harness := mysql.NewMysqlHarness("sqllogictest:password@tcp(127.0.0.1:3306)/sqllogictest")
-
-	mode := args[0]
-	switch mode {
-	case "verify":
-		logictest.RunTestFiles(harness, args[1:]...)
-	case "generate":
-		logictest.GenerateTestFiles(harness, args[1:]...)
-	case "filter":
-		logictest.GenerateTestFilesWithFailedTestsExcluded(harness, args[1:]...)
-	case "analyze":
-		logictest.AnalyzeStatements(harness, args[1:]...)
-	default:
-		exitWithUsage()
+	if err := logictest.RunEngineMain(harness, os.Args[1:]); err != nil {
+		fmt.Println(err)
+		fmt.Println("Usage: go run main.go " + logictest.EngineMainUsage)
+		os.Exit(1)
 	}
 }
-
-func exitWithUsage() {
-	fmt.Println("Usage: sqllogictest (verify|generate|filter) testfile1 [testfiles2 ...] ")
-	os.Exit(1)
-}
@@ -18,19 +18,38 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	logictest "github.com/andyyu2004/sqllogictest"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/andyyu2004/sqllogictest/parser"
+	"github.com/andyyu2004/sqllogictest/sqldb"
+	"github.com/go-sql-driver/mysql"
 )
 
 // sqllogictest harness for MySQL databases.
 type MysqlHarness struct {
-	db *sql.DB
+	db        *sql.DB
+	dsn       string
+	formatter *logictest.ValueFormatter
 }
 
 // compile check for interface compliance
 var _ logictest.Harness = &MysqlHarness{}
+var _ logictest.StreamingHarness = &MysqlHarness{}
+var _ logictest.MultiResultHarness = &MysqlHarness{}
+var _ logictest.ProcedureHarness = &MysqlHarness{}
+var _ logictest.ErrorClassifier = &MysqlHarness{}
+var _ logictest.PreparedStatementHarness = &MysqlHarness{}
+var _ logictest.CapabilityHarness = &MysqlHarness{}
+var _ logictest.ExplainHarness = &MysqlHarness{}
+var _ logictest.MultiConnectionHarness = &MysqlHarness{}
+var _ logictest.WarningHarness = &MysqlHarness{}
+var _ logictest.CatalogHarness = &MysqlHarness{}
+var _ logictest.ColumnNameHarness = &MysqlHarness{}
+var _ logictest.BatchHarness = &MysqlHarness{}
+var _ logictest.FormattingHarness = &MysqlHarness{}
 
 // NewMysqlHarness returns a new MySQL test harness for the data source name given. Panics if it cannot open a
 // connection using the DSN.
@@ -39,7 +58,42 @@ func NewMysqlHarness(dsn string) *MysqlHarness {
 	if err != nil {
 		panic(err)
 	}
-	return &MysqlHarness{db: db}
+	return &MysqlHarness{db: db, dsn: dsn, formatter: logictest.NewValueFormatter()}
+}
+
+// NewConnection returns a harness for a new session on the database of this one, using a single connection so that
+// session state such as transactions carries over between statements. See MultiConnectionHarness.NewConnection
+func (h *MysqlHarness) NewConnection() (logictest.Harness, error) {
+	return h.NewSession()
+}
+
+// NewSession returns a MySQL harness for a new session on the database of this one, for the harnesses of
+// MySQL-compatible engines to wrap in their NewConnection.
+func (h *MysqlHarness) NewSession() (*MysqlHarness, error) {
+	db, err := sql.Open("mysql", h.dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	// Closing the only connection would end the session
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+	return &MysqlHarness{db: db, dsn: h.dsn, formatter: h.formatter}, nil
+}
+
+// Close closes the database handle of the harness.
+func (h *MysqlHarness) Close() error {
+	return h.db.Close()
+}
+
+// DB returns the database handle of the harness, for harnesses of MySQL-compatible engines that build on this one.
+func (h *MysqlHarness) DB() *sql.DB {
+	return h.db
+}
+
+// See FormattingHarness.SetFormatter
+func (h *MysqlHarness) SetFormatter(formatter *logictest.ValueFormatter) {
+	h.formatter = formatter
 }
 
 // See Harness.EngineStr
@@ -47,6 +101,24 @@ func (h *MysqlHarness) EngineStr() string {
 	return "mysql"
 }
 
+// See CapabilityHarness.Capabilities. MySQL has no FULL OUTER JOIN, and INTERSECT and EXCEPT require 8.0.31 or later.
+func (h *MysqlHarness) Capabilities() []logictest.Capability {
+	return []logictest.Capability{
+		logictest.CapSubqueries,
+		logictest.CapCTEs,
+		logictest.CapRecursiveCTEs,
+		logictest.CapWindowFunctions,
+		logictest.CapRightJoin,
+		logictest.CapViews,
+		logictest.CapTriggers,
+		logictest.CapStoredProcedures,
+		logictest.CapTransactions,
+		logictest.CapJSON,
+		logictest.CapForeignKeys,
+		logictest.CapCheckConstraints,
+	}
+}
+
 // See Harness.Init
 func (h *MysqlHarness) Init() error {
 	if err := h.dropAllTables(); err != nil {
@@ -62,6 +134,63 @@ func (h *MysqlHarness) ExecuteStatement(ctx context.Context, statement string) e
 	return err
 }
 
+// See BatchHarness.ExecuteStatements. The statements execute in a transaction, as by sqldb.ExecuteStatements, which
+// can't undo the statements of a failed batch on tables of non-transactional storage engines such as MyISAM.
+func (h *MysqlHarness) ExecuteStatements(ctx context.Context, statements []string) error {
+	return sqldb.ExecuteStatements(ctx, h.db, nil, statements)
+}
+
+// See BatchHarness.AtomicBatches. Batches aren't atomic, since tables may use non-transactional storage engines.
+func (h *MysqlHarness) AtomicBatches() bool {
+	return false
+}
+
+// See CatalogHarness.Catalog
+func (h *MysqlHarness) Catalog(ctx context.Context) (logictest.Catalog, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT c.table_name, c.column_name FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = DATABASE() AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	return logictest.ReadCatalog(rows)
+}
+
+// See WarningHarness.ExecuteStatementWarnings. The statement and SHOW WARNINGS execute on the same connection, since
+// warnings belong to a session.
+func (h *MysqlHarness) ExecuteStatementWarnings(ctx context.Context, statement string) ([]logictest.Warning, error) {
+	conn, err := h.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, statement); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	warnings := []logictest.Warning{}
+	for rows.Next() {
+		var level, code, message string
+		if err := rows.Scan(&level, &code, &message); err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, logictest.Warning{Code: code, Message: message})
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return warnings, nil
+}
+
 // See Harness.ExecuteQuery
 func (h *MysqlHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
 	rows, err := h.db.QueryContext(ctx, statement)
@@ -69,6 +198,58 @@ func (h *MysqlHarness) ExecuteQuery(ctx context.Context, statement string) (sche
 		return "", nil, err
 	}
 
+	return h.queryResults(rows)
+}
+
+// See ColumnNameHarness.ExecuteQueryColumns
+func (h *MysqlHarness) ExecuteQueryColumns(ctx context.Context, statement string) (schema string, columns []string, results []string, err error) {
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	columns, err = rows.Columns()
+	if err != nil {
+		rows.Close()
+		return "", nil, nil, err
+	}
+
+	schema, results, err = h.queryResults(rows)
+	return schema, columns, results, err
+}
+
+// See PreparedStatementHarness.ExecutePreparedStatement
+func (h *MysqlHarness) ExecutePreparedStatement(ctx context.Context, statement string, args []interface{}) error {
+	stmt, err := h.db.PrepareContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, args...)
+	return err
+}
+
+// See PreparedStatementHarness.ExecutePreparedQuery
+func (h *MysqlHarness) ExecutePreparedQuery(ctx context.Context, query string, args []interface{}) (schema string, results []string, err error) {
+	stmt, err := h.db.PrepareContext(ctx, query)
+	if err != nil {
+		return "", nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return h.queryResults(rows)
+}
+
+// queryResults reads the schema and results of the rows given, and closes them.
+func (h *MysqlHarness) queryResults(rows *sql.Rows) (schema string, results []string, err error) {
+	defer rows.Close()
+
 	schema, columns, err := columns(rows)
 	if err != nil {
 		return "", nil, err
@@ -81,7 +262,7 @@ func (h *MysqlHarness) ExecuteQuery(ctx context.Context, statement string) (sche
 		}
 
 		for _, col := range columns {
-			results = append(results, stringVal(col))
+			results = append(results, formatValue(h.formatter, col))
 		}
 	}
 
@@ -92,6 +273,241 @@ func (h *MysqlHarness) ExecuteQuery(ctx context.Context, statement string) (sche
 	return schema, results, nil
 }
 
+// See MultiResultHarness.ExecuteQueryResultSets
+func (h *MysqlHarness) ExecuteQueryResultSets(ctx context.Context, statement string) (schemas []string, results [][]string, err error) {
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return h.resultSets(rows)
+}
+
+// resultSets reads the schema and results of every result set of the rows given. Result sets without columns, such as
+// the status result that ends a CALL statement, are omitted.
+func (h *MysqlHarness) resultSets(rows *sql.Rows) (schemas []string, results [][]string, err error) {
+	for {
+		schema, columns, err := columns(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(columns) == 0 {
+			if !rows.NextResultSet() {
+				break
+			}
+			continue
+		}
+
+		var setResults []string
+		for rows.Next() {
+			if err := rows.Scan(columns...); err != nil {
+				return nil, nil, err
+			}
+			for _, col := range columns {
+				setResults = append(setResults, formatValue(h.formatter, col))
+			}
+		}
+
+		schemas = append(schemas, schema)
+		results = append(results, setResults)
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+
+	if rows.Err() != nil {
+		return nil, nil, rows.Err()
+	}
+
+	return schemas, results, nil
+}
+
+// See ProcedureHarness.CallProcedure
+func (h *MysqlHarness) CallProcedure(ctx context.Context, name string, args []parser.ProcedureArg) (*logictest.ProcedureResult, error) {
+	// User variables for OUT parameters are scoped to the session, so the call and reading them back must share a
+	// connection
+	conn, err := h.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var params, outVars []string
+	for _, arg := range args {
+		switch arg.Mode {
+		case parser.ParamIn:
+			params = append(params, arg.Value)
+		case parser.ParamOut, parser.ParamInOut:
+			outVar := "@" + strings.TrimPrefix(arg.Name, "@")
+			if arg.Mode == parser.ParamInOut {
+				if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET %s = %s", outVar, arg.Value)); err != nil {
+					return nil, err
+				}
+			}
+			params = append(params, outVar)
+			outVars = append(outVars, outVar)
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("CALL %s(%s)", name, strings.Join(params, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	schemas, results, err := h.resultSets(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &logictest.ProcedureResult{Schemas: schemas, Results: results}
+	if len(outVars) == 0 {
+		return res, nil
+	}
+
+	rows, err = conn.QueryContext(ctx, "SELECT "+strings.Join(outVars, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outSchemas, outValues, err := h.resultSets(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(outSchemas) > 0 {
+		res.OutSchema, res.OutValues = outSchemas[0], outValues[0]
+	}
+
+	return res, nil
+}
+
+// See ExplainHarness.ExplainQuery. Requires MySQL 8.0.16 or later for tree-formatted plans.
+func (h *MysqlHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	rows, err := h.db.QueryContext(ctx, "EXPLAIN FORMAT=TREE "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if rows.Err() != nil {
+		return "", rows.Err()
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// See StreamingHarness.ExecuteQueryStreaming
+func (h *MysqlHarness) ExecuteQueryStreaming(ctx context.Context, statement string) (string, logictest.RowIterator, error) {
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", nil, err
+	}
+
+	schema, columns, err := columns(rows)
+	if err != nil {
+		rows.Close()
+		return "", nil, err
+	}
+
+	return schema, &rowIterator{rows: rows, columns: columns, formatter: h.formatter}, nil
+}
+
+// rowIterator adapts sql.Rows to the logictest.RowIterator interface.
+type rowIterator struct {
+	rows      *sql.Rows
+	columns   []interface{}
+	formatter *logictest.ValueFormatter
+}
+
+// See RowIterator.NextRow
+func (i *rowIterator) NextRow() ([]string, error) {
+	if !i.rows.Next() {
+		if err := i.rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	if err := i.rows.Scan(i.columns...); err != nil {
+		return nil, err
+	}
+
+	row := make([]string, len(i.columns))
+	for j, col := range i.columns {
+		row[j] = formatValue(i.formatter, col)
+	}
+	return row, nil
+}
+
+// See RowIterator.Close
+func (i *rowIterator) Close() error {
+	return i.rows.Close()
+}
+
+// mysqlErrorStates map MySQL error numbers to their SQLSTATEs, which this version of the driver doesn't report.
+var mysqlErrorStates = map[uint16]string{
+	1044: "42000", // ER_DBACCESS_DENIED_ERROR
+	1048: "23000", // ER_BAD_NULL_ERROR
+	1049: "42000", // ER_BAD_DB_ERROR
+	1050: "42S01", // ER_TABLE_EXISTS_ERROR
+	1051: "42S02", // ER_BAD_TABLE_ERROR
+	1054: "42S22", // ER_BAD_FIELD_ERROR
+	1060: "42S21", // ER_DUP_FIELDNAME
+	1061: "42000", // ER_DUP_KEYNAME
+	1062: "23000", // ER_DUP_ENTRY
+	1064: "42000", // ER_PARSE_ERROR
+	1142: "42000", // ER_TABLEACCESS_DENIED_ERROR
+	1146: "42S02", // ER_NO_SUCH_TABLE
+	1205: "HY000", // ER_LOCK_WAIT_TIMEOUT
+	1213: "40001", // ER_LOCK_DEADLOCK
+	1235: "42000", // ER_NOT_SUPPORTED_YET
+	1264: "22003", // ER_WARN_DATA_OUT_OF_RANGE
+	1305: "42000", // ER_SP_DOES_NOT_EXIST
+	1365: "22012", // ER_DIVISION_BY_ZERO
+	1406: "22001", // ER_DATA_TOO_LONG
+	1451: "23000", // ER_ROW_IS_REFERENCED_2
+	1452: "23000", // ER_NO_REFERENCED_ROW_2
+	3819: "HY000", // ER_CHECK_CONSTRAINT_VIOLATED
+}
+
+// mysqlErrorCategories override the categories implied by the SQLSTATEs of MySQL errors whose SQLSTATE is too coarse.
+var mysqlErrorCategories = map[uint16]logictest.ErrorCategory{
+	1044: logictest.CategoryPermission,
+	1049: logictest.CategoryNotFound,
+	1061: logictest.CategoryAlreadyExists,
+	1142: logictest.CategoryPermission,
+	1205: logictest.CategoryLockTimeout,
+	1213: logictest.CategoryDeadlock,
+	1235: logictest.CategoryUnsupported,
+	1305: logictest.CategoryNotFound,
+	3819: logictest.CategoryConstraint,
+}
+
+// See ErrorClassifier.ClassifyError
+func (h *MysqlHarness) ClassifyError(err error) logictest.ErrorClass {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	if !ok {
+		return logictest.ErrorClass{}
+	}
+
+	class := logictest.ErrorClass{SQLState: mysqlErrorStates[mysqlErr.Number], Code: strconv.Itoa(int(mysqlErr.Number))}
+	class.Category = logictest.CategoryForSQLState(class.SQLState)
+	if category, ok := mysqlErrorCategories[mysqlErr.Number]; ok {
+		class.Category = category
+	}
+	return class
+}
+
 func (h *MysqlHarness) GetTimeout() int64 {
 	return 0
 }
@@ -161,70 +577,3 @@ func (h *MysqlHarness) dropAllViews() error {
 
 	return nil
 }
-
-// Returns the string representation of the column value given
-func stringVal(col interface{}) string {
-	switch v := col.(type) {
-	case *sql.NullBool:
-		if !v.Valid {
-			return "NULL"
-		}
-		if v.Bool {
-			return "1"
-		} else {
-			return "0"
-		}
-	case *sql.NullInt64:
-		if !v.Valid {
-			return "NULL"
-		}
-		return fmt.Sprintf("%d", v.Int64)
-	case *sql.NullFloat64:
-		if !v.Valid {
-			return "NULL"
-		}
-		return fmt.Sprintf("%.3f", v.Float64)
-	case *sql.NullString:
-		if !v.Valid {
-			return "NULL"
-		}
-		return v.String
-	default:
-		panic(fmt.Sprintf("unhandled type %T for value %v", v, v))
-	}
-}
-
-// Returns the schema for the rows given, as well as a slice of columns suitable for scanning values into.
-func columns(rows *sql.Rows) (string, []interface{}, error) {
-	types, err := rows.ColumnTypes()
-	if err != nil {
-		return "", nil, err
-	}
-
-	sb := strings.Builder{}
-	var columns []interface{}
-	for _, columnType := range types {
-		switch columnType.DatabaseTypeName() {
-		case "BIT":
-			colVal := sql.NullBool{}
-			columns = append(columns, &colVal)
-			sb.WriteString("I")
-		case "TEXT", "VARCHAR", "MEDIUMTEXT", "CHAR", "TINYTEXT":
-			colVal := sql.NullString{}
-			columns = append(columns, &colVal)
-			sb.WriteString("T")
-		case "DECIMAL", "DOUBLE", "FLOAT":
-			colVal := sql.NullFloat64{}
-			columns = append(columns, &colVal)
-			sb.WriteString("R")
-		case "MEDIUMINT", "INT", "BIGINT", "TINYINT", "SMALLINT":
-			colVal := sql.NullInt64{}
-			columns = append(columns, &colVal)
-			sb.WriteString("I")
-		default:
-			return "", nil, fmt.Errorf("unhandled type %s", columnType.DatabaseTypeName())
-		}
-	}
-
-	return sb.String(), columns, nil
-}
@@ -0,0 +1,104 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+)
+
+// columnValue returns the sqllogictest schema type of a column with the MySQL type name given, as reported by the
+// driver, along with a new value suitable for scanning the column into. Integer and bit types are I, floating point
+// and decimal types are R, binary string and BLOB types are B, and all other types are T. Columns of the NULL type, e.g. in `SELECT NULL`, are I, as in
+// the test corpus.
+func columnValue(typeName string) (byte, interface{}, error) {
+	switch typeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "YEAR":
+		return 'I', &sql.NullInt64{}, nil
+	case "BIGINT":
+		// The driver doesn't distinguish BIGINT UNSIGNED, whose values can overflow an int64, so these are scanned as
+		// their decimal text instead.
+		return 'I', &sql.NullString{}, nil
+	case "BIT":
+		return 'I', &bitValue{}, nil
+	case "NULL":
+		return 'I', &sql.NullString{}, nil
+	case "DECIMAL", "DOUBLE", "FLOAT":
+		return 'R', &sql.NullFloat64{}, nil
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return 'B', &sql.Null[[]byte]{}, nil
+	case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT",
+		"ENUM", "SET", "JSON",
+		"DATE", "DATETIME", "TIMESTAMP", "TIME":
+		return 'T', &sql.NullString{}, nil
+	default:
+		return 0, nil, fmt.Errorf("unhandled type %s", typeName)
+	}
+}
+
+// Returns the schema for the rows given, as well as a slice of columns suitable for scanning values into.
+func columns(rows *sql.Rows) (string, []interface{}, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sb := strings.Builder{}
+	var columns []interface{}
+	for _, columnType := range types {
+		typ, colVal, err := columnValue(columnType.DatabaseTypeName())
+		if err != nil {
+			return "", nil, err
+		}
+		columns = append(columns, colVal)
+		sb.WriteByte(typ)
+	}
+
+	return sb.String(), columns, nil
+}
+
+// formatValue renders a value scanned into one of the values returned by columnValue, with the formatter given.
+func formatValue(f *logictest.ValueFormatter, col interface{}) string {
+	if bit, ok := col.(*bitValue); ok {
+		return f.FormatValue(&bit.NullInt64)
+	}
+	return f.FormatValue(col)
+}
+
+// bitValue scans BIT columns, which MySQL returns as big-endian binary strings, as integers.
+type bitValue struct {
+	sql.NullInt64
+}
+
+// See sql.Scanner.Scan
+func (b *bitValue) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return b.NullInt64.Scan(src)
+	}
+	if len(bytes) > 8 {
+		return fmt.Errorf("BIT value of %d bytes overflows int64", len(bytes))
+	}
+
+	var v int64
+	for _, c := range bytes {
+		v = v<<8 | int64(c)
+	}
+	b.Int64, b.Valid = v, true
+	return nil
+}
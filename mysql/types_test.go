@@ -0,0 +1,71 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnValue(t *testing.T) {
+	tests := []struct {
+		typeName string
+		typ      byte
+		src      interface{}
+		expected string
+	}{
+		{"INT", 'I', []byte("-42"), "-42"},
+		{"TINYINT", 'I', int64(1), "1"},
+		{"YEAR", 'I', []byte("2020"), "2020"},
+		{"BIGINT", 'I', []byte("18446744073709551615"), "18446744073709551615"},
+		{"BIGINT", 'I', int64(-7), "-7"},
+		{"BIT", 'I', []byte{0x01, 0x02}, "258"},
+		{"BIT", 'I', nil, "NULL"},
+		{"NULL", 'I', nil, "NULL"},
+		{"DECIMAL", 'R', []byte("1.23456"), "1.235"},
+		{"DOUBLE", 'R', float64(2), "2.000"},
+		{"FLOAT", 'R', nil, "NULL"},
+		{"VARCHAR", 'T', []byte("abc"), "abc"},
+		{"LONGTEXT", 'T', []byte(""), ""},
+		{"VARBINARY", 'B', []byte("xyz"), "78797a"},
+		{"BLOB", 'B', []byte{}, "(empty)"},
+		{"BLOB", 'B', nil, "NULL"},
+		{"ENUM", 'T', []byte("small"), "small"},
+		{"JSON", 'T', []byte(`{"a": 1}`), `{"a": 1}`},
+		{"DATE", 'T', []byte("2020-01-02"), "2020-01-02"},
+		{"TIME", 'T', []byte("10:11:12"), "10:11:12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			typ, col, err := columnValue(tt.typeName)
+			require.NoError(t, err)
+			assert.Equal(t, string(tt.typ), string(typ))
+			require.NoError(t, col.(sql.Scanner).Scan(tt.src))
+			assert.Equal(t, tt.expected, formatValue(logictest.NewValueFormatter(), col))
+		})
+	}
+
+	_, _, err := columnValue("GEOMETRY")
+	assert.Error(t, err)
+}
+
+func TestBitValueOverflow(t *testing.T) {
+	assert.Error(t, (&bitValue{}).Scan(make([]byte, 9)))
+}
@@ -0,0 +1,206 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A Normalizer rewrites a value before the expected and actual values are compared, so that engines whose results
+// differ only cosmetically from the ones in a shared corpus can still pass it. Normalizers are given the schema type
+// of the column of result values, e.g. 'T', or MessageText for the text of warnings. They're applied in order to both
+// expected and actual values, so they must leave values they've already normalized unchanged.
+type Normalizer func(value string, typ byte) string
+
+// MessageText is the type Normalizers are given for the text of warnings, rather than for result values.
+const MessageText byte = 0
+
+// TrimTrailingZeros removes the trailing zeros after the decimal point of R-typed values, and the decimal point if
+// nothing's left after it, so that e.g. 1.500 and 1.5 compare equal.
+func TrimTrailingZeros(value string, typ byte) string {
+	if typ != 'R' || !strings.Contains(value, ".") || strings.ContainsAny(value, "eE") {
+		return value
+	}
+	return strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+}
+
+// CollapseWhitespace replaces each run of whitespace in values with a single space, and removes leading and trailing
+// whitespace.
+func CollapseWhitespace(value string, typ byte) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// sqlKeywords are the keywords lowercased by LowercaseKeywords.
+var sqlKeywords = map[string]bool{
+	"ALTER": true, "AND": true, "AS": true, "BETWEEN": true, "BY": true, "CHECK": true, "COLUMN": true,
+	"CONSTRAINT": true, "CREATE": true, "DATABASE": true, "DEFAULT": true, "DELETE": true, "DISTINCT": true,
+	"DROP": true, "EXISTS": true, "FOREIGN": true, "FROM": true, "FUNCTION": true, "GROUP": true, "HAVING": true,
+	"IN": true, "INDEX": true, "INSERT": true, "INTO": true, "IS": true, "JOIN": true, "KEY": true, "LIKE": true,
+	"LIMIT": true, "NOT": true, "NULL": true, "ON": true, "OR": true, "ORDER": true, "PRIMARY": true,
+	"REFERENCES": true, "SELECT": true, "SET": true, "TABLE": true, "TRIGGER": true, "UNION": true, "UNIQUE": true,
+	"UPDATE": true, "VALUES": true, "VIEW": true, "WHERE": true,
+}
+
+var wordRegex = regexp.MustCompile(`[A-Za-z_]+`)
+
+// LowercaseKeywords lowercases the SQL keywords in the text of warnings, e.g. "UNIQUE constraint failed" for "unique
+// constraint failed", outside of quotes. Result values are left unchanged.
+func LowercaseKeywords(value string, typ byte) string {
+	if typ != MessageText {
+		return value
+	}
+
+	var sb strings.Builder
+	lowercase := func(text string) {
+		sb.WriteString(wordRegex.ReplaceAllStringFunc(text, func(word string) string {
+			if sqlKeywords[strings.ToUpper(word)] {
+				return strings.ToLower(word)
+			}
+			return word
+		}))
+	}
+
+	var quote rune
+	start := 0
+	for i, c := range value {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				sb.WriteString(value[start : i+1])
+				start = i + 1
+			}
+		case c == '\'' || c == '"' || c == '`':
+			lowercase(value[start:i])
+			quote = c
+			start = i
+		}
+	}
+	if quote != 0 {
+		sb.WriteString(value[start:])
+	} else {
+		lowercase(value[start:])
+	}
+	return sb.String()
+}
+
+// normalizers are the built-in normalizers, by the names they're given on the command line.
+var normalizers = map[string]Normalizer{
+	"trim-trailing-zeros": TrimTrailingZeros,
+	"collapse-whitespace": CollapseWhitespace,
+	"lowercase-keywords":  LowercaseKeywords,
+}
+
+// LookupNormalizer returns the built-in normalizer with the name given, e.g. trim-trailing-zeros.
+func LookupNormalizer(name string) (Normalizer, error) {
+	normalizer, ok := normalizers[name]
+	if !ok {
+		names := make([]string, 0, len(normalizers))
+		for name := range normalizers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown normalizer %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return normalizer, nil
+}
+
+// normalize applies the runner's normalizers to the value given, of the type given.
+func (c *RunConfig) normalize(value string, typ byte) string {
+	for _, normalizer := range c.Normalizers {
+		value = normalizer(value, typ)
+	}
+	return value
+}
+
+// normalizeAll applies the runner's normalizers to the result values given, which have the schema given, returning
+// the values unchanged if there are none.
+func (c *RunConfig) normalizeAll(values []string, schema string) []string {
+	if len(c.Normalizers) == 0 || len(schema) == 0 {
+		return values
+	}
+	normalized := make([]string, len(values))
+	for i, value := range values {
+		normalized[i] = c.normalize(value, schema[i%len(schema)])
+	}
+	return normalized
+}
+
+// expectedResults returns the expected results of the query record given with the runner's normalizers applied to
+// all but patterns, sorted according to the record's sort mode, since normalizing them may change their order. Rows
+// with patterns are kept where they are, as are the values that are patterns of valuesort records, since patterns
+// don't sort like the values they match.
+func (c *RunConfig) expectedResults(record *parser.Record) []string {
+	schema := record.Schema()
+	expected := make([]string, len(record.Result()))
+	for i, value := range record.Result() {
+		if !isPattern(value) {
+			value = EscapeLiteral(c.normalize(unescapeLiteral(value), schema[i%len(schema)]))
+		}
+		expected[i] = value
+	}
+	return sortExpected(record, expected)
+}
+
+// sortExpected sorts the expected values given of the record given according to its sort mode, around the rows with
+// patterns, which keep their positions, or the values that are patterns for valuesort records.
+func sortExpected(record *parser.Record, values []string) []string {
+	width := record.NumCols()
+	if record.SortMode() == parser.ValueSort {
+		width = 1
+	}
+	if record.SortMode() == parser.NoSort || width == 0 {
+		return values
+	}
+
+	// The rows without patterns are sorted, and put back into their positions in order
+	var unpinned []int
+	var sortable []string
+	for start := 0; start+width <= len(values); start += width {
+		row := values[start : start+width]
+		if !slices.ContainsFunc(row, isPattern) {
+			unpinned = append(unpinned, start)
+			sortable = append(sortable, row...)
+		}
+	}
+	if len(unpinned)*width == len(values) {
+		return sortLiterals(record, values)
+	}
+
+	sortable = sortLiterals(record, sortable)
+	for i, start := range unpinned {
+		copy(values[start:start+width], sortable[i*width:(i+1)*width])
+	}
+	return values
+}
+
+// sortLiterals sorts the expected values given, none of which are patterns, according to the sort mode of the record
+// given, by the actual values they're written for, since values escaped with EscapeLiteral don't sort like them.
+func sortLiterals(record *parser.Record, values []string) []string {
+	for i, value := range values {
+		values[i] = unescapeLiteral(value)
+	}
+	values = record.SortResults(values)
+	for i, value := range values {
+		values[i] = EscapeLiteral(value)
+	}
+	return values
+}
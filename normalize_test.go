@@ -0,0 +1,70 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinNormalizers(t *testing.T) {
+	assert.Equal(t, "1.5", TrimTrailingZeros("1.500", 'R'))
+	assert.Equal(t, "2", TrimTrailingZeros("2.000", 'R'))
+	assert.Equal(t, "100", TrimTrailingZeros("100", 'R'))
+	assert.Equal(t, "1.0e10", TrimTrailingZeros("1.0e10", 'R'))
+	assert.Equal(t, "1.500", TrimTrailingZeros("1.500", 'T'))
+
+	assert.Equal(t, "a b c", CollapseWhitespace("  a \t b\n\nc ", 'T'))
+
+	assert.Equal(t, "unique constraint failed on table 'T1 WHERE'",
+		LowercaseKeywords("UNIQUE constraint failed ON TABLE 'T1 WHERE'", MessageText))
+	assert.Equal(t, "UNIQUE", LowercaseKeywords("UNIQUE", 'T'))
+
+	normalizer, err := LookupNormalizer("collapse-whitespace")
+	require.NoError(t, err)
+	assert.Equal(t, "a b", normalizer("a  b", 'T'))
+	_, err = LookupNormalizer("unknown")
+	assert.Error(t, err)
+}
+
+func TestNormalizers(t *testing.T) {
+	harness := newWarningHarness()
+	harness.queryResults["SELECT a, b FROM t2"] = fakeResult{schema: "RT", results: []string{"2.50", "b  c", "1.000", "a"}}
+	harness.warnings["INSERT INTO t1 VALUES (NULL)"] = []Warning{{Code: "1048", Message: "Column 'a' cannot be NULL"}}
+	contents := "query RT rowsort\nSELECT a, b FROM t2\n----\n1\na\n2.5\nb c\n\n" +
+		"warning 1048 Column 'a' cannot be null\nstatement ok\nINSERT INTO t1 VALUES (NULL)\n"
+
+	// Without normalizers, both records fail
+	path := writeTestFile(t, contents)
+	reporter := &collectingReporter{}
+	runner := NewRunner(&harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithExpectedFailures(path+":2", path+":11"))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, WarningMismatch, reporter.entries[1].FailureCode)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(&harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithNormalizers(TrimTrailingZeros, CollapseWhitespace, LowercaseKeywords))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.ErrorMessage)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseBindArgs parses the values of a bind directive, which binds values to the parameters of the prepared statement
+// of the next record, e.g.:
+//
+//	bind 1 2.5 'it''s' NULL
+//	query IRT nosort
+//	SELECT ?, ?, ? WHERE ? IS NULL
+//
+// Values are separated by whitespace and are integers, floats, single-quoted strings (with quotes escaped by doubling
+// them) or NULL.
+func parseBindArgs(s string) ([]interface{}, error) {
+	var args []interface{}
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" || strings.HasPrefix(s, "#") {
+			return args, nil
+		}
+
+		if s[0] == '\'' {
+			value, rest, err := parseQuotedString(s)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, value)
+			s = rest
+			continue
+		}
+
+		token := s
+		if i := strings.IndexAny(s, " \t"); i >= 0 {
+			token, s = s[:i], s[i:]
+		} else {
+			s = ""
+		}
+
+		value, err := parseBindValue(token)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+}
+
+// parseQuotedString parses the single-quoted string at the start of the string given, and returns its value and the
+// remainder of the string.
+func parseQuotedString(s string) (string, string, error) {
+	sb := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			sb.WriteByte('\'')
+			i++
+			continue
+		}
+		return sb.String(), s[i+1:], nil
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// parseBindValue parses an unquoted bind value.
+func parseBindValue(token string) (interface{}, error) {
+	if strings.EqualFold(token, "NULL") {
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(token, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unrecognized value %s", token)
+}
@@ -0,0 +1,69 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import "strings"
+
+// Comments in test files start with #, with rules that depend on where they appear:
+//   - A line starting with # is a comment anywhere in a test file, including among the SQL and results of a record.
+//   - On directive and header lines, # starts a comment that runs to the end of the line.
+//   - On the lines of a statement or query, # outside single-quoted strings, double-quoted and backquoted
+//     identifiers starts a comment that runs to the end of the line, so that e.g. SELECT '#' keeps its literal.
+//     Quotes in strings are escaped by doubling them, or with a backslash as in MySQL, e.g. 'it''s' or 'it\'s'.
+//   - On result lines, # is part of the value, and never starts a comment. A value starting with # is written with a
+//     backslash before it, \#, so that it isn't read as a comment line, and a value starting with backslashes and
+//     then # is written with one more backslash. See EscapeResult.
+
+// StripComment returns the line of SQL given without its trailing comment, if it has one: the text from the first #
+// that isn't inside a quoted string or identifier. A backslash inside a single- or double-quoted string escapes the
+// character after it, but not inside a backquoted identifier.
+func StripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && quote != '`' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// EscapeResult returns the result value given as it's written on a result line of a test file: with a backslash
+// added before it if it starts with #, or with backslashes followed by #, so that it isn't read as a comment.
+func EscapeResult(value string) string {
+	if strings.HasPrefix(strings.TrimLeft(value, `\`), "#") {
+		return `\` + value
+	}
+	return value
+}
+
+// unescapeResult returns the result value written on the result line given, reversing EscapeResult.
+func unescapeResult(line string) string {
+	if strings.HasPrefix(line, `\`) && strings.HasPrefix(strings.TrimLeft(line, `\`), "#") {
+		return line[1:]
+	}
+	return line
+}
@@ -0,0 +1,166 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// TestFileSuffix is the suffix of the names of sqllogictest files.
+const TestFileSuffix = ".test"
+
+// Test files may be compressed with gzip or zstd, with the suffix of the compression after .test, e.g. select1.test.gz.
+// They're decompressed transparently wherever test files are read, and files generated from them are compressed the
+// same way.
+const (
+	gzipSuffix = ".gz"
+	zstdSuffix = ".zst"
+)
+
+// IsTestFile returns whether the path given names a sqllogictest file, compressed or not.
+func IsTestFile(path string) bool {
+	path, _ = SplitCompressionSuffix(path)
+	return strings.HasSuffix(path, TestFileSuffix)
+}
+
+// SplitCompressionSuffix splits the suffix of the compression of the file at the path given from the path, returning
+// the path without it and the suffix, which is empty for files that aren't compressed.
+func SplitCompressionSuffix(path string) (string, string) {
+	switch ext := filepath.Ext(path); ext {
+	case gzipSuffix, zstdSuffix:
+		return strings.TrimSuffix(path, ext), ext
+	default:
+		return path, ""
+	}
+}
+
+// OpenFile opens the file at the path given for reading, decompressing it if its name has the suffix of a
+// compression. The file must be closed when done.
+func OpenFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(path, file)
+}
+
+// OpenFileFS opens the file with the name given in the file system given for reading, decompressing it as OpenFile
+// does. The file must be closed when done.
+func OpenFileFS(fsys fs.FS, name string) (io.ReadCloser, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(name, file)
+}
+
+// decompress returns a reader of the decompressed contents of the file given, opened from the path given, which is
+// closed along with the reader.
+func decompress(path string, file io.ReadCloser) (io.ReadCloser, error) {
+	var r io.ReadCloser
+	var err error
+	switch _, ext := SplitCompressionSuffix(path); ext {
+	case gzipSuffix:
+		r, err = gzip.NewReader(file)
+	case zstdSuffix:
+		var decoder *zstd.Decoder
+		if decoder, err = zstd.NewReader(file); err == nil {
+			r = decoder.IOReadCloser()
+		}
+	default:
+		return file, nil
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &compressedFile{ReadCloser: r, file: file}, nil
+}
+
+// ReadFile reads the whole file at the path given, decompressing it if its name has the suffix of a compression.
+func ReadFile(path string) ([]byte, error) {
+	r, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// CreateFile creates or truncates the file at the path given for writing, compressing what's written if its name has
+// the suffix of a compression. The file must be closed to complete it.
+func CreateFile(path string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.WriteCloser
+	switch _, ext := SplitCompressionSuffix(path); ext {
+	case gzipSuffix:
+		w = gzip.NewWriter(file)
+	case zstdSuffix:
+		if w, err = zstd.NewWriter(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+	default:
+		return file, nil
+	}
+	return &compressedFile{WriteCloser: w, file: file}, nil
+}
+
+// WriteFile writes the contents given to the file at the path given, compressing them if its name has the suffix of a
+// compression.
+func WriteFile(path string, contents []byte) error {
+	w, err := CreateFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, bytes.NewReader(contents)); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// A compressedFile is a decompressing reader or compressing writer of a file, which closes the file along with the
+// reader or writer.
+type compressedFile struct {
+	io.ReadCloser
+	io.WriteCloser
+	file io.Closer
+}
+
+func (f *compressedFile) Close() error {
+	var err error
+	if f.ReadCloser != nil {
+		err = f.ReadCloser.Close()
+	} else {
+		err = f.WriteCloser.Close()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTestFile(t *testing.T) {
+	assert.True(t, IsTestFile("select1.test"))
+	assert.True(t, IsTestFile("dir/select1.test.gz"))
+	assert.True(t, IsTestFile("select1.test.zst"))
+	assert.False(t, IsTestFile("select1.test.bak"))
+	assert.False(t, IsTestFile("select1.gz"))
+	assert.False(t, IsTestFile("results.log.zst"))
+}
+
+func TestCompressedTestFiles(t *testing.T) {
+	contents, err := os.ReadFile("testdata/select1.test")
+	require.NoError(t, err)
+	expected, err := ParseTestFile("testdata/select1.test")
+	require.NoError(t, err)
+
+	for _, suffix := range []string{".gz", ".zst"} {
+		t.Run(suffix, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "select1.test"+suffix)
+			require.NoError(t, WriteFile(path, contents))
+
+			// The file on disk is compressed
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.NotEqual(t, contents, raw)
+
+			read, err := ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, contents, read)
+
+			records, err := ParseTestFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, records)
+
+			reader, err := OpenTestFile(path)
+			require.NoError(t, err)
+			record, err := reader.Next()
+			require.NoError(t, err)
+			assert.Equal(t, expected[0], record)
+			require.NoError(t, reader.Close())
+		})
+	}
+}
+
+func TestCorruptCompressedTestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "select1.test.gz")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\n"), 0644))
+	_, err := ParseTestFile(path)
+	assert.Error(t, err)
+}
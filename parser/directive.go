@@ -0,0 +1,99 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A Directive is a custom record type, registered by a program that embeds the parser so that its test files can
+// contain records for engine-specific extensions without forking the parser. A custom record is a line beginning with
+// the directive's name followed by its arguments, and for directives with a body, the lines after it up to the next
+// blank line, e.g.:
+//
+//	dolt_branch create b1
+//
+//	analyze
+//	t1
+//	t2
+//
+// Custom records have type Custom, and can be preceded by the directives that apply to any record, such as skipif,
+// onlyif, require and connection.
+type Directive struct {
+	// Name is the keyword that begins the directive's records
+	Name string
+	// Body is whether the directive's records include the lines after their first, up to the next blank line
+	Body bool
+	// Parse parses a record of the directive given its arguments, the fields of its first line after its name, and
+	// its body. It returns the value held by the record for the runner, see Record.DirectiveValue, or an error if the
+	// record is invalid. When nil, records take any arguments and hold no value.
+	Parse func(args []string, body []string) (interface{}, error)
+}
+
+var (
+	directivesMux sync.RWMutex
+	directives    = make(map[string]Directive)
+)
+
+// builtinDirectives are the keywords of the records and directives this package parses itself.
+var builtinDirectives = map[string]bool{
+	"statement": true, "query": true, "procedure": true, halt: true, hashThreshold: true, skipif: true, onlyif: true,
+	floatEpsilon: true, timeoutDirective: true, ambiguousSchema: true, prepared: true, bind: true,
+	requireDirective: true, route: true, txn: true, connection: true, awaitStatement: true, awaitDeadlock: true,
+	awaitLockTimeout: true, warningDirective: true, warningsDirective: true, tableChecksum: true, snapshot: true,
+	restore: true, setSeed: true, colnames: true, expectPlanContains: true, expectPlanNotContain: true,
+	expectMetric: true, expectErrorMessage: true,
+}
+
+// RegisterDirective registers a custom directive, so that test files parsed afterwards can contain its records. Like
+// sql.Register, it panics if the directive has no name, or if its name is a built-in directive or registered already.
+func RegisterDirective(d Directive) {
+	directivesMux.Lock()
+	defer directivesMux.Unlock()
+
+	if d.Name == "" {
+		panic("parser: RegisterDirective directive has no name")
+	}
+	if builtinDirectives[d.Name] {
+		panic("parser: RegisterDirective called for built-in directive " + d.Name)
+	}
+	if _, dup := directives[d.Name]; dup {
+		panic("parser: RegisterDirective called twice for directive " + d.Name)
+	}
+	directives[d.Name] = d
+}
+
+// lookupDirective returns the custom directive with the name given, if one is registered.
+func lookupDirective(name string) (Directive, bool) {
+	directivesMux.RLock()
+	defer directivesMux.RUnlock()
+	d, ok := directives[name]
+	return d, ok
+}
+
+// parseDirective parses this custom record with the Parse function of its directive, once its body is complete.
+func (r *Record) parseDirective(d Directive) error {
+	if d.Parse == nil {
+		return nil
+	}
+
+	value, err := d.Parse(r.directiveArgs, r.directiveBody)
+	if err != nil {
+		return fmt.Errorf("invalid %s on line %d: %v", d.Name, r.lineNum, err)
+	}
+	r.directiveValue = value
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MetricAssertion is a bound on an execution metric of a record, as given by an expect-metric directive, e.g.
+// "expect-metric rows_examined <= 100".
+type MetricAssertion struct {
+	// Metric is the name of the metric, as reported by the harness
+	Metric string
+	// Op is the comparison the metric's value must satisfy: one of <, <=, =, >= and >
+	Op string
+	// Bound is the value the metric is compared to
+	Bound float64
+}
+
+// Holds returns whether the value of the metric given satisfies this assertion.
+func (a MetricAssertion) Holds(value float64) bool {
+	switch a.Op {
+	case "<":
+		return value < a.Bound
+	case "<=":
+		return value <= a.Bound
+	case "=":
+		return value == a.Bound
+	case ">=":
+		return value >= a.Bound
+	case ">":
+		return value > a.Bound
+	default:
+		return false
+	}
+}
+
+func (a MetricAssertion) String() string {
+	return a.Metric + " " + a.Op + " " + strconv.FormatFloat(a.Bound, 'g', -1, 64)
+}
+
+// parseMetricAssertion parses the fields of an expect-metric directive after its keyword: a metric name, a comparison
+// and a number.
+func parseMetricAssertion(fields []string) (MetricAssertion, error) {
+	if len(fields) != 3 {
+		return MetricAssertion{}, fmt.Errorf("expected <metric> <comparison> <value>")
+	}
+
+	assertion := MetricAssertion{Metric: fields[0], Op: fields[1]}
+	switch assertion.Op {
+	case "<", "<=", "=", ">=", ">":
+	default:
+		return MetricAssertion{}, fmt.Errorf("unknown comparison %s", assertion.Op)
+	}
+
+	bound, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return MetricAssertion{}, fmt.Errorf("invalid value %s", fields[2])
+	}
+	assertion.Bound = bound
+	return assertion, nil
+}
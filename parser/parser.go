@@ -19,56 +19,218 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
 const (
 	Separator            = "----"
 	halt                 = "halt"
+	haltRun              = "run"
 	hashThreshold        = "hash-threshold"
 	skipif               = "skipif"
 	onlyif               = "onlyif"
+	floatEpsilon         = "float-epsilon"
+	timeoutDirective     = "timeout"
+	ambiguousSchema      = "ambiguous-schema"
+	prepared             = "prepared"
+	bind                 = "bind"
+	requireDirective     = "require"
+	route                = "route"
+	txn                  = "txn"
+	connection           = "connection"
+	async                = "async"
+	awaitStatement       = "awaitstatement"
+	awaitDeadlock        = "awaitdeadlock"
+	awaitLockTimeout     = "awaitlocktimeout"
+	warningDirective     = "warning"
+	warningsDirective    = "warnings"
+	tableChecksum        = "table-checksum"
+	snapshot             = "snapshot"
+	restore              = "restore"
+	setSeed              = "set-seed"
+	colnames             = "colnames"
+	expectPlanContains   = "expect-plan-contains"
+	expectPlanNotContain = "expect-plan-not-contains"
+	expectMetric         = "expect-metric"
+	expectErrorMessage   = "expect-error-message"
 	defaultHashThreshold = 8
 	hashThresholdUnset   = -1
 )
 
 // ParseTestFile parses a sqllogictest file and returns the array of records it contains, or an error if it cannot.
+// Compressed files are decompressed, as described by OpenFile.
 func ParseTestFile(f string) ([]*Record, error) {
-	file, err := os.Open(f)
+	file, err := OpenFile(f)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	var records []*Record
+	return Parse(file)
+}
+
+// ParseTestFileFS parses the sqllogictest file with the name given in the file system given, as ParseTestFile does.
+func ParseTestFileFS(fsys fs.FS, name string) ([]*Record, error) {
+	file, err := OpenFileFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse parses the contents of a sqllogictest file read from the reader given, as ParseTestFile does.
+func Parse(r io.Reader) ([]*Record, error) {
+	records, _, err := ParseWithWarnings(r)
+	return records, err
+}
 
-	scanner := LineScanner{bufio.NewScanner(file), 0}
-	var prevRecord *Record
+// ParseTestFileWithWarnings parses a sqllogictest file as ParseTestFile does, and also returns the warnings about
+// problems that didn't prevent parsing it, in the order of their lines.
+func ParseTestFileWithWarnings(f string) ([]*Record, []ParseWarning, error) {
+	file, err := OpenFile(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	return ParseWithWarnings(file)
+}
 
+// ParseWithWarnings parses the contents of a sqllogictest file read from the reader given, as
+// ParseTestFileWithWarnings does.
+func ParseWithWarnings(r io.Reader) ([]*Record, []ParseWarning, error) {
+	var records []*Record
+
+	reader := NewRecordReader(r)
 	for {
-		record, err := parseRecord(&scanner)
+		record, err := reader.Next()
 		if err == io.EOF {
-			return records, nil
+			return records, reader.Warnings(), nil
 		} else if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// parseSortMode parses the sort mode given from a query or procedure header, as written by Record.SortString.
+func (r *Record) parseSortMode(mode string) error {
+	columns, ok := strings.CutPrefix(mode, string(PartialSort)+"(")
+	if !ok {
+		r.sortMode = SortMode(mode)
+		return nil
+	}
+
+	columns, ok = strings.CutSuffix(columns, ")")
+	if !ok || columns == "" {
+		return fmt.Errorf("invalid sort mode %s, expected %s(<column>,...)", mode, PartialSort)
+	}
+	r.sortMode = PartialSort
+	r.partialSortColumns = nil
+	for _, column := range strings.Split(columns, ",") {
+		position, err := strconv.Atoi(column)
+		if err != nil || position < 1 || position > len(r.schema) {
+			return fmt.Errorf("invalid column %s for %s, expected a position in schema %s", column, PartialSort, r.schema)
+		}
+		r.partialSortColumns = append(r.partialSortColumns, position-1)
+	}
+	return nil
+}
+
+// A RecordReader parses the records of a sqllogictest file one at a time, as they're read, so that the records of
+// large files can be executed without holding the whole file in memory.
+type RecordReader struct {
+	scanner    LineScanner
+	prevRecord *Record
+	closer     io.Closer
+	warnings   []ParseWarning
+	labels     map[string]labeledSchema
+}
+
+// NewRecordReader returns a reader of the records of the sqllogictest file read from the reader given.
+func NewRecordReader(r io.Reader) *RecordReader {
+	return &RecordReader{scanner: LineScanner{bufio.NewScanner(r), 0}}
+}
+
+// OpenTestFile opens the sqllogictest file given to read its records, decompressing it as described by OpenFile. The
+// reader must be closed when done.
+func OpenTestFile(f string) (*RecordReader, error) {
+	file, err := OpenFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := NewRecordReader(file)
+	reader.closer = file
+	return reader, nil
+}
+
+// OpenTestFileFS opens the sqllogictest file with the name given in the file system given to read its records, as
+// OpenTestFile does. The reader must be closed when done.
+func OpenTestFileFS(fsys fs.FS, name string) (*RecordReader, error) {
+	file, err := OpenFileFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := NewRecordReader(file)
+	reader.closer = file
+	return reader, nil
+}
+
+// Next parses and returns the next record of the file, or io.EOF after the last one. Records before an error
+// in the file are returned as usual.
+func (r *RecordReader) Next() (*Record, error) {
+	for {
+		record, err := parseRecord(&r.scanner)
+		if err != nil {
 			return nil, err
 		}
-		if record != nil {
-			if record.hashThreshold == hashThresholdUnset {
-				if prevRecord != nil {
-					record.hashThreshold = prevRecord.hashThreshold
-				} else {
-					record.hashThreshold = defaultHashThreshold
-				}
+		if record == nil {
+			continue
+		}
+
+		if record.recordType == Procedure {
+			if err := record.parseCall(); err != nil {
+				return nil, fmt.Errorf("invalid procedure call on line %d: %v", record.lineNum, err)
 			}
+		}
 
-			prevRecord = record
-			records = append(records, record)
+		if record.hashThreshold == hashThresholdUnset {
+			if r.prevRecord != nil {
+				record.hashThreshold = r.prevRecord.hashThreshold
+			} else {
+				record.hashThreshold = defaultHashThreshold
+			}
 		}
+
+		r.checkRecord(record)
+		r.prevRecord = record
+		return record, nil
 	}
 }
 
+// Warnings returns the warnings about the records read so far, such as a query with several rows of results that
+// gives no sort mode, which are problems that don't prevent parsing the file.
+func (r *RecordReader) Warnings() []ParseWarning {
+	return r.warnings
+}
+
+// Close closes the file opened by OpenTestFile. It does nothing for readers returned by NewRecordReader.
+func (r *RecordReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
 type recordParseState int
 
 const (
@@ -76,6 +238,7 @@ const (
 	stateStatement
 	stateQuery
 	stateResults
+	stateDirectiveBody
 )
 
 var commentRegex = regexp.MustCompile("([^#]*)#?.*")
@@ -105,6 +268,14 @@ func parseRecord(scanner *LineScanner) (*Record, error) {
 
 	state := stateStart
 	queryBuilder := strings.Builder{}
+	// rawLines are the lines of the query as written, and rawComments the comment lines since the last of them, which
+	// are only part of the raw query if more of its lines follow
+	var rawLines, rawComments []string
+	endQuery := func() {
+		record.query = queryBuilder.String()
+		record.rawQuery = strings.Join(rawLines, "\n")
+	}
+	var err error
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -113,6 +284,9 @@ func parseRecord(scanner *LineScanner) (*Record, error) {
 
 		// skip lines that are entirely comments
 		if strings.HasPrefix(line, "#") {
+			if (state == stateStatement || state == stateQuery) && len(rawLines) > 0 {
+				rawComments = append(rawComments, line)
+			}
 			continue
 		}
 
@@ -120,80 +294,362 @@ func parseRecord(scanner *LineScanner) (*Record, error) {
 
 		switch state {
 		case stateStart:
+			// Directives separated from the header by a blank line, e.g. a hash-threshold line, aren't part of the
+			// record's lines
 			if isBlankLine {
+				record.startLine = 0
 				continue
 			}
+			if record.startLine == 0 {
+				record.startLine = scanner.LineNum
+			}
+			record.headerLine = scanner.LineNum
+			record.endLine = scanner.LineNum
 
 			switch fields[0] {
 			case halt:
 				record.recordType = Halt
 				record.lineNum = scanner.LineNum
+				if len(fields) > 1 {
+					if fields[1] != haltRun {
+						return nil, fmt.Errorf("unexpected token %s on line %d", fields[1], scanner.LineNum)
+					}
+					record.haltsRun = true
+				}
+				return record, nil
+			case connection:
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("expected %s <name> on line %d", connection, scanner.LineNum)
+				}
+				record.connection = fields[1]
+			case awaitStatement:
+				record.recordType = AwaitStatement
+				record.lineNum = scanner.LineNum
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("expected %s <name> on line %d", awaitStatement, scanner.LineNum)
+				}
+				record.asyncName = fields[1]
+				return record, nil
+			case awaitDeadlock, awaitLockTimeout:
+				record.recordType = AwaitConflict
+				record.lineNum = scanner.LineNum
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("expected %s <name>... on line %d", fields[0], scanner.LineNum)
+				}
+				record.expectError = true
+				record.expectedError = ConflictDeadlock
+				if fields[0] == awaitLockTimeout {
+					record.expectedError = ConflictLockTimeout
+				}
+				record.asyncNames = fields[1:]
+				return record, nil
+			case snapshot, restore:
+				record.recordType = Snapshot
+				if fields[0] == restore {
+					record.recordType = Restore
+				}
+				record.lineNum = scanner.LineNum
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("expected %s <name> on line %d", fields[0], scanner.LineNum)
+				}
+				record.snapshotName = fields[1]
+				return record, nil
+			case setSeed:
+				record.recordType = SetSeed
+				record.lineNum = scanner.LineNum
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("expected %s <seed> on line %d", setSeed, scanner.LineNum)
+				}
+				record.seed, err = strconv.ParseInt(fields[1], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid seed for %s on line %d: %v", setSeed, scanner.LineNum, err)
+				}
+				return record, nil
+			case tableChecksum:
+				record.recordType = TableChecksum
+				record.lineNum = scanner.LineNum
+				if len(fields) != 2 && len(fields) != 3 {
+					return nil, fmt.Errorf("expected %s <table> [<hash>] on line %d", tableChecksum, scanner.LineNum)
+				}
+				record.table = fields[1]
+				record.query = "SELECT * FROM " + record.table
+				if len(fields) == 3 {
+					record.checksum = fields[2]
+				}
+				return record, nil
+			case txn:
+				record.recordType = Txn
+				record.lineNum = scanner.LineNum
+				if len(fields) != 2 || !txnActions[TxnAction(fields[1])] {
+					return nil, fmt.Errorf("expected txn begin|commit|rollback|active|inactive on line %d", scanner.LineNum)
+				}
+				record.txnAction = TxnAction(fields[1])
 				return record, nil
 			case skipif, onlyif:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing engine for %s on line %d", fields[0], scanner.LineNum)
+				}
 				record.conditions = append(record.conditions, &Condition{
 					isOnly: fields[0] == onlyif,
 					isSkip: fields[0] == skipif,
 					engine: fields[1],
 				})
 			case hashThreshold:
-				record.hashThreshold, _ = strconv.Atoi(fields[1])
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing value for %s on line %d", hashThreshold, scanner.LineNum)
+				}
+				record.hashThreshold, err = strconv.Atoi(fields[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value for %s on line %d: %v", hashThreshold, scanner.LineNum, err)
+				}
+			case floatEpsilon:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing value for %s on line %d", floatEpsilon, scanner.LineNum)
+				}
+				record.floatEpsilon, err = strconv.ParseFloat(fields[1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value for %s on line %d: %v", floatEpsilon, scanner.LineNum, err)
+				}
+				record.hasFloatEpsilon = true
+			case timeoutDirective:
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("expected %s <duration> on line %d", timeoutDirective, scanner.LineNum)
+				}
+				record.timeout, err = time.ParseDuration(fields[1])
+				if err != nil || record.timeout <= 0 {
+					return nil, fmt.Errorf("invalid duration for %s on line %d: %s", timeoutDirective, scanner.LineNum, fields[1])
+				}
+			case ambiguousSchema:
+				if len(fields) != 1 {
+					return nil, fmt.Errorf("unexpected arguments for %s on line %d", ambiguousSchema, scanner.LineNum)
+				}
+				record.ambiguousSchema = true
+			case requireDirective:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing capability for %s on line %d", requireDirective, scanner.LineNum)
+				}
+				record.requires = append(record.requires, fields[1:]...)
+			case route:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing backend for %s on line %d", route, scanner.LineNum)
+				}
+				record.routes = append(record.routes, fields[1:]...)
+			case warningDirective:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing code for %s on line %d", warningDirective, scanner.LineNum)
+				}
+				rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(commentsRemoved), warningDirective))
+				code, message, _ := strings.Cut(rest, " ")
+				record.warnings = append(record.warnings, Warning{Code: code, Message: strings.TrimSpace(message)})
+			case warningsDirective:
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("expected %s <count> on line %d", warningsDirective, scanner.LineNum)
+				}
+				record.warningCount, err = strconv.Atoi(fields[1])
+				if err != nil || record.warningCount < 0 {
+					return nil, fmt.Errorf("invalid count for %s on line %d", warningsDirective, scanner.LineNum)
+				}
+				record.warningCountSet = true
+			case expectPlanContains, expectPlanNotContain:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing operator for %s on line %d", fields[0], scanner.LineNum)
+				}
+				record.planAssertions = append(record.planAssertions, PlanAssertion{
+					Operator: strings.Join(fields[1:], " "),
+					Contains: fields[0] == expectPlanContains,
+				})
+			case expectErrorMessage:
+				pattern := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(commentsRemoved), expectErrorMessage))
+				if pattern == "" {
+					return nil, fmt.Errorf("missing pattern for %s on line %d", expectErrorMessage, scanner.LineNum)
+				}
+				if _, err := regexp.Compile(pattern); err != nil {
+					return nil, fmt.Errorf("invalid pattern for %s on line %d: %v", expectErrorMessage,
+						scanner.LineNum, err)
+				}
+				record.errorPattern = pattern
+			case expectMetric:
+				assertion, err := parseMetricAssertion(fields[1:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s on line %d: %v", expectMetric, scanner.LineNum, err)
+				}
+				record.metricAssertions = append(record.metricAssertions, assertion)
+			case colnames:
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing column names for %s on line %d", colnames, scanner.LineNum)
+				}
+				record.columnNames = fields[1:]
+			case prepared:
+				record.prepared = true
+			case bind:
+				record.prepared = true
+				record.bindArgs, err = parseBindArgs(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), bind)))
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s on line %d: %v", bind, scanner.LineNum, err)
+				}
 			case "statement":
 				record.recordType = Statement
+				if record.warningCountSet && len(record.warnings) > 0 && record.warningCount != len(record.warnings) {
+					return nil, fmt.Errorf("%s %d disagrees with the %d %s directives before line %d", warningsDirective,
+						record.warningCount, len(record.warnings), warningDirective, scanner.LineNum)
+				}
+				if len(fields) > 1 && fields[1] == async {
+					if len(fields) < 4 {
+						return nil, fmt.Errorf("expected statement async <name> ok|error on line %d", scanner.LineNum)
+					}
+					// Later records run on the default connection, so an async statement on it would race with them
+					if record.connection == "" {
+						return nil, fmt.Errorf("async statement on line %d must run on a named connection", scanner.LineNum)
+					}
+					record.asyncName = fields[2]
+					fields = fields[2:]
+				}
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("expected statement ok|error on line %d", scanner.LineNum)
+				}
 				if fields[1] == "ok" {
 					record.expectError = false
 				} else if fields[1] == "error" {
 					record.expectError = true
+					if len(fields) > 2 {
+						record.expectedError = fields[2]
+					}
 				} else {
 					return nil, errors.New("unexpected token " + fields[1])
 				}
 				state = stateStatement
 			case "query":
 				record.recordType = Query
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing schema for query on line %d", scanner.LineNum)
+				}
 				record.schema = fields[1]
+				if strings.Contains(fields[1], ",") {
+					record.schemas = strings.Split(fields[1], ",")
+					record.schema = record.schemas[0]
+				}
 				if len(fields) > 2 {
-					record.sortMode = SortMode(fields[2])
+					if err := record.parseSortMode(fields[2]); err != nil {
+						return nil, fmt.Errorf("%v on line %d", err, scanner.LineNum)
+					}
 				} else {
 					record.sortMode = NoSort
+					record.sortModeOmitted = true
 				}
 				if len(fields) > 3 {
 					record.label = fields[3]
 				}
 				state = stateQuery
+			case "procedure":
+				record.recordType = Procedure
+				if len(fields) < 2 {
+					return nil, fmt.Errorf("missing schema for procedure on line %d", scanner.LineNum)
+				}
+				switch fields[1] {
+				case "ok":
+				case "error":
+					record.expectError = true
+				default:
+					record.schemas = strings.Split(fields[1], ",")
+					record.schema = record.schemas[0]
+				}
+				if len(fields) > 2 {
+					if err := record.parseSortMode(fields[2]); err != nil {
+						return nil, fmt.Errorf("%v on line %d", err, scanner.LineNum)
+					}
+				} else {
+					record.sortMode = NoSort
+				}
+				state = stateQuery
 			default:
-				return nil, fmt.Errorf("Unhandled statement %s on line %d", fields[0], scanner.LineNum)
+				d, ok := lookupDirective(fields[0])
+				if !ok {
+					return nil, fmt.Errorf("Unhandled statement %s on line %d", fields[0], scanner.LineNum)
+				}
+
+				record.recordType = Custom
+				record.lineNum = scanner.LineNum
+				record.directive = d.Name
+				record.directiveArgs = fields[1:]
+				if d.Body {
+					state = stateDirectiveBody
+					continue
+				}
+				if err := record.parseDirective(d); err != nil {
+					return nil, err
+				}
+				return record, nil
 			}
 
 		case stateStatement:
 			if isBlankLine {
-				record.query = queryBuilder.String()
+				endQuery()
 				return record, nil
 			}
 
 			if record.lineNum == 0 {
 				record.lineNum = scanner.LineNum
 			}
+			record.endLine = scanner.LineNum
+			if len(fields) == 1 && fields[0] == Separator {
+				record.warn(scanner.LineNum, "statement records have no results, so the %s line and the lines after it are "+
+					"parsed as part of the statement on line %d", Separator, record.lineNum)
+			}
 
-			queryBuilder.WriteString(commentsRemoved)
+			rawLines = append(append(rawLines, rawComments...), line)
+			rawComments = nil
+			queryBuilder.WriteString(StripComment(line))
 		case stateQuery:
 			if record.lineNum == 0 {
 				record.lineNum = scanner.LineNum
 			}
 
 			if len(fields) == 1 && fields[0] == Separator {
-				record.query = queryBuilder.String()
+				endQuery()
+				record.separatorLine = scanner.LineNum
 				state = stateResults
 			} else if isBlankLine {
-				record.query = queryBuilder.String()
+				endQuery()
+				return record, nil
+			} else {
+				rawLines = append(append(rawLines, rawComments...), line)
+				rawComments = nil
+			}
+			record.endLine = scanner.LineNum
+
+			queryBuilder.WriteString(StripComment(line))
+		case stateDirectiveBody:
+			if isBlankLine {
+				d, _ := lookupDirective(record.directive)
+				if err := record.parseDirective(d); err != nil {
+					return nil, err
+				}
 				return record, nil
 			}
 
-			queryBuilder.WriteString(commentsRemoved)
+			record.endLine = scanner.LineNum
+			record.directiveBody = append(record.directiveBody, strings.TrimRightFunc(commentsRemoved, unicode.IsSpace))
 		case stateResults:
 			if isBlankLine {
 				return record, nil
 			}
+			record.endLine = scanner.LineNum
 
-			record.result = append(record.result, commentsRemoved)
+			// Queries with multiple result sets separate the results of each with a separator line
+			if len(record.schemas) > 1 && len(fields) == 1 && fields[0] == Separator {
+				if record.resultSets == nil {
+					record.resultSets = [][]string{record.result}
+				}
+				record.resultSets = append(record.resultSets, nil)
+				continue
+			}
+
+			if len(record.resultSets) > 1 {
+				last := len(record.resultSets) - 1
+				record.resultSets[last] = append(record.resultSets[last], unescapeResult(line))
+			} else {
+				record.result = append(record.result, unescapeResult(line))
+			}
 		}
 	}
 
@@ -206,8 +662,13 @@ func parseRecord(scanner *LineScanner) (*Record, error) {
 	}
 
 	switch state {
-	case stateStatement:
-		record.query = queryBuilder.String()
+	case stateStatement, stateQuery:
+		endQuery()
+	case stateDirectiveBody:
+		d, _ := lookupDirective(record.directive)
+		if err := record.parseDirective(d); err != nil {
+			return nil, err
+		}
 	}
 
 	return record, nil
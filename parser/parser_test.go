@@ -15,8 +15,12 @@
 package parser
 
 import (
+	"bufio"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,6 +37,9 @@ func TestParseFile(t *testing.T) {
 			expectError: false,
 			query:       "CREATE TABLE t1(a INTEGER, b INTEGER, c INTEGER, d INTEGER, e INTEGER)",
 			lineNum:     2,
+			startLine: 1,
+			headerLine: 1,
+			endLine: 2,
 			hashThreshold: 8,
 		},
 		{
@@ -40,6 +47,9 @@ func TestParseFile(t *testing.T) {
 			expectError: false,
 			query:       "INSERT INTO t1(e,c,b,d,a) VALUES(103,102,100,101,104)",
 			lineNum:     5,
+			startLine: 4,
+			headerLine: 4,
+			endLine: 5,
 			hashThreshold: 8,
 		},
 		{
@@ -47,11 +57,17 @@ func TestParseFile(t *testing.T) {
 			expectError: true,
 			query:       "INSERT INTO t1(a,c,d,e,b) VALUES(107,106,108,109,105)",
 			lineNum:     8,
+			startLine: 7,
+			headerLine: 7,
+			endLine: 8,
 			hashThreshold: 8,
 		},
 		{
 			recordType: Halt,
 			lineNum:    11,
+			startLine: 11,
+			headerLine: 11,
+			endLine: 11,
 			hashThreshold: 8,
 		},
 		{
@@ -63,6 +79,10 @@ func TestParseFile(t *testing.T) {
  ORDER BY 1`),
 			result:  []string{"30 values hashing to 3c13dee48d9356ae19af2515e05e6b54"},
 			lineNum: 14,
+			startLine: 13,
+			headerLine: 13,
+			separatorLine: 17,
+			endLine: 18,
 			hashThreshold: 8,
 		},
 		{
@@ -76,6 +96,10 @@ func TestParseFile(t *testing.T) {
  ORDER BY 1,2`),
 			result:  []string{"60 values hashing to 808146289313018fce25f1a280bd8c30"},
 			lineNum: 29,
+			startLine: 28,
+			headerLine: 28,
+			separatorLine: 33,
+			endLine: 34,
 			hashThreshold: 16,
 		},
 		{
@@ -87,6 +111,9 @@ func TestParseFile(t *testing.T) {
 				},
 			},
 			lineNum: 37,
+			startLine: 36,
+			headerLine: 37,
+			endLine: 37,
 			hashThreshold: 16,
 		},
 		{
@@ -112,6 +139,10 @@ func TestParseFile(t *testing.T) {
 			},
 			result:  []string{"1", "2", "3", "4", "5"},
 			lineNum: 41,
+			startLine: 39,
+			headerLine: 40,
+			separatorLine: 52,
+			endLine: 57,
 			hashThreshold: 16,
 		},
 		{
@@ -133,6 +164,10 @@ func TestParseFile(t *testing.T) {
 			},
 			result:  []string{"-3", "222", "-3", "222", "-1", "222", "-1", "222"},
 			lineNum: 62,
+			startLine: 60,
+			headerLine: 61,
+			separatorLine: 69,
+			endLine: 77,
 			hashThreshold: 16,
 		},
 		{
@@ -146,6 +181,9 @@ func TestParseFile(t *testing.T) {
   x1 VARCHAR(30)
 )`),
 			lineNum: 80,
+			startLine: 79,
+			headerLine: 79,
+			endLine: 87,
 			hashThreshold: 16,
 		},
 		{
@@ -159,6 +197,10 @@ func TestParseFile(t *testing.T) {
     AND a29=b51
     AND b55=a31`),
 			lineNum: 90,
+			startLine: 89,
+			headerLine: 89,
+			separatorLine: 96,
+			endLine: 100,
 			schema: "TTTT",
 			result: []string {"table t29 row 6", "table t31 row 9", "table t51 row 5", "table t55 row 4"},
 			hashThreshold: 16,
@@ -168,6 +210,10 @@ func TestParseFile(t *testing.T) {
 			sortMode: NoSort,
 			query: removeNewlines(`SELECT 1 FROM t1 WHERE 1.0 IN ()`),
 			lineNum: 106,
+			startLine: 102,
+			headerLine: 105,
+			separatorLine: 107,
+			endLine: 107,
 			schema: "I",
 			conditions: []*Condition{
 				{
@@ -187,9 +233,589 @@ func TestParseFile(t *testing.T) {
 		},
 	}
 
+	// The raw queries keep the line breaks between the lines of the queries, and are otherwise the same
+	for _, record := range records {
+		assert.Equal(t, record.query, removeNewlines(record.rawQuery))
+		record.rawQuery = ""
+	}
 	assert.Equal(t, expectedRecords, records)
 }
 
 func removeNewlines(s string) string {
 	return strings.ReplaceAll(s, "\n", "")
 }
+
+func TestParseDirectives(t *testing.T) {
+	records, err := ParseTestFile("testdata/directives.test")
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	assert.Equal(t, 0.001, records[0].FloatEpsilon())
+	assert.Equal(t, 0.0, records[1].FloatEpsilon())
+	assert.True(t, records[0].HasFloatEpsilon())
+	assert.False(t, records[1].HasFloatEpsilon())
+
+	assert.Empty(t, records[1].Requires())
+	assert.Equal(t, []string{"cte", "window-functions", "full-outer-join"}, records[2].Requires())
+
+	assert.Zero(t, records[1].Timeout())
+	assert.Equal(t, 10*time.Second, records[2].Timeout())
+
+	for _, invalid := range []string{"timeout\n", "timeout 10\n", "timeout -1s\n", "timeout 1s 2s\n"} {
+		_, err := Parse(strings.NewReader(invalid + "query I nosort\nSELECT 1\n"))
+		assert.Error(t, err, invalid)
+	}
+
+	assert.Empty(t, records[2].Routes())
+	assert.Equal(t, Statement, records[3].Type())
+	assert.Equal(t, []string{"ks/-80", "ks/80-", "ks/-80"}, records[3].Routes())
+}
+
+func TestParseTxn(t *testing.T) {
+	records, err := ParseTestFile("testdata/txn.test")
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	assert.Equal(t, Txn, records[0].Type())
+	assert.Equal(t, TxnBegin, records[0].TxnAction())
+	assert.Equal(t, 1, records[0].LineNum())
+	assert.Equal(t, Statement, records[1].Type())
+	assert.Equal(t, TxnActive, records[2].TxnAction())
+	assert.Equal(t, TxnRollback, records[3].TxnAction())
+	assert.False(t, records[3].ShouldExecuteForEngine("postgresql"))
+	assert.Equal(t, TxnInactive, records[4].TxnAction())
+	assert.Equal(t, 9, records[4].LineNum())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("txn savepoint\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseAsync(t *testing.T) {
+	records, err := ParseTestFile("testdata/async.test")
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	assert.Equal(t, "c1", records[0].Connection())
+	assert.Empty(t, records[0].AsyncName())
+
+	assert.Equal(t, Statement, records[2].Type())
+	assert.Equal(t, "c2", records[2].Connection())
+	assert.Equal(t, "upd", records[2].AsyncName())
+	assert.True(t, records[2].ExpectError())
+	assert.Equal(t, "40001", records[2].ExpectedError())
+	assert.Equal(t, "UPDATE t1 SET a = 3", records[2].Query())
+
+	assert.Equal(t, Txn, records[3].Type())
+	assert.Equal(t, "c1", records[3].Connection())
+
+	assert.Equal(t, AwaitStatement, records[4].Type())
+	assert.Equal(t, "upd", records[4].AsyncName())
+	assert.Empty(t, records[4].Connection())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("statement async ok\nSELECT 1\n"))})
+	assert.Error(t, err)
+
+	// Async statements can't run on the default connection, which later records use
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("statement async s ok\nSELECT 1\n"))})
+	assert.ErrorContains(t, err, "must run on a named connection")
+}
+
+func TestParseAwaitConflict(t *testing.T) {
+	record, err := parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("\nawaitdeadlock a b\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, AwaitConflict, record.Type())
+	assert.Equal(t, 2, record.LineNum())
+	assert.Equal(t, []string{"a", "b"}, record.AsyncNames())
+	assert.Equal(t, ConflictDeadlock, record.ExpectedError())
+
+	record, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("awaitlocktimeout a\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, AwaitConflict, record.Type())
+	assert.Equal(t, []string{"a"}, record.AsyncNames())
+	assert.Equal(t, ConflictLockTimeout, record.ExpectedError())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("awaitdeadlock\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseWarnings(t *testing.T) {
+	records, err := ParseTestFile("testdata/warnings.test")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.True(t, records[0].ExpectsWarnings())
+	assert.Equal(t, 2, records[0].WarningCount())
+	assert.Equal(t, []Warning{
+		{Code: "1265", Message: "Data truncated for column 'a' at row 1"},
+		{Code: "1264"},
+	}, records[0].Warnings())
+	assert.Equal(t, "1265 Data truncated for column 'a' at row 1", records[0].Warnings()[0].String())
+
+	assert.True(t, records[1].ExpectsWarnings())
+	assert.Equal(t, 0, records[1].WarningCount())
+	assert.Empty(t, records[1].Warnings())
+
+	assert.False(t, records[2].ExpectsWarnings())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("warnings 2\nwarning 1265\nstatement ok\nSELECT 1\n"))})
+	assert.Error(t, err)
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("warnings some\nstatement ok\nSELECT 1\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseTableChecksum(t *testing.T) {
+	record, err := parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("\ntable-checksum t1 0123abcd\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, TableChecksum, record.Type())
+	assert.Equal(t, 2, record.LineNum())
+	assert.Equal(t, "t1", record.Table())
+	assert.Equal(t, "0123abcd", record.Checksum())
+	assert.Equal(t, "SELECT * FROM t1", record.Query())
+
+	record, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("table-checksum t1\n"))})
+	require.NoError(t, err)
+	assert.Empty(t, record.Checksum())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("table-checksum\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseSnapshot(t *testing.T) {
+	record, err := parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("snapshot seeded\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, Snapshot, record.Type())
+	assert.Equal(t, 1, record.LineNum())
+	assert.Equal(t, "seeded", record.SnapshotName())
+
+	record, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("restore seeded\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, Restore, record.Type())
+	assert.Equal(t, "seeded", record.SnapshotName())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("restore\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseSetSeed(t *testing.T) {
+	record, err := parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("set-seed -42\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, SetSeed, record.Type())
+	assert.Equal(t, int64(-42), record.Seed())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("set-seed x\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseHalt(t *testing.T) {
+	records, err := ParseTestFile("testdata/halt.test")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, Halt, records[0].Type())
+	assert.False(t, records[0].HaltsRun())
+	assert.Equal(t, Halt, records[1].Type())
+	assert.True(t, records[1].HaltsRun())
+	assert.False(t, records[1].ShouldExecuteForEngine("postgresql"))
+}
+
+func TestParseMultipleResultSets(t *testing.T) {
+	records, err := ParseTestFile("testdata/multiresult.test")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	record := records[0]
+	assert.Equal(t, 3, record.NumResultSets())
+	assert.Equal(t, "I", record.Schema())
+	assert.Equal(t, []string{"1"}, record.Result())
+
+	sets := record.ResultSets()
+	require.Len(t, sets, 3)
+	assert.Equal(t, "I", sets[0].Schema())
+	assert.Equal(t, []string{"1"}, sets[0].Result())
+	assert.Equal(t, "IT", sets[1].Schema())
+	assert.Equal(t, []string{"2", "two"}, sets[1].Result())
+	assert.Equal(t, "I", sets[2].Schema())
+	assert.Empty(t, sets[2].Result())
+	assert.Equal(t, 8, sets[2].HashThreshold())
+
+	assert.Equal(t, 1, records[1].NumResultSets())
+	assert.Equal(t, []*Record{records[1]}, records[1].ResultSets())
+}
+
+func TestParseProcedures(t *testing.T) {
+	records, err := ParseTestFile("testdata/procedure.test")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, Procedure, records[0].Type())
+	assert.False(t, records[0].ExpectError())
+	assert.Equal(t, "reset_counts", records[0].ProcedureName())
+	assert.Empty(t, records[0].ProcedureArgs())
+	assert.Equal(t, 0, records[0].NumResultSets())
+
+	assert.True(t, records[1].ExpectError())
+	assert.Equal(t, "missing_proc", records[1].ProcedureName())
+	assert.Equal(t, []ProcedureArg{{Mode: ParamIn, Value: "1"}}, records[1].ProcedureArgs())
+
+	record := records[2]
+	assert.Equal(t, "count_rows", record.ProcedureName())
+	assert.Equal(t, []ProcedureArg{
+		{Mode: ParamIn, Value: "'t1, t2'"},
+		{Mode: ParamOut, Name: "total"},
+		{Mode: ParamInOut, Name: "seen", Value: "(1 + 2)"},
+	}, record.ProcedureArgs())
+	assert.Equal(t, 2, record.NumOutParams())
+	assert.Equal(t, 2, record.NumResultSets())
+
+	sets := record.ResultSets()
+	require.Len(t, sets, 2)
+	assert.Equal(t, Query, sets[0].Type())
+	assert.Equal(t, NoSort, sets[0].SortMode())
+	assert.Equal(t, []string{"2"}, sets[0].Result())
+	assert.Equal(t, ValueSort, sets[1].SortMode())
+	assert.Equal(t, []string{"2", "two"}, sets[1].Result())
+}
+
+func TestParseExpectedErrors(t *testing.T) {
+	records, err := ParseTestFile("testdata/errors.test")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	for _, record := range records {
+		assert.True(t, record.ExpectError())
+	}
+	assert.Equal(t, "", records[0].ExpectedError())
+	assert.Equal(t, "23000", records[1].ExpectedError())
+	assert.Equal(t, "not-found", records[2].ExpectedError())
+}
+
+func TestParsePrepared(t *testing.T) {
+	records, err := ParseTestFile("testdata/prepared.test")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.True(t, records[0].Prepared())
+	assert.Equal(t, []interface{}{int64(1), -2.5, "it's # not a comment", nil}, records[0].BindArgs())
+
+	assert.True(t, records[1].Prepared())
+	assert.Empty(t, records[1].BindArgs())
+
+	assert.False(t, records[2].Prepared())
+}
+
+func TestParseBindArgsErrors(t *testing.T) {
+	_, err := parseBindArgs("'unterminated")
+	assert.Error(t, err)
+	_, err = parseBindArgs("1 abc")
+	assert.Error(t, err)
+}
+
+func TestParseCustomDirectives(t *testing.T) {
+	defer func() {
+		directivesMux.Lock()
+		delete(directives, "test_branch")
+		delete(directives, "test_analyze")
+		directivesMux.Unlock()
+	}()
+	RegisterDirective(Directive{Name: "test_branch"})
+	RegisterDirective(Directive{
+		Name: "test_analyze",
+		Body: true,
+		Parse: func(args []string, body []string) (interface{}, error) {
+			if len(body) == 0 {
+				return nil, errors.New("no tables to analyze")
+			}
+			return len(body), nil
+		},
+	})
+
+	scanner := &LineScanner{Scanner: bufio.NewScanner(strings.NewReader(
+		"onlyif dolt\ntest_branch create b1\n\ntest_analyze full\nt1\nt2 # comment\n\nstatement ok\nSELECT 1\n"))}
+	record, err := parseRecord(scanner)
+	require.NoError(t, err)
+	assert.Equal(t, Custom, record.Type())
+	assert.Equal(t, 2, record.LineNum())
+	assert.Equal(t, "test_branch", record.Directive())
+	assert.Equal(t, []string{"create", "b1"}, record.DirectiveArgs())
+	assert.Empty(t, record.DirectiveBody())
+	assert.Nil(t, record.DirectiveValue())
+	assert.False(t, record.ShouldExecuteForEngine("mysql"))
+
+	record, err = parseRecord(scanner)
+	require.NoError(t, err)
+	assert.Equal(t, Custom, record.Type())
+	assert.Equal(t, 4, record.LineNum())
+	assert.Equal(t, []string{"full"}, record.DirectiveArgs())
+	assert.Equal(t, []string{"t1", "t2"}, record.DirectiveBody())
+	assert.Equal(t, 2, record.DirectiveValue())
+
+	record, err = parseRecord(scanner)
+	require.NoError(t, err)
+	assert.Equal(t, Statement, record.Type())
+
+	// Bodies may end at the end of the file
+	record, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("test_analyze\nt1"))})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t1"}, record.DirectiveBody())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("test_analyze\n\n"))})
+	assert.EqualError(t, err, "invalid test_analyze on line 1: no tables to analyze")
+
+	assert.Panics(t, func() { RegisterDirective(Directive{Name: "test_branch"}) })
+	assert.Panics(t, func() { RegisterDirective(Directive{Name: "statement"}) })
+	assert.Panics(t, func() { RegisterDirective(Directive{}) })
+}
+
+func TestParseColumnNames(t *testing.T) {
+	record, err := parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader(
+		"colnames a count(*)\nquery II rowsort\nSELECT a, count(*) FROM t1 GROUP BY a\n"))})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "count(*)"}, record.ColumnNames())
+
+	record, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("query I\nSELECT 1\n"))})
+	require.NoError(t, err)
+	assert.Nil(t, record.ColumnNames())
+
+	_, err = parseRecord(&LineScanner{Scanner: bufio.NewScanner(strings.NewReader("colnames\nquery I\nSELECT 1\n"))})
+	assert.Error(t, err)
+}
+
+func TestParseMissingArguments(t *testing.T) {
+	for _, contents := range []string{
+		"skipif\nstatement ok\nCREATE TABLE t1(a INTEGER)\n",
+		"onlyif\nstatement ok\nCREATE TABLE t1(a INTEGER)\n",
+		"hash-threshold\n",
+		"hash-threshold many\n",
+		"statement\nCREATE TABLE t1(a INTEGER)\n",
+		"query\nSELECT 1\n",
+	} {
+		_, err := Parse(strings.NewReader(contents))
+		assert.Error(t, err, contents)
+	}
+}
+
+func TestRecordReader(t *testing.T) {
+	reader := NewRecordReader(strings.NewReader(`hash-threshold 4
+
+statement ok
+CREATE TABLE t1(a INTEGER)
+
+query I nosort
+SELECT a FROM t1
+----
+
+statement maybe
+INSERT INTO t1 VALUES(1)
+`))
+	defer reader.Close()
+
+	record, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, Statement, record.Type())
+	assert.Equal(t, 4, record.HashThreshold())
+
+	record, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT a FROM t1", record.Query())
+	assert.Equal(t, 4, record.HashThreshold())
+
+	// The records before an error in the file are returned first
+	_, err = reader.Next()
+	assert.Error(t, err)
+}
+
+func TestOpenTestFile(t *testing.T) {
+	reader, err := OpenTestFile("testdata/select1.test")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var streamed []*Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		streamed = append(streamed, record)
+	}
+
+	parsed, err := ParseTestFile("testdata/select1.test")
+	require.NoError(t, err)
+	assert.Equal(t, parsed, streamed)
+
+	_, err = OpenTestFile("testdata/missing.test")
+	assert.Error(t, err)
+}
+
+func TestParsePartialSort(t *testing.T) {
+	records, err := Parse(strings.NewReader("query ITI partialsort(1,3)\nSELECT a, b, c FROM t1 ORDER BY a, c\n----\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, PartialSort, records[0].SortMode())
+	assert.Equal(t, []int{0, 2}, records[0].PartialSortColumns())
+	assert.Equal(t, "partialsort(1,3)", records[0].SortString())
+
+	for _, invalid := range []string{"partialsort()", "partialsort(1", "partialsort(0)", "partialsort(4)", "partialsort(a)"} {
+		_, err := Parse(strings.NewReader("query ITI " + invalid + "\nSELECT a, b, c FROM t1\n"))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseAmbiguousSchema(t *testing.T) {
+	query := "query R nosort\nSELECT 1\n----\n1.000\n"
+	records, err := Parse(strings.NewReader("ambiguous-schema\n" + query + "\n" + query))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.True(t, records[0].AmbiguousSchema())
+	assert.False(t, records[1].AmbiguousSchema())
+
+	_, err = Parse(strings.NewReader("ambiguous-schema 1\nquery R nosort\nSELECT 1\n"))
+	assert.Error(t, err)
+}
+
+func TestParsePlanAssertions(t *testing.T) {
+	records, err := Parse(strings.NewReader("expect-plan-contains IndexScan\nexpect-plan-not-contains  Index  Scan\n" +
+		"query I nosort\nSELECT a FROM t1 WHERE a = 1\n----\n1\n\nquery I nosort\nSELECT 1\n----\n1\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []PlanAssertion{
+		{Operator: "IndexScan", Contains: true},
+		{Operator: "Index Scan", Contains: false},
+	}, records[0].PlanAssertions())
+	assert.Equal(t, "expect-plan-not-contains Index Scan", records[0].PlanAssertions()[1].String())
+	assert.Empty(t, records[1].PlanAssertions())
+
+	_, err = Parse(strings.NewReader("expect-plan-contains\nquery I nosort\nSELECT 1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseMetricAssertions(t *testing.T) {
+	records, err := Parse(strings.NewReader("expect-metric rows_examined <= 100\nexpect-metric spills = 0\n" +
+		"query I nosort\nSELECT a FROM t1 WHERE a = 1\n----\n1\n\nstatement ok\nDELETE FROM t1\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []MetricAssertion{
+		{Metric: "rows_examined", Op: "<=", Bound: 100},
+		{Metric: "spills", Op: "=", Bound: 0},
+	}, records[0].MetricAssertions())
+	assert.Equal(t, "rows_examined <= 100", records[0].MetricAssertions()[0].String())
+	assert.True(t, records[0].MetricAssertions()[0].Holds(100))
+	assert.False(t, records[0].MetricAssertions()[0].Holds(101))
+	assert.Empty(t, records[1].MetricAssertions())
+
+	for _, invalid := range []string{
+		"expect-metric rows_examined\n",
+		"expect-metric rows_examined <= 100 200\n",
+		"expect-metric rows_examined ~ 100\n",
+		"expect-metric rows_examined <= many\n",
+	} {
+		_, err = Parse(strings.NewReader(invalid + "query I nosort\nSELECT 1\n"))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseExpectedErrorPattern(t *testing.T) {
+	records, err := Parse(strings.NewReader("expect-error-message relation \"t\\d+\" does not exist\nstatement error\n" +
+		"SELECT * FROM t1\n\nstatement error\nSELECT * FROM t2\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, `relation "t\d+" does not exist`, records[0].ExpectedErrorPattern())
+	assert.Empty(t, records[1].ExpectedErrorPattern())
+
+	for _, invalid := range []string{"expect-error-message\n", "expect-error-message (unclosed\n"} {
+		_, err = Parse(strings.NewReader(invalid + "statement error\nSELECT 1\n"))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseCommentCharacters(t *testing.T) {
+	records, err := Parse(strings.NewReader("query TTT nosort # comment\nSELECT '#', \"a#b\", `#` # comment\n" +
+		"# comment line\n----\n\\#\na # b\n# comment line\n\\\\#\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "SELECT '#', \"a#b\", `#` ", records[0].Query())
+	assert.Equal(t, []string{"#", "a # b", `\#`}, records[0].Result())
+}
+
+func TestStripComment(t *testing.T) {
+	for line, expected := range map[string]string{
+		`SELECT 'it\'s' # x`:   `SELECT 'it\'s' `,
+		`SELECT 'it''s' # x`:   `SELECT 'it''s' `,
+		`SELECT "a\"#b" # x`:  `SELECT "a\"#b" `,
+		`SELECT 'a\\' # x`:    `SELECT 'a\\' `,
+		"SELECT `a\\` # x":   "SELECT `a\\` ",
+		`SELECT 1 # it\'s`:    `SELECT 1 `,
+		`SELECT '#\'' FROM t1`: `SELECT '#\'' FROM t1`,
+	} {
+		assert.Equal(t, expected, StripComment(line), line)
+	}
+}
+
+func TestEscapeResult(t *testing.T) {
+	for _, value := range []string{"#", "# x", `\#`, `\\#`, `a#`, `\`, `\a`, ""} {
+		escaped := EscapeResult(value)
+		assert.False(t, strings.HasPrefix(escaped, "#"), value)
+		assert.Equal(t, value, unescapeResult(escaped), value)
+	}
+	assert.Equal(t, `\#`, EscapeResult("#"))
+	assert.Equal(t, `a#`, EscapeResult("a#"))
+	assert.Equal(t, `\a`, EscapeResult(`\a`))
+}
+
+func TestParseRawQuery(t *testing.T) {
+	records, err := Parse(strings.NewReader("# leading comment\nquery I nosort\nSELECT a # the column\n" +
+		"# a comment line\n  FROM t1\n# trailing comment\n----\n1\n\nstatement ok\nDELETE FROM t1\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "SELECT a   FROM t1", records[0].Query())
+	assert.Equal(t, "SELECT a # the column\n# a comment line\n  FROM t1", records[0].RawQuery())
+	assert.Equal(t, "DELETE FROM t1", records[1].RawQuery())
+
+	rewritten := records[0].WithQuery("SELECT a FROM t1")
+	assert.Equal(t, records[0].RawQuery(), rewritten.RawQuery())
+}
+
+func TestParseHygieneWarnings(t *testing.T) {
+	records, warnings, err := ParseWithWarnings(strings.NewReader(`query II
+SELECT a, b FROM t1
+----
+1
+2
+3
+4
+
+query I
+SELECT a FROM t1 LIMIT 1
+----
+1
+
+query I nosort label-1
+SELECT a FROM t1
+----
+1
+
+query T nosort label-1
+SELECT c FROM t1
+----
+1
+
+statement ok
+INSERT INTO t1 VALUES(1)
+----
+1
+`))
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, []ParseWarning{
+		{Line: 2, Message: "query with 2 rows of results gives no sort mode, so it defaults to nosort"},
+		{Line: 20, Message: "label label-1 is reused with schema T, but the query with the label on line 15 has schema I"},
+		{Line: 26, Message: "statement records have no results, so the ---- line and the lines after it are parsed as " +
+			"part of the statement on line 25"},
+	}, warnings)
+	assert.Equal(t, "line 2: query with 2 rows of results gives no sort mode, so it defaults to nosort",
+		warnings[0].String())
+
+	_, warnings, err = ParseWithWarnings(strings.NewReader("query I nosort\nSELECT 1\n----\n1\n"))
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
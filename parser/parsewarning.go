@@ -0,0 +1,84 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ParseWarning is a problem with the hygiene of a test file found while parsing it that doesn't prevent parsing it,
+// such as a query with several rows of results that gives no sort mode. Parse warnings are returned by
+// RecordReader.Warnings and ParseTestFileWithWarnings.
+type ParseWarning struct {
+	// Line is the line of the test file the warning is about
+	Line int
+	// Message describes the problem
+	Message string
+}
+
+// String returns the warning in the form line N: message.
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// labeledSchema is the schema of the first query with a label, and its line.
+type labeledSchema struct {
+	schema string
+	line   int
+}
+
+// warn records a parse warning about the line given.
+func (r *RecordReader) warn(line int, format string, args ...interface{}) {
+	r.warnings = append(r.warnings, ParseWarning{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// warn records a parse warning about the line given of the record being parsed.
+func (r *Record) warn(line int, format string, args ...interface{}) {
+	r.parseWarnings = append(r.parseWarnings, ParseWarning{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkRecord records the parse warnings about the record given: those found while parsing it, and those that depend
+// on the records before it.
+func (r *RecordReader) checkRecord(record *Record) {
+	r.warnings = append(r.warnings, record.parseWarnings...)
+	if record.recordType != Query {
+		return
+	}
+
+	multiRow := len(record.schemas) == 0 && record.NumCols() > 0 && record.NumResults() > record.NumCols()
+	if record.sortModeOmitted && multiRow {
+		r.warn(record.lineNum, "query with %d rows of results gives no sort mode, so it defaults to %s",
+			record.NumResults()/record.NumCols(), NoSort)
+	}
+
+	if record.label == "" {
+		return
+	}
+	schema := record.schema
+	if len(record.schemas) > 0 {
+		schema = strings.Join(record.schemas, ",")
+	}
+	first, ok := r.labels[record.label]
+	if !ok {
+		if r.labels == nil {
+			r.labels = make(map[string]labeledSchema)
+		}
+		r.labels[record.label] = labeledSchema{schema: schema, line: record.lineNum}
+	} else if first.schema != schema {
+		r.warn(record.lineNum, "label %s is reused with schema %s, but the query with the label on line %d has schema %s",
+			record.label, schema, first.line, first.schema)
+	}
+}
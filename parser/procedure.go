@@ -0,0 +1,116 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parseCall parses the procedure call of a procedure record, which names the procedure followed by its arguments in
+// parentheses. Arguments are SQL expressions for IN parameters, "OUT <name>" for OUT parameters, and
+// "INOUT <name> = <expression>" for INOUT parameters, e.g.:
+// procedure I,II rowsort
+// add_rows(3, 'abc', OUT total)
+// ----
+// 7
+// ----
+// 1 2
+// 3 4
+func (r *Record) parseCall() error {
+	call := strings.TrimSpace(r.query)
+	open := strings.Index(call, "(")
+	if open < 0 || !strings.HasSuffix(call, ")") {
+		return errors.New("expected procedure call of the form name(args...)")
+	}
+
+	r.procedureName = strings.TrimSpace(call[:open])
+	if r.procedureName == "" {
+		return errors.New("missing procedure name")
+	}
+
+	r.procedureArgs = nil
+	for _, arg := range splitArgs(call[open+1 : len(call)-1]) {
+		parsed, err := parseProcedureArg(arg)
+		if err != nil {
+			return err
+		}
+		r.procedureArgs = append(r.procedureArgs, parsed)
+	}
+
+	return nil
+}
+
+// parseProcedureArg parses a single argument of a procedure call.
+func parseProcedureArg(arg string) (ProcedureArg, error) {
+	arg = strings.TrimSpace(arg)
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		return ProcedureArg{}, errors.New("empty argument")
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "OUT":
+		if len(fields) != 2 {
+			return ProcedureArg{}, fmt.Errorf("expected OUT <name>, got %s", arg)
+		}
+		return ProcedureArg{Mode: ParamOut, Name: fields[1]}, nil
+	case "INOUT":
+		rest := strings.TrimSpace(arg[len(fields[0]):])
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			return ProcedureArg{}, fmt.Errorf("expected INOUT <name> = <value>, got %s", arg)
+		}
+		name := strings.TrimSpace(rest[:eq])
+		value := strings.TrimSpace(rest[eq+1:])
+		if name == "" || value == "" {
+			return ProcedureArg{}, fmt.Errorf("expected INOUT <name> = <value>, got %s", arg)
+		}
+		return ProcedureArg{Mode: ParamInOut, Name: name, Value: value}, nil
+	default:
+		return ProcedureArg{Mode: ParamIn, Value: arg}, nil
+	}
+}
+
+// splitArgs splits the argument list of a procedure call on commas, ignoring commas inside quotes or parentheses.
+func splitArgs(args string) []string {
+	if strings.TrimSpace(args) == "" {
+		return nil
+	}
+
+	var split []string
+	var quote rune
+	depth := 0
+	start := 0
+	for i, c := range args {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			split = append(split, args[start:i])
+			start = i + 1
+		}
+	}
+	return append(split, args[start:])
+}
@@ -17,8 +17,11 @@ package parser
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type SortMode string
@@ -27,6 +30,10 @@ const (
 	NoSort    SortMode = "nosort"
 	Rowsort   SortMode = "rowsort"
 	ValueSort SortMode = "valuesort"
+	// PartialSort checks the order of the rows by some of their columns only, and compares the rows that have equal
+	// values in those columns as multisets, for queries that order by columns that aren't unique. It's written with
+	// the 1-based positions of the ordered columns, e.g. partialsort(1,2).
+	PartialSort SortMode = "partialsort"
 )
 
 type RecordType int
@@ -36,33 +43,213 @@ const (
 	Statement RecordType = iota
 	// Query is a record to execute and validate that results are as expected
 	Query
-	// Halt is a record that terminates the current test script's execution
+	// Halt is a record that terminates the current test script's execution, or the entire run for "halt run"
 	Halt
+	// Procedure is a record that calls a stored procedure and optionally validates its OUT parameters and result sets
+	Procedure
+	// Txn is a record that controls the current transaction or asserts whether one is active
+	Txn
+	// AwaitStatement is a record that waits for an async statement to complete and validates its outcome
+	AwaitStatement
+	// AwaitConflict is a record that waits for several async statements to complete, and validates that one of them
+	// failed with a deadlock or lock wait timeout
+	AwaitConflict
+	// TableChecksum is a record that validates the MD5 checksum of the full contents of a table
+	TableChecksum
+	// Snapshot is a record that saves the state of the database under a name
+	Snapshot
+	// Restore is a record that restores the state of the database saved by an earlier snapshot record
+	Restore
+	// SetSeed is a record that seeds the engine's random number generator
+	SetSeed
+	// Custom is a record of a custom directive registered with RegisterDirective
+	Custom
 )
 
+const (
+	// ConflictDeadlock is the error an awaitdeadlock record expects, an error category
+	ConflictDeadlock = "deadlock"
+	// ConflictLockTimeout is the error an awaitlocktimeout record expects, an error category
+	ConflictLockTimeout = "lock-timeout"
+)
+
+// TxnAction is the action of a txn record.
+type TxnAction string
+
+const (
+	// TxnBegin begins a transaction
+	TxnBegin TxnAction = "begin"
+	// TxnCommit commits the current transaction
+	TxnCommit TxnAction = "commit"
+	// TxnRollback rolls back the current transaction
+	TxnRollback TxnAction = "rollback"
+	// TxnActive asserts that a transaction is active
+	TxnActive TxnAction = "active"
+	// TxnInactive asserts that no transaction is active
+	TxnInactive TxnAction = "inactive"
+)
+
+// txnActions are the valid actions of txn records.
+var txnActions = map[TxnAction]bool{
+	TxnBegin:    true,
+	TxnCommit:   true,
+	TxnRollback: true,
+	TxnActive:   true,
+	TxnInactive: true,
+}
+
 // A test script contains many Records, which can be either statements to execute or queries with results.
 type Record struct {
 	// The type of this record
 	recordType RecordType
 	// Whether this record expects an error to occur on execution.
 	expectError bool
+	// The SQLSTATE or error category of the error this record expects, if it names one
+	expectedError string
+	// The regular expression the text of the error this record expects must match, if it gives one
+	errorPattern string
 	// The conditions for executing this record, if applicable
 	conditions []*Condition
 	// The schema for results of this query record, in the form e.g. "ITTR"
 	schema string
 	// The sort mode for validating results of a query
 	sortMode SortMode
+	// Whether the header of this query gave no sort mode, so that it defaulted to nosort
+	sortModeOmitted bool
+	// The problems found while parsing this record that didn't prevent parsing it
+	parseWarnings []ParseWarning
+	// The 0-based indexes of the columns whose order is checked, for partialsort queries
+	partialSortColumns []int
 	// The query string or statement to execute
 	query string
+	// The query or statement exactly as written in the test file
+	rawQuery string
 	// The canonical line number for this record, which is the first line number of the SQL statement or
 	// query to execute.
 	lineNum int
+	// The lines of the test file this record spans: its first line, including any directives before its header, the
+	// line of its header, the line of the separator before its results if it has one, and its last line. Comment lines
+	// at the end of a record are not part of it.
+	startLine     int
+	headerLine    int
+	separatorLine int
+	endLine       int
 	// The expected result of the query, represented as strings
 	result []string
 	// Label used to store results for a query, currently unused.
 	label string
 	// Hash threshold is the number of records to begin hashing results at
 	hashThreshold int
+	// The tolerance for comparing floating point results of this query, when hasFloatEpsilon is set
+	floatEpsilon    float64
+	hasFloatEpsilon bool
+	// The timeout for executing this record, or 0 to use the runner's
+	timeout time.Duration
+	// Whether the column types of this query are acknowledged to be ambiguous, so I results satisfy R columns even
+	// when schemas are verified strictly
+	ambiguousSchema bool
+	// Whether this halt record terminates the entire run, rather than just the current test script
+	haltsRun bool
+	// The schemas of each result set, for queries with multiple result sets. schema holds the first.
+	schemas []string
+	// The expected results of each result set, for queries with multiple result sets. result holds the first.
+	resultSets [][]string
+	// The name of the procedure to call, for procedure records
+	procedureName string
+	// The arguments to the procedure to call, for procedure records
+	procedureArgs []ProcedureArg
+	// Whether this record must be executed as a prepared statement
+	prepared bool
+	// The values bound to the parameters of this record's prepared statement
+	bindArgs []interface{}
+	// The capabilities an engine must have to execute this record
+	requires []string
+	// The backends a proxy must route this record to
+	routes []string
+	// The operators the query's plan must or must not contain
+	planAssertions []PlanAssertion
+	// The bounds on the engine's execution metrics for this record
+	metricAssertions []MetricAssertion
+	// The action of a txn record
+	txnAction TxnAction
+	// The name of the connection to execute this record on, or empty for the default connection
+	connection string
+	// The name of an async statement, or of the async statement an awaitstatement record waits for
+	asyncName string
+	// The names of the async statements an awaitdeadlock or awaitlocktimeout record waits for
+	asyncNames []string
+	// The warnings a statement must produce, and their number if given by a warnings directive
+	warnings        []Warning
+	warningCount    int
+	warningCountSet bool
+	// The table a table-checksum record reads, and the checksum it expects
+	table    string
+	checksum string
+	// The name of the snapshot a snapshot record saves or a restore record restores
+	snapshotName string
+	// The seed of a set-seed record
+	seed int64
+	// The names of the columns of a query's results, as given by a colnames directive
+	columnNames []string
+	// The name of the directive of a custom record, its arguments and body, and the value parsed from them
+	directive      string
+	directiveArgs  []string
+	directiveBody  []string
+	directiveValue interface{}
+}
+
+// Warning is a warning a statement record expects to produce, as given by a warning directive, e.g.
+// "warning 1265 Data truncated for column 'a' at row 1".
+type Warning struct {
+	// Code is the engine's code for the warning
+	Code string
+	// Message is the text of the warning, or empty to accept any text
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Message == "" {
+		return w.Code
+	}
+	return w.Code + " " + w.Message
+}
+
+// PlanAssertion is an assertion about the plan of a query record, as given by an expect-plan-contains or
+// expect-plan-not-contains directive, e.g. "expect-plan-contains IndexScan".
+type PlanAssertion struct {
+	// Operator is the name of the plan operator, which may contain spaces, e.g. "Index Scan"
+	Operator string
+	// Contains is whether the plan must contain the operator, rather than must not contain it
+	Contains bool
+}
+
+func (a PlanAssertion) String() string {
+	if a.Contains {
+		return "expect-plan-contains " + a.Operator
+	}
+	return "expect-plan-not-contains " + a.Operator
+}
+
+// ParamMode is the mode of a stored procedure parameter.
+type ParamMode int
+
+const (
+	// ParamIn is a parameter whose value is passed to the procedure
+	ParamIn ParamMode = iota
+	// ParamOut is a parameter whose value is set by the procedure
+	ParamOut
+	// ParamInOut is a parameter whose value is passed to the procedure and then set by it
+	ParamInOut
+)
+
+// A ProcedureArg is an argument to a stored procedure called by a procedure record.
+type ProcedureArg struct {
+	// Mode is the mode of the parameter this argument is passed for
+	Mode ParamMode
+	// Name is the name of the variable receiving the value of an OUT or INOUT parameter
+	Name string
+	// Value is the SQL expression passed for an IN or INOUT parameter
+	Value string
 }
 
 // A condition is a directive to execute a record or not depending on the underlying engine being evaluated.
@@ -72,7 +259,17 @@ type Condition struct {
 	engine string
 }
 
-var hashRegex = regexp.MustCompile("(\\d+) values hashing to ([0-9a-f]+)")
+// Engine returns the engine identifier this condition names.
+func (c *Condition) Engine() string {
+	return c.engine
+}
+
+// IsOnly returns whether this is an onlyif condition, rather than a skipif condition.
+func (c *Condition) IsOnly() bool {
+	return c.isOnly
+}
+
+var hashRegex = regexp.MustCompile("(\\d+) values hashing to (?:([a-z0-9]+):)?([0-9a-f]+)")
 
 // Type returns the type of this record.
 func (r *Record) Type() RecordType {
@@ -84,30 +281,109 @@ func (r *Record) ExpectError() bool {
 	return r.expectError
 }
 
+// ExpectedError returns the SQLSTATE or error category of the error this record expects, as written after
+// "statement error", or the empty string if it expects any error. For AwaitConflict records, it's ConflictDeadlock or
+// ConflictLockTimeout.
+func (r *Record) ExpectedError() string {
+	return r.expectedError
+}
+
+// ExpectedErrorPattern returns the regular expression that the text of the error this statement record expects must
+// match, as given by a preceding expect-error-message directive, or the empty string if any text is accepted. The
+// pattern is matched against the text after it's normalized, and can match any part of it.
+func (r *Record) ExpectedErrorPattern() string {
+	return r.errorPattern
+}
+
 // Schema returns the schema for the results of this query, in the form e.g. "ITTR"
 func (r *Record) Schema() string {
 	return r.schema
 }
 
 // Query returns the query for this record, which is either a statement to execute or a query to validate results for.
+// Its lines are concatenated as they are, without their trailing comments.
 func (r *Record) Query() string {
 	return r.query
 }
 
+// RawQuery returns the query for this record exactly as written in the test file, with its line breaks, comments and
+// spacing, for showing to users. Unlike Query, it's kept by WithQuery.
+func (r *Record) RawQuery() string {
+	return r.rawQuery
+}
+
 // Returns the expected results of the query for this record. For many records, this is a hash of sorted results
 // instead of the full list of values. Use IsHashResult to disambiguate.
 func (r *Record) Result() []string {
 	return r.result
 }
 
-// IsHashResult returns whether this record has a hash result (as opposed to enumerating each value).
+// IsHashResult returns whether this record has a hash result (as opposed to enumerating each value). A hash result is
+// a single hash line, optionally followed by checkpoint hash lines (see HashCheckpoints).
 func (r *Record) IsHashResult() bool {
-	return len(r.result) == 1 && hashRegex.MatchString(r.result[0])
+	if len(r.result) == 0 {
+		return false
+	}
+	for _, line := range r.result {
+		if !hashRegex.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// A HashCheckpoint is the hash of a prefix of the sorted result values of a query.
+type HashCheckpoint struct {
+	// NumValues is the number of leading values hashed.
+	NumValues int
+	// Algorithm is the name of the algorithm used to compute Hash.
+	Algorithm string
+	// Hash is the hash of the first NumValues values.
+	Hash string
+}
+
+// HashCheckpoints returns the checkpoint hashes of this record, in increasing order of the number of values hashed.
+// Checkpoints are optional lines following the hash line of a hash result, in the same format, which hash a prefix of
+// the sorted results. They allow the runner to locate the first diverging value when hashes differ. Returns nil for
+// records without a hash result.
+func (r *Record) HashCheckpoints() []HashCheckpoint {
+	if !r.IsHashResult() {
+		return nil
+	}
+
+	var checkpoints []HashCheckpoint
+	for _, line := range r.result[1:] {
+		numValues, _ := strconv.Atoi(hashRegex.ReplaceAllString(line, "$1"))
+		algorithm := hashRegex.ReplaceAllString(line, "$2")
+		if algorithm == "" {
+			algorithm = "md5"
+		}
+		checkpoints = append(checkpoints, HashCheckpoint{
+			NumValues: numValues,
+			Algorithm: algorithm,
+			Hash:      hashRegex.ReplaceAllString(line, "$3"),
+		})
+	}
+
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].NumValues < checkpoints[j].NumValues
+	})
+	return checkpoints
 }
 
 // HashResult returns the hash for result values for this record.
 func (r *Record) HashResult() string {
-	return hashRegex.ReplaceAllString(r.result[0], "$2")
+	return hashRegex.ReplaceAllString(r.result[0], "$3")
+}
+
+// HashAlgorithm returns the name of the algorithm used to compute the hash for result values for this record, which is
+// "md5" unless the hash in the test file is prefixed with the name of another algorithm, e.g. "xxh64:".
+func (r *Record) HashAlgorithm() string {
+	algorithm := hashRegex.ReplaceAllString(r.result[0], "$2")
+	if algorithm == "" {
+		return "md5"
+	}
+	return algorithm
 }
 
 // NumRows returns the number of results (not rows) for this record. Panics if the record is a statement instead of a
@@ -142,15 +418,47 @@ func (r *Record) LineNum() int {
 	return r.lineNum
 }
 
+// StartLine returns the first line of this record in its test file, including any directives before its header.
+func (r *Record) StartLine() int {
+	return r.startLine
+}
+
+// HeaderLine returns the line of this record's header, e.g. "query III rowsort". For records that are a single line,
+// this is the record's line.
+func (r *Record) HeaderLine() int {
+	return r.headerLine
+}
+
+// SeparatorLine returns the line of the separator before this record's results, or 0 if it has none.
+func (r *Record) SeparatorLine() int {
+	return r.separatorLine
+}
+
+// EndLine returns the last line of this record in its test file, excluding any comment lines at its end.
+func (r *Record) EndLine() int {
+	return r.endLine
+}
+
+// Conditions returns the skipif and onlyif conditions of this record, in the order they were written.
+func (r *Record) Conditions() []*Condition {
+	return r.conditions
+}
+
 // ShouldExecuteForEngine returns whether this record should be executed for the engine with the identifier given.
 func (r *Record) ShouldExecuteForEngine(engine string) bool {
+	return r.ShouldExecuteForEngines(engine)
+}
+
+// ShouldExecuteForEngines returns whether this record should be executed for an engine known by any of the
+// identifiers given, e.g. an engine that should be treated as mysql by conditions in addition to its own name.
+func (r *Record) ShouldExecuteForEngines(engines ...string) bool {
 	// skipif and onlyif don't really play nicely together. We honor an onlyif only as the single condition for a record.
 	if len(r.conditions) == 1 && r.conditions[0].isOnly {
-		return r.conditions[0].engine == engine
+		return containsEngine(engines, r.conditions[0].engine)
 	}
 
 	for _, condition := range r.conditions {
-		if condition.isSkip && condition.engine == engine {
+		if condition.isSkip && containsEngine(engines, condition.engine) {
 			return false
 		}
 	}
@@ -158,39 +466,59 @@ func (r *Record) ShouldExecuteForEngine(engine string) bool {
 	return true
 }
 
-// rowSorter sorts a slice of result values with by-row semantics.
-type rowSorter struct {
-	record *Record
-	values []string
+func containsEngine(engines []string, engine string) bool {
+	for _, e := range engines {
+		if e == engine {
+			return true
+		}
+	}
+	return false
 }
 
-func (s rowSorter) toRow(i int) []string {
-	return s.values[i*s.record.NumCols() : (i+1)*s.record.NumCols()]
+// sortedRow is a row of results being sorted: its index, and its first value, which decides most comparisons
+// without reading the other values.
+type sortedRow struct {
+	first string
+	index int
 }
 
-func (s rowSorter) Len() int {
-	return len(s.values) / s.record.NumCols()
-}
+// sortRows sorts the result values given, numCols values to a row, by row. Rather than swapping the values of rows
+// column by column, the rows are sorted by index, and the values are then moved into their sorted positions once,
+// following the cycles of the permutation.
+func sortRows(values []string, numCols int) {
+	if numCols <= 1 {
+		sort.Strings(values)
+		return
+	}
 
-func (s rowSorter) Less(i, j int) bool {
-	rowI := s.toRow(i)
-	rowJ := s.toRow(j)
-	for k := range rowI {
-		if rowI[k] < rowJ[k] {
-			return true
+	rows := make([]sortedRow, len(values)/numCols)
+	for i := range rows {
+		rows[i] = sortedRow{first: values[i*numCols], index: i}
+	}
+	slices.SortFunc(rows, func(a, b sortedRow) int {
+		if c := strings.Compare(a.first, b.first); c != 0 {
+			return c
 		}
-		if rowI[k] > rowJ[k] {
-			return false
+		return slices.Compare(values[a.index*numCols+1:(a.index+1)*numCols], values[b.index*numCols+1:(b.index+1)*numCols])
+	})
+
+	// rows[i] is now the row that belongs in position i. Each cycle of the permutation is applied with one row of
+	// temporary storage, and positions already in place are marked with an index of -1.
+	tmp := make([]string, numCols)
+	for start := range rows {
+		if rows[start].index < 0 || rows[start].index == start {
+			continue
 		}
-	}
-	return false
-}
-
-func (s rowSorter) Swap(i, j int) {
-	rowI := s.toRow(i)
-	rowJ := s.toRow(j)
-	for col := range rowI {
-		rowI[col], rowJ[col] = rowJ[col], rowI[col]
+		copy(tmp, values[start*numCols:(start+1)*numCols])
+		i := start
+		for rows[i].index != start {
+			next := rows[i].index
+			copy(values[i*numCols:(i+1)*numCols], values[next*numCols:(next+1)*numCols])
+			rows[i].index = -1
+			i = next
+		}
+		copy(values[i*numCols:(i+1)*numCols], tmp)
+		rows[i].index = -1
 	}
 }
 
@@ -201,21 +529,65 @@ func (r *Record) SortResults(results []string) []string {
 	case NoSort:
 		return results
 	case Rowsort:
-		sorter := rowSorter{
-			record: r,
-			values: results,
-		}
-		sort.Sort(sorter)
-		return sorter.values
+		sortRows(results, r.NumCols())
+		return results
 	case ValueSort:
 		sort.Strings(results)
 		return results
+	case PartialSort:
+		sortPartially(results, r.NumCols(), r.partialSortColumns)
+		return results
 	default:
 		panic(fmt.Sprintf("unrecognized sort mode `%v`", r.sortMode))
 	}
 }
 
+// sortPartially sorts the result values given, numCols values to a row, within each run of consecutive rows with
+// equal values in the columns given, so that results that only differ in the order of rows that are equal in those
+// columns sort the same, while the order of the runs is kept.
+func sortPartially(values []string, numCols int, columns []int) {
+	if numCols == 0 {
+		return
+	}
+
+	numRows := len(values) / numCols
+	sameKey := func(a, b int) bool {
+		for _, col := range columns {
+			if col < numCols && values[a*numCols+col] != values[b*numCols+col] {
+				return false
+			}
+		}
+		return true
+	}
+	for start := 0; start < numRows; {
+		end := start + 1
+		for end < numRows && sameKey(start, end) {
+			end++
+		}
+		sortRows(values[start*numCols:end*numCols], numCols)
+		start = end
+	}
+}
+
+// SortMode returns the sort mode for validating results of this record's query.
+func (r *Record) SortMode() SortMode {
+	return r.sortMode
+}
+
+// PartialSortColumns returns the 0-based indexes of the columns whose order is checked, for partialsort queries.
+func (r *Record) PartialSortColumns() []int {
+	return r.partialSortColumns
+}
+
+// SortString returns the sort mode as written in the record's header, including the columns of partialsort queries.
 func (r *Record) SortString() string {
+	if r.sortMode == PartialSort {
+		positions := make([]string, len(r.partialSortColumns))
+		for i, col := range r.partialSortColumns {
+			positions[i] = strconv.Itoa(col + 1)
+		}
+		return fmt.Sprintf("%s(%s)", PartialSort, strings.Join(positions, ","))
+	}
 	return string(r.sortMode)
 }
 
@@ -226,3 +598,232 @@ func (r *Record) Label() string {
 func (r *Record) HashThreshold() int {
 	return r.hashThreshold
 }
+
+// FloatEpsilon returns the tolerance for comparing floating point results of this record, as given by a preceding
+// float-epsilon directive, or 0 if none was given.
+func (r *Record) FloatEpsilon() float64 {
+	return r.floatEpsilon
+}
+
+// HasFloatEpsilon returns whether a float-epsilon directive precedes this record, in which case its FloatEpsilon
+// applies even if it's 0, to compare the record's results exactly.
+func (r *Record) HasFloatEpsilon() bool {
+	return r.hasFloatEpsilon
+}
+
+// Timeout returns the timeout for executing this record, as given by a preceding timeout directive, e.g. timeout 10s,
+// or 0 if none was given.
+func (r *Record) Timeout() time.Duration {
+	return r.timeout
+}
+
+// AmbiguousSchema returns whether the column types of this query are acknowledged to be ambiguous by a preceding
+// ambiguous-schema directive, so that integer results satisfy its R-typed columns even when schemas are verified
+// strictly.
+func (r *Record) AmbiguousSchema() bool {
+	return r.ambiguousSchema
+}
+
+// HaltsRun returns whether this halt record terminates the entire run, as written "halt run", rather than only the
+// current test script.
+func (r *Record) HaltsRun() bool {
+	return r.haltsRun
+}
+
+// NumResultSets returns the number of result sets expected from this record's query, which is greater than 1 only for
+// queries whose schema lists one schema per result set separated by commas, e.g. "query II,T". For procedure records,
+// this includes the OUT parameter values if the procedure has any, and is 0 for "procedure ok" and "procedure error".
+func (r *Record) NumResultSets() int {
+	if len(r.schemas) > 1 || r.recordType == Procedure {
+		return len(r.schemas)
+	}
+	return 1
+}
+
+// ResultSets returns a query record for each result set expected from this record's query, with the schema and
+// expected results of that result set. In test files, the results of each result set are separated by a separator
+// line. Returns a slice containing only this record for query records with a single result set. For procedure records
+// with OUT parameters, the first result set holds the values of those parameters, which are never sorted.
+func (r *Record) ResultSets() []*Record {
+	if r.recordType == Query && r.NumResultSets() == 1 {
+		return []*Record{r}
+	}
+
+	sets := make([]*Record, len(r.schemas))
+	for i, schema := range r.schemas {
+		set := *r
+		set.recordType = Query
+		if r.recordType == Procedure && i == 0 && r.NumOutParams() > 0 {
+			set.sortMode = NoSort
+		}
+		set.schema = schema
+		set.schemas = nil
+		set.resultSets = nil
+		set.result = nil
+		if i == 0 {
+			set.result = r.result
+		} else if i < len(r.resultSets) {
+			set.result = r.resultSets[i]
+		}
+		sets[i] = &set
+	}
+	return sets
+}
+
+// Requires returns the capabilities an engine must have to execute this record, as given by preceding require
+// directives, e.g. "require window-functions cte".
+func (r *Record) Requires() []string {
+	return r.requires
+}
+
+// Connection returns the name of the connection to execute this record on, as given by a preceding connection
+// directive, or the empty string for the default connection.
+func (r *Record) Connection() string {
+	return r.connection
+}
+
+// AsyncName returns the name of an async statement record, which executes in the background on its named connection
+// until a later awaitstatement record with the same name waits for it, or the name of the statement an awaitstatement record waits
+// for. Returns the empty string for other records.
+func (r *Record) AsyncName() string {
+	return r.asyncName
+}
+
+// AsyncNames returns the names of the async statements an awaitdeadlock or awaitlocktimeout record waits for, one of
+// which must fail with the error returned by ExpectedError.
+func (r *Record) AsyncNames() []string {
+	return r.asyncNames
+}
+
+// TxnAction returns the action of a txn record.
+func (r *Record) TxnAction() TxnAction {
+	return r.txnAction
+}
+
+// Table returns the table whose contents a table-checksum record validates. Its Query reads the full table.
+func (r *Record) Table() string {
+	return r.table
+}
+
+// Checksum returns the checksum a table-checksum record expects of its table's contents, or empty if the record has
+// none yet, e.g. before its test file is generated.
+func (r *Record) Checksum() string {
+	return r.checksum
+}
+
+// SnapshotName returns the name of the snapshot a snapshot record saves or a restore record restores.
+func (r *Record) SnapshotName() string {
+	return r.snapshotName
+}
+
+// Seed returns the seed of a set-seed record.
+func (r *Record) Seed() int64 {
+	return r.seed
+}
+
+// ColumnNames returns the names of the columns a query record's results must have, as given by a preceding colnames
+// directive, e.g. "colnames a b". Nil if the record makes no assertion about its column names.
+func (r *Record) ColumnNames() []string {
+	return r.columnNames
+}
+
+// Directive returns the name of the directive of a custom record.
+func (r *Record) Directive() string {
+	return r.directive
+}
+
+// DirectiveArgs returns the arguments of a custom record, the fields of its first line after the directive's name.
+func (r *Record) DirectiveArgs() []string {
+	return r.directiveArgs
+}
+
+// DirectiveBody returns the lines of the body of a custom record, for directives with a body.
+func (r *Record) DirectiveBody() []string {
+	return r.directiveBody
+}
+
+// DirectiveValue returns the value returned by the Parse function of a custom record's directive, or nil if it has
+// none.
+func (r *Record) DirectiveValue() interface{} {
+	return r.directiveValue
+}
+
+// ExpectsWarnings returns whether this statement record asserts the warnings it produces, with warning or warnings
+// directives.
+func (r *Record) ExpectsWarnings() bool {
+	return r.warningCountSet || len(r.warnings) > 0
+}
+
+// WarningCount returns the number of warnings this statement record expects to produce, given by a warnings directive
+// such as "warnings 0" or by the number of warning directives.
+func (r *Record) WarningCount() int {
+	if r.warningCountSet {
+		return r.warningCount
+	}
+	return len(r.warnings)
+}
+
+// Warnings returns the warnings this statement record expects to produce in order, as given by warning directives.
+// Empty if the record only asserts the number of its warnings.
+func (r *Record) Warnings() []Warning {
+	return r.warnings
+}
+
+// Routes returns the backends, such as shards, that a proxy must route this record to, as given by preceding route
+// directives, e.g. "route commerce/-80 commerce/80-". Empty if the record makes no assertion about its routing.
+func (r *Record) Routes() []string {
+	return r.routes
+}
+
+// PlanAssertions returns the operators the plan of this query record must or must not contain, as given by preceding
+// expect-plan-contains and expect-plan-not-contains directives. Empty if the record makes no assertion about its plan.
+func (r *Record) PlanAssertions() []PlanAssertion {
+	return r.planAssertions
+}
+
+// MetricAssertions returns the bounds on the execution metrics of this record, as given by preceding expect-metric
+// directives, e.g. "expect-metric rows_examined <= 100". Empty if the record makes no assertion about its metrics.
+func (r *Record) MetricAssertions() []MetricAssertion {
+	return r.metricAssertions
+}
+
+// Prepared returns whether this record must be executed as a prepared statement, as requested by a preceding
+// prepared or bind directive.
+func (r *Record) Prepared() bool {
+	return r.prepared
+}
+
+// BindArgs returns the values bound to the parameters of this record's prepared statement, as given by a preceding
+// bind directive. Values are int64, float64, string or nil for NULL.
+func (r *Record) BindArgs() []interface{} {
+	return r.bindArgs
+}
+
+// ProcedureName returns the name of the stored procedure called by this procedure record.
+func (r *Record) ProcedureName() string {
+	return r.procedureName
+}
+
+// ProcedureArgs returns the arguments passed to the stored procedure called by this procedure record.
+func (r *Record) ProcedureArgs() []ProcedureArg {
+	return r.procedureArgs
+}
+
+// NumOutParams returns the number of OUT and INOUT parameters of the stored procedure called by this procedure record.
+func (r *Record) NumOutParams() int {
+	n := 0
+	for _, arg := range r.procedureArgs {
+		if arg.Mode != ParamIn {
+			n++
+		}
+	}
+	return n
+}
+
+// WithQuery returns a copy of this record with its query replaced by the one given, e.g. to execute a query rewritten
+// for another SQL dialect. The raw query is left as it is.
+func (r *Record) WithQuery(query string) *Record {
+	rewritten := *r
+	rewritten.query = query
+	return &rewritten
+}
@@ -15,10 +15,28 @@
 package parser
 
 import (
-	"github.com/stretchr/testify/assert"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
+func TestRecordHashAlgorithm(t *testing.T) {
+	record := Record{
+		recordType: Query,
+		schema:     "I",
+		result:     []string{"30 values hashing to xxh64:8a3f5c2e9b1d4f60"},
+	}
+
+	assert.True(t, record.IsHashResult())
+	assert.Equal(t, 30, record.NumResults())
+	assert.Equal(t, "xxh64", record.HashAlgorithm())
+	assert.Equal(t, "8a3f5c2e9b1d4f60", record.HashResult())
+}
+
 func TestRecordMethods(t *testing.T) {
 	record := Record {
 		recordType: Query,
@@ -149,6 +167,7 @@ func TestRecordMethods(t *testing.T) {
 	assert.False(t, record.ExpectError())
 	assert.True(t, record.IsHashResult())
 	assert.Equal(t, "808146289313018fce25f1a280bd8c30", record.HashResult())
+	assert.Equal(t, "md5", record.HashAlgorithm())
 	assert.True(t, record.ShouldExecuteForEngine("mysql"))
 	assert.Equal(t, []string { "c", "b", "a"}, record.SortResults([]string {"c", "b", "a"}))
 
@@ -219,3 +238,80 @@ func TestRecordMethods(t *testing.T) {
 	assert.False(t, record.ShouldExecuteForEngine("mysql"))
 	assert.True(t, record.ShouldExecuteForEngine("postgresql"))
 }
+
+func TestShouldExecuteForEngines(t *testing.T) {
+	skip := Record{conditions: []*Condition{{isSkip: true, engine: "mysql"}}}
+	assert.True(t, skip.ShouldExecuteForEngines("dolt"))
+	assert.False(t, skip.ShouldExecuteForEngines("dolt", "mysql"))
+
+	only := Record{conditions: []*Condition{{isOnly: true, engine: "mysql"}}}
+	assert.False(t, only.ShouldExecuteForEngines("dolt"))
+	assert.True(t, only.ShouldExecuteForEngines("dolt", "mysql"))
+	assert.False(t, only.ShouldExecuteForEngines())
+}
+
+func TestSortRows(t *testing.T) {
+	record := Record{recordType: Query, schema: "IIT", sortMode: Rowsort}
+	results := randomResults(3, 300, 5)
+
+	// Rows sorted by joining their values with a separator that sorts before any value character
+	var expected []string
+	rows := make([]string, len(results)/3)
+	for i := range rows {
+		rows[i] = strings.Join(results[i*3:i*3+3], "\x00")
+	}
+	sort.Strings(rows)
+	for _, row := range rows {
+		expected = append(expected, strings.Split(row, "\x00")...)
+	}
+
+	sorted := record.SortResults(results)
+	assert.Equal(t, expected, sorted)
+	assert.Equal(t, expected, results, "results are sorted in place")
+}
+
+func TestSortPartially(t *testing.T) {
+	record := Record{recordType: Query, schema: "IT", sortMode: PartialSort, partialSortColumns: []int{0}}
+	assert.Equal(t, "partialsort(1)", record.SortString())
+
+	// Rows are sorted within each run of equal first values, and the runs keep their order
+	results := []string{"2", "b", "2", "a", "1", "c", "1", "a", "2", "c"}
+	sorted := record.SortResults(results)
+	assert.Equal(t, []string{"2", "a", "2", "b", "1", "a", "1", "c", "2", "c"}, sorted)
+}
+
+// randomResults returns the values of the number of rows of random results given, with numCols values to a row,
+// each one of distinct values.
+func randomResults(numCols, numRows, distinct int) []string {
+	random := rand.New(rand.NewSource(1))
+	results := make([]string, numCols*numRows)
+	for i := range results {
+		results[i] = fmt.Sprint(random.Intn(distinct))
+	}
+	return results
+}
+
+func BenchmarkSortResults(b *testing.B) {
+	for _, bb := range []struct {
+		schema   string
+		sortMode SortMode
+		rows     int
+	}{
+		{"I", Rowsort, 1000000},
+		{"IIT", Rowsort, 10000},
+		{"IIT", Rowsort, 1000000},
+		{"IIIIIIIIII", Rowsort, 100000},
+		{"IIT", ValueSort, 1000000},
+	} {
+		record := Record{recordType: Query, schema: bb.schema, sortMode: bb.sortMode}
+		results := randomResults(len(bb.schema), bb.rows, bb.rows)
+		b.Run(fmt.Sprintf("%s/%s/%d", bb.sortMode, bb.schema, bb.rows), func(b *testing.B) {
+			values := make([]string, len(results))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				copy(values, results)
+				record.SortResults(values)
+			}
+		})
+	}
+}
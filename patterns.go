@@ -0,0 +1,111 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RegexPrefix marks expected result values that are regular expressions matched against the actual values, rather
+// than compared to them, e.g. re:^\d{4}-\d{2}-\d{2}$ for a date, for values such as timestamps, generated IDs and
+// version strings that can't be pinned exactly. Patterns aren't anchored unless they're written with ^ and $. Since
+// patterns don't sort like the values they match, they're best used in unsorted queries, or in columns that don't
+// determine the order of sorted ones. Actual values that would be read as patterns are written with a backslash before
+// them, as by EscapeLiteral.
+const RegexPrefix = "re:"
+
+// Wildcard is an expected result value that matches any actual value, including NULL, so that volatile columns can
+// be ignored while the rest of their rows are still verified.
+const Wildcard = "<any>"
+
+// EscapeLiteral returns the actual value given as it's written in the expected results of a test file: with a
+// backslash added before it if it would otherwise be read as a pattern, such as a value starting with re: or equal to
+// <any>, or if it's such a value with backslashes before it already. Values are written with parser.EscapeResult
+// after this.
+func EscapeLiteral(value string) string {
+	if isPattern(strings.TrimLeft(value, `\`)) {
+		return `\` + value
+	}
+	return value
+}
+
+// unescapeLiteral returns the actual value written as the expected value given, reversing EscapeLiteral. Patterns are
+// returned unchanged.
+func unescapeLiteral(expected string) string {
+	if strings.HasPrefix(expected, `\`) && isPattern(strings.TrimLeft(expected, `\`)) {
+		return expected[1:]
+	}
+	return expected
+}
+
+// cellRegexes caches the compiled patterns of expected values, by pattern.
+var cellRegexes sync.Map
+
+// isPattern returns whether the expected value given is a pattern, a regular expression or the wildcard, rather than
+// a value.
+func isPattern(expected string) bool {
+	return expected == Wildcard || isRegex(expected)
+}
+
+// isRegex returns whether the expected value given is a regular expression.
+func isRegex(expected string) bool {
+	return strings.HasPrefix(expected, RegexPrefix)
+}
+
+// compileRegex compiles the regular expression of the expected value given, which must be one.
+func compileRegex(expected string) (*regexp.Regexp, error) {
+	pattern := strings.TrimPrefix(expected, RegexPrefix)
+	if re, ok := cellRegexes.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	cellRegexes.Store(pattern, re)
+	return re, nil
+}
+
+// checkPatterns returns an error for the first of the expected values given that's an invalid regular expression, so
+// that it's reported as such rather than as a value mismatch.
+func checkPatterns(expected []string) error {
+	for i, value := range expected {
+		if !isRegex(value) {
+			continue
+		}
+		if _, err := compileRegex(value); err != nil {
+			return fmt.Errorf("invalid pattern %s at value %d: %v", value, i+1, err)
+		}
+	}
+	return nil
+}
+
+// resultMatches returns whether the actual value given matches the expected value given from a test file, which is
+// either a pattern or a value, escaped with EscapeLiteral, compared with valuesEqual. Invalid regular expressions
+// match nothing, and are reported by checkPatterns before results are matched.
+func (c *RunConfig) resultMatches(expected, actual string, typ byte, epsilon float64) bool {
+	switch {
+	case expected == Wildcard:
+		return true
+	case isRegex(expected):
+		re, err := compileRegex(expected)
+		return err == nil && re.MatchString(actual)
+	default:
+		return c.valuesEqual(unescapeLiteral(expected), actual, typ, epsilon)
+	}
+}
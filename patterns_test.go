@@ -0,0 +1,169 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultMatches(t *testing.T) {
+	config := DefaultRunConfig()
+	assert.True(t, config.resultMatches(`re:^\d{4}-\d{2}-\d{2}$`, "2024-01-31", 'T', 0))
+	assert.False(t, config.resultMatches(`re:^\d{4}-\d{2}-\d{2}$`, "2024-01-31 12:00:00", 'T', 0))
+	assert.True(t, config.resultMatches("re:v1", "version v1.2", 'T', 0))
+	assert.False(t, config.resultMatches("re:(", "(", 'T', 0))
+	assert.True(t, config.resultMatches("1.000", "1.000", 'R', 0))
+	assert.False(t, config.resultMatches("1.000", "re:1", 'R', 0))
+
+	assert.True(t, config.resultMatches(Wildcard, "anything", 'T', 0))
+	assert.True(t, config.resultMatches(Wildcard, "NULL", 'I', 0))
+	assert.False(t, config.resultMatches("<any>x", "anything", 'T', 0))
+}
+
+func TestRegexResults(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT id, created FROM t2"] = fakeResult{
+		schema: "IT", results: []string{"1", "2024-01-31 12:00:00", "2", "2024-02-01 08:30:00"},
+	}
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "query IT rowsort\nSELECT id, created FROM t2\n----\n"+
+		"1\nre:^2024-01-31 \\d\\d:\\d\\d:\\d\\d$\n2\nre:^\\d{4}-\\d{2}-\\d{2} \\d\\d:\\d\\d:\\d\\d$\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query IT rowsort\nSELECT id, created FROM t2\n----\n"+
+			"1\nre:^2024-02\n2\nre:^2024-02\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+}
+
+func TestSortedPatternResults(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT name, id FROM t2"] = fakeResult{schema: "TI", results: []string{"b", "2", "apple", "1"}}
+
+	// Patterns keep their rows in the order of the file, whether or not normalizers are configured
+	for _, opts := range [][]RunOption{nil, {WithNormalizers(CollapseWhitespace)}} {
+		for _, contents := range []string{
+			"query TI rowsort\nSELECT name, id FROM t2\n----\nre:^a\n1\nb\n2\n",
+			"query TI valuesort\nSELECT name, id FROM t2\n----\n1\n2\nre:^a\nb\n",
+		} {
+			reporter := &collectingReporter{}
+			runner := NewRunner(harness, append(opts, WithOutput(&bytes.Buffer{}), WithReporters(reporter))...)
+			runner.RunTestFiles(writeTestFile(t, contents))
+			require.Len(t, reporter.entries, 1)
+			assert.Equal(t, Ok, reporter.entries[0].Result, reporter.entries[0].ErrorMessage)
+		}
+	}
+}
+
+func TestWildcardResults(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT id, uuid() FROM t2"] = fakeResult{
+		schema: "IT", results: []string{"1", "0b6ad3a1-5d1f-4a2c-9bd3-0a4f1c3c8e11", "2", "77f2c4de-08b9-4a8e-b1f2-6c7d9e0a1b23"},
+	}
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "query IT nosort\nSELECT id, uuid() FROM t2\n----\n1\n<any>\n2\n<any>\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	// The rest of each row is still verified
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query IT nosort\nSELECT id, uuid() FROM t2\n----\n1\n<any>\n3\n<any>\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+}
+
+func TestValidatePatterns(t *testing.T) {
+	// Patterns aren't checked for sort order, but must be valid
+	path := writeTestFile(t, "query T rowsort\nSELECT a FROM t1\n----\nre:b\n<any>\n\nquery T nosort\nSELECT a FROM t1\n----\nre:(\n")
+	problems := ValidateTestFile(path)
+	require.Len(t, problems, 1)
+	assert.Equal(t, 8, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "invalid pattern re:( at value 1")
+}
+
+func TestEscapeLiteral(t *testing.T) {
+	for value, escaped := range map[string]string{
+		"re:1":      `\re:1`,
+		Wildcard:    `\<any>`,
+		`\re:1`:     `\\re:1`,
+		`\<any>`:    `\\<any>`,
+		"v1":        "v1",
+		`\v1`:       `\v1`,
+		"<any>x":    "<any>x",
+		"are:maybe": "are:maybe",
+	} {
+		assert.Equal(t, escaped, EscapeLiteral(value))
+		assert.Equal(t, value, unescapeLiteral(escaped))
+	}
+	assert.Equal(t, "re:1", unescapeLiteral("re:1"))
+
+	config := DefaultRunConfig()
+	assert.True(t, config.resultMatches(`\re:1`, "re:1", 'T', 0))
+	assert.False(t, config.resultMatches(`\re:1`, "1", 'T', 0))
+	assert.True(t, config.resultMatches(`\<any>`, Wildcard, 'T', 0))
+	assert.False(t, config.resultMatches(`\<any>`, "anything", 'T', 0))
+}
+
+func TestGenerateLiteralPatterns(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT name FROM t2"] = fakeResult{schema: "T", results: []string{"re:a", "a", "<any>", `\re:b`}}
+
+	path := writeTestFile(t, "query T rowsort\nSELECT name FROM t2\n----\nx\n")
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithRegenerateFailing(true)).GenerateTestFiles(path)
+	assertFileContents(t, path+".generated", "query T rowsort\nSELECT name FROM t2\n----\n\\<any>\n\\\\re:b\na\n\\re:a\n")
+
+	// The values written are compared literally, in the order of the values they're written for
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(path + ".generated")
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result, reporter.entries[0].ErrorMessage)
+	assert.Empty(t, ValidateTestFile(path+".generated"))
+
+	harness.queryResults["SELECT name FROM t2"] = fakeResult{schema: "T", results: []string{"re:a", "a", "anything", `\re:b`}}
+	reporter = &collectingReporter{}
+	assert.Panics(t, func() {
+		NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(path + ".generated")
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+}
+
+func TestInvalidPatternResults(t *testing.T) {
+	harness := newFakeHarness()
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query II nosort\nSELECT a, b FROM t1\n----\n1\nre:(\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, UnexpectedError, reporter.entries[0].FailureCode)
+	assert.Contains(t, reporter.entries[0].ErrorMessage, "invalid pattern re:( at value 2")
+}
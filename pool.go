@@ -0,0 +1,192 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A HarnessPool manages a fixed number of independent harness instances, which are checked out for exclusive use and
+// checked back in when done. Each instance must have its own database, so that test files running concurrently on
+// different instances don't interfere with each other.
+type HarnessPool struct {
+	all       []Harness
+	available chan Harness
+}
+
+// NewHarnessPool returns a pool of n harnesses created by the function given, which is called with the index of each
+// instance, from 0 to n-1. The index can be used to give each instance its own database, e.g. by including it in the
+// database name of a connection string. If any harness can't be created, the ones already created are closed.
+func NewHarnessPool(n int, newHarness func(i int) (Harness, error)) (*HarnessPool, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("harness pool size must be at least 1, got %d", n)
+	}
+
+	p := &HarnessPool{available: make(chan Harness, n)}
+	for i := 0; i < n; i++ {
+		h, err := newHarness(i)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.all = append(p.all, h)
+		p.available <- h
+	}
+	return p, nil
+}
+
+// Size returns the number of harnesses in this pool.
+func (p *HarnessPool) Size() int {
+	return len(p.all)
+}
+
+// Checkout returns a harness for exclusive use by the caller, waiting for one to be checked in if none are available.
+// Returns the context's error if it's done before a harness becomes available.
+func (p *HarnessPool) Checkout(ctx context.Context) (Harness, error) {
+	select {
+	case h := <-p.available:
+		return h, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Checkin returns a harness previously checked out to this pool.
+func (p *HarnessPool) Checkin(h Harness) {
+	p.available <- h
+}
+
+// Close closes every harness in this pool that implements io.Closer, and returns the first error encountered.
+func (p *HarnessPool) Close() error {
+	var firstErr error
+	for _, h := range p.all {
+		if closer, ok := h.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// A ParallelRunner runs test files concurrently, each on a harness checked out from a pool, with as many files running
+// at once as there are harnesses in the pool. Results are reported as each record completes, so the results of
+// different files are interleaved in the result log. In stress mode, set with WithStressCopies, each file is run on
+// several harnesses at once instead.
+type ParallelRunner struct {
+	pool   *HarnessPool
+	config RunConfig
+}
+
+// NewParallelRunner returns a runner for the harnesses in the pool given, configured by DefaultRunConfig with the
+// options given applied.
+func NewParallelRunner(pool *HarnessPool, opts ...RunOption) *ParallelRunner {
+	config := DefaultRunConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.Reference != nil {
+		panic("differential mode is not supported for parallel runs")
+	}
+	if config.StressCopies > pool.Size() {
+		panic(fmt.Sprintf("%d stress copies can't run at once on a pool of %d harnesses", config.StressCopies, pool.Size()))
+	}
+	return &ParallelRunner{pool: pool, config: config}
+}
+
+// errHaltRun stops a parallel run after a "halt run" record.
+var errHaltRun = errors.New("halt run")
+
+// RunTestFiles runs the test files found under any of the paths given, as described by the package-level RunTestFiles.
+// No further files are started once any file fails or halts the run, and a failure panics once the files already
+// started have finished. In stress mode, the copies of each file are started one after another, each on the next
+// harness available, so that they run at once.
+func (p *ParallelRunner) RunTestFiles(paths ...string) {
+	files := make(chan string)
+	var mu sync.Mutex
+	var stopped error
+	stop := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped == nil || stopped == errHaltRun {
+			stopped = err
+		}
+	}
+	isStopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopped != nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.pool.Size(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range files {
+				if err := p.runTestFile(file); err != nil {
+					stop(err)
+				}
+			}
+		}()
+	}
+
+	copies := max(p.config.StressCopies, 1)
+	budget := p.config.newRunBudget()
+	for _, file := range p.config.testFiles(paths) {
+		if isStopped() {
+			break
+		}
+		if budget.start(file) {
+			for i := 0; i < copies; i++ {
+				files <- file
+			}
+		}
+	}
+	close(files)
+	wg.Wait()
+	budget.report(p.config.Output)
+
+	if stopped != nil && stopped != errHaltRun {
+		panic(stopped)
+	}
+}
+
+// runTestFile runs the test file given on a harness from the pool, returning errHaltRun if it halts the run, or the
+// cause of the failure if it fails.
+func (p *ParallelRunner) runTestFile(file string) (err error) {
+	h, err := p.pool.Checkout(context.Background())
+	if err != nil {
+		return err
+	}
+	defer p.pool.Checkin(h)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", file, r)
+		}
+	}()
+
+	p.config.setFormatter(h)
+	runner := &Runner{harness: h, config: p.config, capabilities: newCapabilitySet(h)}
+	if !runner.runTestFile(file) {
+		return errHaltRun
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHarnessPool(t *testing.T) {
+	_, err := NewHarnessPool(0, func(i int) (Harness, error) { return newFakeHarness(), nil })
+	assert.Error(t, err)
+
+	_, err = NewHarnessPool(2, func(i int) (Harness, error) {
+		if i == 1 {
+			return nil, errors.New("no database")
+		}
+		return newFakeHarness(), nil
+	})
+	assert.EqualError(t, err, "no database")
+
+	pool, err := NewHarnessPool(2, func(i int) (Harness, error) { return newFakeHarness(), nil })
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Size())
+
+	h1, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+	h2, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+	assert.NotSame(t, h1, h2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Checkout(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	pool.Checkin(h1)
+	h3, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, h1, h3)
+}
+
+// writeTestFiles writes n copies of the test file contents given to a temporary directory and returns it.
+func writeTestFiles(t *testing.T, n int, contents string) string {
+	dir := t.TempDir()
+	for i := 0; i < n; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("test%d.test", i)), []byte(contents), 0644))
+	}
+	return dir
+}
+
+func TestParallelRunner(t *testing.T) {
+	pool, err := NewHarnessPool(3, func(i int) (Harness, error) { return newFakeHarness(), nil })
+	require.NoError(t, err)
+
+	reporter := &collectingReporter{}
+	runner := NewParallelRunner(pool, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFiles(t, 8, "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"))
+
+	require.Len(t, reporter.entries, 8)
+	files := make(map[string]bool)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+		files[entry.TestFile] = true
+	}
+	assert.Len(t, files, 8)
+
+	runner = NewParallelRunner(pool, WithOutput(&bytes.Buffer{}))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFiles(t, 4, "query II nosort\nSELECT a, b FROM t1\n----\n1\n3\n"))
+	})
+
+	// The pool is intact after a failed run
+	assert.Equal(t, 3, len(pool.available))
+}
+
+// barrierHarness executes queries only once every harness sharing its barrier is executing one.
+type barrierHarness struct {
+	*fakeHarness
+	barrier *sync.WaitGroup
+}
+
+func (h *barrierHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	h.barrier.Done()
+	met := make(chan struct{})
+	go func() {
+		h.barrier.Wait()
+		close(met)
+	}()
+	select {
+	case <-met:
+		return h.fakeHarness.ExecuteQuery(ctx, statement)
+	case <-ctx.Done():
+		return "", nil, errors.New("copies didn't run at once")
+	}
+}
+
+func TestParallelRunnerStress(t *testing.T) {
+	barrier := &sync.WaitGroup{}
+	barrier.Add(3)
+	pool, err := NewHarnessPool(3, func(i int) (Harness, error) {
+		return &barrierHarness{fakeHarness: newFakeHarness(), barrier: barrier}, nil
+	})
+	require.NoError(t, err)
+
+	// Every copy of the file runs at once, on its own harness
+	reporter := &collectingReporter{}
+	runner := NewParallelRunner(pool, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithStressCopies(3),
+		WithTimeout(5*time.Second))
+	runner.RunTestFiles(writeTestFiles(t, 1, "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"))
+
+	require.Len(t, reporter.entries, 3)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.ErrorMessage)
+		assert.Equal(t, reporter.entries[0].TestFile, entry.TestFile)
+	}
+
+	assert.Panics(t, func() { NewParallelRunner(pool, WithStressCopies(4)) })
+}
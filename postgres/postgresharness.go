@@ -0,0 +1,254 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqldb"
+	"github.com/andyyu2004/sqllogictest/translate"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// sqllogictest harness for PostgreSQL databases. The corpus is written in MySQL's dialect, so every statement and query
+// is first translated with translate.MySQLToPostgres. Don't also configure the runner with that translator, since
+// translation isn't idempotent.
+type PostgresHarness struct {
+	db         *sql.DB
+	translator logictest.Translator
+	formatter  *logictest.ValueFormatter
+}
+
+// compile check for interface compliance
+var _ logictest.Harness = &PostgresHarness{}
+var _ logictest.ErrorClassifier = &PostgresHarness{}
+var _ logictest.CapabilityHarness = &PostgresHarness{}
+var _ logictest.ExplainHarness = &PostgresHarness{}
+var _ logictest.SeedHarness = &PostgresHarness{}
+var _ logictest.ObjectCleanupHarness = &PostgresHarness{}
+var _ logictest.CatalogHarness = &PostgresHarness{}
+var _ logictest.BatchHarness = &PostgresHarness{}
+var _ logictest.FormattingHarness = &PostgresHarness{}
+
+// NewPostgresHarness returns a new PostgreSQL test harness for the connection string given, in any form accepted by
+// pgx. Panics if it cannot open a connection using the connection string.
+func NewPostgresHarness(dsn string) *PostgresHarness {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		panic(err)
+	}
+	return &PostgresHarness{db: db, translator: translate.MySQLToPostgres, formatter: logictest.NewValueFormatter()}
+}
+
+// See FormattingHarness.SetFormatter
+func (h *PostgresHarness) SetFormatter(formatter *logictest.ValueFormatter) {
+	h.formatter = formatter
+}
+
+// See Harness.EngineStr
+func (h *PostgresHarness) EngineStr() string {
+	return "postgresql"
+}
+
+// See CapabilityHarness.Capabilities. Triggers and stored procedures are omitted, since the corpus defines them with
+// MySQL syntax that can't be translated.
+func (h *PostgresHarness) Capabilities() []logictest.Capability {
+	return []logictest.Capability{
+		logictest.CapSubqueries,
+		logictest.CapCTEs,
+		logictest.CapRecursiveCTEs,
+		logictest.CapWindowFunctions,
+		logictest.CapFullOuterJoin,
+		logictest.CapRightJoin,
+		logictest.CapViews,
+		logictest.CapTransactions,
+		logictest.CapJSON,
+		logictest.CapIntersectExcept,
+		logictest.CapForeignKeys,
+		logictest.CapCheckConstraints,
+	}
+}
+
+// See Harness.Init
+func (h *PostgresHarness) Init() error {
+	// Lift the limit of SetSeed, which doesn't carry over to the next test file
+	h.db.SetMaxOpenConns(0)
+
+	if err := h.dropAll("VIEW", "SELECT table_name FROM information_schema.views WHERE table_schema = current_schema()"); err != nil {
+		return err
+	}
+
+	return h.dropAll("TABLE", "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()")
+}
+
+// See SeedHarness.SetSeed. PostgreSQL seeds are per session, so the harness is limited to a single connection, the seeded
+// one, for the rest of the test file.
+func (h *PostgresHarness) SetSeed(ctx context.Context, seed int64) error {
+	h.db.SetMaxOpenConns(1)
+	_, err := h.db.ExecContext(ctx, "SELECT setseed($1)", logictest.SeedFraction(seed))
+	return err
+}
+
+// See ObjectCleanupHarness.DropObject. PostgreSQL indexes are dropped by name alone.
+func (h *PostgresHarness) DropObject(ctx context.Context, object logictest.SchemaObject) error {
+	return h.ExecuteStatement(ctx, fmt.Sprintf("DROP %s IF EXISTS %s CASCADE", object.Kind, object.Name))
+}
+
+// See CatalogHarness.Catalog
+func (h *PostgresHarness) Catalog(ctx context.Context) (logictest.Catalog, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT c.table_name, c.column_name FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	return logictest.ReadCatalog(rows)
+}
+
+// See Harness.ExecuteStatement
+func (h *PostgresHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	statement, err := h.translator.Translate(statement)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.db.ExecContext(ctx, statement)
+	return err
+}
+
+// See BatchHarness.ExecuteStatements. The statements are translated and execute in a transaction, as by
+// sqldb.ExecuteStatements.
+func (h *PostgresHarness) ExecuteStatements(ctx context.Context, statements []string) error {
+	return sqldb.ExecuteStatements(ctx, h.db, h.translator, statements)
+}
+
+// See BatchHarness.AtomicBatches
+func (h *PostgresHarness) AtomicBatches() bool {
+	return true
+}
+
+// See Harness.ExecuteQuery
+func (h *PostgresHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
+	statement, err = h.translator.Translate(statement)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	schema, columns, err := columns(rows)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(columns...); err != nil {
+			return "", nil, err
+		}
+
+		for _, col := range columns {
+			results = append(results, formatValue(h.formatter, col))
+		}
+	}
+
+	if rows.Err() != nil {
+		return "", nil, rows.Err()
+	}
+
+	return schema, results, nil
+}
+
+// See ExplainHarness.ExplainQuery
+func (h *PostgresHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	query, err := h.translator.Translate(query)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := h.db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if rows.Err() != nil {
+		return "", rows.Err()
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// See ErrorClassifier.ClassifyError
+func (h *PostgresHarness) ClassifyError(err error) logictest.ErrorClass {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return logictest.ErrorClass{}
+	}
+
+	return logictest.ErrorClass{SQLState: pgErr.Code, Category: logictest.CategoryForSQLState(pgErr.Code)}
+}
+
+func (h *PostgresHarness) GetTimeout() int64 {
+	return 0
+}
+
+// dropAll drops every object of the kind given (TABLE or VIEW) named by the query given.
+func (h *PostgresHarness) dropAll(kind string, query string) error {
+	rows, err := h.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, `"`+strings.ReplaceAll(name, `"`, `""`)+`"`)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if len(names) > 0 {
+		_, err = h.db.Exec(fmt.Sprintf("DROP %s IF EXISTS %s CASCADE", kind, strings.Join(names, ",")))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnValue(t *testing.T) {
+	tests := []struct {
+		typeName string
+		typ      byte
+		src      interface{}
+		expected string
+	}{
+		{"INT4", 'I', int64(-42), "-42"},
+		{"INT8", 'I', nil, "NULL"},
+		{"BOOL", 'I', true, "1"},
+		{"BOOL", 'I', false, "0"},
+		{"NUMERIC", 'R', "1.23456", "1.235"},
+		{"FLOAT8", 'R', float64(2), "2.000"},
+		{"TEXT", 'T', "abc", "abc"},
+		{"BPCHAR", 'T', "", ""},
+		{"DATE", 'T', time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "2020-01-02"},
+		{"TIMESTAMP", 'T', time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), "2020-01-02 03:04:05"},
+		{"TIMESTAMPTZ", 'T', nil, "NULL"},
+		{"JSONB", 'T', []byte(`{"a": 1}`), `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			typ, col, err := columnValue(tt.typeName)
+			require.NoError(t, err)
+			assert.Equal(t, string(tt.typ), string(typ))
+			require.NoError(t, col.(sql.Scanner).Scan(tt.src))
+			assert.Equal(t, tt.expected, formatValue(logictest.NewValueFormatter(), col))
+		})
+	}
+
+	_, _, err := columnValue("TSVECTOR")
+	assert.Error(t, err)
+}
+
+func TestClassifyError(t *testing.T) {
+	h := &PostgresHarness{}
+	assert.Equal(t, logictest.ErrorClass{SQLState: "42P01", Category: logictest.CategoryNotFound},
+		h.ClassifyError(&pgconn.PgError{Code: "42P01"}))
+	assert.Equal(t, logictest.ErrorClass{SQLState: "23505", Category: logictest.CategoryConstraint},
+		h.ClassifyError(&pgconn.PgError{Code: "23505"}))
+	assert.Equal(t, logictest.ErrorClass{}, h.ClassifyError(errors.New("connection refused")))
+}
@@ -0,0 +1,93 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+)
+
+// columnValue returns the sqllogictest schema type of a column with the PostgreSQL type name given, as reported by
+// pgx, along with a new value suitable for scanning the column into. Integer and boolean types are I, floating point
+// and numeric types are R, BYTEA is B, and all other types are T. Booleans are rendered as 1 and 0, as MySQL renders them in the
+// corpus.
+func columnValue(typeName string) (byte, interface{}, error) {
+	switch typeName {
+	case "INT2", "INT4", "INT8", "OID":
+		return 'I', &sql.NullInt64{}, nil
+	case "BOOL":
+		return 'I', &sql.NullBool{}, nil
+	case "FLOAT4", "FLOAT8", "NUMERIC":
+		return 'R', &sql.NullFloat64{}, nil
+	case "DATE":
+		return 'T', &timeValue{layout: "2006-01-02"}, nil
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		return 'T', &timeValue{layout: "2006-01-02 15:04:05"}, nil
+	case "BYTEA":
+		return 'B', &sql.Null[[]byte]{}, nil
+	case "TEXT", "VARCHAR", "BPCHAR", "NAME", "CHAR", "JSON", "JSONB", "UUID", "TIME", "TIMETZ", "INTERVAL":
+		return 'T', &sql.NullString{}, nil
+	default:
+		return 0, nil, fmt.Errorf("unhandled type %s", typeName)
+	}
+}
+
+// Returns the schema for the rows given, as well as a slice of columns suitable for scanning values into.
+func columns(rows *sql.Rows) (string, []interface{}, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sb := strings.Builder{}
+	var columns []interface{}
+	for _, columnType := range types {
+		typ, colVal, err := columnValue(columnType.DatabaseTypeName())
+		if err != nil {
+			return "", nil, err
+		}
+		columns = append(columns, colVal)
+		sb.WriteByte(typ)
+	}
+
+	return sb.String(), columns, nil
+}
+
+// formatValue renders a value scanned into one of the values returned by columnValue, with the formatter given.
+func formatValue(f *logictest.ValueFormatter, col interface{}) string {
+	if t, ok := col.(*timeValue); ok {
+		return f.FormatValue(&t.NullString)
+	}
+	return f.FormatValue(col)
+}
+
+// timeValue scans date and time columns, which pgx returns as time.Time values, as strings in MySQL's format.
+type timeValue struct {
+	sql.NullString
+	layout string
+}
+
+// See sql.Scanner.Scan
+func (t *timeValue) Scan(src interface{}) error {
+	if tm, ok := src.(time.Time); ok {
+		t.String, t.Valid = tm.Format(t.layout), true
+		return nil
+	}
+	return t.NullString.Scan(src)
+}
@@ -0,0 +1,139 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A PreludeHarness is a Harness that can save the state of its database after the prelude of a test file, the leading
+// run of statements that create and populate its tables, and restore it for later files with the same prelude. Many
+// files in a corpus share their prelude, so with RunConfig.CachePreludes set, the runner executes each prelude only
+// once per harness and restores it for the other files that begin with it.
+type PreludeHarness interface {
+	Harness
+
+	// SavePrelude saves the state of the database under the key given. Saved states must survive Init.
+	SavePrelude(ctx context.Context, key string) error
+	// RestorePrelude restores the state of the database saved under the key given, returning false if there's none.
+	RestorePrelude(ctx context.Context, key string) (bool, error)
+}
+
+// A prelude is the leading run of setup statements of a test file.
+type prelude struct {
+	records []*parser.Record
+	// key identifies the state the prelude leaves the database in, given the runner's setup statements
+	key string
+	// next returns the records of the file after the prelude
+	next func() (*parser.Record, error)
+}
+
+// preludeHarness returns the runner's harness if the preludes of test files should be cached on it.
+func (r *Runner) preludeHarness() (PreludeHarness, bool) {
+	harness, ok := r.harness.(PreludeHarness)
+	// Differential runs need the reference engine seeded too, and observed runs need every record executed
+	if !ok || !r.config.CachePreludes || r.config.Reference != nil || r.recordObserver != nil {
+		return nil, false
+	}
+	return harness, true
+}
+
+// isPreludeRecord returns whether the record given can be part of a prelude: a setup statement that executes on the
+// default connection and has no expectations beyond succeeding.
+func (r *Runner) isPreludeRecord(record *parser.Record) bool {
+	if !isSetupStatement(record) || record.Prepared() || record.AsyncName() != "" ||
+		record.Connection() != defaultConnection || record.ExpectsWarnings() || len(record.Routes()) > 0 ||
+		len(record.MetricAssertions()) > 0 || len(record.Requires()) > 0 {
+		return false
+	}
+	return r.shouldExecute(r.harness, record)
+}
+
+// readPrelude reads the prelude of the test file being read by the reader given. Any error reading the file ends the
+// prelude, and is returned by the prelude's next function once the records before it have been run.
+func (r *Runner) readPrelude(reader *parser.RecordReader) *prelude {
+	p := &prelude{next: reader.Next}
+	hash := sha256.New()
+	for _, statement := range r.config.SetupStatements {
+		hash.Write([]byte(statement))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte{0})
+
+	for {
+		record, err := reader.Next()
+		if err != nil || !r.isPreludeRecord(record) {
+			pending := true
+			p.next = func() (*parser.Record, error) {
+				if pending {
+					pending = false
+					return record, err
+				}
+				return reader.Next()
+			}
+			break
+		}
+		p.records = append(p.records, record)
+		hash.Write([]byte(record.Query()))
+		hash.Write([]byte{0})
+	}
+	p.key = hex.EncodeToString(hash.Sum(nil))
+	return p
+}
+
+// recordSource returns a function that returns the records of the prelude in turn, then io.EOF.
+func (p *prelude) recordSource() func() (*parser.Record, error) {
+	records := p.records
+	return func() (*parser.Record, error) {
+		if len(records) == 0 {
+			return nil, io.EOF
+		}
+		record := records[0]
+		records = records[1:]
+		return record, nil
+	}
+}
+
+// restorePrelude restores the state the prelude given leaves the database in, if it was saved by an earlier file, and
+// logs the prelude's records as passing without executing them. Returns whether the prelude was restored.
+func (r *Runner) restorePrelude(file string, harness PreludeHarness, p *prelude) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	restored, err := harness.RestorePrelude(ctx, p.key)
+	if err != nil || !restored {
+		return false, err
+	}
+
+	for _, record := range p.records {
+		ctx, cancel := r.newRecordContext(file, record, false)
+		r.trackObjects(record)
+		r.trackCatalog(record)
+		logResult(ctx, Ok, "")
+		cancel()
+	}
+	return true, nil
+}
+
+// savePrelude saves the state the prelude given leaves the database in, for later files with the same prelude.
+func (r *Runner) savePrelude(harness PreludeHarness, p *prelude) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	return harness.SavePrelude(ctx, p.key)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// preludeHarness saves the keys of preludes, and records the statements it executes.
+type preludeHarness struct {
+	*fakeHarness
+	preludes   map[string]bool
+	restored   int
+	statements []string
+}
+
+var _ PreludeHarness = &preludeHarness{}
+
+func newPreludeHarness() *preludeHarness {
+	return &preludeHarness{fakeHarness: newFakeHarness(), preludes: make(map[string]bool)}
+}
+
+func (h *preludeHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	h.statements = append(h.statements, statement)
+	return h.fakeHarness.ExecuteStatement(ctx, statement)
+}
+
+func (h *preludeHarness) SavePrelude(ctx context.Context, key string) error {
+	h.preludes[key] = true
+	return nil
+}
+
+func (h *preludeHarness) RestorePrelude(ctx context.Context, key string) (bool, error) {
+	if h.preludes[key] {
+		h.restored++
+	}
+	return h.preludes[key], nil
+}
+
+const preludeTestFile = `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+statement ok
+INSERT INTO t1 VALUES(1, 2)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+statement ok
+INSERT INTO t1 VALUES(3, 4)
+`
+
+func TestPreludeCache(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.test", "b.test"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(preludeTestFile), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.test"),
+		[]byte("statement ok\nCREATE TABLE t1(a INTEGER, b INTEGER)\n\nquery II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"), 0644))
+
+	harness := newPreludeHarness()
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithPreludeCache(true)).RunTestFiles(dir)
+
+	// The prelude of b.test is restored from the state saved after a.test's, and c.test's is a different prelude
+	assert.Equal(t, 1, harness.restored)
+	assert.Len(t, harness.preludes, 2)
+	assert.Equal(t, []string{
+		"CREATE TABLE t1(a INTEGER, b INTEGER)", "INSERT INTO t1 VALUES(1, 2)", "INSERT INTO t1 VALUES(3, 4)",
+		"INSERT INTO t1 VALUES(3, 4)",
+		"CREATE TABLE t1(a INTEGER, b INTEGER)",
+	}, harness.statements)
+
+	require.Len(t, reporter.entries, 10)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
+
+func TestPreludeCacheDisabled(t *testing.T) {
+	harness := newPreludeHarness()
+	path := writeTestFile(t, preludeTestFile)
+	NewRunner(harness, WithOutput(&bytes.Buffer{})).RunTestFiles(path, path)
+
+	assert.Empty(t, harness.preludes)
+	assert.Len(t, harness.statements, 6)
+}
+
+func TestPreludeNotSavedAfterFailure(t *testing.T) {
+	harness := newPreludeHarness()
+	path := writeTestFile(t, "statement ok\nCREATE TABLE t1(a INTEGER, b INTEGER)\n\n"+
+		"statement ok\nINSERT INTO missing VALUES(1, 2)\n\nquery II nosort\nSELECT a, b FROM t1\n----\n1\n2\n")
+	harness.statementErrors["INSERT INTO missing VALUES(1, 2)"] = assert.AnError
+
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithPreludeCache(true), WithSkipAfterSetupFailure(true)).
+		RunTestFiles(path, path)
+	assert.Empty(t, harness.preludes)
+	assert.Zero(t, harness.restored)
+}
@@ -0,0 +1,63 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A PreparedStatementHarness is a Harness that can execute statements and queries by preparing them, binding values to
+// their parameters, and executing the prepared statement, rather than by executing their text directly. Records are
+// executed this way when preceded by a prepared or bind directive in the test file, or for all records when the runner
+// is configured with WithPreparedStatements:
+//
+//	bind 1 'one'
+//	query IT nosort
+//	SELECT ?, ?
+//	----
+//	1
+//	one
+//
+// Records that must be executed as prepared statements are skipped for harnesses that don't implement this interface.
+type PreparedStatementHarness interface {
+	Harness
+
+	// ExecutePreparedStatement prepares the statement given, binds the arguments given to its parameters and executes
+	// it, returning any error. Arguments are int64, float64, string or nil for NULL.
+	ExecutePreparedStatement(ctx context.Context, statement string, args []interface{}) error
+
+	// ExecutePreparedQuery prepares the query given, binds the arguments given to its parameters and executes it,
+	// returning its results as described by Harness.ExecuteQuery.
+	ExecutePreparedQuery(ctx context.Context, query string, args []interface{}) (schema string, results []string, err error)
+}
+
+// usePreparedStatement returns whether the record given must be executed as a prepared statement.
+func (r *Runner) usePreparedStatement(record *parser.Record) bool {
+	return r.config.PreparedStatements || record.Prepared()
+}
+
+// executePreparedQuery executes the query record given as a prepared statement and verifies its results, logging any
+// failure.
+func (r *Runner) executePreparedQuery(ctx context.Context, harness PreparedStatementHarness, record *parser.Record) *R {
+	schemaStr, results, err := harness.ExecutePreparedQuery(ctx, record.Query(), record.BindArgs())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{err: err}
+	}
+
+	return r.verifyQuery(ctx, record, schemaStr, results)
+}
@@ -0,0 +1,96 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// preparedHarness records the arguments of prepared executions, and returns its bind arguments as the results of
+// prepared queries.
+type preparedHarness struct {
+	*fakeHarness
+	prepared []string
+}
+
+var _ PreparedStatementHarness = &preparedHarness{}
+
+func (h *preparedHarness) ExecutePreparedStatement(ctx context.Context, statement string, args []interface{}) error {
+	h.prepared = append(h.prepared, statement)
+	return h.ExecuteStatement(ctx, statement)
+}
+
+func (h *preparedHarness) ExecutePreparedQuery(ctx context.Context, query string, args []interface{}) (string, []string, error) {
+	h.prepared = append(h.prepared, query)
+	if len(args) == 0 {
+		return h.ExecuteQuery(ctx, query)
+	}
+
+	schema := ""
+	var results []string
+	for _, arg := range args {
+		schema += "T"
+		results = append(results, NewValueFormatter().FormatValue(arg))
+	}
+	return schema, results, nil
+}
+
+const preparedTest = `statement ok
+CREATE TABLE t1 (a int)
+
+bind 1 'it''s' NULL
+query TTT nosort
+SELECT ?, ?, ?
+----
+1
+it's
+NULL
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestPreparedStatements(t *testing.T) {
+	harness := &preparedHarness{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, preparedTest))
+	require.Len(t, reporter.entries, 3)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.ErrorMessage)
+	}
+	assert.Equal(t, []string{"SELECT ?, ?, ?"}, harness.prepared)
+
+	harness = &preparedHarness{fakeHarness: newFakeHarness()}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithPreparedStatements(true))
+	runner.RunTestFiles(writeTestFile(t, preparedTest))
+	assert.Equal(t, []string{"CREATE TABLE t1 (a int)", "SELECT ?, ?, ?", "SELECT a, b FROM t1"}, harness.prepared)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, preparedTest))
+	require.Len(t, reporter.entries, 3)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Skipped, reporter.entries[1].Result)
+	assert.Equal(t, Ok, reporter.entries[2].Result)
+}
@@ -0,0 +1,93 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A ProcedureHarness is a Harness that can call stored procedures with IN, OUT and INOUT parameters. Procedure calls
+// are written in test files as procedure records, which validate the values of OUT and INOUT parameters as a first
+// result set with one column per parameter, followed by any result sets returned by the procedure:
+//
+//	procedure I,IT rowsort
+//	count_rows('t1', OUT total)
+//	----
+//	2
+//	----
+//	1
+//	one
+//	2
+//	two
+//
+// Records written "procedure ok" or "procedure error" only check whether the call succeeds. Procedure records are
+// skipped for harnesses that don't implement this interface.
+type ProcedureHarness interface {
+	Harness
+
+	// CallProcedure calls the stored procedure with the name and arguments given and returns the values of its OUT and
+	// INOUT parameters, as well as any result sets it returns, with values formatted as described by
+	// Harness.ExecuteQuery.
+	CallProcedure(ctx context.Context, name string, args []parser.ProcedureArg) (*ProcedureResult, error)
+}
+
+// ProcedureResult is the outcome of a stored procedure call.
+type ProcedureResult struct {
+	// OutSchema is the schema of the OUT and INOUT parameter values, with one column per parameter in argument order
+	OutSchema string
+	// OutValues are the values of the OUT and INOUT parameters after the call, in argument order
+	OutValues []string
+	// Schemas are the schemas of the result sets returned by the procedure
+	Schemas []string
+	// Results are the results of the result sets returned by the procedure
+	Results [][]string
+}
+
+// executeProcedure calls the stored procedure of the procedure record given and verifies its OUT parameter values and
+// result sets, logging any failure.
+func (r *Runner) executeProcedure(ctx context.Context, record *parser.Record) *R {
+	harness, ok := r.harness.(ProcedureHarness)
+	if !ok {
+		logResult(ctx, Skipped, "")
+		return &R{skipped: true}
+	}
+
+	res, err := harness.CallProcedure(ctx, record.ProcedureName(), record.ProcedureArgs())
+	if record.ExpectError() {
+		if err == nil {
+			logFailure(ctx, MissingExpectedError, "Expected error but didn't get one")
+			return &R{err: errors.New("expected procedure error but got no error")}
+		}
+		return &R{}
+	} else if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{err: err}
+	}
+
+	if record.NumResultSets() == 0 {
+		return &R{}
+	}
+
+	schemas, results := res.Schemas, res.Results
+	if record.NumOutParams() > 0 {
+		schemas = append([]string{res.OutSchema}, schemas...)
+		results = append([][]string{res.OutValues}, results...)
+	}
+
+	return r.verifyResultSets(ctx, record, schemas, results)
+}
@@ -0,0 +1,102 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// procedureHarness implements the procedure add_one(x, OUT result), which returns x + 1 and a single result set. Calls
+// to any other procedure fail.
+type procedureHarness struct {
+	*fakeHarness
+}
+
+var _ ProcedureHarness = procedureHarness{}
+
+func (h procedureHarness) CallProcedure(ctx context.Context, name string, args []parser.ProcedureArg) (*ProcedureResult, error) {
+	if name != "add_one" || len(args) != 2 {
+		return nil, errors.New("procedure not found")
+	}
+	if args[0].Value != "1" {
+		return &ProcedureResult{OutSchema: "I", OutValues: []string{"0"}}, nil
+	}
+	return &ProcedureResult{
+		OutSchema: "I",
+		OutValues: []string{"2"},
+		Schemas:   []string{"T"},
+		Results:   [][]string{{"b", "a"}},
+	}, nil
+}
+
+const procedureTest = `procedure I,T rowsort
+add_one(1, OUT result)
+----
+2
+----
+a
+b
+
+procedure ok
+add_one(1, OUT result)
+
+procedure error
+missing()
+`
+
+func TestProcedures(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(procedureHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, procedureTest))
+	require.Len(t, reporter.entries, 3)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.ErrorMessage)
+	}
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(procedureHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "procedure I\nadd_one(2, OUT result)\n----\n3\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, procedureTest))
+	require.Len(t, reporter.entries, 3)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Skipped, entry.Result)
+	}
+}
+
+func TestGenerateProcedures(t *testing.T) {
+	path := writeTestFile(t, "procedure I,T nosort\nadd_one(1, OUT result)\n----\n2\n----\nb\na\n\nprocedure ok\nadd_one(1, OUT result)\n")
+	runner := NewRunner(procedureHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, "procedure I,T nosort\nadd_one(1, OUT result)\n----\n2\n----\nb\na\n\nprocedure ok\nadd_one(1, OUT result)\n", string(generated))
+}
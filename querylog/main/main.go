@@ -0,0 +1,77 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest/mysql"
+	"github.com/andyyu2004/sqllogictest/querylog"
+)
+
+// defaultDSN is the data source name used when SQLLOGICTEST_MYSQL_DSN isn't set.
+const defaultDSN = "sqllogictest:password@tcp(127.0.0.1:3306)/sqllogictest"
+
+// Query log importer. Reads a MySQL general or slow query log, replays its statements against MySQL, and writes a test
+// file with their results to STDOUT. Connects as the MySQL test runner does, to the data source name given in the
+// SQLLOGICTEST_MYSQL_DSN environment variable or a local MySQL with user sqllogictest, password "password" and database
+// "sqllogictest", whose tables are dropped first.
+//
+// Usage: go run main.go (general|slow) logfile > workload.test
+func main() {
+	if len(os.Args) != 3 {
+		exitWithUsage()
+	}
+
+	var parse func(io.Reader) ([]querylog.Entry, error)
+	switch os.Args[1] {
+	case "general":
+		parse = querylog.ParseGeneralLog
+	case "slow":
+		parse = querylog.ParseSlowLog
+	default:
+		exitWithUsage()
+	}
+
+	file, err := os.Open(os.Args[2])
+	if err != nil {
+		exitWithError(err)
+	}
+	entries, err := parse(file)
+	file.Close()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	dsn := os.Getenv("SQLLOGICTEST_MYSQL_DSN")
+	if dsn == "" {
+		dsn = defaultDSN
+	}
+	if err := querylog.Replay(os.Stdout, mysql.NewMysqlHarness(dsn), entries); err != nil {
+		exitWithError(err)
+	}
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func exitWithUsage() {
+	fmt.Println("Usage: querylog (general|slow) logfile")
+	os.Exit(1)
+}
@@ -0,0 +1,150 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package querylog turns the statements captured in an engine's query log into test files, by replaying them against a
+// harness and recording the results.
+package querylog
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// An Entry is a statement captured in a query log.
+type Entry struct {
+	// Connection is the identifier of the connection the statement was executed on
+	Connection string
+	// Statement is the statement executed, without a trailing semicolon
+	Statement string
+}
+
+// generalLogEntryRegex matches the first line of an entry of a MySQL general query log, which starts with a timestamp
+// in MySQL 5.7 and later, and either with a timestamp or with two tabs in older versions.
+var generalLogEntryRegex = regexp.MustCompile(
+	`^(?:\d{4}-\d\d-\d\dT\S+\s+|\d{6}\s+\d{1,2}:\d\d:\d\d\s+|\t\t\s*)(\d+)\s([A-Z][a-z]*(?: [A-Za-z]+)?)\t?(.*)$`)
+
+// logHeaderRegex matches the lines a MySQL server writes at the start of its query logs, and again whenever it
+// restarts.
+var logHeaderRegex = regexp.MustCompile(`^(?:\S+, Version: |Tcp port: |Time\s+Id\s+Command\s+Argument)`)
+
+// slowLogConnectionRegex matches the connection identifier in the header of an entry of a MySQL slow query log.
+var slowLogConnectionRegex = regexp.MustCompile(`\bId:\s*(\d+)`)
+
+// slowLogTimestampRegex matches the statement a MySQL slow query log writes before each entry to give its time.
+var slowLogTimestampRegex = regexp.MustCompile(`(?i)^SET\s+timestamp\s*=\s*\d+$`)
+
+// ParseGeneralLog returns the statements in the MySQL general query log given. Queries and executed prepared
+// statements are returned as they were logged, and changes of the default database as USE statements. Other commands,
+// such as connecting and preparing statements, are left out.
+func ParseGeneralLog(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	// continues is whether lines that aren't the start of an entry continue the last entry's statement
+	continues := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if logHeaderRegex.MatchString(line) {
+			continues = false
+			continue
+		}
+
+		match := generalLogEntryRegex.FindStringSubmatch(line)
+		if match == nil {
+			if continues {
+				entries[len(entries)-1].Statement += "\n" + line
+			}
+			continue
+		}
+
+		continues = false
+		connection, command, argument := match[1], match[2], match[3]
+		switch command {
+		case "Query", "Execute":
+			entries = append(entries, Entry{Connection: connection, Statement: argument})
+			continues = true
+		case "Init DB":
+			entries = append(entries, Entry{Connection: connection, Statement: "USE " + strings.TrimSpace(argument)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Statement = trimStatement(entries[i].Statement)
+	}
+	return removeEmpty(entries), nil
+}
+
+// ParseSlowLog returns the statements in the MySQL slow query log given. The statements the log writes to give the time
+// of each entry are left out.
+func ParseSlowLog(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var connection string
+	var statement strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if match := slowLogConnectionRegex.FindStringSubmatch(line); match != nil {
+				connection = match[1]
+			}
+			continue
+		}
+		if logHeaderRegex.MatchString(line) {
+			continue
+		}
+
+		if statement.Len() > 0 {
+			statement.WriteString("\n")
+		}
+		statement.WriteString(line)
+		if !strings.HasSuffix(strings.TrimSpace(line), ";") {
+			continue
+		}
+
+		if s := trimStatement(statement.String()); !slowLogTimestampRegex.MatchString(s) {
+			entries = append(entries, Entry{Connection: connection, Statement: s})
+		}
+		statement.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if statement.Len() > 0 {
+		entries = append(entries, Entry{Connection: connection, Statement: trimStatement(statement.String())})
+	}
+	return removeEmpty(entries), nil
+}
+
+// trimStatement removes the surrounding whitespace and trailing semicolons of the statement given.
+func trimStatement(statement string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(statement), ";"))
+}
+
+// removeEmpty removes the entries with empty statements from the entries given.
+func removeEmpty(entries []Entry) []Entry {
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.Statement != "" {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
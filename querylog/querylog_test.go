@@ -0,0 +1,180 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querylog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generalLog = `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+2024-01-01T00:00:00.000000Z	    8 Connect	root@localhost on test using Socket
+2024-01-01T00:00:00.000100Z	    8 Init DB	test
+2024-01-01T00:00:00.000200Z	    8 Query	CREATE TABLE t1(a INTEGER, b TEXT)
+2024-01-01T00:00:00.000300Z	    9 Connect	root@localhost on test using Socket
+2024-01-01T00:00:00.000400Z	    9 Prepare	INSERT INTO t1 VALUES (?, ?)
+2024-01-01T00:00:00.000500Z	    9 Execute	INSERT INTO t1 VALUES (1, 'x')
+2024-01-01T00:00:00.000600Z	    8 Query	SELECT a, b
+FROM t1
+WHERE a > 0;
+/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+240101  0:00:01	   10 Query	SELECT b FROM t1 ORDER BY a
+		   10 Query	DROP TABLE missing
+		   10 Quit	
+`
+
+func TestParseGeneralLog(t *testing.T) {
+	entries, err := ParseGeneralLog(strings.NewReader(generalLog))
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Connection: "8", Statement: "USE test"},
+		{Connection: "8", Statement: "CREATE TABLE t1(a INTEGER, b TEXT)"},
+		{Connection: "9", Statement: "INSERT INTO t1 VALUES (1, 'x')"},
+		{Connection: "8", Statement: "SELECT a, b\nFROM t1\nWHERE a > 0"},
+		{Connection: "10", Statement: "SELECT b FROM t1 ORDER BY a"},
+		{Connection: "10", Statement: "DROP TABLE missing"},
+	}, entries)
+}
+
+const slowLog = `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-01-01T00:00:00.000000Z
+# User@Host: root[root] @ localhost []  Id:     8
+# Query_time: 2.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 1000
+use test;
+SET timestamp=1704067200;
+SELECT COUNT(*)
+FROM t1;
+# Time: 2024-01-01T00:00:01.000000Z
+# User@Host: root[root] @ localhost []  Id:     9
+# Query_time: 3.000000  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 1000
+SET timestamp=1704067201;
+UPDATE t1 SET b = 'y';
+`
+
+func TestParseSlowLog(t *testing.T) {
+	entries, err := ParseSlowLog(strings.NewReader(slowLog))
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Connection: "8", Statement: "use test"},
+		{Connection: "8", Statement: "SELECT COUNT(*)\nFROM t1"},
+		{Connection: "9", Statement: "UPDATE t1 SET b = 'y'"},
+	}, entries)
+}
+
+func TestReplay(t *testing.T) {
+	entries := []Entry{
+		{Connection: "8", Statement: "CREATE TABLE t1(a INTEGER, b TEXT)"},
+		{Connection: "8", Statement: "INSERT INTO t1 VALUES (2, 'y'), (1, 'x')"},
+		{Connection: "8", Statement: "SELECT a, b\nFROM t1"},
+		{Connection: "8", Statement: "SELECT b FROM t1 ORDER BY a DESC"},
+		{Connection: "8", Statement: "SELECT b FROM t1 # trailing comment"},
+		{Connection: "8", Statement: "DROP TABLE missing"},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, Replay(&out, sqlite.NewSqliteHarness(":memory:"), entries))
+	assert.Equal(t, `statement ok
+CREATE TABLE t1(a INTEGER, b TEXT)
+
+statement ok
+INSERT INTO t1 VALUES (2, 'y'), (1, 'x')
+
+query IT rowsort
+SELECT a, b FROM t1
+----
+1
+x
+2
+y
+
+query T nosort
+SELECT b FROM t1 ORDER BY a DESC
+----
+y
+x
+
+# Left out a statement of connection 8 containing a comment
+
+statement error
+DROP TABLE missing
+
+`, out.String())
+
+	// The recorded file passes against the harness it was recorded with
+	path := filepath.Join(t.TempDir(), "workload.test")
+	require.NoError(t, os.WriteFile(path, out.Bytes(), 0644))
+	output := &bytes.Buffer{}
+	logictest.NewRunner(sqlite.NewSqliteHarness(":memory:"), logictest.WithOutput(output)).RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Equal(t, 5, strings.Count(output.String(), " ok"))
+}
+
+// multiConnectionHarness opens connections that share the state of the harness it wraps.
+type multiConnectionHarness struct {
+	logictest.Harness
+}
+
+type sharedConnection struct {
+	logictest.Harness
+}
+
+func (h multiConnectionHarness) NewConnection() (logictest.Harness, error) {
+	return sharedConnection{h.Harness}, nil
+}
+
+func TestReplayConnections(t *testing.T) {
+	entries := []Entry{
+		{Connection: "8", Statement: "CREATE TABLE t1(a INTEGER)"},
+		{Connection: "9", Statement: "INSERT INTO t1 VALUES (1)"},
+		{Connection: "8", Statement: "SELECT a FROM t1"},
+	}
+
+	var out bytes.Buffer
+	harness := multiConnectionHarness{sqlite.NewSqliteHarness(":memory:")}
+	require.NoError(t, Replay(&out, harness, entries))
+	assert.Equal(t, `statement ok
+CREATE TABLE t1(a INTEGER)
+
+connection conn9
+statement ok
+INSERT INTO t1 VALUES (1)
+
+query I rowsort
+SELECT a FROM t1
+----
+1
+
+`, out.String())
+
+	// Without multiple connections, every statement is executed on the harness
+	out.Reset()
+	require.NoError(t, Replay(&out, sqlite.NewSqliteHarness(":memory:"), entries))
+	assert.NotContains(t, out.String(), "connection")
+}
@@ -0,0 +1,118 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querylog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/convert"
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// defaultTimeout is the timeout for replaying each statement, unless the harness gives one.
+const defaultTimeout = time.Minute
+
+// connectionPrefix is prepended to the identifiers of the connections in a query log to name the connections of the
+// test file.
+const connectionPrefix = "conn"
+
+// Replay executes the statements of the entries given on the harness given, in order, and writes a test file with
+// their results to w. Statements that return rows are written as query records, and all others as statement records
+// that expect the outcome they had.
+//
+// The statements of the first connection in the log are executed on the harness itself. When the harness implements
+// logictest.MultiConnectionHarness, the statements of every other connection are executed on a connection of their
+// own, and written with a connection directive. Otherwise every statement is executed on the harness. Statements
+// containing #, which test files treat as the start of a comment, are left out with a comment saying so.
+func Replay(w io.Writer, harness logictest.Harness, entries []Entry) error {
+	if err := harness.Init(); err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+	if t := harness.GetTimeout(); t != 0 {
+		timeout = time.Duration(t) * time.Second
+	}
+
+	// connections are the connections opened for the connections in the log other than the first
+	connections := map[string]logictest.Harness{}
+	defer func() {
+		for _, conn := range connections {
+			if closer, ok := conn.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}()
+
+	multiHarness, multipleConnections := harness.(logictest.MultiConnectionHarness)
+	var firstConnection string
+	wr := bufio.NewWriter(w)
+	for _, entry := range entries {
+		statement, err := convert.FormatStatement(entry.Statement)
+		if err != nil {
+			wr.WriteString(fmt.Sprintf("# Left out a statement of connection %s containing a comment\n\n", entry.Connection))
+			continue
+		}
+
+		if firstConnection == "" {
+			firstConnection = entry.Connection
+		}
+		conn := harness
+		if multipleConnections && entry.Connection != firstConnection {
+			var ok bool
+			if conn, ok = connections[entry.Connection]; !ok {
+				if conn, err = multiHarness.NewConnection(); err != nil {
+					return fmt.Errorf("unable to open connection %s: %v", entry.Connection, err)
+				}
+				connections[entry.Connection] = conn
+			}
+			wr.WriteString("connection " + connectionPrefix + entry.Connection + "\n")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		if convert.IsQuery(statement) {
+			writeQuery(ctx, wr, conn, statement)
+		} else if err := conn.ExecuteStatement(ctx, statement); err != nil {
+			wr.WriteString("statement error\n" + statement + "\n\n")
+		} else {
+			wr.WriteString("statement ok\n" + statement + "\n\n")
+		}
+		cancel()
+	}
+	return wr.Flush()
+}
+
+// writeQuery executes the query given and writes a query record with its results, or a statement record expecting an
+// error if it fails.
+func writeQuery(ctx context.Context, wr *bufio.Writer, harness logictest.Harness, query string) {
+	schema, results, err := harness.ExecuteQuery(ctx, query)
+	if err != nil {
+		wr.WriteString("statement error\n" + query + "\n\n")
+		return
+	}
+
+	sortMode := convert.DefaultSortMode(query)
+	results = convert.SortValues(sortMode, results, len(schema))
+	wr.WriteString("query " + schema + " " + string(sortMode) + "\n" + query + "\n----\n")
+	for _, result := range results {
+		wr.WriteString(parser.EscapeResult(logictest.EscapeLiteral(result)) + "\n")
+	}
+	wr.WriteString("\n")
+}
@@ -0,0 +1,95 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A QueryNormalizer rewrites the text of a statement or query before it's executed. Normalizers are applied in turn to
+// the text exactly as written in the test file, as returned by parser.Record.RawQuery, before any Translators.
+type QueryNormalizer func(query string) string
+
+// StripComments removes comment lines from queries, along with the # comments at the end of their other lines outside
+// quotes.
+func StripComments(query string) string {
+	lines := strings.Split(query, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, parser.StripComment(line))
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// JoinLines concatenates the lines of queries as they are, without anything between them.
+func JoinLines(query string) string {
+	return strings.ReplaceAll(query, "\n", "")
+}
+
+// CollapseNewlines joins the lines of queries with single spaces, removing the whitespace around their line breaks,
+// so that a line break always separates tokens.
+func CollapseNewlines(query string) string {
+	lines := strings.Split(query, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, " ")
+}
+
+// DefaultQueryNormalizers are the query normalizers of the parser, whose result is returned by parser.Record.Query:
+// comments are stripped and lines concatenated as they are. The runner executes these queries unless it's configured
+// with other normalizers.
+var DefaultQueryNormalizers = []QueryNormalizer{StripComments, JoinLines}
+
+// queryNormalizers are the built-in query normalizers, by the names they're given on the command line.
+var queryNormalizers = map[string]QueryNormalizer{
+	"strip-comments":    StripComments,
+	"join-lines":        JoinLines,
+	"collapse-newlines": CollapseNewlines,
+}
+
+// LookupQueryNormalizer returns the built-in query normalizer with the name given, e.g. strip-comments.
+func LookupQueryNormalizer(name string) (QueryNormalizer, error) {
+	normalizer, ok := queryNormalizers[name]
+	if !ok {
+		names := make([]string, 0, len(queryNormalizers))
+		for name := range queryNormalizers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown query normalizer %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return normalizer, nil
+}
+
+// executableQuery returns the text of the statement or query of the record given to execute: its raw text rewritten
+// by the configured query normalizers, or its parsed query if none are configured or it has no raw text.
+func (c RunConfig) executableQuery(record *parser.Record) string {
+	if c.QueryNormalizers == nil || record.RawQuery() == "" {
+		return record.Query()
+	}
+
+	query := record.RawQuery()
+	for _, normalizer := range c.QueryNormalizers {
+		query = normalizer(query)
+	}
+	return query
+}
@@ -0,0 +1,82 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+const queryTextTest = `query II nosort
+SELECT /*+ INDEX(t1 idx) */ a, b
+# the hint forces the index
+FROM t1 # one row
+----
+1
+2
+`
+
+func TestQueryNormalizers(t *testing.T) {
+	query := "SELECT /*+ INDEX(t1 idx) */ a, b\n# the hint forces the index\nFROM t1 # one row"
+	assert.Equal(t, "SELECT /*+ INDEX(t1 idx) */ a, b\nFROM t1 ", StripComments(query))
+	assert.Equal(t, "SELECT a,b", JoinLines("SELECT a,\nb"))
+	assert.Equal(t, "SELECT a, b", CollapseNewlines("SELECT a,  \n  b"))
+
+	normalizer, err := LookupQueryNormalizer("collapse-newlines")
+	require.NoError(t, err)
+	assert.Equal(t, "a b", normalizer("a\nb"))
+	_, err = LookupQueryNormalizer("strip-hints")
+	assert.Error(t, err)
+}
+
+func TestDefaultQueryNormalizers(t *testing.T) {
+	records, err := parser.ParseTestFile(writeTestFile(t, queryTextTest))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	runConfig := RunConfig{QueryNormalizers: DefaultQueryNormalizers}
+	assert.Equal(t, records[0].Query(), runConfig.executableQuery(records[0]))
+}
+
+func TestExecuteNormalizedQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []RunOption
+		query string
+	}{
+		{"default", nil, "SELECT /*+ INDEX(t1 idx) */ a, bFROM t1 "},
+		{"verbatim", []RunOption{WithQueryNormalizers()},
+			"SELECT /*+ INDEX(t1 idx) */ a, b\n# the hint forces the index\nFROM t1 # one row"},
+		{"collapsed", []RunOption{WithQueryNormalizers(StripComments, CollapseNewlines)},
+			"SELECT /*+ INDEX(t1 idx) */ a, b FROM t1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			harness := newFakeHarness()
+			harness.queryResults[tt.query] = fakeResult{schema: "II", results: []string{"1", "2"}}
+
+			reporter := &collectingReporter{}
+			opts := append([]RunOption{WithOutput(&bytes.Buffer{}), WithReporters(reporter)}, tt.opts...)
+			NewRunner(harness, opts...).RunTestFiles(writeTestFile(t, queryTextTest))
+			require.Len(t, reporter.entries, 1)
+			assert.Equal(t, Ok, reporter.entries[0].Result)
+		})
+	}
+}
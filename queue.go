@@ -0,0 +1,244 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A WorkQueue hands out the test files of a run spread across runner processes, possibly on different machines, so
+// that each test file is run by exactly one of them without sharding the corpus ahead of time. Processes that finish
+// their files early simply claim more.
+type WorkQueue interface {
+	// Claim claims the next unclaimed test file of the queue for the caller, returning false once every test file has
+	// been claimed. A test file is only ever claimed once, however many processes claim concurrently.
+	Claim(ctx context.Context) (string, bool, error)
+}
+
+// RunQueue runs the test files claimed from the queue given until every one has been claimed, or a "halt run" record
+// stops the run of this process. Test files are otherwise run as by RunTestFiles, except that the queue takes the
+// place of sharding. Excluded test files are claimed but not run. Once the run's time budget is exhausted, no further
+// test files are claimed, leaving them to other processes. Panics if a test file can't be claimed.
+func (r *Runner) RunQueue(queue WorkQueue) {
+	budget := r.config.newRunBudget()
+	for !budget.exhausted() {
+		file, ok, err := queue.Claim(context.Background())
+		if err != nil {
+			panic(err)
+		}
+		if !ok {
+			return
+		}
+		if len(r.config.excludeTestFiles([]string{file})) == 0 {
+			continue
+		}
+		budget.ran = append(budget.ran, file)
+		if !r.runTestFile(file) {
+			return
+		}
+	}
+	fmt.Fprintf(r.config.Output, "Time budget of %s exhausted: ran %d test files and left the rest of the queue to "+
+		"other processes\n", budget.max, len(budget.ran))
+}
+
+// A FileQueue is a WorkQueue kept in a file on a file system shared by the processes of a run, such as a network
+// file system. The queue file lists the test files of the run, one per line, and claims are recorded as marker files in
+// a directory next to it, named after the queue file with a .claims suffix. Markers are created exclusively, so that
+// each test file is claimed once even by processes on different machines. Test file paths are used as they're written
+// in the queue file, so every process must see the test files at those paths, e.g. relative to the same working
+// directory.
+type FileQueue struct {
+	files  []string
+	claims string
+	// next is the index of the first test file this process hasn't tried to claim yet
+	next int
+	mu   sync.Mutex
+}
+
+// CreateFileQueue returns a FileQueue for the queue file at the path given, creating it with the test files given if
+// it doesn't exist. Every process of a run can create the queue with the same test files: the first one to do so
+// writes the queue file, and the others use it as it was written, since the file only ever appears complete.
+func CreateFileQueue(path string, files []string) (*FileQueue, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, file := range files {
+		if strings.ContainsAny(file, "\r\n") {
+			tmp.Close()
+			return nil, fmt.Errorf("test file path %q can't be written to a queue file", file)
+		}
+		fmt.Fprintln(w, file)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	// Linking the complete file into place fails if another process created the queue file first
+	if err := os.Link(tmp.Name(), path); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, err
+	}
+	return OpenFileQueue(path)
+}
+
+// OpenFileQueue returns a FileQueue for the existing queue file at the path given.
+func OpenFileQueue(path string) (*FileQueue, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+
+	claims := path + ".claims"
+	if err := os.MkdirAll(claims, 0755); err != nil {
+		return nil, err
+	}
+	return &FileQueue{files: files, claims: claims}, nil
+}
+
+// Claim claims the next test file of the queue that no process has claimed, by creating its marker file.
+func (q *FileQueue) Claim(ctx context.Context) (string, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for ; q.next < len(q.files); q.next++ {
+		if err := ctx.Err(); err != nil {
+			return "", false, err
+		}
+
+		// Markers are named by line number, since the same test file can be listed more than once
+		marker, err := os.OpenFile(filepath.Join(q.claims, strconv.Itoa(q.next+1)), os.O_CREATE|os.O_EXCL|os.O_WRONLY,
+			0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		} else if err != nil {
+			return "", false, err
+		}
+		if err := marker.Close(); err != nil {
+			return "", false, err
+		}
+
+		file := q.files[q.next]
+		q.next++
+		return file, true, nil
+	}
+	return "", false, nil
+}
+
+// A QueueServer is an http.Handler that serves a WorkQueue of the test files it's given to the processes of a run, for
+// runs without a shared file system. Each POST request claims the next test file, which is returned as the plain text
+// body of the response, and the response has status 204 No Content once every test file has been claimed. Use an
+// HTTPQueue to claim test files from it.
+type QueueServer struct {
+	files []string
+	next  int
+	mu    sync.Mutex
+}
+
+// NewQueueServer returns a QueueServer for the test files given, which are handed out in order.
+func NewQueueServer(files []string) *QueueServer {
+	return &QueueServer{files: files}
+}
+
+// ServeHTTP claims the next test file for the request.
+func (s *QueueServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "test files are claimed with POST requests", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	if s.next == len(s.files) {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	file := s.files[s.next]
+	s.next++
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, file)
+}
+
+// Remaining returns the number of test files that haven't been claimed yet.
+func (s *QueueServer) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files) - s.next
+}
+
+// An HTTPQueue is a WorkQueue that claims test files from a QueueServer at a URL.
+type HTTPQueue struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPQueue returns an HTTPQueue that claims test files from the QueueServer at the URL given.
+func NewHTTPQueue(url string) *HTTPQueue {
+	return &HTTPQueue{url: url, client: http.DefaultClient}
+}
+
+// Claim claims the next test file from the server.
+func (q *HTTPQueue) Claim(ctx context.Context) (string, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.url, nil)
+	if err != nil {
+		return "", false, err
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return string(body), true, nil
+	case http.StatusNoContent:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("claiming a test file from %s: %s: %s", q.url, resp.Status,
+			strings.TrimSpace(string(body)))
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// claimAll claims test files from the queues given concurrently until they're all claimed, and returns the test files
+// claimed, sorted.
+func claimAll(t *testing.T, queues ...WorkQueue) []string {
+	var mu sync.Mutex
+	var claimed []string
+	var wg sync.WaitGroup
+	for _, queue := range queues {
+		wg.Add(1)
+		go func(queue WorkQueue) {
+			defer wg.Done()
+			for {
+				file, ok, err := queue.Claim(context.Background())
+				if !assert.NoError(t, err) || !ok {
+					return
+				}
+				mu.Lock()
+				claimed = append(claimed, file)
+				mu.Unlock()
+			}
+		}(queue)
+	}
+	wg.Wait()
+	sort.Strings(claimed)
+	return claimed
+}
+
+func TestFileQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.queue")
+	files := []string{"a.test", "b.test", "c.test", "d.test", "a.test"}
+
+	first, err := CreateFileQueue(path, files)
+	require.NoError(t, err)
+	// Later processes use the queue file as the first one wrote it
+	second, err := CreateFileQueue(path, []string{"other.test"})
+	require.NoError(t, err)
+	third, err := OpenFileQueue(path)
+	require.NoError(t, err)
+	assertFileContents(t, path, "a.test\nb.test\nc.test\nd.test\na.test\n")
+
+	assert.Equal(t, []string{"a.test", "a.test", "b.test", "c.test", "d.test"}, claimAll(t, first, second, third))
+
+	// Once claimed, test files aren't claimed again by new processes
+	fourth, err := OpenFileQueue(path)
+	require.NoError(t, err)
+	_, ok, err := fourth.Claim(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = OpenFileQueue(filepath.Join(t.TempDir(), "missing.queue"))
+	assert.Error(t, err)
+}
+
+func TestHTTPQueue(t *testing.T) {
+	queueServer := NewQueueServer([]string{"a.test", "b.test", "c.test", "d.test"})
+	server := httptest.NewServer(queueServer)
+	defer server.Close()
+
+	assert.Equal(t, []string{"a.test", "b.test", "c.test", "d.test"},
+		claimAll(t, NewHTTPQueue(server.URL), NewHTTPQueue(server.URL), NewHTTPQueue(server.URL)))
+	assert.Equal(t, 0, queueServer.Remaining())
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	_, _, err = NewHTTPQueue(notFound.URL).Claim(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRunQueue(t *testing.T) {
+	dir := writeCorpus(t, "a.test", "b.test", "c.test")
+	path := filepath.Join(dir, "run.queue")
+	files := []string{filepath.Join(dir, "a.test"), filepath.Join(dir, "b.test"), filepath.Join(dir, "c.test")}
+
+	// Two runners share the queue, and each test file is run by one of them
+	var reporters []*collectingReporter
+	for i := 0; i < 2; i++ {
+		queue, err := CreateFileQueue(path, files)
+		require.NoError(t, err)
+		reporter := &collectingReporter{}
+		reporters = append(reporters, reporter)
+		NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunQueue(queue)
+	}
+	assert.Len(t, reporters[0].entries, 3)
+	assert.Empty(t, reporters[1].entries)
+}
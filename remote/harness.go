@@ -0,0 +1,159 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remote provides a harness for engines running in another process, which implement the gRPC service defined
+// in proto/harness.proto. Engines not written in Go can implement this service in their own language to be tested by
+// this runner.
+package remote
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative proto/harness.proto
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	pb "github.com/andyyu2004/sqllogictest/remote/proto"
+)
+
+// RemoteHarness is a harness that executes records on an engine serving the Harness gRPC service.
+type RemoteHarness struct {
+	conn    *grpc.ClientConn
+	client  pb.HarnessClient
+	engine  string
+	timeout int64
+}
+
+var _ logictest.Harness = &RemoteHarness{}
+
+// NewRemoteHarness returns a harness for the engine serving the Harness gRPC service at the address given, connecting
+// with the dial options given, or without transport security if none are given. The engine string and timeout are
+// requested from the server once, when connecting.
+func NewRemoteHarness(address string, opts ...grpc.DialOption) (*RemoteHarness, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewRemoteHarnessForConn(conn)
+	res, err := h.client.EngineStr(context.Background(), &pb.EngineStrRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	h.engine = res.GetEngine()
+	h.timeout = res.GetTimeoutSeconds()
+
+	return h, nil
+}
+
+// NewRemoteHarnessForConn returns a harness using the client connection given, without requesting the engine string
+// or timeout from the server. Callers that need them should use NewRemoteHarness instead.
+func NewRemoteHarnessForConn(conn *grpc.ClientConn) *RemoteHarness {
+	return &RemoteHarness{conn: conn, client: pb.NewHarnessClient(conn)}
+}
+
+// Close closes the connection to the server.
+func (h *RemoteHarness) Close() error {
+	return h.conn.Close()
+}
+
+// See Harness.EngineStr
+func (h *RemoteHarness) EngineStr() string {
+	return h.engine
+}
+
+// See Harness.Init
+func (h *RemoteHarness) Init() error {
+	_, err := h.client.Init(context.Background(), &pb.InitRequest{})
+	return err
+}
+
+// See Harness.ExecuteStatement
+func (h *RemoteHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	res, err := h.client.ExecuteStatement(ctx, &pb.ExecuteStatementRequest{Statement: statement})
+	if err != nil {
+		return err
+	}
+	if res.GetError() != "" {
+		return errors.New(res.GetError())
+	}
+	return nil
+}
+
+// See Harness.ExecuteQuery
+func (h *RemoteHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
+	res, err := h.client.ExecuteQuery(ctx, &pb.ExecuteQueryRequest{Query: statement})
+	if err != nil {
+		return "", nil, err
+	}
+	if res.GetError() != "" {
+		return "", nil, errors.New(res.GetError())
+	}
+	return res.GetSchema(), res.GetResults(), nil
+}
+
+// See Harness.GetTimeout
+func (h *RemoteHarness) GetTimeout() int64 {
+	return h.timeout
+}
+
+// server serves the Harness gRPC service for a Go harness.
+type server struct {
+	pb.UnimplementedHarnessServer
+	harness logictest.Harness
+}
+
+// NewServer returns an implementation of the Harness gRPC service backed by the harness given, for serving Go
+// harnesses to runners in other processes, e.g.:
+//
+//	s := grpc.NewServer()
+//	pb.RegisterHarnessServer(s, remote.NewServer(harness))
+//	s.Serve(listener)
+func NewServer(harness logictest.Harness) pb.HarnessServer {
+	return &server{harness: harness}
+}
+
+func (s *server) EngineStr(ctx context.Context, req *pb.EngineStrRequest) (*pb.EngineStrResponse, error) {
+	return &pb.EngineStrResponse{Engine: s.harness.EngineStr(), TimeoutSeconds: s.harness.GetTimeout()}, nil
+}
+
+func (s *server) Init(ctx context.Context, req *pb.InitRequest) (*pb.InitResponse, error) {
+	if err := s.harness.Init(); err != nil {
+		return nil, err
+	}
+	return &pb.InitResponse{}, nil
+}
+
+func (s *server) ExecuteStatement(ctx context.Context, req *pb.ExecuteStatementRequest) (*pb.ExecuteStatementResponse, error) {
+	if err := s.harness.ExecuteStatement(ctx, req.GetStatement()); err != nil {
+		return &pb.ExecuteStatementResponse{Error: err.Error()}, nil
+	}
+	return &pb.ExecuteStatementResponse{}, nil
+}
+
+func (s *server) ExecuteQuery(ctx context.Context, req *pb.ExecuteQueryRequest) (*pb.ExecuteQueryResponse, error) {
+	schema, results, err := s.harness.ExecuteQuery(ctx, req.GetQuery())
+	if err != nil {
+		return &pb.ExecuteQueryResponse{Error: err.Error()}, nil
+	}
+	return &pb.ExecuteQueryResponse{Schema: schema, Results: results}, nil
+}
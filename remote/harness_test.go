@@ -0,0 +1,91 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/andyyu2004/sqllogictest/remote/proto"
+)
+
+// testHarness returns a single row for every query and fails statements other than "CREATE TABLE t1 (a int)".
+type testHarness struct {
+	inits int
+}
+
+func (h *testHarness) EngineStr() string {
+	return "test"
+}
+
+func (h *testHarness) Init() error {
+	h.inits++
+	return nil
+}
+
+func (h *testHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	if statement != "CREATE TABLE t1 (a int)" {
+		return errors.New("unsupported statement")
+	}
+	return nil
+}
+
+func (h *testHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	return "IT", []string{"1", "one"}, nil
+}
+
+func (h *testHarness) GetTimeout() int64 {
+	return 30
+}
+
+func TestRemoteHarness(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	harness := &testHarness{}
+	pb.RegisterHarnessServer(s, NewServer(harness))
+	go s.Serve(listener)
+	defer s.Stop()
+
+	remote, err := NewRemoteHarness("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer remote.Close()
+
+	assert.Equal(t, "test", remote.EngineStr())
+	assert.Equal(t, int64(30), remote.GetTimeout())
+
+	require.NoError(t, remote.Init())
+	assert.Equal(t, 1, harness.inits)
+
+	ctx := context.Background()
+	assert.NoError(t, remote.ExecuteStatement(ctx, "CREATE TABLE t1 (a int)"))
+	assert.EqualError(t, remote.ExecuteStatement(ctx, "DROP TABLE t1"), "unsupported statement")
+
+	schema, results, err := remote.ExecuteQuery(ctx, "SELECT 1, 'one'")
+	require.NoError(t, err)
+	assert.Equal(t, "IT", schema)
+	assert.Equal(t, []string{"1", "one"}, results)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A Reporter receives a structured entry for the result of every record logged by the runner.
+type Reporter interface {
+	Report(entry *ResultLogEntry)
+}
+
+// TextReporter writes results in the line-oriented log format understood by ParseResultFile.
+type TextReporter struct {
+	w io.Writer
+}
+
+var _ Reporter = &TextReporter{}
+
+// NewTextReporter returns a reporter that writes text log lines to the writer given.
+func NewTextReporter(w io.Writer) *TextReporter {
+	return &TextReporter{w: w}
+}
+
+// See Reporter.Report
+func (r *TextReporter) Report(entry *ResultLogEntry) {
+	fmt.Fprintln(r.w, formatLogEntry(entry))
+}
+
+// JSONReporter writes results as JSON objects, one per line, suitable for consumption by other tools.
+type JSONReporter struct {
+	enc *json.Encoder
+}
+
+var _ Reporter = &JSONReporter{}
+
+// NewJSONReporter returns a reporter that writes one JSON object per result to the writer given.
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{enc: json.NewEncoder(w)}
+}
+
+// See Reporter.Report
+func (r *JSONReporter) Report(entry *ResultLogEntry) {
+	if err := r.enc.Encode(entry); err != nil {
+		panic(err)
+	}
+}
+
+// formatLogEntry renders the entry given as a single result log line, e.g.:
+// 2019-10-16T12:20:29.0594292-07:00 123 index/random/10/slt_good_0.test:535: SELECT * FROM tab0 ok
+func formatLogEntry(entry *ResultLogEntry) string {
+	prefix := fmt.Sprintf("%s %d %s:%d: %s",
+		entry.EntryTime.Format(time.RFC3339Nano),
+		entry.Duration.Milliseconds(),
+		entry.TestFile,
+		entry.LineNum,
+		entry.Query)
+
+	if entry.Result == NotOk {
+		message := entry.ErrorMessage
+		if entry.Confirmation != "" {
+			message += confirmationSuffix(entry.Confirmation)
+		}
+		return strings.ReplaceAll(prefix+" not ok: "+message, "\n", " ")
+	}
+	if entry.Result == DidNotRun && entry.ErrorMessage != "" {
+		// The root cause of records that didn't run follows their result, as the message of a failure does
+		return strings.ReplaceAll(prefix+" did not run: "+entry.ErrorMessage, "\n", " ")
+	}
+	return prefix + " " + entry.Result.String()
+}
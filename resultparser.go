@@ -36,15 +36,138 @@ const (
 	DidNotRun
 )
 
+var resultTypeStrings = map[ResultType]string{
+	Ok:        "ok",
+	NotOk:     "not ok",
+	Skipped:   "skipped",
+	Timeout:   "timeout",
+	DidNotRun: "did not run",
+}
+
+// String returns the string used for this result type in result logs.
+func (rt ResultType) String() string {
+	if s, ok := resultTypeStrings[rt]; ok {
+		return s
+	}
+	return fmt.Sprintf("ResultType(%d)", int(rt))
+}
+
+// MarshalText implements encoding.TextMarshaler, so that result types are readable in JSON reports.
+func (rt ResultType) MarshalText() ([]byte, error) {
+	return []byte(rt.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (rt *ResultType) UnmarshalText(text []byte) error {
+	for t, s := range resultTypeStrings {
+		if s == string(text) {
+			*rt = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized result type %q", string(text))
+}
+
+// FailureCode classifies the cause of a failed record, so that failures can be aggregated by category.
+type FailureCode string
+
+const (
+	// NoFailure is the code for results that did not fail.
+	NoFailure FailureCode = ""
+	// SchemaMismatch means the schema of a query's results differed from the expected schema.
+	SchemaMismatch FailureCode = "SchemaMismatch"
+	// RowCountMismatch means a query returned a different number of result values than expected.
+	RowCountMismatch FailureCode = "RowCountMismatch"
+	// ValueMismatch means a query returned a result value different from the expected value.
+	ValueMismatch FailureCode = "ValueMismatch"
+	// HashMismatch means the hash of a query's results differed from the expected hash.
+	HashMismatch FailureCode = "HashMismatch"
+	// UnexpectedError means a statement or query returned an error when none was expected.
+	UnexpectedError FailureCode = "UnexpectedError"
+	// MissingExpectedError means a statement expected to fail succeeded instead.
+	MissingExpectedError FailureCode = "MissingExpectedError"
+	// ErrorMismatch means a statement failed with a different error than the one expected.
+	ErrorMismatch FailureCode = "ErrorMismatch"
+	// RouteMismatch means a proxy routed a record to different backends than the ones expected.
+	RouteMismatch FailureCode = "RouteMismatch"
+	// PlanMismatch means a query's plan contains an operator its plan assertions forbid, or lacks one they require.
+	PlanMismatch FailureCode = "PlanMismatch"
+	// MetricMismatch means the engine's execution metrics for a record are outside the bounds it asserts.
+	MetricMismatch FailureCode = "MetricMismatch"
+	// ChecksumMismatch means a table-checksum record found different table contents than the ones expected.
+	ChecksumMismatch FailureCode = "ChecksumMismatch"
+	// ColumnNameMismatch means a query's columns have different names than the ones given by its colnames directive.
+	ColumnNameMismatch FailureCode = "ColumnNameMismatch"
+	// CatalogMismatch means the tables left in the catalog after a test file ran differ from the ones its DDL
+	// statements should have created, altered and dropped.
+	CatalogMismatch FailureCode = "CatalogMismatch"
+	// WarningMismatch means a statement produced different warnings than the ones expected.
+	WarningMismatch FailureCode = "WarningMismatch"
+	// TxnStateMismatch means a txn record found a transaction active when it expected none, or vice versa.
+	TxnStateMismatch FailureCode = "TxnStateMismatch"
+	// Panic means the harness panicked while executing a record.
+	Panic FailureCode = "Panic"
+	// TimedOut means the record did not complete before the timeout elapsed.
+	TimedOut FailureCode = "Timeout"
+)
+
+// failureMessagePrefixes map the messages written by the runner for failures to their failure codes, so that codes
+// can be recovered from text result logs.
+var failureMessagePrefixes = []struct {
+	prefix string
+	code   FailureCode
+}{
+	{"Schemas differ", SchemaMismatch},
+	{"Incorrect number of results", RowCountMismatch},
+	{"Incorrect number of result sets", RowCountMismatch},
+	{"Incorrect result at position", ValueMismatch},
+	{"Hash of results differ", HashMismatch},
+	{"Expected error but didn't get one", MissingExpectedError},
+	{"Expected error ", ErrorMismatch},
+	{"Expected deadlock ", MissingExpectedError},
+	{"Expected lock-timeout ", MissingExpectedError},
+	{"Expected routes ", RouteMismatch},
+	{"Expected plan ", PlanMismatch},
+	{"Expected metric ", MetricMismatch},
+	{"Expected transaction state ", TxnStateMismatch},
+	{"Expected warnings ", WarningMismatch},
+	{"Expected checksum ", ChecksumMismatch},
+	{"Expected column names ", ColumnNameMismatch},
+	{"Expected table ", CatalogMismatch},
+	{"Expected columns ", CatalogMismatch},
+	{"Panic", Panic},
+}
+
+// classifyFailure returns the failure code for the failure message given. Errors that don't match a known message
+// are classified as unexpected errors.
+func classifyFailure(message string) FailureCode {
+	for _, p := range failureMessagePrefixes {
+		if strings.HasPrefix(message, p.prefix) {
+			return p.code
+		}
+	}
+	return UnexpectedError
+}
+
 // ResultLogEntry is a single line in a sqllogictest result log file.
 type ResultLogEntry struct {
 	EntryTime    time.Time
 	TestFile     string
 	LineNum      int
 	Query        string
-	Duration	 time.Duration
+	Duration     time.Duration
 	Result       ResultType
 	ErrorMessage string
+	FailureCode  FailureCode `json:",omitempty"`
+	// Plan is the engine's plan for a query that returned the wrong results, for harnesses that implement
+	// ExplainHarness. Plans aren't written to text result logs.
+	Plan string `json:",omitempty"`
+	// Confirmation is whether a failure was consistent or flaky when the record was rerun, for runs that confirm
+	// failures.
+	Confirmation Confirmation `json:",omitempty"`
+	// RawQuery is the query exactly as written in the test file, with its line breaks and comments, when it differs
+	// from Query. Raw queries aren't written to text result logs.
+	RawQuery string `json:",omitempty"`
 }
 
 // ParseResultFile parses a result log file produced by the test runner and returns a slice of results, in the order
@@ -74,10 +197,8 @@ func parseLogEntry(scanner *parser.LineScanner) (*ResultLogEntry, error) {
 	entry := &ResultLogEntry{}
 
 	var err error
-	linesScanned := 0
 	for scanner.Scan() {
 		line := scanner.Text()
-		linesScanned++
 
 		// Sample line:
 		// 2019-10-16T12:20:29.0594292-07:00 123456 index/random/10/slt_good_0.test:535: SELECT * FROM tab0 AS cor0 WHERE NULL <> 29 + col0 not ok: Schemas differ. Expected IIIIIII, got IIRTIRT
@@ -115,7 +236,7 @@ func parseLogEntry(scanner *parser.LineScanner) (*ResultLogEntry, error) {
 			entry.Result = Timeout
 		} else if strings.HasSuffix(line, "skipped") {
 			entry.Result = Skipped
-		} else if strings.HasSuffix(line, "did not run") {
+		} else if strings.HasSuffix(line, "did not run") || strings.Contains(line, " did not run: ") {
 			entry.Result = DidNotRun
 		} else {
 			panic("Couldn't determine result of log line " + line)
@@ -145,10 +266,12 @@ func parseLogEntry(scanner *parser.LineScanner) (*ResultLogEntry, error) {
 		case NotOk:
 			eoq := strings.Index(line[colonIdx2+1:], "not ok: ") + colonIdx2 + 1
 			entry.Query = line[colonIdx2+2 : eoq-1]
-			entry.ErrorMessage = line[eoq+len("not ok: "):]
+			entry.ErrorMessage, entry.Confirmation = splitConfirmation(line[eoq+len("not ok: "):])
+			entry.FailureCode = classifyFailure(entry.ErrorMessage)
 		case Timeout:
 			eoq := strings.Index(line[colonIdx2+1:], "timeout") + colonIdx2 + 1
 			entry.Query = line[colonIdx2+2 : eoq-1]
+			entry.FailureCode = TimedOut
 		case Ok:
 			eoq := strings.Index(line[colonIdx2+1:], "ok") + colonIdx2 + 1
 			entry.Query = line[colonIdx2+2 : eoq-1]
@@ -158,6 +281,7 @@ func parseLogEntry(scanner *parser.LineScanner) (*ResultLogEntry, error) {
 		case DidNotRun:
 			eoq := strings.Index(line[colonIdx2+1:], "did not run") + colonIdx2 + 1
 			entry.Query = line[colonIdx2+2 : eoq-1]
+			entry.ErrorMessage = strings.TrimPrefix(line[eoq+len("did not run"):], ": ")
 		}
 
 		return entry, nil
@@ -167,9 +291,6 @@ func parseLogEntry(scanner *parser.LineScanner) (*ResultLogEntry, error) {
 		return nil, scanner.Err()
 	}
 
-	if scanner.Err() == nil && linesScanned == 0 {
-		return nil, io.EOF
-	}
-
-	return entry, nil
+	// Unrecognized lines at the end of the log, such as the report of a run's time budget, aren't an entry
+	return nil, io.EOF
 }
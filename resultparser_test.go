@@ -99,6 +99,7 @@ func TestParseResultFile(t *testing.T) {
 			Duration:     mustParseDuration("87838293"),
 			Result:       NotOk,
 			ErrorMessage: "Unexpected error no primary key columns",
+			FailureCode:  UnexpectedError,
 		},
 		{
 			EntryTime: mustParseTime("2019-10-16T16:02:18.3428692-07:00"),
@@ -108,6 +109,23 @@ func TestParseResultFile(t *testing.T) {
 			Duration:  mustParseDuration("98321"),
 			Result:    Skipped,
 		},
+		{
+			EntryTime:    mustParseTime("2019-10-16T16:02:18.3428692-07:00"),
+			TestFile:     "evidence/in1.test",
+			LineNum:      77,
+			Query:        "SELECT 1 IN t1",
+			Duration:     mustParseDuration("1204"),
+			Result:       DidNotRun,
+			ErrorMessage: "Skipped due to failed setup statement on line 68",
+		},
+		{
+			EntryTime: mustParseTime("2019-10-16T16:02:18.3428692-07:00"),
+			TestFile:  "evidence/in1.test",
+			LineNum:   82,
+			Query:     "SELECT 2 IN t1",
+			Duration:  mustParseDuration("904"),
+			Result:    DidNotRun,
+		},
 	}
 
 	assert.Equal(t, expectedResults, entries)
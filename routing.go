@@ -0,0 +1,81 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A RoutingHarness is a Harness for a proxy layer, such as Vitess, that routes statements and queries to one or more
+// backends. Harnesses that implement this interface can run records that assert their routing, written e.g.:
+//
+//	route commerce/-80
+//	query I nosort
+//	SELECT id FROM customer WHERE id = 1
+//
+// For harnesses that don't implement this interface, route directives are ignored.
+type RoutingHarness interface {
+	Harness
+
+	// Routes returns the names of the backends the statement or query given was routed to. It's called right after the
+	// statement or query executes successfully.
+	Routes(ctx context.Context, query string) ([]string, error)
+}
+
+// verifyRoutes verifies that the record given, which just executed successfully, was routed to the backends it
+// expects, if it names any and the harness it executed on can report routes, logging any failure. Routes are compared
+// as sets.
+func (r *Runner) verifyRoutes(ctx context.Context, harness Harness, record *parser.Record) error {
+	if len(record.Routes()) == 0 {
+		return nil
+	}
+
+	routingHarness, ok := harness.(RoutingHarness)
+	if !ok {
+		return nil
+	}
+
+	routes, err := routingHarness.Routes(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unable to determine routes: %v", err)
+		return err
+	}
+
+	expected, actual := routeSet(record.Routes()), routeSet(routes)
+	if expected != actual {
+		logFailure(ctx, RouteMismatch, "Expected routes %s but got %s", expected, actual)
+		return fmt.Errorf("expected routes %s but got %s", expected, actual)
+	}
+	return nil
+}
+
+// routeSet returns a canonical description of the set of routes given: sorted, without duplicates.
+func routeSet(routes []string) string {
+	seen := make(map[string]bool)
+	var set []string
+	for _, route := range routes {
+		if !seen[route] {
+			seen[route] = true
+			set = append(set, route)
+		}
+	}
+	sort.Strings(set)
+	return "[" + strings.Join(set, " ") + "]"
+}
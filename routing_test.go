@@ -0,0 +1,87 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routingHarness routes every query to the shards -80 and 80-, and every statement to the shard -80.
+type routingHarness struct {
+	*fakeHarness
+}
+
+var _ RoutingHarness = routingHarness{}
+
+func (h routingHarness) Routes(ctx context.Context, query string) ([]string, error) {
+	if _, ok := h.queryResults[query]; ok {
+		return []string{"ks/80-", "ks/-80", "ks/80-"}, nil
+	}
+	return []string{"ks/-80"}, nil
+}
+
+const routingTest = `route ks/-80
+statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+route ks/-80 ks/80-
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+route ks/-80
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`
+
+func TestRoutes(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(routingHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, routingTest))
+	})
+	require.Len(t, reporter.entries, 4)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+	assert.Equal(t, Ok, reporter.entries[2].Result)
+	assert.Equal(t, NotOk, reporter.entries[3].Result)
+	assert.Equal(t, RouteMismatch, reporter.entries[3].FailureCode)
+	assert.Equal(t, "Expected routes [ks/-80] but got [ks/-80 ks/80-]", reporter.entries[3].ErrorMessage)
+
+	// Harnesses that don't report routes ignore route directives
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, routingTest))
+	require.Len(t, reporter.entries, 4)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
@@ -16,10 +16,12 @@ package logictest
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"crypto/md5"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -32,385 +34,895 @@ import (
 
 const defaultTimeout = time.Minute * 20
 
-var (
-	currTestFile            string
-	currRecord              *parser.Record
-	_, TruncateQueriesInLog = os.LookupEnv("SQLLOGICTEST_TRUNCATE_QUERIES")
-)
+var currTestFile string
 
-var (
-	startTime        time.Time
-	testTimeoutError = errors.New("test in file timed out")
-)
+var testTimeoutError = errors.New("test in file timed out")
+
+// currTestFileMux guards currTestFile, which is set concurrently by parallel runs.
+var currTestFileMux sync.Mutex
 
-// GetCurrentFileName returns path to the test file that is currently executing.
+// GetCurrentFileName returns path to the test file that is currently executing. For parallel runs, this is the file
+// most recently started.
 func GetCurrentFileName() string {
+	currTestFileMux.Lock()
+	defer currTestFileMux.Unlock()
 	return testFilePath(currTestFile)
 }
 
+func setCurrentFileName(f string) {
+	currTestFileMux.Lock()
+	defer currTestFileMux.Unlock()
+	currTestFile = f
+}
+
+// A Runner runs and generates test files against a harness, with the configuration given to NewRunner.
+type Runner struct {
+	harness Harness
+	config  RunConfig
+	// capabilities are the capabilities declared by the harness, or nil if it doesn't declare any
+	capabilities map[Capability]bool
+
+	// stateMux guards the state of the current test file below
+	stateMux sync.Mutex
+	// activeTxns records which connections have an active transaction in the current test file
+	activeTxns map[string]bool
+	// connections are the named connections opened by the current test file
+	connections map[string]Harness
+	// asyncStatements are the async statements of the current test file that haven't been awaited, in order of
+	// execution
+	asyncStatements []*asyncStatement
+	// objects are the schema objects created by the current test file, in order of creation, when they're cleaned up
+	objects []SchemaObject
+	// catalog is the tables the current test file should have created or dropped, when the catalog is verified
+	catalog []*expectedTable
+	// fileCtx is the parent context of the records of the current test file, which carries its span when runs are
+	// traced
+	fileCtx context.Context
+	// recordObserver, when set, observes the outcome of every record run by runTestFile, and failures don't stop the
+	// run
+	recordObserver func(file string, record *parser.Record, res *R)
+	// blessAnswers reads the answers to the prompts of interactive blessing
+	blessAnswers *bufio.Reader
+	// heldFailures are the failures of the current test file held back from the result log until they're confirmed
+	heldFailures []heldFailure
+}
+
+// NewRunner returns a runner for the harness given, configured by DefaultRunConfig with the options given applied.
+func NewRunner(harness Harness, opts ...RunOption) *Runner {
+	config := DefaultRunConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	config.setFormatter(harness)
+	config.setFormatter(config.Reference)
+	config.setFormatter(config.HashBisectionReference)
+	return &Runner{harness: harness, config: config, capabilities: newCapabilitySet(harness)}
+}
+
+// Config returns the configuration of this runner.
+func (r *Runner) Config() RunConfig {
+	return r.config
+}
+
 // RunTestFiles runs the test files found under any of the paths given. Can specify individual test files, or directories that
 // contain test files somewhere underneath. All files named *.test encountered under a directory will be attempted to be
-// parsed as a test file, and will panic for malformed test files or paths that don't exist.
+// parsed as a test file, and will panic for malformed test files or paths that don't exist. Files named *.test.gz and
+// *.test.zst are test files compressed with gzip and zstd, and are decompressed as they're read.
 func RunTestFiles(harness Harness, paths ...string) {
-	testFiles := collectTestFiles(paths)
+	NewRunner(harness).RunTestFiles(paths...)
+}
+
+// RunTestFiles runs the test files found under any of the paths given, as described by the package-level RunTestFiles.
+func (r *Runner) RunTestFiles(paths ...string) {
+	testFiles := r.config.testFiles(paths)
 
+	budget := r.config.newRunBudget()
 	for _, file := range testFiles {
-		runTestFile(harness, file)
+		if !budget.start(file) {
+			continue
+		}
+		if !r.runTestFile(file) {
+			return
+		}
 	}
+	budget.report(r.config.Output)
 }
 
-// Returns all the test files residing at the paths given.
+// CollectTestFiles returns the test files found under any of the paths given, as RunTestFiles finds them: paths of
+// directories are descended recursively for files with the .test extension, compressed or not, and other paths are taken as test files.
+// Paths can be glob patterns, such as test/select*.test, which are expanded to the test files and directories they
+// match. Directories are walked following symlinks, leaving out the files and directories ignored by the .sltignore
+// files in them, which have gitignore-style patterns. Panics for paths that don't exist and patterns that match
+// nothing.
+func CollectTestFiles(paths ...string) []string {
+	return collectTestFiles(paths)
+}
+
+// FindTestFiles returns the test files found under any of the paths given, as CollectTestFiles does, but returns an
+// error rather than panicking for paths that don't exist and patterns that match nothing, e.g. to report a mistyped
+// path to the user.
+func FindTestFiles(paths ...string) ([]string, error) {
+	return findTestFiles(paths)
+}
+
+// Returns all the test files residing at the paths given, panicking if they can't be found.
 func collectTestFiles(paths []string) []string {
+	testFiles, err := findTestFiles(paths)
+	if err != nil {
+		panic(err)
+	}
+	return testFiles
+}
+
+// Returns all the test files residing at the paths given.
+func findTestFiles(paths []string) ([]string, error) {
 	var testFiles []string
 	for _, arg := range paths {
-		abs, err := filepath.Abs(arg)
+		matches, err := expandPattern(arg, func(pattern string) ([]string, error) { return filepath.Glob(pattern) })
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
-		stat, err := os.Stat(abs)
-		if err != nil {
-			panic(err)
-		}
+		for _, match := range matches {
+			abs, err := filepath.Abs(match)
+			if err != nil {
+				return nil, err
+			}
 
-		if stat.IsDir() {
-			filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if info.IsDir() {
-					return nil
-				}
+			stat, err := os.Stat(abs)
+			if err != nil {
+				return nil, err
+			}
 
-				if strings.HasSuffix(path, ".test") {
-					testFiles = append(testFiles, path)
+			if stat.IsDir() {
+				walker := newTestFileWalker()
+				if err := walker.walk(match, nil); err != nil {
+					return nil, err
 				}
-				return nil
-			})
-		} else {
-			testFiles = append(testFiles, abs)
+				testFiles = append(testFiles, walker.files...)
+			} else if match == arg || parser.IsTestFile(abs) {
+				// Files matched by a pattern are only taken if they're test files, as in directories
+				testFiles = append(testFiles, abs)
+			}
 		}
 	}
-	return testFiles
+	return testFiles, nil
 }
 
 // Generates the test files given by executing the query and replacing expected results with the ones obtained by the
-// test run. Files written will have the .generated suffix.
+// test run. Only the lines of records whose results changed are rewritten, so comments and formatting are kept. Files
+// written will have the .generated suffix, unless the runner is configured to regenerate test files in place or to
+// write them to an output directory.
 func GenerateTestFiles(harness Harness, paths ...string) {
-	testFiles := collectTestFiles(paths)
+	NewRunner(harness).GenerateTestFiles(paths...)
+}
 
-	for _, file := range testFiles {
-		generateTestFile(harness, file, false)
-	}
+// GenerateTestFiles generates the test files given, as described by the package-level GenerateTestFiles.
+func (r *Runner) GenerateTestFiles(paths ...string) {
+	r.generateTestFiles(paths, false)
 }
 
 // GenerateTestFilesWithFailedTestsExcluded generates the specified test files by executing statements and queries,
-// filtering out any failed tests, and replacing expected results with the ones from the test run. Files written will
-// have the .generated suffix.
+// filtering out any failed tests, and replacing expected results with the ones from the test run. Files are written as
+// by GenerateTestFiles.
 func GenerateTestFilesWithFailedTestsExcluded(harness Harness, paths ...string) {
-	testFiles := collectTestFiles(paths)
+	NewRunner(harness).GenerateTestFilesWithFailedTestsExcluded(paths...)
+}
 
-	for _, file := range testFiles {
-		generateTestFile(harness, file, true)
+// GenerateTestFilesWithFailedTestsExcluded generates the test files given, as described by the package-level
+// GenerateTestFilesWithFailedTestsExcluded.
+func (r *Runner) GenerateTestFilesWithFailedTestsExcluded(paths ...string) {
+	r.generateTestFiles(paths, true)
+}
+
+// timeout returns the timeout for executing each record.
+func (r *Runner) timeout() time.Duration {
+	if r.config.Timeout != 0 {
+		return r.config.Timeout
 	}
+	if t := r.harness.GetTimeout(); t != 0 {
+		return time.Second * time.Duration(t)
+	}
+	return defaultTimeout
 }
 
-// generateTestFile generates a test file by executing the statements in the specified file, including the query
-// results in the generated file, and optionally filtering out any statements that don't execute correctly.
-func generateTestFile(harness Harness, f string, filterOutFailedTests bool) {
-	currTestFile = f
+// recordTimeout returns the timeout for executing the record given, which is its own timeout if it has one, and the
+// runner's otherwise.
+func (r *Runner) recordTimeout(record *parser.Record) time.Duration {
+	if record.Timeout() != 0 {
+		return record.Timeout()
+	}
+	return r.timeout()
+}
+
+// newRecordContext returns a context for executing the record given from the test file given, with the record's own
+// timeout applied if it has one, and the runner's otherwise. Records executed to generate test files must have their
+// results returned.
+func (r *Runner) newRecordContext(testFile string, record *parser.Record, generating bool) (context.Context, context.CancelFunc) {
+	parent := r.fileCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, r.recordTimeout(record))
+	ctx, cancel = r.startRecordSpan(ctx, cancel, testFile, record)
+	return context.WithValue(ctx, "lock", &loggingLock{
+		runner:     r,
+		testFile:   testFile,
+		record:     record,
+		startTime:  time.Now(),
+		generating: generating,
+	}), cancel
+}
 
-	err := harness.Init()
+// isGenerating returns whether the record executing in the context given is being executed to generate a test file.
+func isGenerating(ctx context.Context) bool {
+	return ctx.Value("lock").(*loggingLock).generating
+}
+
+// generateTestFile generates a test file by executing the statements in the specified file, including the query
+// results in the generated file at the path given, and optionally filtering out any statements that don't execute
+// correctly. Returns whether the run should continue with the next file, which is false only after a "halt run"
+// record.
+func (r *Runner) generateTestFile(f, generatedPath string, filterOutFailedTests bool) bool {
+	setCurrentFileName(f)
+	harness := r.harness
+	defer r.startFileSpan(f).End()
+
+	err := r.initHarness()
 	if err != nil {
 		panic(err)
 	}
+	defer r.cleanupObjects()
+	defer r.forgetCatalog()
+	defer r.closeConnections()
 
-	file, err := os.Open(f)
+	// The records are parsed from the same contents the generated file copies lines from, so that the lines of the
+	// records always match the lines copied, even if the test file changes during generation
+	contents, err := parser.ReadFile(f)
 	if err != nil {
 		panic(err)
 	}
 
-	testRecords, err := parser.ParseTestFile(f)
+	testRecords, err := parser.Parse(bytes.NewReader(contents))
 	if err != nil {
 		panic(err)
 	}
 
-	generatedFile, err := os.Create(f + ".generated")
+	outFile, err := createGeneratedFile(generatedPath)
 	if err != nil {
 		panic(err)
 	}
 
-	scanner := &parser.LineScanner{
-		bufio.NewScanner(file), 0,
-	}
-	wr := bufio.NewWriter(generatedFile)
+	gen := newGeneratedFile(contents, outFile)
 
+	// completed is whether the whole test file was generated, rather than generation panicking part way through
+	completed := false
 	defer func() {
-		err = wr.Flush()
+		err = gen.flush()
 		if err != nil {
 			panic(err)
 		}
 
-		err = generatedFile.Close()
+		err = outFile.Close()
 		if err != nil {
 			panic(err)
 		}
-	}()
 
-	curTimeout := defaultTimeout
-	if t := harness.GetTimeout(); t != 0 {
-		curTimeout = time.Second * time.Duration(t)
-	}
+		if completed {
+			err = r.finishGeneratedFile(f, generatedPath)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}()
 
+	// Records are copied as they are unless they're rewritten or dropped below
 	for _, record := range testRecords {
-		// currRecord is used by logMessagePrefix, so needs to be set as we iterate
-		currRecord = record
+		ctx, cancel := r.newRecordContext(f, record, true)
+		res := r.executeRecord(ctx, cancel, record)
+		err := res.err
+
+		// Txn, await, snapshot, restore, set-seed and custom records have no results to rewrite
+		switch record.Type() {
+		case parser.Txn, parser.AwaitStatement, parser.AwaitConflict, parser.Snapshot, parser.Restore, parser.SetSeed,
+			parser.Custom:
+			continue
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), curTimeout)
-		lockCtx := context.WithValue(ctx, "lock", &loggingLock{})
+		// When fixing schemas, only the schemas of queries are rewritten, and every other record is kept unchanged
+		if r.config.FixSchemas && record.Type() != parser.Halt {
+			if (err == nil || res.mismatched) && !res.skipped && r.shouldExecute(harness, record) {
+				if header := r.fixSchema(record, res.schema, res.results); header != "" {
+					gen.replaceLine(record.HeaderLine(), header)
+				}
+			}
+			continue
+		}
 
-		schema, records, _, err := executeRecord(lockCtx, cancel, harness, record)
+		// Table-checksum records are rewritten with the actual checksum unless they failed, or it's the expected one
+		// when only failing records are regenerated
+		if record.Type() == parser.TableChecksum {
+			if err == nil && !res.skipped && r.shouldExecute(harness, record) &&
+				!(r.config.RegenerateFailing && res.checksum == record.Checksum()) {
+				rewrite := func(g *generatedFile) {
+					g.replaceLine(record.HeaderLine(), fmt.Sprintf("table-checksum %s %s", record.Table(), res.checksum))
+				}
+				if r.blessing() {
+					switch r.promptBless(f, record, gen, rewrite) {
+					case blessSkip:
+						continue
+					case blessQuit:
+						gen.copyRest()
+						completed = true
+						return false
+					}
+				}
+				rewrite(gen)
+			}
+			continue
+		}
 
-		// If there was an error and we're filtering out failed tests, skip copying
-		// this record over to the generated test file and continue to the next record.
+		// When only failing records are regenerated, passing records are kept unchanged, and queries whose results
+		// differ are regenerated as if they had passed
+		if r.config.RegenerateFailing {
+			if res.mismatched {
+				err = nil
+			} else if err == nil && !res.skipped && record.Type() != parser.Halt {
+				continue
+			}
+		}
+
+		// If there was an error and we're filtering out failed tests, leave this record out of the generated file
 		if err != nil && filterOutFailedTests {
-			skipUntilEndOfRecord(scanner, wr)
+			gen.dropRecord(record)
 			continue
 		}
 
-		// If there was an error or we skipped this test, then just copy output until the next record.
-		if err != nil || !record.ShouldExecuteForEngine(harness.EngineStr()) {
-			copyUntilEndOfRecord(scanner, wr) // advance until the next record
+		// If there was an error or we skipped this test, then just keep the record as it is
+		if err != nil || res.skipped || !r.shouldExecute(harness, record) {
 			continue
 		} else if record.Type() == parser.Halt {
-			copyRestOfFile(scanner, wr)
-			return
+			gen.copyRest()
+			completed = true
+			return !record.HaltsRun()
 		}
 
-		// Copy until we get to the line before the query we executed (e.g. "query IIRT no-sort")
-		for scanner.Scan() && scanner.LineNum < record.LineNum()-1 {
-			line := scanner.Text()
-			writeLine(wr, line)
+		if record.Type() != parser.Query && (record.Type() != parser.Procedure || record.NumResultSets() == 0) {
+			// Statements are kept as they are
+			continue
 		}
 
-		if record.Type() == parser.Statement {
-			// Copy statements directly
-			writeLine(wr, scanner.Text())
-			copyUntilEndOfRecord(scanner, wr)
-		} else if record.Type() == parser.Query {
-			// Fill in the actual query result schema
-			var label string
-			if record.Label() != "" {
-				label = " " + record.Label()
-			}
-
-			writeLine(wr, fmt.Sprintf("query %s %s%s", schema, record.SortString(), label))
-			copyUntilSeparator(scanner, wr)   // copy the original query and separator
-			writeResults(record, records, wr) // write the query result
-			skipUntilEndOfRecord(scanner, wr) // advance until the next record
+		// Fill in the actual query result schema and results
+		keyword := "query"
+		if record.Type() == parser.Procedure {
+			keyword = "procedure"
 		}
-	}
-
-	copyRestOfFile(scanner, wr)
-}
-
-func writeLine(wr *bufio.Writer, s string) {
-	_, err := wr.WriteString(s + "\n")
-	if err != nil {
-		panic(err)
-	}
-}
 
-func copyRestOfFile(scanner *parser.LineScanner, wr *bufio.Writer) {
-	for scanner.Scan() {
-		writeLine(wr, scanner.Text())
-	}
-}
+		var label string
+		if record.Label() != "" {
+			label = " " + record.Label()
+		}
 
-func writeResults(record *parser.Record, results []string, wr *bufio.Writer) {
-	results = record.SortResults(results)
+		if len(res.resultSets) > 0 {
+			schemas := make([]string, len(res.resultSets))
+			var results []string
+			for i, set := range record.ResultSets() {
+				schemas[i] = res.resultSets[i].schema
+				if i > 0 {
+					results = append(results, parser.Separator)
+				}
+				results = append(results, r.resultLines(set, res.resultSets[i].schema, res.resultSets[i].results)...)
+			}
+			header := fmt.Sprintf("%s %s %s%s", keyword, strings.Join(schemas, ","), record.SortString(), label)
+			gen.rewriteRecord(record, nil, header, results)
+			continue
+		}
 
-	if len(results) > record.HashThreshold() {
-		hash, err := hashResults(results)
-		if err != nil {
-			panic(err)
+		header := fmt.Sprintf("query %s %s%s", res.schema, record.SortString(), label)
+		results := r.resultLines(record, res.schema, res.results)
+		rewrite := func(g *generatedFile) {
+			g.rewriteRecord(record, res.columns, header, results)
 		}
-		writeLine(wr, fmt.Sprintf("%d values hashing to %s", len(results), hash))
-	} else {
-		for _, result := range results {
-			writeLine(wr, fmt.Sprintf("%s", result))
+
+		// When blessing, quitting keeps the rest of the file as it is, and ends the run
+		if r.blessing() {
+			switch r.promptBless(f, record, gen, rewrite) {
+			case blessSkip:
+				continue
+			case blessQuit:
+				gen.copyRest()
+				completed = true
+				return false
+			}
 		}
+		rewrite(gen)
 	}
+
+	// Failures of async statements that were never awaited are logged, but the generated file keeps them
+	r.awaitAsyncStatements()
+	gen.copyRest()
+	completed = true
+	return true
 }
 
-func copyUntilSeparator(scanner *parser.LineScanner, wr *bufio.Writer) {
-	for scanner.Scan() {
-		line := scanner.Text()
-		writeLine(wr, line)
+// resultLines returns the lines of the results given for the record given, which have the schema given, sorted as the
+// record specifies and escaped with parser.EscapeResult, or their hash if there are more than the hash threshold. Large
+// values are compacted first.
+func (r *Runner) resultLines(record *parser.Record, schema string, results []string) []string {
+	results = record.SortResults(r.config.compactValues(results, schema))
 
-		if strings.TrimSpace(line) == parser.Separator {
-			break
-		}
+	threshold := record.HashThreshold()
+	if r.config.HashThreshold != 0 {
+		threshold = r.config.HashThreshold
 	}
-}
 
-func copyUntilEndOfRecord(scanner *parser.LineScanner, wr *bufio.Writer) {
-	for scanner.Scan() {
-		line := scanner.Text()
-		writeLine(wr, line)
-		if strings.TrimSpace(line) == "" {
-			break
+	if len(results) <= threshold {
+		lines := make([]string, len(results))
+		for i, result := range results {
+			lines[i] = parser.EscapeResult(EscapeLiteral(result))
 		}
+		return lines
 	}
-}
 
-func skipUntilEndOfRecord(scanner *parser.LineScanner, wr *bufio.Writer) {
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
-			writeLine(wr, "")
-			break
-		}
+	algorithm := r.config.HashAlgorithm
+	hash, err := hashResults(algorithm, results)
+	if err != nil {
+		panic(err)
 	}
+	checkpoints, err := hashCheckpointLines(algorithm, results, record.NumCols(), r.config.HashCheckpointInterval)
+	if err != nil {
+		panic(err)
+	}
+	return append([]string{fmt.Sprintf("%d values hashing to %s", len(results), algorithm.formatHash(hash))}, checkpoints...)
 }
 
+// loggingLock guards the logging of the result of a record, which can race between the goroutine executing the record
+// and the timeout, and describes the record being logged.
 type loggingLock struct {
 	mux    sync.Mutex
 	logged bool
+
+	runner     *Runner
+	testFile   string
+	record     *parser.Record
+	startTime  time.Time
+	generating bool
 }
 
-func runTestFile(harness Harness, file string) {
-	currTestFile = file
+// runTestFile runs the test file given and returns whether the run should continue with the next file, which is false
+// only after a "halt run" record.
+func (r *Runner) runTestFile(file string) bool {
+	setCurrentFileName(file)
+	defer r.startFileSpan(file).End()
+	// Failures are confirmed once the test file is done with the harness
+	defer r.confirmFailures(file)
 
-	err := harness.Init()
+	err := r.initHarness()
 	if err != nil {
 		panic(err)
 	}
+	defer r.cleanupObjects()
+	defer r.forgetCatalog()
+	defer r.closeConnections()
+	r.warmUp()
 
-	testRecords, err := parser.ParseTestFile(file)
+	if r.config.Reference != nil {
+		if err := r.config.Reference.Init(); err != nil {
+			panic(err)
+		}
+	}
+
+	// Records are executed as they're parsed, so an error in the file only fails the run once the records before it
+	// have run
+	reader, err := r.config.openTestFile(file)
 	if err != nil {
 		panic(err)
 	}
+	defer reader.Close()
+
+	dnr, failed := false, false
+	var setupFailureLine int
+	// warmedUp is the number of queries of the file warmed up so far
+	warmedUp := 0
+	// runRecord runs the record given, returning whether to run the rest of the file, and if not, whether the run
+	// should continue with the next file
+	runRecord := func(record *parser.Record) (bool, bool) {
+		if !dnr && r.shouldWarmUp(record, warmedUp) {
+			r.warmUpQuery(record)
+			warmedUp++
+		}
+		ctx, cancel := r.newRecordContext(file, record, false)
 
-	curTimeout := defaultTimeout
-	if t := harness.GetTimeout(); t != 0 {
-		curTimeout = time.Second * time.Duration(t)
+		if dnr && record.Type() != parser.Halt {
+			logResult(ctx, DidNotRun, "Skipped due to failed setup statement on line %d", setupFailureLine)
+			cancel()
+			return true, true
+		}
+
+		res := r.executeRecord(ctx, cancel, record)
+		if r.recordObserver != nil {
+			r.recordObserver(file, record, res)
+		}
+		if err := res.err; err != nil {
+			failed = true
+			if r.config.SkipAfterSetupFailure && isSetupStatement(record) {
+				dnr = true
+				setupFailureLine = record.LineNum()
+				return true, true
+			}
+			// A failure that stops the run is still confirmed before it's reported, as the test file is unwound
+			if r.config.isExpectedFailure(file, record.LineNum()) || r.recordObserver != nil || r.config.ContinueOnFailure {
+				return true, true
+			}
+			panic(err)
+		}
+
+		if !res.cont {
+			return false, !record.HaltsRun()
+		}
+		return true, true
 	}
 
-	dnr := false
-	for _, record := range testRecords {
-		currRecord = record
-		startTime = time.Now()
+	// runRecords runs the records returned by the function given until it returns io.EOF, returning whether the file
+	// ran to the end, and if not, whether the run should continue with the next file. Consecutive INSERT statements
+	// are executed in batches on harnesses that support it. A batch that fails has no effect, and its records are run
+	// again one at a time to report the failure.
+	batchSize := r.statementBatchSize()
+	runRecords := func(next func() (*parser.Record, error)) (bool, bool) {
+		var batch []*parser.Record
+		for {
+			record, err := next()
+			if err != nil && err != io.EOF {
+				panic(fmt.Errorf("%s: %w", file, err))
+			}
 
-		ctx, cancel := context.WithTimeout(context.Background(), curTimeout)
-		lockCtx := context.WithValue(ctx, "lock", &loggingLock{})
+			batched := err == nil && batchSize > 1 && !dnr && r.isBatchable(record)
+			if batched {
+				batch = append(batch, record)
+			}
+			if len(batch) > 0 && (!batched || len(batch) == batchSize) {
+				if len(batch) == 1 || !r.executeBatch(file, batch) {
+					for _, record := range batch {
+						if cont, next := runRecord(record); !cont {
+							return false, next
+						}
+					}
+				}
+				batch = nil
+			}
 
-		if dnr {
-			logResult(lockCtx, DidNotRun, "")
-			cancel()
-			continue
+			if err == io.EOF {
+				return true, true
+			}
+			if !batched {
+				if cont, next := runRecord(record); !cont {
+					return false, next
+				}
+			}
 		}
+	}
 
-		_, _, cont, err := executeRecord(lockCtx, cancel, harness, record)
+	// The prelude of the file is restored from the state saved by an earlier file with the same prelude if there is
+	// one, and otherwise saved once it has run without failures
+	next := reader.Next
+	if harness, ok := r.preludeHarness(); ok {
+		prelude := r.readPrelude(reader)
+		next = prelude.next
+		restored, err := r.restorePrelude(file, harness, prelude)
 		if err != nil {
-			panic(err)
+			panic(fmt.Errorf("%s: unable to restore prelude: %w", file, err))
+		}
+		if !restored && len(prelude.records) > 0 {
+			if cont, next := runRecords(prelude.recordSource()); !cont {
+				return next
+			}
+			if !failed {
+				if err := r.savePrelude(harness, prelude); err != nil {
+					panic(fmt.Errorf("%s: unable to save prelude: %w", file, err))
+				}
+			}
 		}
+	}
+	if cont, next := runRecords(next); !cont {
+		return next
+	}
 
-		if !cont {
-			break
+	if err := r.awaitAsyncStatements(); err != nil && !r.config.ContinueOnFailure {
+		panic(err)
+	}
+	if err := r.verifyCatalog(file); err != nil && !r.config.ContinueOnFailure {
+		panic(err)
+	}
+	return true
+}
+
+// setupStatementPrefixes are the prefixes of statements that set up state that later records in a file depend on.
+var setupStatementPrefixes = []string{"CREATE ", "INSERT ", "REPLACE ", "ALTER "}
+
+// isSetupStatement returns whether the record given is a statement that later records are likely to depend on, such as
+// a CREATE TABLE or an INSERT of seed data.
+func isSetupStatement(record *parser.Record) bool {
+	if record.Type() != parser.Statement || record.ExpectError() {
+		return false
+	}
+
+	query := strings.ToUpper(strings.TrimSpace(record.Query()))
+	for _, prefix := range setupStatementPrefixes {
+		if strings.HasPrefix(query, prefix) {
+			return true
 		}
 	}
+	return false
 }
 
+// R is the outcome of executing a record.
 type R struct {
+	// schema and results are the schema and results of a query record, returned for generating test files
 	schema  string
 	results []string
-	cont    bool
-	err     error
+	// resultSets holds the schema and results of each result set, for queries with multiple result sets
+	resultSets []*R
+	// checksum is the checksum of the table of a table-checksum record, returned for generating test files
+	checksum string
+	// columns are the names of the columns of a query's results, when they were needed
+	columns []string
+	// mismatched is whether a query's schema or results differ from the expected ones, rather than the query failing
+	// to execute. schema and results hold the actual ones, for regenerating failing records.
+	mismatched bool
+	// skipped is whether the record was skipped rather than executed
+	skipped bool
+	// cont is whether execution of records should continue
+	cont bool
+	err  error
 }
 
-// Executes a single record and returns whether execution of records should continue
-func executeRecord(ctx context.Context, cancel context.CancelFunc, harness Harness, record *parser.Record) (schema string, results []string, cont bool, err error) {
+// Executes a single record and returns its outcome, including whether execution of records should continue
+func (r *Runner) executeRecord(ctx context.Context, cancel context.CancelFunc, record *parser.Record) *R {
 	defer cancel()
 
 	rc := make(chan *R, 1)
 	go func() {
-		schema, results, cont, err := execute(ctx, harness, record)
-		rc <- &R{
-			schema:  schema,
-			results: results,
-			cont:    cont,
-			err:     err,
-		}
+		defer func() {
+			if p := recover(); p != nil {
+				logFailure(ctx, Panic, "Panic: %v", p)
+				rc <- &R{cont: true, err: fmt.Errorf("panic executing record: %v", p)}
+			}
+		}()
+
+		rc <- r.execute(ctx, record)
 	}()
 
 	select {
 	case res := <-rc:
-		return res.schema, res.results, res.cont, res.err
+		return res
 	case <-ctx.Done():
 		logResult(ctx, Timeout, "")
-		return "", []string{}, true, testTimeoutError
+		return &R{results: []string{}, cont: true, err: testTimeoutError}
+	}
+}
+
+// shouldExecute returns whether the record given should be executed on the harness given, according to its conditions.
+func (r *Runner) shouldExecute(harness Harness, record *parser.Record) bool {
+	engine := r.config.Engine
+	if engine == "" {
+		engine = harness.EngineStr()
 	}
+	return record.ShouldExecuteForEngines(append([]string{engine}, r.config.ConditionEngines...)...)
 }
 
-func execute(ctx context.Context, harness Harness, record *parser.Record) (schema string, results []string, cont bool, err error) {
-	if !record.ShouldExecuteForEngine(harness.EngineStr()) {
-		// Log a skip for queries and statements only, not other control records
-		if record.Type() == parser.Query || record.Type() == parser.Statement {
+func (r *Runner) execute(ctx context.Context, record *parser.Record) *R {
+	harness := r.harness
+	if !r.shouldExecute(harness, record) {
+		// Log a skip for queries, statements, procedure calls, txn, table-checksum, snapshot, restore, set-seed and
+		// custom records only, not other control records
+		switch record.Type() {
+		case parser.Query, parser.Statement, parser.Procedure, parser.Txn, parser.TableChecksum, parser.Snapshot,
+			parser.Restore, parser.SetSeed, parser.Custom:
 			logResult(ctx, Skipped, "")
 		}
-		return "", nil, true, nil
+		return &R{skipped: true, cont: true}
 	}
 
-	switch record.Type() {
-	case parser.Statement:
-		err := harness.ExecuteStatement(ctx, record.Query())
+	if missing := r.missingCapability(record); missing != "" {
+		logResult(ctx, Skipped, "Requires unsupported capability %s", missing)
+		return &R{skipped: true, cont: true}
+	}
 
-		if record.ExpectError() {
-			if err == nil {
-				logResult(ctx, NotOk, "Expected error but didn't get one")
-				return "", nil, true, errors.New("expected statement error but got no error")
-			}
-		} else if err != nil {
-			logResult(ctx, NotOk, "Unexpected error %v", err)
-			return "", nil, true, err
+	harness, err := r.connection(record.Connection())
+	if err == errNoConnections {
+		logResult(ctx, Skipped, "Requires multiple connections")
+		return &R{skipped: true, cont: true}
+	} else if err != nil {
+		logFailure(ctx, UnexpectedError, "Unable to open connection %s: %v", record.Connection(), err)
+		return &R{cont: true, err: err}
+	}
+
+	if r.implicitTransaction(record) {
+		return r.executeInImplicitTransaction(ctx, harness, record)
+	}
+	return r.executeOnConnection(ctx, harness, record)
+}
+
+// executeOnConnection executes the record given on the harness given, which is the one for the record's connection.
+func (r *Runner) executeOnConnection(ctx context.Context, harness Harness, record *parser.Record) *R {
+	if r.config.Reference != nil && !isGenerating(ctx) && record.Connection() == defaultConnection &&
+		record.AsyncName() == "" &&
+		(record.Type() == parser.Statement || (record.Type() == parser.Query && record.NumResultSets() == 1)) {
+		res := r.executeDifferential(ctx, record)
+		if res.err == nil && !res.skipped && record.Type() == parser.Statement {
+			r.trackTransaction(defaultConnection, record)
+			r.trackObjects(record)
+			r.trackCatalog(record)
 		}
+		return res
+	}
 
-		logResult(ctx, Ok, "")
-		return "", nil, true, nil
-	case parser.Query:
-		schemaStr, results, err := harness.ExecuteQuery(ctx, record.Query())
+	if record.Type() == parser.Statement || record.Type() == parser.Query || record.Type() == parser.TableChecksum {
+		translated, err := r.translate(record)
 		if err != nil {
-			logResult(ctx, NotOk, "Unexpected error %v", err)
-			return "", nil, true, err
+			logFailure(ctx, UnexpectedError, "Unable to translate query: %v", err)
+			return &R{cont: true, err: err}
+		}
+		record = translated
+	}
+
+	var preparedHarness PreparedStatementHarness
+	if (record.Type() == parser.Statement || record.Type() == parser.Query) && r.usePreparedStatement(record) {
+		var ok bool
+		if preparedHarness, ok = harness.(PreparedStatementHarness); !ok {
+			logResult(ctx, Skipped, "")
+			return &R{skipped: true, cont: true}
+		}
+	}
+
+	switch record.Type() {
+	case parser.Statement:
+		if record.AsyncName() != "" {
+			return r.startAsyncStatement(ctx, harness, preparedHarness, record)
+		}
+		warnings, err := executeStatement(ctx, harness, preparedHarness, record)
+		return r.verifyStatement(ctx, harness, record, warnings, err)
+	case parser.Query, parser.Procedure:
+		// Queries on named connections are executed by a runner for that connection
+		qr := r
+		if harness != r.harness {
+			qr = &Runner{harness: harness, config: r.config, capabilities: r.capabilities}
 		}
 
-		// Only log one error per record, so if schema comparison fails don't bother with result comparison
-		if err := verifySchema(ctx, record, schemaStr); err != nil {
-			return "", nil, true, err
+		var res *R
+		if record.Type() == parser.Procedure {
+			res = qr.executeProcedure(ctx, record)
+		} else if record.NumResultSets() > 1 {
+			res = qr.executeMultiResultQuery(ctx, record)
+		} else if preparedHarness != nil {
+			res = qr.executePreparedQuery(ctx, preparedHarness, record)
+		} else if columnHarness, ok := harness.(ColumnNameHarness); ok && qr.wantsColumnNames(ctx, record) {
+			res = qr.executeColumnsQuery(ctx, columnHarness, record)
+		} else if streamingHarness, ok := harness.(StreamingHarness); ok {
+			res = qr.executeStreamingQuery(ctx, streamingHarness, record)
+		} else {
+			res = qr.executeQuery(ctx, record)
 		}
 
-		return schemaStr, results, true, verifyResults(ctx, record, schemaStr, results)
+		if res.err == nil && !res.skipped && record.Type() == parser.Query {
+			res.err = r.verifyRoutes(ctx, harness, record)
+		}
+		if res.err == nil && !res.skipped && record.Type() == parser.Query {
+			res.err = r.verifyPlan(ctx, harness, record)
+		}
+		if res.err == nil && !res.skipped && record.Type() == parser.Query {
+			res.err = r.verifyMetrics(ctx, harness, record)
+		}
+		if res.err == nil && !res.skipped {
+			logResult(ctx, Ok, "")
+		}
+		res.cont = true
+		return res
+	case parser.Txn:
+		return r.executeTxn(ctx, harness, record)
+	case parser.AwaitStatement:
+		return r.awaitStatement(ctx, record)
+	case parser.AwaitConflict:
+		return r.awaitConflict(ctx, record)
+	case parser.TableChecksum:
+		return r.executeTableChecksum(ctx, harness, record)
+	case parser.Snapshot, parser.Restore:
+		return r.executeSnapshot(ctx, record)
+	case parser.SetSeed:
+		return r.executeSetSeed(ctx, record)
+	case parser.Custom:
+		return r.executeCustom(ctx, harness, record)
 	case parser.Halt:
-		return "", nil, false, nil
+		return &R{cont: false}
 	default:
 		panic(fmt.Sprintf("Uncrecognized record type %v", record.Type()))
 	}
 }
 
-func verifyResults(ctx context.Context, record *parser.Record, schema string, results []string) error {
+// executeStatement executes the statement record given on the harness given, as a prepared statement if a prepared
+// statement harness is given. Returns the warnings the statement produced if the record asserts them and the harness
+// can report them, or nil otherwise.
+func executeStatement(ctx context.Context, harness Harness, preparedHarness PreparedStatementHarness, record *parser.Record) ([]Warning, error) {
+	if preparedHarness != nil {
+		return nil, preparedHarness.ExecutePreparedStatement(ctx, record.Query(), record.BindArgs())
+	}
+	if warningHarness, ok := harness.(WarningHarness); ok && record.ExpectsWarnings() {
+		return warningHarness.ExecuteStatementWarnings(ctx, record.Query())
+	}
+	return nil, harness.ExecuteStatement(ctx, record.Query())
+}
+
+// verifyStatement verifies that the statement record given executed on the harness given with the warnings and error
+// given as expected, and logs its result.
+func (r *Runner) verifyStatement(ctx context.Context, harness Harness, record *parser.Record, warnings []Warning, err error) *R {
+	if timedOut(ctx, err) {
+		logResult(ctx, Timeout, "")
+		return &R{cont: true, err: testTimeoutError}
+	}
+	if record.ExpectError() {
+		if err == nil {
+			logFailure(ctx, MissingExpectedError, "Expected error but didn't get one")
+			return &R{cont: true, err: errors.New("expected statement error but got no error")}
+		}
+		if err := r.verifyError(ctx, record, err); err != nil {
+			return &R{cont: true, err: err}
+		}
+	} else if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{cont: true, err: err}
+	} else if err := r.verifyRoutes(ctx, harness, record); err != nil {
+		return &R{cont: true, err: err}
+	} else if err := r.verifyWarnings(ctx, record, warnings); err != nil {
+		return &R{cont: true, err: err}
+	} else if err := r.verifyMetrics(ctx, harness, record); err != nil {
+		return &R{cont: true, err: err}
+	} else {
+		r.trackTransaction(record.Connection(), record)
+		r.trackObjects(record)
+		r.trackCatalog(record)
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true}
+}
+
+// executeQuery executes the query record given and verifies its results, logging any failure.
+func (r *Runner) executeQuery(ctx context.Context, record *parser.Record) *R {
+	schemaStr, results, err := r.harness.ExecuteQuery(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{err: err}
+	}
+
+	return r.verifyQuery(ctx, record, schemaStr, results)
+}
+
+// verifyQuery verifies the schema and results of the query record given, logging any failure.
+func (r *Runner) verifyQuery(ctx context.Context, record *parser.Record, schema string, results []string) *R {
+	// Only log one error per record, so if schema comparison fails don't bother with result comparison
+	err := r.verifySchema(ctx, record, schema)
+	if err == nil {
+		err = r.verifyResults(ctx, record, schema, results)
+	}
+	return &R{schema: schema, results: results, err: err, mismatched: err != nil}
+}
+
+func (r *Runner) verifyResults(ctx context.Context, record *parser.Record, schema string, results []string) error {
 	if len(results) != record.NumResults() {
-		logResult(ctx, NotOk, fmt.Sprintf("Incorrect number of results. Expected %v, got %v", record.NumResults(), len(results)))
+		logFailure(ctx, RowCountMismatch, "Incorrect number of results. Expected %v, got %v", record.NumResults(), len(results))
 		return fmt.Errorf("incorrect number of results. expected %v, got %v", record.NumResults(), len(results))
 	}
 
-	results = normalizeResults(results, record.Schema())
+	results = r.config.normalizeResults(r.config.compactValues(results, record.Schema()), record.Schema())
+	if !record.IsHashResult() {
+		results = r.config.normalizeAll(results, record.Schema())
+	}
 	results = record.SortResults(results)
 
 	if record.IsHashResult() {
-		return verifyHash(ctx, record, results)
+		return r.verifyHash(ctx, record, results)
 	} else {
-		return verifyRows(ctx, record, results)
+		return r.verifyRows(ctx, record, results)
 	}
 }
 
@@ -418,85 +930,156 @@ func verifyResults(ctx context.Context, record *parser.Record, schema string, re
 // Test files have type rules that conform to MySQL's actual behavior, which is pretty odd in some cases. For example,
 // the type of the expression `- - - 8` is decimal (float) as of MySQL 8.0. Rather than expect all databases to
 // duplicate these semantics, we allow integer types to be freely converted to floats. This means we need to format
-// integer results as float results, with three trailing zeros, where necessary.
-func normalizeResults(results []string, schema string) []string {
+// integer results as float results, with the trailing zeros given by the Formatter, where necessary.
+func (c *RunConfig) normalizeResults(results []string, schema string) []string {
 	newResults := make([]string, len(results))
 	for i := range results {
-		typ := schema[i%len(schema)]
-		if typ == 'R' && !strings.Contains(results[i], ".") {
-			_, err := strconv.Atoi(results[i])
-			if err == nil {
-				newResults[i] = results[i] + ".000"
-				continue
-			}
-		}
-		newResults[i] = results[i]
+		newResults[i] = c.normalizeValue(results[i], schema[i%len(schema)])
 	}
 	return newResults
 }
 
-// Verifies that the rows given exactly match the expected rows of the record, in the order given. Rows must have been
-// previously sorted according to the semantics of the record.
-func verifyRows(ctx context.Context, record *parser.Record, results []string) error {
-	for i := range record.Result() {
-		if record.Result()[i] != results[i] {
-			logResult(ctx, NotOk, "Incorrect result at position %d. Expected %v, got %v", i, record.Result()[i], results[i])
-			return fmt.Errorf("incorrect result at position %d, expected `%v`, got `%v`", i, record.Result()[i], results[i])
+// normalizeValue normalizes a single result value in a column of the type given. See normalizeResults. Binary values
+// in B-typed columns are normalized to the lowercase hex of ValueFormatter.FormatBytes, without any 0x or \x prefix.
+func (c *RunConfig) normalizeValue(value string, typ byte) string {
+	if typ == 'R' && !strings.Contains(value, ".") {
+		if _, err := strconv.Atoi(value); err == nil {
+			return c.Formatter.formatIntAsFloat(value)
+		}
+	}
+	if typ == 'B' && value != c.Formatter.NullString {
+		digits := value
+		for _, prefix := range []string{"0x", "0X", `\x`} {
+			digits = strings.TrimPrefix(digits, prefix)
+		}
+		if len(digits)%2 == 0 && strings.Trim(digits, "0123456789abcdefABCDEF") == "" {
+			return strings.ToLower(digits)
+		}
+	}
+	return value
+}
+
+// Verifies that the rows given exactly match the expected rows of the record, in the order given, logging any failure.
+// Rows must have been previously sorted according to the semantics of the record.
+func (r *Runner) verifyRows(ctx context.Context, record *parser.Record, results []string) error {
+	epsilon := r.config.floatEpsilon(record)
+	expected := r.config.expectedResults(record)
+	if err := checkPatterns(expected); err != nil {
+		logFailure(ctx, UnexpectedError, "Invalid expected results: %v", err)
+		return fmt.Errorf("invalid expected results: %v", err)
+	}
+	numCols := record.NumCols()
+	for i := range expected {
+		if !r.config.resultMatches(expected[i], results[i], record.Schema()[i%numCols], epsilon) {
+			row, col := i/numCols, i%numCols
+			expectedRow := rowAt(expected, row, numCols)
+			actualRow := rowAt(results, row, numCols)
+			logFailure(ctx, ValueMismatch, "Incorrect result at position %d. Expected %v, got %v, at row %d column %d. Expected row %v, got %v",
+				i, expected[i], results[i], row, col, expectedRow, actualRow)
+			return fmt.Errorf("incorrect result at position %d, expected `%v`, got `%v`", i, expected[i], results[i])
 		}
 	}
 
-	logResult(ctx, Ok, "")
 	return nil
 }
 
+// rowAt returns the values of the row with the index given from the flattened values given.
+func rowAt(values []string, row, numCols int) []string {
+	end := (row + 1) * numCols
+	if end > len(values) {
+		end = len(values)
+	}
+	return values[row*numCols : end]
+}
+
+// floatEpsilon returns the tolerance for comparing the R-typed values of the record given, which is its own if it's
+// given by a float-epsilon directive, even if that's 0, and the configured one otherwise.
+func (c *RunConfig) floatEpsilon(record *parser.Record) float64 {
+	if record.HasFloatEpsilon() {
+		return record.FloatEpsilon()
+	}
+	return c.FloatEpsilon
+}
+
+// valuesEqual returns whether the expected and actual values given are equal. Values are compared with the configured
+// comparator for their type, if any. Otherwise, values of R-typed columns are compared numerically when epsilon is
+// non-zero, and are considered equal if they differ by no more than epsilon relative to the larger of their magnitudes
+// (or absolutely, for magnitudes less than 1).
+func (c *RunConfig) valuesEqual(expected, actual string, typ byte, epsilon float64) bool {
+	if expected == actual {
+		return true
+	}
+	if comparator, ok := c.Comparators[rune(typ)]; ok {
+		return comparator(expected, actual)
+	}
+	if typ != 'R' || epsilon == 0 {
+		return false
+	}
+
+	e, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	a, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false
+	}
+
+	scale := math.Max(1, math.Max(math.Abs(e), math.Abs(a)))
+	return math.Abs(e-a) <= epsilon*scale
+}
+
 // Verifies that the hash of the rows given exactly match the expected hash of the record given. Rows must have been
 // previously sorted according to the semantics of the record.
-func verifyHash(ctx context.Context, record *parser.Record, results []string) error {
+func (r *Runner) verifyHash(ctx context.Context, record *parser.Record, results []string) error {
 	results = record.SortResults(results)
 
-	computedHash, err := hashResults(results)
+	computedHash, err := hashResults(HashAlgorithm(record.HashAlgorithm()), results)
 	if err != nil {
-		logResult(ctx, NotOk, "Error hashing results: %v", err)
+		logFailure(ctx, UnexpectedError, "Error hashing results: %v", err)
 		return fmt.Errorf("error hashing results: %v", err)
 	}
 
 	if record.HashResult() != computedHash {
-		logResult(ctx, NotOk, "Hash of results differ. Expected %v, got %v", record.HashResult(), computedHash)
+		if r.config.BisectHashMismatches {
+			divergence := describeHashDivergence(record, results)
+			if divergence == "" && len(record.HashCheckpoints()) == 0 {
+				divergence = r.enumerateHashDivergence(ctx, record, results)
+			}
+			if divergence != "" {
+				logFailure(ctx, HashMismatch, "Hash of results differ. Expected %v, got %v, %s", record.HashResult(), computedHash, divergence)
+				return fmt.Errorf("hash of results differ, expected %v, got %v, %s", record.HashResult(), computedHash, divergence)
+			}
+		}
+
+		logFailure(ctx, HashMismatch, "Hash of results differ. Expected %v, got %v", record.HashResult(), computedHash)
 		return fmt.Errorf("hash of results differ, expected %v, got %v", record.HashResult(), computedHash)
-	} else {
-		logResult(ctx, Ok, "")
 	}
 
 	return nil
 }
 
-// Computes the md5 hash of the results given, using the same algorithm as the original sqllogictest C code.
-func hashResults(results []string) (string, error) {
-	h := md5.New()
-	for _, r := range results {
-		if _, err := h.Write(append([]byte(r), byte('\n'))); err != nil {
-			return "", err
-		}
-	}
-	return fmt.Sprintf("%x", h.Sum(nil)), nil
-}
-
 // Returns whether the schema given matches the record's expected schema, and logging an error if not.
-func verifySchema(ctx context.Context, record *parser.Record, schemaStr string) error {
+func (r *Runner) verifySchema(ctx context.Context, record *parser.Record, schemaStr string) error {
 	if schemaStr == record.Schema() {
 		return nil
 	}
 
 	if len(schemaStr) != len(record.Schema()) {
-		logResult(ctx, NotOk, "Schemas differ. Expected %s, got %s", record.Schema(), schemaStr)
+		logFailure(ctx, SchemaMismatch, "Schemas differ. Expected %s, got %s", record.Schema(), schemaStr)
 		return fmt.Errorf("schemas differs: expected %s, got %s", record.Schema(), schemaStr)
 	}
 
 	// MySQL has odd rules for when a result is a float v. an integer. Rather than try to replicate MySQL's type logic
-	// exactly, we allow integer results in place of floats. See normalizeResults for details.
+	// exactly, we allow integer results in place of floats. See normalizeResults for details. In strict mode, this is
+	// only allowed for records that acknowledge their schema is ambiguous, so that type regressions are reported.
+	if r.config.StrictSchemas && !record.AmbiguousSchema() {
+		logFailure(ctx, SchemaMismatch, "Schemas differ. Expected %s, got %s (strict)", record.Schema(), schemaStr)
+		return fmt.Errorf("schemas differ, expected %s, got %s", record.Schema(), schemaStr)
+	}
 	for i, c := range record.Schema() {
 		if !compatibleSchemaTypes(c, rune(schemaStr[i])) {
-			logResult(ctx, NotOk, "Schemas differ. Expected %s, got %s", record.Schema(), schemaStr)
+			logFailure(ctx, SchemaMismatch, "Schemas differ. Expected %s, got %s", record.Schema(), schemaStr)
 			return fmt.Errorf("schemas differ, expected %s, got %s", record.Schema(), schemaStr)
 		}
 	}
@@ -515,7 +1098,31 @@ func compatibleSchemaTypes(expected, actual rune) bool {
 	return true
 }
 
+// timedOut returns whether the error given, returned by the harness for the record executing in the context given, is
+// because the record's deadline passed rather than a failure of the record.
+func timedOut(ctx context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// logResult logs a result that isn't a failure for the current record. Only the first result logged for a record
+// is recorded.
 func logResult(ctx context.Context, rt ResultType, message string, args ...interface{}) {
+	code := NoFailure
+	if rt == Timeout {
+		code = TimedOut
+	}
+	report(ctx, rt, code, message, args...)
+}
+
+// logFailure logs a failure of the current record with the failure code given.
+func logFailure(ctx context.Context, code FailureCode, message string, args ...interface{}) {
+	report(ctx, NotOk, code, message, args...)
+}
+
+// reportMux serializes the results reported by concurrent runs, so that reporters needn't be safe for concurrent use.
+var reportMux sync.Mutex
+
+func report(ctx context.Context, rt ResultType, code FailureCode, message string, args ...interface{}) {
 	lock := ctx.Value("lock").(*loggingLock)
 	if lock == nil {
 		panic("Unable to acquire lock from context")
@@ -528,52 +1135,53 @@ func logResult(ctx context.Context, rt ResultType, message string, args ...inter
 		return
 	}
 
-	switch rt {
-	case Ok:
-		logSuccess()
-	case NotOk:
-		logFailure(message, args...)
-	case Skipped:
-		logSkip()
-	case Timeout:
-		logTimeout()
-	case DidNotRun:
-		logDidNotRun()
+	// Harnesses that return the error of the record's context once its deadline passes race the timeout in
+	// executeRecord, so unexpected errors after the deadline are logged as the timeout
+	if code == UnexpectedError && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		rt, code, message, args = Timeout, TimedOut, "", nil
 	}
 
-	lock.logged = true
-}
-
-func logFailure(message string, args ...interface{}) {
-	newMsg := logMessagePrefix() + " not ok: " + message
-	failureMessage := fmt.Sprintf(newMsg, args...)
-	failureMessage = strings.ReplaceAll(failureMessage, "\n", " ")
-	fmt.Println(failureMessage)
-}
-
-func logSkip() {
-	fmt.Println(logMessagePrefix(), "skipped")
-}
-
-func logSuccess() {
-	fmt.Println(logMessagePrefix(), "ok")
-}
+	config := lock.runner.config
+	entry := &ResultLogEntry{
+		EntryTime:   time.Now(),
+		TestFile:    testFilePath(lock.testFile),
+		LineNum:     lock.record.LineNum(),
+		Query:       truncateQuery(lock.record.Query(), config.TruncateQueries),
+		Duration:    time.Since(lock.startTime),
+		Result:      rt,
+		FailureCode: code,
+	}
+	if raw := lock.record.RawQuery(); raw != lock.record.Query() {
+		entry.RawQuery = raw
+	}
+	if rt == NotOk || message != "" {
+		entry.ErrorMessage = fmt.Sprintf(message, args...)
+	}
+	if rt == NotOk && config.isExpectedFailure(lock.testFile, lock.record.LineNum()) {
+		entry.Result = Skipped
+		entry.ErrorMessage = "expected failure: " + entry.ErrorMessage
+	}
+	if entry.Result == NotOk {
+		entry.Plan = lock.runner.explainFailure(lock.record, code)
+	}
 
-func logTimeout() {
-	fmt.Println(logMessagePrefix(), "timeout")
-}
+	annotateRecordSpan(ctx, entry)
 
-func logDidNotRun() {
-	fmt.Println(logMessagePrefix(), "did not run")
+	lock.logged = true
+	if entry.Result == NotOk && !lock.generating && lock.runner.holdFailure(lock.record, entry) {
+		return
+	}
+	emitLogEntry(config, entry)
 }
 
-func logMessagePrefix() string {
-	return fmt.Sprintf("%s %d %s:%d: %s",
-		time.Now().Format(time.RFC3339Nano),
-		time.Since(startTime).Milliseconds(),
-		testFilePath(currTestFile),
-		currRecord.LineNum(),
-		truncateQuery(currRecord.Query()))
+// emitLogEntry writes the entry given to the text result log and the reporters of the configuration given.
+func emitLogEntry(config RunConfig, entry *ResultLogEntry) {
+	reportMux.Lock()
+	defer reportMux.Unlock()
+	fmt.Fprintln(config.Output, formatLogEntry(entry))
+	for _, r := range config.Reporters {
+		r.Report(entry)
+	}
 }
 
 func testFilePath(f string) string {
@@ -593,8 +1201,17 @@ func testFilePath(f string) string {
 	return strings.ReplaceAll(filepath.Join(pathElements...), "\\", "/")
 }
 
-func truncateQuery(query string) string {
-	if TruncateQueriesInLog && len(query) > 50 {
+// firstLine returns the first line of the raw query of the record given, followed by an ellipsis if it has more, for
+// showing the query as written in the test file on one line.
+func firstLine(record *parser.Record) string {
+	if line, _, more := strings.Cut(record.RawQuery(), "\n"); more {
+		return line + " ..."
+	}
+	return record.RawQuery()
+}
+
+func truncateQuery(query string, truncate bool) string {
+	if truncate && len(query) > 50 {
 		return query[:47] + "..."
 	}
 	return query
@@ -0,0 +1,554 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResult struct {
+	schema  string
+	results []string
+}
+
+// fakeHarness is an in-memory harness that returns canned results for the queries it knows about.
+type fakeHarness struct {
+	statementErrors map[string]error
+	queryResults    map[string]fakeResult
+}
+
+var _ Harness = &fakeHarness{}
+
+func newFakeHarness() *fakeHarness {
+	return &fakeHarness{
+		statementErrors: map[string]error{
+			"INSERT INTO missing VALUES(1, 2)": errors.New("table not found: missing"),
+		},
+		queryResults: map[string]fakeResult{
+			"SELECT a, b FROM t1":          {schema: "II", results: []string{"1", "2"}},
+			"SELECT a FROM t1 WHERE a > 1": {schema: "I"},
+			"SELECT 1.0 / 3":               {schema: "R", results: []string{"0.333333333333333"}},
+		},
+	}
+}
+
+func (h *fakeHarness) EngineStr() string {
+	return "fake"
+}
+
+func (h *fakeHarness) Init() error {
+	return nil
+}
+
+func (h *fakeHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	return h.statementErrors[statement]
+}
+
+func (h *fakeHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	result, ok := h.queryResults[statement]
+	if !ok {
+		return "", nil, errors.New("unknown query: " + statement)
+	}
+	return result.schema, result.results, nil
+}
+
+func (h *fakeHarness) GetTimeout() int64 {
+	return 0
+}
+
+// runAndCaptureOutput runs the test files given with the harness given and returns the lines of the result log.
+func runAndCaptureOutput(t *testing.T, harness Harness, paths ...string) []string {
+	buf := &bytes.Buffer{}
+	NewRunner(harness, WithOutput(buf)).RunTestFiles(paths...)
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestRunTestFilesOutput(t *testing.T) {
+	lines := runAndCaptureOutput(t, newFakeHarness(), "testdata/basic.test")
+	require.Len(t, lines, 5)
+	for _, line := range lines {
+		assert.True(t, strings.HasSuffix(line, " ok"), line)
+	}
+	assert.Contains(t, lines[3], "basic.test:11: SELECT a, b FROM t1 ok")
+}
+
+func TestOutputFile(t *testing.T) {
+	path := t.TempDir() + "/results.log"
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	NewRunner(newFakeHarness(), WithOutput(f)).RunTestFiles("testdata/basic.test")
+	require.NoError(t, f.Close())
+
+	entries, err := ParseResultFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+	assert.Equal(t, "INSERT INTO missing VALUES(1, 2)", entries[2].Query)
+	assert.Equal(t, Ok, entries[2].Result)
+}
+
+// collectingReporter records every entry reported to it.
+type collectingReporter struct {
+	entries []*ResultLogEntry
+}
+
+func (r *collectingReporter) Report(entry *ResultLogEntry) {
+	r.entries = append(r.entries, entry)
+}
+
+// writeTestFile writes a test file with the contents given to a temporary directory and returns its path.
+func writeTestFile(t *testing.T, contents string) string {
+	path := t.TempDir() + "/test.test"
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	return path
+}
+
+func TestFailureCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		code     FailureCode
+	}{
+		{
+			name:     "missing expected error",
+			contents: "statement error\nCREATE TABLE t1(a INTEGER, b INTEGER)\n",
+			code:     MissingExpectedError,
+		},
+		{
+			name:     "unexpected error",
+			contents: "statement ok\nINSERT INTO missing VALUES(1, 2)\n",
+			code:     UnexpectedError,
+		},
+		{
+			name:     "schema mismatch",
+			contents: "query T nosort\nSELECT a FROM t1 WHERE a > 1\n----\n",
+			code:     SchemaMismatch,
+		},
+		{
+			name:     "row count mismatch",
+			contents: "query II nosort\nSELECT a, b FROM t1\n----\n1\n",
+			code:     RowCountMismatch,
+		},
+		{
+			name:     "value mismatch",
+			contents: "query II nosort\nSELECT a, b FROM t1\n----\n1\n3\n",
+			code:     ValueMismatch,
+		},
+		{
+			name:     "hash mismatch",
+			contents: "query II nosort\nSELECT a, b FROM t1\n----\n2 values hashing to 00000000000000000000000000000000\n",
+			code:     HashMismatch,
+		},
+		{
+			name:     "panic",
+			contents: "query I nosort\nSELECT panic\n----\n",
+			code:     Panic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, tt.contents)
+			reporter := &collectingReporter{}
+			harness := &panickingHarness{newFakeHarness()}
+			buf := &bytes.Buffer{}
+
+			assert.Panics(t, func() {
+				NewRunner(harness, WithOutput(buf), WithReporters(reporter)).RunTestFiles(path)
+			})
+
+			require.Len(t, reporter.entries, 1)
+			assert.Equal(t, NotOk, reporter.entries[0].Result)
+			assert.Equal(t, tt.code, reporter.entries[0].FailureCode)
+
+			logPath := t.TempDir() + "/results.log"
+			require.NoError(t, os.WriteFile(logPath, buf.Bytes(), 0644))
+			entries, err := ParseResultFile(logPath)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.code, entries[0].FailureCode)
+		})
+	}
+}
+
+// panickingHarness panics when asked to execute the query "SELECT panic".
+type panickingHarness struct {
+	*fakeHarness
+}
+
+func (h *panickingHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	if statement == "SELECT panic" {
+		panic("harness panic")
+	}
+	return h.fakeHarness.ExecuteQuery(ctx, statement)
+}
+
+func TestJSONReporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(NewJSONReporter(buf))).
+		RunTestFiles("testdata/basic.test")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+
+	var entry ResultLogEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[3]), &entry))
+	assert.Equal(t, Ok, entry.Result)
+	assert.Equal(t, "SELECT a, b FROM t1", entry.Query)
+	assert.Equal(t, 11, entry.LineNum)
+	assert.Contains(t, lines[3], `"Result":"ok"`)
+}
+
+func TestValuesEqual(t *testing.T) {
+	config := DefaultRunConfig()
+	assert.True(t, config.valuesEqual("1.000", "1.000", 'R', 0))
+	assert.False(t, config.valuesEqual("1.000", "1.001", 'R', 0))
+	assert.True(t, config.valuesEqual("1.000", "1.001", 'R', 0.01))
+	assert.False(t, config.valuesEqual("1.000", "1.1", 'R', 0.01))
+	assert.True(t, config.valuesEqual("123456789012345.6", "123456789012345.7", 'R', 1e-14))
+	assert.False(t, config.valuesEqual("1", "2", 'I', 10))
+	assert.False(t, config.valuesEqual("NULL", "1.000", 'R', 10))
+}
+
+func TestNormalizeBinaryValues(t *testing.T) {
+	config := DefaultRunConfig()
+	assert.Equal(t, "0aff", config.normalizeValue("0AFF", 'B'))
+	assert.Equal(t, "0aff", config.normalizeValue("0x0aff", 'B'))
+	assert.Equal(t, "0aff", config.normalizeValue(`\x0AFF`, 'B'))
+	assert.Equal(t, "NULL", config.normalizeValue("NULL", 'B'))
+	assert.Equal(t, "xyz", config.normalizeValue("xyz", 'B'))
+	assert.Equal(t, "0AFF", config.normalizeValue("0AFF", 'T'))
+}
+
+func TestFloatEpsilon(t *testing.T) {
+	contents := "query R nosort\nSELECT 1.0 / 3\n----\n0.333\n"
+	assert.Panics(t, func() {
+		runAndCaptureOutput(t, newFakeHarness(), writeTestFile(t, contents))
+	})
+
+	lines := runAndCaptureOutput(t, newFakeHarness(), writeTestFile(t, "float-epsilon 0.001\n"+contents))
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasSuffix(lines[0], " ok"), lines[0])
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithFloatEpsilon(0.001))
+	runner.RunTestFiles(writeTestFile(t, contents))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	// A record's own epsilon of 0 compares its results exactly
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithFloatEpsilon(0.001))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "float-epsilon 0\n"+contents))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+}
+
+// slowHarness executes queries after a delay, unless its context is done first.
+type slowHarness struct {
+	*fakeHarness
+	delay time.Duration
+}
+
+func (h *slowHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	select {
+	case <-time.After(h.delay):
+		return h.fakeHarness.ExecuteQuery(ctx, statement)
+	case <-ctx.Done():
+		return "", nil, ctx.Err()
+	}
+}
+
+func TestRecordTimeout(t *testing.T) {
+	harness := &slowHarness{fakeHarness: newFakeHarness(), delay: 50 * time.Millisecond}
+	query := "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"
+
+	// The record's timeout extends the runner's
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithTimeout(10*time.Millisecond))
+	runner.RunTestFiles(writeTestFile(t, "timeout 5s\n"+query))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	// And it shortens it too
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithTimeout(5*time.Second))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "timeout 10ms\n"+query))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Timeout, reporter.entries[0].Result)
+}
+
+func TestPartialSort(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT a, b FROM t1 ORDER BY a"] = fakeResult{
+		schema: "IT", results: []string{"1", "b", "1", "a", "2", "a"},
+	}
+
+	// Rows with equal values in the ordered column may come back in any order
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "query IT partialsort(1)\nSELECT a, b FROM t1 ORDER BY a\n----\n1\na\n1\nb\n2\na\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	// But the order of the ordered column is still checked
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query IT partialsort(1)\nSELECT a, b FROM t1 ORDER BY a\n----\n2\na\n1\na\n1\nb\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ValueMismatch, reporter.entries[0].FailureCode)
+}
+
+func TestStrictSchemas(t *testing.T) {
+	query := "query RI nosort\nSELECT a, b FROM t1\n----\n1.000\n2\n"
+
+	// Integer results are accepted for R columns unless schemas are verified strictly
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(writeTestFile(t, query))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+
+	reporter = &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithStrictSchemas(true))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, query))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, SchemaMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Schemas differ. Expected RI, got II (strict)", reporter.entries[0].ErrorMessage)
+
+	// Unless the record acknowledges its schema is ambiguous
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithStrictSchemas(true))
+	runner.RunTestFiles(writeTestFile(t, "ambiguous-schema\n"+query))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
+
+func TestHalt(t *testing.T) {
+	// The first halt is skipped for this engine, and the second halts only the first file
+	lines := runAndCaptureOutput(t, newFakeHarness(), "testdata/halt.test", "testdata/basic.test")
+	require.Len(t, lines, 6)
+	assert.Contains(t, lines[0], "halt.test:5: CREATE TABLE t1(a INTEGER, b INTEGER) ok")
+	assert.Contains(t, lines[1], "basic.test:2: CREATE TABLE t1(a INTEGER, b INTEGER) ok")
+
+	haltRun := writeTestFile(t, "statement ok\nCREATE TABLE t1(a INTEGER, b INTEGER)\n\nonlyif fake\nhalt run\n")
+	lines = runAndCaptureOutput(t, newFakeHarness(), haltRun, "testdata/basic.test")
+	require.Len(t, lines, 1)
+}
+
+func TestSkipAfterSetupFailure(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+statement ok
+INSERT INTO missing VALUES(1, 2)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+statement ok
+INSERT INTO t1 VALUES(1, 2)
+`)
+
+	assert.Panics(t, func() {
+		NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{})).RunTestFiles(path)
+	})
+
+	reporter := &collectingReporter{}
+	output := &bytes.Buffer{}
+	NewRunner(newFakeHarness(),
+		WithOutput(output),
+		WithReporters(reporter),
+		WithSkipAfterSetupFailure(true),
+	).RunTestFiles(path)
+
+	require.Len(t, reporter.entries, 4)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+	assert.Equal(t, NotOk, reporter.entries[1].Result)
+	assert.Equal(t, UnexpectedError, reporter.entries[1].FailureCode)
+	for _, entry := range reporter.entries[2:] {
+		assert.Equal(t, DidNotRun, entry.Result)
+		assert.Equal(t, "Skipped due to failed setup statement on line 5", entry.ErrorMessage)
+	}
+
+	// The root cause is written to the text result log too, and parsed back from it
+	logPath := filepath.Join(t.TempDir(), "results.log")
+	require.NoError(t, os.WriteFile(logPath, output.Bytes(), 0644))
+	entries, err := ParseResultFile(logPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	for _, entry := range entries[2:] {
+		assert.Equal(t, DidNotRun, entry.Result)
+		assert.Equal(t, "Skipped due to failed setup statement on line 5", entry.ErrorMessage)
+	}
+}
+
+func TestContinueOnFailure(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+INSERT INTO missing VALUES(1, 2)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+3
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`)
+
+	reporter := &collectingReporter{}
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithContinueOnFailure(true)).
+		RunTestFiles(path, path)
+
+	require.Len(t, reporter.entries, 6)
+	for i, result := range []ResultType{NotOk, NotOk, Ok, NotOk, NotOk, Ok} {
+		assert.Equal(t, result, reporter.entries[i].Result)
+	}
+}
+
+// rowHarness serves the results of a fakeHarness through the RowHarness interface.
+type rowHarness struct {
+	*fakeHarness
+}
+
+var _ RowHarness = rowHarness{}
+
+func (h rowHarness) ExecuteQueryRows(ctx context.Context, statement string) (string, [][]string, error) {
+	schema, results, err := h.fakeHarness.ExecuteQuery(ctx, statement)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rows [][]string
+	for i := 0; i < len(results); i += len(schema) {
+		rows = append(rows, results[i:i+len(schema)])
+	}
+	if statement == "SELECT a, b FROM t1" {
+		rows = append(rows, []string{"3"})
+	}
+	return schema, rows, nil
+}
+
+func TestRowHarnessAdapter(t *testing.T) {
+	harness := NewRowHarnessAdapter(rowHarness{newFakeHarness()})
+	schema, results, err := harness.ExecuteQuery(context.Background(), "SELECT 1.0 / 3")
+	require.NoError(t, err)
+	assert.Equal(t, "R", schema)
+	assert.Equal(t, []string{"0.333333333333333"}, results)
+
+	_, _, err = harness.ExecuteQuery(context.Background(), "SELECT a, b FROM t1")
+	assert.EqualError(t, err, "row 1 has 1 values, but schema II has 2 columns")
+}
+
+func TestValueMismatchMessage(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "query II nosort\nSELECT a, b FROM t1\n----\n1\n3\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, "Incorrect result at position 1. Expected 3, got 2, at row 0 column 1. Expected row [1 3], got [1 2]", reporter.entries[0].ErrorMessage)
+}
+
+func init() {
+	parser.RegisterDirective(parser.Directive{Name: "test_backup", Body: true})
+}
+
+const customRecordTest = `test_backup full
+t1
+
+statement ok
+INSERT INTO t1 VALUES (1)
+`
+
+func TestCustomRecordsWithoutHandlers(t *testing.T) {
+	path := writeTestFile(t, customRecordTest)
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(path)
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+
+	runner.GenerateTestFiles(path)
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, customRecordTest, string(generated))
+}
+
+func TestRunTestFileStreamsRecords(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+statement maybe
+INSERT INTO t1 VALUES(1, 2)
+`)
+
+	// Records are executed as they're parsed, so the ones before an error in the file run first
+	reporter := &collectingReporter{}
+	assert.Panics(t, func() {
+		NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter)).RunTestFiles(path)
+	})
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+}
+
+func TestRawQueryReported(t *testing.T) {
+	reporter := &collectingReporter{}
+	output := &bytes.Buffer{}
+	runner := NewRunner(newFakeHarness(), WithOutput(output), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "query II nosort\nSELECT a, \n# the table\nb FROM t1\n----\n1\n2\n\n"+
+		"query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, "SELECT a, b FROM t1", reporter.entries[0].Query)
+	assert.Equal(t, "SELECT a, \n# the table\nb FROM t1", reporter.entries[0].RawQuery)
+	assert.Empty(t, reporter.entries[1].RawQuery)
+
+	// Text result logs only have the query
+	assert.NotContains(t, output.String(), "# the table")
+}
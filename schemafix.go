@@ -0,0 +1,54 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// fixSchema returns the header of the query record given rewritten with the schema given, the one the engine returned
+// for its results, if that differs from the record's schema and the record's expected results still match the
+// results given with it. Returns the empty string otherwise. Only the schema letters of the header change, so that
+// e.g. the I columns of legacy corpora that an engine returns as R, or vice versa, are fixed without rewriting any
+// expected results.
+func (r *Runner) fixSchema(record *parser.Record, schema string, results []string) string {
+	if record.Type() != parser.Query || record.NumResultSets() != 1 || schema == record.Schema() ||
+		len(schema) != len(record.Schema()) || len(results) != record.NumResults() {
+		return ""
+	}
+
+	results = record.SortResults(r.config.normalizeResults(r.config.compactValues(results, schema), schema))
+	if record.IsHashResult() {
+		hash, err := hashResults(HashAlgorithm(record.HashAlgorithm()), results)
+		if err != nil || hash != record.HashResult() {
+			return ""
+		}
+	} else {
+		epsilon := r.config.floatEpsilon(record)
+		for i, expected := range record.Result() {
+			if !r.config.resultMatches(expected, results[i], schema[i%len(schema)], epsilon) {
+				return ""
+			}
+		}
+	}
+
+	var label string
+	if record.Label() != "" {
+		label = " " + record.Label()
+	}
+	return fmt.Sprintf("query %s %s%s", schema, record.SortString(), label)
+}
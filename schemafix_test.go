@@ -0,0 +1,97 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+)
+
+const legacySchemaTest = `query TT
+SELECT a, b FROM t1
+----
+1
+2
+
+query IT rowsort
+SELECT a, b FROM t1
+----
+3
+4
+
+query R nosort label-empty
+SELECT a FROM t1 WHERE a > 1
+----
+
+query II
+SELECT a, b FROM t1
+----
+1
+2
+
+statement ok
+INSERT INTO missing VALUES(1, 2)
+`
+
+func TestFixSchemas(t *testing.T) {
+	path := writeTestFile(t, legacySchemaTest)
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithFixSchemas(true))
+	runner.GenerateTestFiles(path)
+
+	// Only the schemas of the queries whose results still match are rewritten, and nothing else is
+	assertFileContents(t, path+".generated", `query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+query IT rowsort
+SELECT a, b FROM t1
+----
+3
+4
+
+query I nosort label-empty
+SELECT a FROM t1 WHERE a > 1
+----
+
+query II
+SELECT a, b FROM t1
+----
+1
+2
+
+statement ok
+INSERT INTO missing VALUES(1, 2)
+`)
+}
+
+func TestFixSchemasHashResults(t *testing.T) {
+	harness := newFakeHarness()
+	harness.queryResults["SELECT a FROM t2"] = fakeResult{schema: "I", results: []string{"1", "2", "3"}}
+	path := writeTestFile(t, `query T nosort
+SELECT a FROM t2
+----
+3 values hashing to c0710d6b4f15dfa88f600b0e6b624077
+`)
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithFixSchemas(true), WithHashThreshold(2))
+	runner.GenerateTestFiles(path)
+
+	assertFileContents(t, path+".generated", `query I nosort
+SELECT a FROM t2
+----
+3 values hashing to c0710d6b4f15dfa88f600b0e6b624077
+`)
+}
@@ -0,0 +1,71 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A SeedHarness is a Harness whose engine's random functions, such as RAND() or RANDOM(), can be seeded. Harnesses
+// that implement this interface can run test files with stable results for queries involving random functions,
+// written e.g.:
+//
+//	set-seed 42
+//
+//	query R nosort
+//	SELECT RAND()
+//	----
+//	0.6555
+//
+// For harnesses that don't implement this interface, set-seed records are skipped.
+type SeedHarness interface {
+	Harness
+
+	// SetSeed seeds the engine's random number generator for the rest of the test file, or until the next seed.
+	SetSeed(ctx context.Context, seed int64) error
+}
+
+// SeedFraction maps the seed given to a fraction in [-1, 1), for harnesses of engines whose setseed function takes a
+// fraction rather than an integer, such as PostgreSQL and DuckDB.
+func SeedFraction(seed int64) float64 {
+	const scale = 1 << 31
+	return float64(seed%scale) / scale
+}
+
+// executeSetSeed executes the set-seed record given, on the reference harness too in differential mode, and logs its
+// result.
+func (r *Runner) executeSetSeed(ctx context.Context, record *parser.Record) *R {
+	harness, ok := r.harness.(SeedHarness)
+	if !ok {
+		logResult(ctx, Skipped, "Requires seeding random functions")
+		return &R{skipped: true, cont: true}
+	}
+
+	if err := harness.SetSeed(ctx, record.Seed()); err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{cont: true, err: err}
+	}
+	if reference, ok := r.config.Reference.(SeedHarness); ok && !isGenerating(ctx) {
+		if err := reference.SetSeed(ctx, record.Seed()); err != nil {
+			logFailure(ctx, UnexpectedError, "Unexpected error from reference %v", err)
+			return &R{cont: true, err: err}
+		}
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true}
+}
@@ -0,0 +1,81 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedHarness records the seeds it's given.
+type seedHarness struct {
+	*fakeHarness
+	seeds []int64
+}
+
+var _ SeedHarness = &seedHarness{}
+
+func (h *seedHarness) SetSeed(ctx context.Context, seed int64) error {
+	h.seeds = append(h.seeds, seed)
+	return nil
+}
+
+func TestSetSeedRecords(t *testing.T) {
+	harness := &seedHarness{fakeHarness: newFakeHarness()}
+	reference := &seedHarness{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithReference(reference))
+	runner.RunTestFiles(writeTestFile(t, "set-seed 42\n\nset-seed -7\n"))
+
+	require.Len(t, reporter.entries, 2)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result)
+	}
+	assert.Equal(t, []int64{42, -7}, harness.seeds)
+	assert.Equal(t, []int64{42, -7}, reference.seeds)
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "set-seed 42\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Skipped, reporter.entries[0].Result)
+}
+
+func TestGenerateSetSeedRecords(t *testing.T) {
+	test := "set-seed 42\n\nquery II nosort\nSELECT a, b FROM t1\n----\n1\n2\n"
+	path := writeTestFile(t, test)
+	runner := NewRunner(&seedHarness{fakeHarness: newFakeHarness()}, WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, test, string(generated))
+}
+
+func TestSeedFraction(t *testing.T) {
+	assert.Equal(t, 0.0, SeedFraction(0))
+	assert.Equal(t, 0.5, SeedFraction(1<<30))
+	assert.Equal(t, -0.5, SeedFraction(-(1 << 30)))
+	assert.Equal(t, SeedFraction(1), SeedFraction(1+1<<31))
+	for _, seed := range []int64{1<<63 - 1, -1 << 63} {
+		fraction := SeedFraction(seed)
+		assert.True(t, fraction >= -1 && fraction < 1, "%d maps to %f", seed, fraction)
+	}
+}
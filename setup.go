@@ -0,0 +1,90 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadSetupScript reads the SQL script at the path given and returns the statements it contains, for use with
+// WithSetupStatements. Statements are separated by semicolons, and lines starting with -- are comments.
+func ReadSetupScript(path string) ([]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return SplitStatements(string(contents)), nil
+}
+
+// SplitStatements splits the SQL script given into statements on semicolons outside of quotes, dropping comment lines
+// and empty statements.
+func SplitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	script = strings.Join(lines, "\n")
+
+	var statements []string
+	var quote byte
+	start := 0
+	for i := 0; i <= len(script); i++ {
+		if i < len(script) {
+			c := script[i]
+			switch {
+			case quote != 0:
+				if c == '\\' {
+					i++
+				} else if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '\'' || c == '"' || c == '`':
+				quote = c
+				continue
+			case c != ';':
+				continue
+			}
+		}
+
+		if statement := strings.TrimSpace(script[start:i]); statement != "" {
+			statements = append(statements, statement)
+		}
+		start = i + 1
+	}
+	return statements
+}
+
+// initHarness initializes the harness for a new test file and executes the configured setup statements on it.
+func (r *Runner) initHarness() error {
+	if err := r.harness.Init(); err != nil {
+		return err
+	}
+	r.resetTransactions()
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	for _, statement := range r.config.SetupStatements {
+		if err := r.harness.ExecuteStatement(ctx, statement); err != nil {
+			return fmt.Errorf("setup statement %q failed: %v", statement, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSetupScript(t *testing.T) {
+	path := t.TempDir() + "/setup.sql"
+	script := "-- session setup\nSET sql_mode = 'ANSI_QUOTES;STRICT';\n\nCREATE SCHEMA s1;\nSELECT \"a;b\""
+	require.NoError(t, os.WriteFile(path, []byte(script), 0644))
+
+	statements, err := ReadSetupScript(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SET sql_mode = 'ANSI_QUOTES;STRICT'", "CREATE SCHEMA s1", "SELECT \"a;b\""}, statements)
+}
+
+// recordingHarness records the statements it executes.
+type recordingHarness struct {
+	*fakeHarness
+	statements []string
+}
+
+func (h *recordingHarness) Init() error {
+	h.statements = nil
+	return nil
+}
+
+func (h *recordingHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	h.statements = append(h.statements, statement)
+	return h.fakeHarness.ExecuteStatement(ctx, statement)
+}
+
+func TestSetupStatements(t *testing.T) {
+	harness := &recordingHarness{fakeHarness: newFakeHarness()}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithSetupStatements("SET a = 1", "SET b = 2"))
+	runner.RunTestFiles(writeTestFile(t, "statement ok\nCREATE TABLE t1 (a int)\n"))
+	assert.Equal(t, []string{"SET a = 1", "SET b = 2", "CREATE TABLE t1 (a int)"}, harness.statements)
+
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithSetupStatements("INSERT INTO missing VALUES(1, 2)"))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "statement ok\nCREATE TABLE t1 (a int)\n"))
+	})
+}
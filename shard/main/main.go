@@ -0,0 +1,58 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/andyyu2004/sqllogictest/shard"
+)
+
+// Shard splitter. Splits a test file into the number of shards given, each with a run of its queries and the records
+// they depend on, and writes them to the directory given, or beside the test file by default.
+//
+// Usage: go run main.go N testfile [outdir]
+func main() {
+	if len(os.Args) != 3 && len(os.Args) != 4 {
+		exitWithUsage()
+	}
+
+	n, err := strconv.Atoi(os.Args[1])
+	if err != nil {
+		exitWithUsage()
+	}
+	path := os.Args[2]
+	dir := filepath.Dir(path)
+	if len(os.Args) == 4 {
+		dir = os.Args[3]
+	}
+
+	paths, err := shard.SplitFile(path, n, dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+}
+
+func exitWithUsage() {
+	fmt.Println("Usage: shard N testfile [outdir]")
+	os.Exit(1)
+}
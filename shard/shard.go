@@ -0,0 +1,144 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shard splits large test files into smaller ones that run on their own, so that they fit within CI time limits
+// and can run in parallel.
+package shard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// hashThreshold is the directive that sets the hash threshold of the records after it.
+const hashThreshold = "hash-threshold"
+
+// An item is a part of a test file copied into shards: a record, along with the comments directly above it, or a
+// hash-threshold directive between records.
+type item struct {
+	lines   []string
+	isQuery bool
+}
+
+// Split splits the test file at the path given into at most n shards, and returns the contents of each. The query
+// records of the file are divided between the shards in runs of about the same size, and every other record, such as
+// the statements that create and fill the tables the queries read, is copied into each shard with queries after it, so
+// that every shard runs on its own. Comments directly above a record are copied with it, and other comments are left
+// out.
+func Split(path string, n int) ([][]byte, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of shards %d", n)
+	}
+
+	contents, err := parser.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	records, err := parser.Parse(bytes.NewReader(contents))
+	if err != nil {
+		return nil, err
+	}
+
+	items, queries := splitItems(strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n"), records)
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s has no queries to split", path)
+	}
+	if n > len(queries) {
+		n = len(queries)
+	}
+
+	shards := make([][]byte, n)
+	for k := range shards {
+		// The shard has the queries from first to last, and every other item before its last query, or before the end of
+		// the file for the last shard
+		first, last := queries[k*len(queries)/n], queries[(k+1)*len(queries)/n-1]
+		end := last
+		if k == n-1 {
+			end = len(items) - 1
+		}
+
+		var sb strings.Builder
+		for i, item := range items[:end+1] {
+			if item.isQuery && i < first {
+				continue
+			}
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			for _, line := range item.lines {
+				sb.WriteString(line + "\n")
+			}
+		}
+		shards[k] = []byte(sb.String())
+	}
+	return shards, nil
+}
+
+// SplitFile splits the test file at the path given as Split does, and writes the shards to the directory given, named
+// after the test file and numbered from 1, e.g. select1.1.test, select1.2.test, and compressed like it. Returns the
+// paths of the shards.
+func SplitFile(path string, n int, dir string) ([]string, error) {
+	shards, err := Split(path, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	base, compression := parser.SplitCompressionSuffix(filepath.Base(path))
+	name := strings.TrimSuffix(base, parser.TestFileSuffix)
+	paths := make([]string, len(shards))
+	for i, shard := range shards {
+		paths[i] = filepath.Join(dir, name+"."+strconv.Itoa(i+1)+parser.TestFileSuffix+compression)
+		if err := parser.WriteFile(paths[i], shard); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// splitItems splits the lines of a test file with the records given into items, and returns them along with the
+// indexes of the items that are queries.
+func splitItems(lines []string, records []*parser.Record) ([]item, []int) {
+	var items []item
+	var queries []int
+	next := 1
+	for _, record := range records {
+		// Comments directly above the record belong to it
+		start := record.StartLine()
+		for start > next && strings.HasPrefix(lines[start-2], "#") {
+			start--
+		}
+
+		for ; next < start; next++ {
+			if fields := strings.Fields(lines[next-1]); len(fields) > 0 && fields[0] == hashThreshold {
+				items = append(items, item{lines: []string{lines[next-1]}})
+			}
+		}
+
+		if record.Type() == parser.Query {
+			queries = append(queries, len(items))
+		}
+		items = append(items, item{lines: lines[start-1 : record.EndLine()], isQuery: record.Type() == parser.Query})
+		next = record.EndLine() + 1
+	}
+	return items, queries
+}
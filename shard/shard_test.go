@@ -0,0 +1,140 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shard
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const largeTest = `hash-threshold 4
+
+statement ok
+CREATE TABLE t1(a INTEGER)
+
+# Fills the table
+statement ok
+INSERT INTO t1 VALUES (1), (2)
+
+query I rowsort
+SELECT a FROM t1
+----
+1
+2
+
+# A comment that's left out
+
+query I nosort
+SELECT COUNT(*) FROM t1
+----
+2
+
+statement ok
+UPDATE t1 SET a = a + 10
+
+query I rowsort
+SELECT a FROM t1
+----
+11
+12
+
+statement ok
+DROP TABLE t1
+`
+
+func TestSplitFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "large.test")
+	require.NoError(t, os.WriteFile(path, []byte(largeTest), 0644))
+
+	paths, err := SplitFile(path, 2, filepath.Join(dir, "shards"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "shards", "large.1.test"), filepath.Join(dir, "shards", "large.2.test")},
+		paths)
+
+	setup := `hash-threshold 4
+
+statement ok
+CREATE TABLE t1(a INTEGER)
+
+# Fills the table
+statement ok
+INSERT INTO t1 VALUES (1), (2)
+`
+	shard1, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, setup+`
+query I rowsort
+SELECT a FROM t1
+----
+1
+2
+`, string(shard1))
+
+	shard2, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	assert.Equal(t, setup+`
+query I nosort
+SELECT COUNT(*) FROM t1
+----
+2
+
+statement ok
+UPDATE t1 SET a = a + 10
+
+query I rowsort
+SELECT a FROM t1
+----
+11
+12
+
+statement ok
+DROP TABLE t1
+`, string(shard2))
+
+	// Each shard runs on its own
+	for _, path := range paths {
+		output := &bytes.Buffer{}
+		logictest.NewRunner(sqlite.NewSqliteHarness(":memory:"), logictest.WithOutput(output)).RunTestFiles(path)
+		assert.NotContains(t, output.String(), "not ok")
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statements.test")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\nCREATE TABLE t1(a INTEGER)\n"), 0644))
+	_, err := Split(path, 2)
+	assert.Error(t, err)
+
+	path = filepath.Join(t.TempDir(), "large.test")
+	require.NoError(t, os.WriteFile(path, []byte(largeTest), 0644))
+	_, err = Split(path, 0)
+	assert.Error(t, err)
+
+	// There are no more shards than queries
+	shards, err := Split(path, 5)
+	require.NoError(t, err)
+	assert.Len(t, shards, 3)
+	assert.Equal(t, 1, strings.Count(string(shards[2]), "query"))
+}
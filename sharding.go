@@ -0,0 +1,127 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Shard selects one of several disjoint partitions of the test files of a run, so that a corpus can be spread across
+// CI machines, each running one shard. Partitions are deterministic, so every machine computes the same ones given the
+// same test files.
+type Shard struct {
+	// Index is the shard to run, from 1 to Count.
+	Index int
+	// Count is the number of shards. Zero disables sharding.
+	Count int
+	// BySize balances shards by the total size of their test files, rather than assigning files by a hash of their
+	// paths. Balanced shards finish at more similar times, but adding a file can move others between shards.
+	BySize bool
+}
+
+// ParseShard parses a shard given as "index/count", e.g. "3/10" for the third of ten shards.
+func ParseShard(s string) (Shard, error) {
+	index, count, ok := strings.Cut(s, "/")
+	if !ok {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected index/count", s)
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return Shard{}, fmt.Errorf("invalid shard index in %q", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return Shard{}, fmt.Errorf("invalid shard count in %q", s)
+	}
+	if n < 1 || i < 1 || i > n {
+		return Shard{}, fmt.Errorf("invalid shard %q, expected an index from 1 to a count of at least 1", s)
+	}
+	return Shard{Index: i, Count: n}, nil
+}
+
+// String returns the shard in the form parsed by ParseShard.
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Select returns the test files given that belong to this shard, in the order given. Returns all of them if sharding
+// is disabled.
+func (s Shard) Select(files []string) []string {
+	if s.Count == 0 {
+		return files
+	}
+
+	shards := s.assign(files)
+	var selected []string
+	for i, file := range files {
+		if shards[i] == s.Index-1 {
+			selected = append(selected, file)
+		}
+	}
+	return selected
+}
+
+// assign returns the index of the shard, from 0, of each of the files given. Files are identified by their paths as
+// written in result logs, so that shards don't depend on where the corpus is checked out.
+func (s Shard) assign(files []string) []int {
+	shards := make([]int, len(files))
+	if !s.BySize {
+		for i, file := range files {
+			h := fnv.New32a()
+			h.Write([]byte(testFilePath(file)))
+			shards[i] = int(h.Sum32() % uint32(s.Count))
+		}
+		return shards
+	}
+
+	// Assign the largest files first, each to the shard with the smallest total size so far, or the fewest files among
+	// those, breaking ties by path and by shard index so that every machine makes the same choices
+	sizes := make([]int64, len(files))
+	for i, file := range files {
+		if stat, err := os.Stat(file); err == nil {
+			sizes[i] = stat.Size()
+		}
+	}
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if sizes[order[a]] != sizes[order[b]] {
+			return sizes[order[a]] > sizes[order[b]]
+		}
+		return testFilePath(files[order[a]]) < testFilePath(files[order[b]])
+	})
+
+	totals := make([]int64, s.Count)
+	counts := make([]int, s.Count)
+	for _, i := range order {
+		smallest := 0
+		for shard := range totals {
+			if totals[shard] < totals[smallest] || totals[shard] == totals[smallest] && counts[shard] < counts[smallest] {
+				smallest = shard
+			}
+		}
+		shards[i] = smallest
+		totals[smallest] += sizes[i]
+		counts[smallest]++
+	}
+	return shards
+}
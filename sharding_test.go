@@ -0,0 +1,105 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShard(t *testing.T) {
+	shard, err := ParseShard("3/10")
+	require.NoError(t, err)
+	assert.Equal(t, Shard{Index: 3, Count: 10}, shard)
+	assert.Equal(t, "3/10", shard.String())
+
+	for _, s := range []string{"3", "0/10", "11/10", "a/10", "1/b", "1/0"} {
+		_, err := ParseShard(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestShardSelect(t *testing.T) {
+	var files []string
+	for i := 0; i < 50; i++ {
+		files = append(files, fmt.Sprintf("/corpus/test/select/slt_%d.test", i))
+	}
+	assert.Equal(t, files, Shard{}.Select(files))
+
+	seen := make(map[string]int)
+	for i := 1; i <= 4; i++ {
+		selected := Shard{Index: i, Count: 4}.Select(files)
+		assert.NotEmpty(t, selected)
+		for _, file := range selected {
+			seen[file]++
+		}
+	}
+	assert.Len(t, seen, len(files))
+	for file, n := range seen {
+		assert.Equal(t, 1, n, file)
+	}
+
+	// Shards don't depend on where the corpus is checked out
+	var moved []string
+	for _, file := range files {
+		moved = append(moved, strings.Replace(file, "/corpus", "/elsewhere/corpus", 1))
+	}
+	shard := Shard{Index: 2, Count: 4}
+	selectedMoved := shard.Select(moved)
+	for i, file := range shard.Select(files) {
+		assert.Equal(t, strings.Replace(file, "/corpus", "/elsewhere/corpus", 1), selectedMoved[i])
+	}
+}
+
+func TestShardSelectBySize(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for i, size := range []int{100, 60, 50, 40, 10, 0, 0} {
+		path := filepath.Join(dir, fmt.Sprintf("test%d.test", i))
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644))
+		files = append(files, path)
+	}
+
+	// 100 goes to the first shard, 60 and 50 to the second, 40 to the first, and the rest to the second
+	first := Shard{Index: 1, Count: 2, BySize: true}.Select(files)
+	second := Shard{Index: 2, Count: 2, BySize: true}.Select(files)
+	assert.Equal(t, []string{files[0], files[3]}, first)
+	assert.Equal(t, []string{files[1], files[2], files[4], files[5], files[6]}, second)
+
+	// Files of equal size are spread evenly
+	empty := writeTestFiles(t, 4, "")
+	assert.Len(t, Shard{Index: 1, Count: 2, BySize: true}.Select(collectTestFiles([]string{empty})), 2)
+}
+
+func TestRunShard(t *testing.T) {
+	dir := writeTestFiles(t, 8, "query II nosort\nSELECT a, b FROM t1\n----\n1\n2\n")
+
+	total := 0
+	for i := 1; i <= 3; i++ {
+		reporter := &collectingReporter{}
+		shard := Shard{Index: i, Count: 3}
+		NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithShard(shard)).RunTestFiles(dir)
+		assert.Len(t, reporter.entries, len(shard.Select(collectTestFiles([]string{dir}))))
+		total += len(reporter.entries)
+	}
+	assert.Equal(t, 8, total)
+}
@@ -0,0 +1,66 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A SnapshotHarness is a Harness that can save the state of its database and restore it later. Harnesses that
+// implement this interface can run test files that set up an expensive dataset once and restore it between
+// destructive sections, written e.g.:
+//
+//	snapshot seeded
+//
+//	statement ok
+//	DELETE FROM t1
+//
+//	restore seeded
+//
+// Snapshots only need to last until the end of the test file that saved them. For harnesses that don't implement this
+// interface, snapshot and restore records are skipped.
+type SnapshotHarness interface {
+	Harness
+
+	// Snapshot saves the state of the database under the name given, replacing any snapshot with the same name.
+	Snapshot(ctx context.Context, name string) error
+	// Restore restores the state of the database saved under the name given, which remains saved.
+	Restore(ctx context.Context, name string) error
+}
+
+// executeSnapshot executes the snapshot or restore record given, logging its result.
+func (r *Runner) executeSnapshot(ctx context.Context, record *parser.Record) *R {
+	harness, ok := r.harness.(SnapshotHarness)
+	if !ok {
+		logResult(ctx, Skipped, "Requires snapshots")
+		return &R{skipped: true, cont: true}
+	}
+
+	var err error
+	if record.Type() == parser.Snapshot {
+		err = harness.Snapshot(ctx, record.SnapshotName())
+	} else {
+		err = harness.Restore(ctx, record.SnapshotName())
+	}
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{cont: true, err: err}
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true}
+}
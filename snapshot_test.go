@@ -0,0 +1,125 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotHarness records snapshots and restores of a database whose state is the number of rows in t1.
+type snapshotHarness struct {
+	*fakeHarness
+	rows      int
+	snapshots map[string]int
+}
+
+var _ SnapshotHarness = &snapshotHarness{}
+
+func (h *snapshotHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	if statement == "INSERT INTO t1 VALUES (1)" {
+		h.rows++
+	}
+	return h.fakeHarness.ExecuteStatement(ctx, statement)
+}
+
+func (h *snapshotHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	if statement == "SELECT COUNT(*) FROM t1" {
+		return "I", []string{fmt.Sprint(h.rows)}, nil
+	}
+	return h.fakeHarness.ExecuteQuery(ctx, statement)
+}
+
+func (h *snapshotHarness) Snapshot(ctx context.Context, name string) error {
+	h.snapshots[name] = h.rows
+	return nil
+}
+
+func (h *snapshotHarness) Restore(ctx context.Context, name string) error {
+	rows, ok := h.snapshots[name]
+	if !ok {
+		return fmt.Errorf("no snapshot named %s", name)
+	}
+	h.rows = rows
+	return nil
+}
+
+const snapshotTest = `statement ok
+INSERT INTO t1 VALUES (1)
+
+snapshot s1
+
+statement ok
+INSERT INTO t1 VALUES (1)
+
+query I nosort
+SELECT COUNT(*) FROM t1
+----
+2
+
+restore s1
+
+query I nosort
+SELECT COUNT(*) FROM t1
+----
+1
+`
+
+func TestSnapshotRecords(t *testing.T) {
+	reporter := &collectingReporter{}
+	harness := &snapshotHarness{fakeHarness: newFakeHarness(), snapshots: map[string]int{}}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, snapshotTest))
+
+	require.Len(t, reporter.entries, 6)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.Query)
+	}
+
+	reporter = &collectingReporter{}
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "restore missing\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, "Unexpected error no snapshot named missing", reporter.entries[0].ErrorMessage)
+}
+
+func TestSnapshotRecordsUnsupported(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "snapshot s1\n\nrestore s1\n"))
+	require.Len(t, reporter.entries, 2)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Skipped, entry.Result)
+	}
+}
+
+func TestGenerateSnapshotRecords(t *testing.T) {
+	path := writeTestFile(t, snapshotTest)
+	harness := &snapshotHarness{fakeHarness: newFakeHarness(), snapshots: map[string]int{}}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, snapshotTest, string(generated))
+}
@@ -0,0 +1,355 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// valueOverhead approximates the memory taken by a result value beyond its bytes, for measuring results against the
+// spill threshold.
+const valueOverhead = 16
+
+// A resultSpill collects the values of a query's results, normalized according to the query's schema, in memory until
+// their size exceeds the spill threshold, and in temporary files after that. Each time the values in memory exceed the
+// threshold they're sorted as the query requires and written to a new file as a run, and the runs are merged as
+// they're read back, so that memory use is bounded by the threshold however large the results are.
+type resultSpill struct {
+	record    *parser.Record
+	config    *RunConfig
+	threshold int64
+	// unitSize is the number of values sorted together: a row for rowsort and unsorted queries, one for valuesort
+	unitSize int
+	// compact and normalize, when set, are applied to each value before and after it's normalized for its type
+	compact   Normalizer
+	normalize Normalizer
+	values    []string
+	size      int64
+	count     int
+	runs      []*os.File
+}
+
+// newResultSpill returns a resultSpill for the results of the query record given, which normalizes values by the
+// config given and spills them to disk once they take more than its spill threshold.
+func newResultSpill(record *parser.Record, config *RunConfig) *resultSpill {
+	unitSize := record.NumCols()
+	if record.SortMode() == parser.ValueSort || unitSize == 0 {
+		unitSize = 1
+	}
+	return &resultSpill{record: record, config: config, threshold: config.SpillThreshold, unitSize: unitSize}
+}
+
+// add adds the values of a row of results.
+func (s *resultSpill) add(row []string) error {
+	schema := s.record.Schema()
+	for _, value := range row {
+		if len(schema) > 0 {
+			if s.compact != nil {
+				value = s.compact(value, schema[s.count%len(schema)])
+			}
+			value = s.config.normalizeValue(value, schema[s.count%len(schema)])
+			if s.normalize != nil {
+				value = s.normalize(value, schema[s.count%len(schema)])
+			}
+		}
+		s.values = append(s.values, value)
+		s.size += int64(len(value)) + valueOverhead
+		s.count++
+	}
+
+	if s.size > s.threshold && len(s.values)%s.unitSize == 0 {
+		return s.writeRun()
+	}
+	return nil
+}
+
+// spilled returns whether any values have been written to disk.
+func (s *resultSpill) spilled() bool {
+	return len(s.runs) > 0
+}
+
+// writeRun sorts the values in memory and writes them to a new run file.
+func (s *resultSpill) writeRun() error {
+	s.record.SortResults(s.values)
+
+	f, err := os.CreateTemp("", "sqllogictest-spill-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	w := bufio.NewWriter(f)
+	var buf [binary.MaxVarintLen64]byte
+	for _, value := range s.values {
+		n := binary.PutUvarint(buf[:], uint64(len(value)))
+		if _, err := w.Write(buf[:n]); err != nil {
+			return err
+		}
+		if _, err := w.WriteString(value); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	s.values, s.size = nil, 0
+	return nil
+}
+
+// rows returns an iterator over the spilled results in the order the query requires, one sort unit at a time. The
+// values still in memory are written to a last run first.
+func (s *resultSpill) rows() (RowIterator, error) {
+	if len(s.values) > 0 {
+		if err := s.writeRun(); err != nil {
+			return nil, err
+		}
+	}
+
+	it := &spillIterator{spill: s}
+	for _, f := range s.runs {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		run := &spillRun{r: bufio.NewReader(f), unitSize: s.unitSize}
+		if err := run.advance(); err != nil {
+			return nil, err
+		}
+		if run.unit != nil {
+			it.runs = append(it.runs, run)
+		}
+	}
+	if s.record.SortMode() != parser.NoSort {
+		heap.Init(it)
+	}
+	return it, nil
+}
+
+// close removes the spill's run files.
+func (s *resultSpill) close() error {
+	var errs []error
+	for _, f := range s.runs {
+		errs = append(errs, f.Close(), os.Remove(f.Name()))
+	}
+	s.runs = nil
+	return errors.Join(errs...)
+}
+
+// A spillRun reads the values of a run file back a sort unit at a time.
+type spillRun struct {
+	r        *bufio.Reader
+	unitSize int
+	// unit is the next unit of the run, or nil once the run is exhausted
+	unit []string
+}
+
+// advance reads the next unit of the run.
+func (r *spillRun) advance() error {
+	r.unit = nil
+	for len(r.unit) < r.unitSize {
+		n, err := binary.ReadUvarint(r.r)
+		if err == io.EOF {
+			// The end of the run, possibly after a short last unit from results with a partial row
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		value := make([]byte, n)
+		if _, err := io.ReadFull(r.r, value); err != nil {
+			return err
+		}
+		r.unit = append(r.unit, string(value))
+	}
+	return nil
+}
+
+// A spillIterator iterates over the units of a spill's runs, in order for unsorted queries and merged in sorted order
+// otherwise, by keeping the runs in a heap ordered by their next units.
+type spillIterator struct {
+	spill *resultSpill
+	runs  []*spillRun
+}
+
+var _ RowIterator = &spillIterator{}
+var _ heap.Interface = &spillIterator{}
+
+// See RowIterator.NextRow
+func (it *spillIterator) NextRow() ([]string, error) {
+	if len(it.runs) == 0 {
+		return nil, io.EOF
+	}
+
+	run := it.runs[0]
+	unit := run.unit
+	if err := run.advance(); err != nil {
+		return nil, err
+	}
+
+	sorted := it.spill.record.SortMode() != parser.NoSort
+	if run.unit == nil && sorted {
+		heap.Pop(it)
+	} else if run.unit == nil {
+		it.runs = it.runs[1:]
+	} else if sorted {
+		heap.Fix(it, 0)
+	}
+	return unit, nil
+}
+
+// See RowIterator.Close
+func (it *spillIterator) Close() error {
+	return it.spill.close()
+}
+
+func (it *spillIterator) Len() int {
+	return len(it.runs)
+}
+
+func (it *spillIterator) Less(i, j int) bool {
+	return slices.Compare(it.runs[i].unit, it.runs[j].unit) < 0
+}
+
+func (it *spillIterator) Swap(i, j int) {
+	it.runs[i], it.runs[j] = it.runs[j], it.runs[i]
+}
+
+func (it *spillIterator) Push(x any) {
+	it.runs = append(it.runs, x.(*spillRun))
+}
+
+func (it *spillIterator) Pop() any {
+	run := it.runs[len(it.runs)-1]
+	it.runs = it.runs[:len(it.runs)-1]
+	return run
+}
+
+// executeSpillingQuery verifies the rows given of the query record given, whose schema has been verified already,
+// spilling them to disk if they take more memory than the runner's spill threshold.
+func (r *Runner) executeSpillingQuery(ctx context.Context, record *parser.Record, schema string, rows RowIterator) *R {
+	spill := newResultSpill(record, &r.config)
+	spill.compact = r.config.compactValue
+	if !record.IsHashResult() {
+		spill.normalize = r.config.normalize
+	}
+	for {
+		row, err := rows.NextRow()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			spill.close()
+			logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+			return &R{err: err}
+		}
+		if err := spill.add(row); err != nil {
+			spill.close()
+			logFailure(ctx, UnexpectedError, "Error spilling results: %v", err)
+			return &R{err: fmt.Errorf("error spilling results: %v", err)}
+		}
+	}
+
+	if !spill.spilled() {
+		return r.verifyQuery(ctx, record, schema, spill.values)
+	}
+	err := r.verifySpilledResults(ctx, record, spill)
+	return &R{schema: schema, err: err, mismatched: err != nil}
+}
+
+// verifySpilledResults verifies the spilled results of the query record given, reading them back from disk, logging
+// any failure.
+func (r *Runner) verifySpilledResults(ctx context.Context, record *parser.Record, spill *resultSpill) error {
+	if spill.count != record.NumResults() {
+		spill.close()
+		logFailure(ctx, RowCountMismatch, "Incorrect number of results. Expected %v, got %v", record.NumResults(), spill.count)
+		return fmt.Errorf("incorrect number of results. expected %v, got %v", record.NumResults(), spill.count)
+	}
+
+	rows, err := spill.rows()
+	if err != nil {
+		spill.close()
+		logFailure(ctx, UnexpectedError, "Error reading spilled results: %v", err)
+		return fmt.Errorf("error reading spilled results: %v", err)
+	}
+	defer rows.Close()
+
+	if record.IsHashResult() {
+		return r.verifyHashStreaming(ctx, record, rows)
+	}
+	return r.verifyRowsStreaming(ctx, record, rows)
+}
+
+// verifyRowsStreaming verifies the rows given, which must have been sorted according to the semantics of the record,
+// against the expected rows of the record given without materializing them, logging any failure.
+func (r *Runner) verifyRowsStreaming(ctx context.Context, record *parser.Record, rows RowIterator) error {
+	epsilon := r.config.floatEpsilon(record)
+
+	expected := r.config.expectedResults(record)
+	if err := checkPatterns(expected); err != nil {
+		logFailure(ctx, UnexpectedError, "Invalid expected results: %v", err)
+		return fmt.Errorf("invalid expected results: %v", err)
+	}
+	numCols := record.NumCols()
+	// actualRow holds the values read of the row being compared, so that a mismatch can be reported with its row
+	var actualRow, unit []string
+	next := func() (string, error) {
+		if len(unit) == 0 {
+			var err error
+			if unit, err = rows.NextRow(); err != nil {
+				return "", err
+			}
+		}
+		value := unit[0]
+		unit = unit[1:]
+		return value, nil
+	}
+
+	for i := range expected {
+		if i%numCols == 0 {
+			actualRow = actualRow[:0]
+		}
+		value, err := next()
+		if err != nil {
+			logFailure(ctx, UnexpectedError, "Error reading spilled results: %v", err)
+			return fmt.Errorf("error reading spilled results: %v", err)
+		}
+		actualRow = append(actualRow, value)
+
+		if !r.config.resultMatches(expected[i], value, record.Schema()[i%numCols], epsilon) {
+			for len(actualRow) < numCols {
+				value, err := next()
+				if err != nil {
+					break
+				}
+				actualRow = append(actualRow, value)
+			}
+			row, col := i/numCols, i%numCols
+			logFailure(ctx, ValueMismatch, "Incorrect result at position %d. Expected %v, got %v, at row %d column %d. Expected row %v, got %v",
+				i, expected[i], actualRow[col], row, col, rowAt(expected, row, numCols), actualRow)
+			return fmt.Errorf("incorrect result at position %d, expected `%v`, got `%v`", i, expected[i], actualRow[col])
+		}
+	}
+
+	return nil
+}
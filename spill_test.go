@@ -0,0 +1,87 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpilledResults(t *testing.T) {
+	// Out of order, so that each sort mode reads the runs back in a different order
+	values := []string{"3", "c", "1", "z", "4", "a", "2", "b", "1", "y"}
+	harness := &streamingHarness{fakeHarness: newFakeHarness()}
+	harness.queryResults["SELECT * FROM t2"] = fakeResult{schema: "IT", results: values}
+
+	rowsorted := []string{"1", "y", "1", "z", "2", "b", "3", "c", "4", "a"}
+	valuesorted := []string{"1", "1", "2", "3", "4", "a", "b", "c", "y", "z"}
+	hash, err := hashResults(MD5, rowsorted)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		sortMode string
+		expected string
+		code     FailureCode
+	}{
+		{name: "nosort", sortMode: "nosort", expected: strings.Join(values, "\n")},
+		{name: "rowsort", sortMode: "rowsort", expected: strings.Join(rowsorted, "\n")},
+		{name: "valuesort", sortMode: "valuesort", expected: strings.Join(valuesorted, "\n")},
+		{name: "hashed", sortMode: "rowsort", expected: "10 values hashing to " + hash},
+		{name: "value mismatch", sortMode: "rowsort", expected: strings.Join(valuesorted, "\n"), code: ValueMismatch},
+		{name: "hash mismatch", sortMode: "valuesort", expected: "10 values hashing to " + hash, code: HashMismatch},
+		{name: "row count mismatch", sortMode: "rowsort", expected: "12 values hashing to " + hash, code: RowCountMismatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+
+			reporter := &collectingReporter{}
+			runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithSpillThreshold(20))
+			run := func() {
+				runner.RunTestFiles(writeTestFile(t, "query IT "+test.sortMode+"\nSELECT * FROM t2\n----\n"+test.expected+"\n"))
+			}
+			if test.code == NoFailure {
+				run()
+			} else {
+				assert.Panics(t, run)
+			}
+
+			require.Len(t, reporter.entries, 1)
+			assert.Equal(t, test.code, reporter.entries[0].FailureCode, reporter.entries[0].ErrorMessage)
+
+			// Spilled results are removed once verified
+			entries, err := os.ReadDir(tmp)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestResultSpillBelowThreshold(t *testing.T) {
+	harness := &streamingHarness{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithSpillThreshold(1<<20)).
+		RunTestFiles(writeTestFile(t, "query R nosort\nSELECT 1.0 / 3\n----\n0.333333333333333\n"))
+
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
@@ -0,0 +1,326 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqldb provides a harness for any engine with a database/sql driver. It knows nothing about the engine beyond
+// what the driver reports, so it's a starting point for engines without a dedicated harness rather than a replacement
+// for one: result types are inferred from column metadata, and Init doesn't drop anything, so it should be run with
+// object cleanup enabled (see logictest.WithObjectCleanup).
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+)
+
+// SQLHarness is a harness that executes records with a database/sql driver.
+type SQLHarness struct {
+	db        *sql.DB
+	driver    string
+	engine    string
+	formatter *logictest.ValueFormatter
+}
+
+var _ logictest.Harness = &SQLHarness{}
+var _ logictest.ColumnNameHarness = &SQLHarness{}
+var _ logictest.ObjectCleanupHarness = &SQLHarness{}
+var _ logictest.BatchHarness = &SQLHarness{}
+var _ logictest.FormattingHarness = &SQLHarness{}
+
+// NewSQLHarness returns a harness for the database/sql driver with the name given, which must be registered by
+// importing its package, connecting to the data source name given. The engine string given is used to evaluate
+// skipif and onlyif conditions, and defaults to the name of the driver.
+func NewSQLHarness(driver, dsn, engine string) (*SQLHarness, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Statements like USE and SET change the state of the session, so all records must run on the same connection
+	db.SetMaxOpenConns(1)
+
+	if engine == "" {
+		engine = driver
+	}
+	return &SQLHarness{db: db, driver: driver, engine: engine, formatter: logictest.NewValueFormatter()}, nil
+}
+
+// See Harness.EngineStr
+func (h *SQLHarness) EngineStr() string {
+	return h.engine
+}
+
+// See FormattingHarness.SetFormatter
+func (h *SQLHarness) SetFormatter(formatter *logictest.ValueFormatter) {
+	h.formatter = formatter
+}
+
+// See Harness.Init. The database is only checked to be reachable: objects left over by earlier test files are dropped
+// by the runner's object cleanup, if enabled.
+func (h *SQLHarness) Init() error {
+	return h.db.Ping()
+}
+
+// See Harness.ExecuteStatement
+func (h *SQLHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	_, err := h.db.ExecContext(ctx, statement)
+	return err
+}
+
+// See BatchHarness.ExecuteStatements. The statements execute in a transaction, as by ExecuteStatements.
+func (h *SQLHarness) ExecuteStatements(ctx context.Context, statements []string) error {
+	return ExecuteStatements(ctx, h.db, nil, statements)
+}
+
+// transactionalDrivers are the database/sql drivers of engines whose transactions roll back every statement that can be
+// batched, unlike MySQL's on tables of non-transactional storage engines, or ClickHouse's.
+var transactionalDrivers = map[string]bool{
+	"pgx":     true,
+	"sqlite3": true,
+	"duckdb":  true,
+}
+
+// See BatchHarness.AtomicBatches. Batches are only atomic for drivers of engines whose transactions can roll back any
+// statement.
+func (h *SQLHarness) AtomicBatches() bool {
+	return transactionalDrivers[h.driver]
+}
+
+// ExecuteStatements executes the statements given in order in a transaction on the database given, for harnesses that
+// implement logictest.BatchHarness. Each statement is first translated by the translator given, unless it's nil. The
+// transaction is rolled back if any statement fails, so the batch is only atomic on engines that can roll back
+// the statements, e.g. not for MySQL's MyISAM tables. See logictest.BatchHarness.AtomicBatches.
+func ExecuteStatements(ctx context.Context, db *sql.DB, translator logictest.Translator, statements []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if translator != nil {
+			if statement, err = translator.Translate(statement); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// See Harness.ExecuteQuery
+func (h *SQLHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
+	schema, _, results, err = h.ExecuteQueryColumns(ctx, statement)
+	return schema, results, err
+}
+
+// See ColumnNameHarness.ExecuteQueryColumns
+func (h *SQLHarness) ExecuteQueryColumns(ctx context.Context, statement string) (schema string, columns []string, results []string, err error) {
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	for _, typ := range types {
+		columns = append(columns, typ.Name())
+	}
+
+	var values [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(types))
+		scanArgs := make([]interface{}, len(types))
+		for i := range row {
+			scanArgs[i] = &row[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return "", nil, nil, err
+		}
+		values = append(values, row)
+	}
+
+	if rows.Err() != nil {
+		return "", nil, nil, rows.Err()
+	}
+
+	schema = schemaOf(types, values)
+	for _, row := range values {
+		for i, v := range row {
+			results = append(results, formatValue(h.formatter, v, schema[i]))
+		}
+	}
+
+	return schema, columns, results, nil
+}
+
+// See ObjectCleanupHarness.DropObject. Indexes are dropped with their tables, since engines disagree on how to drop
+// an index by name.
+func (h *SQLHarness) DropObject(ctx context.Context, object logictest.SchemaObject) error {
+	if object.Kind == logictest.ObjectIndex {
+		return nil
+	}
+	return h.ExecuteStatement(ctx, fmt.Sprintf("DROP %s IF EXISTS %s", object.Kind, object.Name))
+}
+
+// See Harness.GetTimeout
+func (h *SQLHarness) GetTimeout() int64 {
+	return 0
+}
+
+// Close closes the connection to the database.
+func (h *SQLHarness) Close() error {
+	return h.db.Close()
+}
+
+// schemaOf returns the schema of query results with the column types and values given. The type a column is scanned
+// as determines its schema type where the driver reports one, then its declared type, and otherwise the type of its
+// first non-NULL value, or I if it has none.
+func schemaOf(types []*sql.ColumnType, values [][]interface{}) string {
+	sb := strings.Builder{}
+	for i, columnType := range types {
+		typ := scanType(columnType.ScanType())
+		if typ == 0 {
+			typ = declaredType(columnType.DatabaseTypeName())
+		}
+		if typ == 0 {
+			typ = 'I'
+			for _, row := range values {
+				if row[i] != nil {
+					typ = valueType(row[i])
+					break
+				}
+			}
+		}
+		sb.WriteByte(typ)
+	}
+	return sb.String()
+}
+
+// scanType returns the schema type of a column the driver scans as the type given, or 0 if it doesn't determine one.
+func scanType(t reflect.Type) byte {
+	if t == nil {
+		return 0
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}),
+		reflect.TypeOf(sql.NullByte{}), reflect.TypeOf(sql.NullBool{}):
+		return 'I'
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return 'R'
+	case reflect.TypeOf(sql.NullString{}), reflect.TypeOf(sql.NullTime{}), reflect.TypeOf(time.Time{}):
+		return 'T'
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Bool:
+		return 'I'
+	case reflect.Float32, reflect.Float64:
+		return 'R'
+	case reflect.String:
+		return 'T'
+	default:
+		// interface{} and []byte say nothing about the values of the column
+		return 0
+	}
+}
+
+// declaredType returns the schema type of a column with the declared type given, or 0 if it's not a familiar one.
+func declaredType(decl string) byte {
+	decl = strings.ToUpper(decl)
+	switch {
+	case decl == "":
+		return 0
+	case strings.Contains(decl, "INT"), strings.Contains(decl, "BOOL"):
+		return 'I'
+	case strings.Contains(decl, "BLOB"), strings.Contains(decl, "BINARY"), strings.Contains(decl, "BYTEA"):
+		return 'B'
+	case strings.Contains(decl, "FLOAT"), strings.Contains(decl, "DOUBLE"), strings.Contains(decl, "REAL"),
+		strings.Contains(decl, "DECIMAL"), strings.Contains(decl, "NUMERIC"):
+		return 'R'
+	case strings.Contains(decl, "CHAR"), strings.Contains(decl, "TEXT"), strings.Contains(decl, "CLOB"),
+		strings.Contains(decl, "DATE"), strings.Contains(decl, "TIME"):
+		return 'T'
+	default:
+		return 0
+	}
+}
+
+// valueType returns the schema type of a value returned by the driver.
+func valueType(v interface{}) byte {
+	switch v.(type) {
+	case int64, int32, int, bool:
+		return 'I'
+	case float64, float32:
+		return 'R'
+	default:
+		return 'T'
+	}
+}
+
+// formatValue renders a value returned by the driver for a column of the schema type given, with the formatter given.
+// Times are rendered as dates, or as datetimes if they have a time of day, and values of other types the formatter
+// doesn't know are rendered as text, as by formatText.
+func formatValue(f *logictest.ValueFormatter, v interface{}, typ byte) string {
+	switch v := v.(type) {
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return f.FormatString(v.Format("2006-01-02"))
+		}
+		return f.FormatString(v.Format("2006-01-02 15:04:05"))
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, string:
+		return f.FormatValue(v)
+	case []byte:
+		if typ == 'B' {
+			return f.FormatBytes(v)
+		}
+		if v == nil {
+			return f.FormatNull()
+		}
+		return formatText(f, string(v), typ)
+	default:
+		return formatText(f, fmt.Sprint(v), typ)
+	}
+}
+
+// formatText renders a value the driver returned as text for a column of the schema type given. Drivers speaking a
+// text protocol return numbers this way, so they're parsed and formatted as numbers where possible.
+func formatText(f *logictest.ValueFormatter, s string, typ byte) string {
+	switch typ {
+	case 'I':
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return f.FormatInt(i)
+		}
+	case 'R':
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return f.FormatFloat(n)
+		}
+	}
+	return f.FormatString(s)
+}
@@ -0,0 +1,68 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+)
+
+func TestSQLHarness(t *testing.T) {
+	h, err := NewSQLHarness("sqlite3", ":memory:", "")
+	require.NoError(t, err)
+	defer h.Close()
+	assert.Equal(t, "sqlite3", h.EngineStr())
+
+	output := &bytes.Buffer{}
+	runner := logictest.NewRunner(h, logictest.WithOutput(output), logictest.WithObjectCleanup(true))
+	runner.RunTestFiles("testdata/basic.test", "testdata/basic.test")
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Contains(t, output.String(), " ok")
+}
+
+func TestSQLHarnessSchema(t *testing.T) {
+	ctx := context.Background()
+	h, err := NewSQLHarness("sqlite3", ":memory:", "sqlite")
+	require.NoError(t, err)
+	defer h.Close()
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a BIGINT, b TEXT, c DOUBLE)"))
+	require.NoError(t, h.ExecuteStatement(ctx, "INSERT INTO t1 VALUES(1, 'x', 2)"))
+
+	schema, columns, results, err := h.ExecuteQueryColumns(ctx, "SELECT a, b, c, NULL, 1.25 AS d FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, "ITRIR", schema)
+	assert.Equal(t, []string{"a", "b", "c", "NULL", "d"}, columns)
+	assert.Equal(t, []string{"1", "x", "2.000", "NULL", "1.250"}, results)
+
+	// Binary columns are rendered in hex
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t2(a BLOB)"))
+	require.NoError(t, h.ExecuteStatement(ctx, "INSERT INTO t2 VALUES(X'0AFF'), (NULL)"))
+	schema, _, results, err = h.ExecuteQueryColumns(ctx, "SELECT a FROM t2")
+	require.NoError(t, err)
+	assert.Equal(t, "B", schema)
+	assert.Equal(t, []string{"0aff", "NULL"}, results)
+}
+
+func TestNewSQLHarnessUnknownDriver(t *testing.T) {
+	_, err := NewSQLHarness("nosuchdriver", "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqlite"
+)
+
+// defaultDSN is the data source name used when SQLLOGICTEST_SQLITE_DSN isn't set.
+const defaultDSN = ":memory:"
+
+// SQLite test runner. Runs SQLite in-process against a private in-memory database, unless the path of a database file
+// is given in the SQLLOGICTEST_SQLITE_DSN environment variable, in which case all tables in that database are
+// dropped routinely. SQLite is the reference engine of the test corpus, so this runner is a convenient way to
+// generate expected results.
+//
+// The modes and options of the runner are those of logictest.RunEngineMain.
+func main() {
+	dsn := os.Getenv("SQLLOGICTEST_SQLITE_DSN")
+	if dsn == "" {
+		dsn = defaultDSN
+	}
+	harness := sqlite.NewSqliteHarness(dsn)
+
+	if err := logictest.RunEngineMain(harness, os.Args[1:]); err != nil {
+		fmt.Println(err)
+		fmt.Println("Usage: go run main.go " + logictest.EngineMainUsage)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,509 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/sqldb"
+	"github.com/andyyu2004/sqllogictest/translate"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqllogictest harness for SQLite databases, which runs the engine in-process. Since the sqllogictest corpus originally
+// derives from SQLite, this harness is suited to serve as the reference engine for differential runs and for
+// generating expected results. MySQL-isms in the corpus are translated with translate.MySQLToSQLite before
+// execution. Don't also configure the runner with that translator.
+type SqliteHarness struct {
+	db         *sql.DB
+	translator logictest.Translator
+	formatter  *logictest.ValueFormatter
+	// snapshots are private in-memory databases holding copies of the database by snapshot name
+	snapshots map[string]*sqlite3.SQLiteConn
+	// preludes are copies of the database after the preludes of test files by prelude key, which survive Init
+	preludes map[string]*sqlite3.SQLiteConn
+}
+
+// compile check for interface compliance
+var _ logictest.Harness = &SqliteHarness{}
+var _ logictest.ErrorClassifier = &SqliteHarness{}
+var _ logictest.CapabilityHarness = &SqliteHarness{}
+var _ logictest.ExplainHarness = &SqliteHarness{}
+var _ logictest.SnapshotHarness = &SqliteHarness{}
+var _ logictest.ObjectCleanupHarness = &SqliteHarness{}
+var _ logictest.CatalogHarness = &SqliteHarness{}
+var _ logictest.ColumnNameHarness = &SqliteHarness{}
+var _ logictest.BatchHarness = &SqliteHarness{}
+var _ logictest.PreludeHarness = &SqliteHarness{}
+var _ logictest.FormattingHarness = &SqliteHarness{}
+
+// NewSqliteHarness returns a new SQLite test harness for the data source name given, e.g. the path of a database file
+// or ":memory:" for a private in-memory database. Panics if it cannot open the database.
+func NewSqliteHarness(dsn string) *SqliteHarness {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		panic(err)
+	}
+	// Every connection to an in-memory database opens a new, empty database, so all statements must share one
+	// connection. SQLite serializes writers anyway, so nothing is lost for file databases.
+	db.SetMaxOpenConns(1)
+	return &SqliteHarness{db: db, translator: translate.MySQLToSQLite, formatter: logictest.NewValueFormatter()}
+}
+
+// See FormattingHarness.SetFormatter
+func (h *SqliteHarness) SetFormatter(formatter *logictest.ValueFormatter) {
+	h.formatter = formatter
+}
+
+// See Harness.EngineStr
+func (h *SqliteHarness) EngineStr() string {
+	return "sqlite"
+}
+
+// See CapabilityHarness.Capabilities. Foreign keys aren't enforced unless enabled in the data source name, and
+// stored procedures aren't supported at all.
+func (h *SqliteHarness) Capabilities() []logictest.Capability {
+	return []logictest.Capability{
+		logictest.CapSubqueries,
+		logictest.CapCTEs,
+		logictest.CapRecursiveCTEs,
+		logictest.CapWindowFunctions,
+		logictest.CapFullOuterJoin,
+		logictest.CapRightJoin,
+		logictest.CapViews,
+		logictest.CapTriggers,
+		logictest.CapTransactions,
+		logictest.CapJSON,
+		logictest.CapIntersectExcept,
+		logictest.CapCheckConstraints,
+	}
+}
+
+// See Harness.Init
+func (h *SqliteHarness) Init() error {
+	if err := h.closeSnapshots(); err != nil {
+		return err
+	}
+
+	if err := h.dropAll("view"); err != nil {
+		return err
+	}
+
+	return h.dropAll("table")
+}
+
+// See ObjectCleanupHarness.DropObject. SQLite indexes are dropped by name alone.
+func (h *SqliteHarness) DropObject(ctx context.Context, object logictest.SchemaObject) error {
+	return h.ExecuteStatement(ctx, fmt.Sprintf("DROP %s IF EXISTS %s", object.Kind, object.Name))
+}
+
+// See CatalogHarness.Catalog
+func (h *SqliteHarness) Catalog(ctx context.Context) (logictest.Catalog, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT m.name, p.name FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' ORDER BY m.name, p.cid`)
+	if err != nil {
+		return nil, err
+	}
+	return logictest.ReadCatalog(rows)
+}
+
+// See Harness.ExecuteStatement
+func (h *SqliteHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	statement, err := h.translator.Translate(statement)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.db.ExecContext(ctx, statement)
+	return err
+}
+
+// See BatchHarness.ExecuteStatements. The statements are translated and execute in a transaction, as by
+// sqldb.ExecuteStatements.
+func (h *SqliteHarness) ExecuteStatements(ctx context.Context, statements []string) error {
+	return sqldb.ExecuteStatements(ctx, h.db, h.translator, statements)
+}
+
+// See BatchHarness.AtomicBatches
+func (h *SqliteHarness) AtomicBatches() bool {
+	return true
+}
+
+// See Harness.ExecuteQuery
+func (h *SqliteHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
+	schema, _, results, err = h.ExecuteQueryColumns(ctx, statement)
+	return schema, results, err
+}
+
+// See ColumnNameHarness.ExecuteQueryColumns
+func (h *SqliteHarness) ExecuteQueryColumns(ctx context.Context, statement string) (schema string, columns []string, results []string, err error) {
+	statement, err = h.translator.Translate(statement)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, statement)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	for _, typ := range types {
+		columns = append(columns, typ.Name())
+	}
+
+	var values [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(types))
+		scanArgs := make([]interface{}, len(types))
+		for i := range row {
+			scanArgs[i] = &row[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return "", nil, nil, err
+		}
+		values = append(values, row)
+	}
+
+	if rows.Err() != nil {
+		return "", nil, nil, rows.Err()
+	}
+
+	schema = schemaOf(types, values)
+	for _, row := range values {
+		for i, v := range row {
+			results = append(results, formatValue(h.formatter, v, types[i].DatabaseTypeName()))
+		}
+	}
+
+	return schema, columns, results, nil
+}
+
+// See ExplainHarness.ExplainQuery
+func (h *SqliteHarness) ExplainQuery(ctx context.Context, query string) (string, error) {
+	query, err := h.translator.Translate(query)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := h.db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var id, parent, notUsed int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return "", err
+		}
+		lines = append(lines, detail)
+	}
+	if rows.Err() != nil {
+		return "", rows.Err()
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// sqliteErrorCategories map prefixes of SQLite error messages to error categories. SQLite reports most errors with
+// the generic SQLITE_ERROR code, so its messages are the only way to tell them apart.
+var sqliteErrorCategories = []struct {
+	prefix   string
+	category logictest.ErrorCategory
+}{
+	{"near ", logictest.CategorySyntax},
+	{"incomplete input", logictest.CategorySyntax},
+	{"no such ", logictest.CategoryNotFound},
+	{"table ", logictest.CategoryAlreadyExists},
+	{"index ", logictest.CategoryAlreadyExists},
+	{"view ", logictest.CategoryAlreadyExists},
+	{"trigger ", logictest.CategoryAlreadyExists},
+	{"integer overflow", logictest.CategoryData},
+}
+
+// See ErrorClassifier.ClassifyError. SQLite doesn't report SQLSTATEs, so only the category of errors and their
+// extended result code are known.
+func (h *SqliteHarness) ClassifyError(err error) logictest.ErrorClass {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return logictest.ErrorClass{}
+	}
+
+	code := strconv.Itoa(int(sqliteErr.ExtendedCode))
+	return logictest.ErrorClass{Category: sqliteErrorCategory(sqliteErr), Code: code}
+}
+
+// sqliteErrorCategory returns the category of the SQLite error given.
+func sqliteErrorCategory(sqliteErr sqlite3.Error) logictest.ErrorCategory {
+	switch sqliteErr.Code {
+	case sqlite3.ErrConstraint:
+		return logictest.CategoryConstraint
+	case sqlite3.ErrTooBig, sqlite3.ErrMismatch, sqlite3.ErrRange:
+		return logictest.CategoryData
+	case sqlite3.ErrPerm, sqlite3.ErrAuth, sqlite3.ErrReadonly:
+		return logictest.CategoryPermission
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		// SQLite reports a busy database once its busy timeout expires
+		return logictest.CategoryLockTimeout
+	case sqlite3.ErrError:
+		msg := sqliteErr.Error()
+		for _, c := range sqliteErrorCategories {
+			if strings.HasPrefix(msg, c.prefix) {
+				if c.category == logictest.CategoryAlreadyExists && !strings.HasSuffix(msg, "already exists") {
+					continue
+				}
+				return c.category
+			}
+		}
+	}
+
+	return logictest.CategoryUnknown
+}
+
+func (h *SqliteHarness) GetTimeout() int64 {
+	return 0
+}
+
+// See SnapshotHarness.Snapshot. The database is copied to a private in-memory database with SQLite's backup API.
+func (h *SqliteHarness) Snapshot(ctx context.Context, name string) error {
+	snapshot, err := h.copyDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	if old, ok := h.snapshots[name]; ok {
+		old.Close()
+	}
+	if h.snapshots == nil {
+		h.snapshots = make(map[string]*sqlite3.SQLiteConn)
+	}
+	h.snapshots[name] = snapshot
+	return nil
+}
+
+// See SnapshotHarness.Restore
+func (h *SqliteHarness) Restore(ctx context.Context, name string) error {
+	snapshot, ok := h.snapshots[name]
+	if !ok {
+		return fmt.Errorf("no snapshot named %s", name)
+	}
+
+	return h.withConn(ctx, func(conn *sqlite3.SQLiteConn) error {
+		return backup(conn, snapshot)
+	})
+}
+
+// See PreludeHarness.SavePrelude
+func (h *SqliteHarness) SavePrelude(ctx context.Context, key string) error {
+	prelude, err := h.copyDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	if old, ok := h.preludes[key]; ok {
+		old.Close()
+	}
+	if h.preludes == nil {
+		h.preludes = make(map[string]*sqlite3.SQLiteConn)
+	}
+	h.preludes[key] = prelude
+	return nil
+}
+
+// See PreludeHarness.RestorePrelude
+func (h *SqliteHarness) RestorePrelude(ctx context.Context, key string) (bool, error) {
+	prelude, ok := h.preludes[key]
+	if !ok {
+		return false, nil
+	}
+
+	err := h.withConn(ctx, func(conn *sqlite3.SQLiteConn) error {
+		return backup(conn, prelude)
+	})
+	return err == nil, err
+}
+
+// copyDatabase returns a private in-memory database holding a copy of the database.
+func (h *SqliteHarness) copyDatabase(ctx context.Context) (*sqlite3.SQLiteConn, error) {
+	driverConn, err := (&sqlite3.SQLiteDriver{}).Open(":memory:")
+	if err != nil {
+		return nil, err
+	}
+	dest := driverConn.(*sqlite3.SQLiteConn)
+
+	err = h.withConn(ctx, func(conn *sqlite3.SQLiteConn) error {
+		return backup(dest, conn)
+	})
+	if err != nil {
+		dest.Close()
+		return nil, err
+	}
+	return dest, nil
+}
+
+// withConn calls the function given with the driver connection of the harness's only connection.
+func (h *SqliteHarness) withConn(ctx context.Context, f func(conn *sqlite3.SQLiteConn) error) error {
+	conn, err := h.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		return f(driverConn.(*sqlite3.SQLiteConn))
+	})
+}
+
+// backup copies the main database of the source connection given over the main database of the destination.
+func backup(dest, src *sqlite3.SQLiteConn) error {
+	b, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return err
+	}
+	if _, err := b.Step(-1); err != nil {
+		b.Finish()
+		return err
+	}
+	return b.Finish()
+}
+
+// closeSnapshots discards all snapshots.
+func (h *SqliteHarness) closeSnapshots() error {
+	var firstErr error
+	for name, snapshot := range h.snapshots {
+		if err := snapshot.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(h.snapshots, name)
+	}
+	return firstErr
+}
+
+// dropAll drops every schema object of the type given (table or view).
+func (h *SqliteHarness) dropAll(typ string) error {
+	rows, err := h.db.Query("SELECT name FROM sqlite_master WHERE type = ? AND name NOT LIKE 'sqlite_%'", typ)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// SQLite can only drop one object per statement
+	for _, name := range names {
+		drop := fmt.Sprintf(`DROP %s IF EXISTS "%s"`, typ, strings.ReplaceAll(name, `"`, `""`))
+		if _, err := h.db.Exec(drop); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// schemaOf returns the schema of query results with the column types and values given. SQLite is dynamically typed,
+// so the declared type of a column determines its schema type by the affinity rules of SQLite where possible. Columns
+// without a declared type, such as expressions, take the type of their first non-NULL value, or I if they have none.
+func schemaOf(types []*sql.ColumnType, values [][]interface{}) string {
+	sb := strings.Builder{}
+	for i, columnType := range types {
+		typ := declaredType(columnType.DatabaseTypeName())
+		if typ == 0 {
+			typ = 'I'
+			for _, row := range values {
+				if row[i] != nil {
+					typ = valueType(row[i])
+					break
+				}
+			}
+		}
+		sb.WriteByte(typ)
+	}
+	return sb.String()
+}
+
+// declaredType returns the schema type of a column with the declared type given, following SQLite's rules for column
+// affinity, or 0 if the declared type doesn't determine one.
+func declaredType(decl string) byte {
+	decl = strings.ToUpper(decl)
+	switch {
+	case decl == "":
+		return 0
+	case strings.Contains(decl, "INT"), strings.Contains(decl, "BOOL"):
+		return 'I'
+	case strings.Contains(decl, "CHAR"), strings.Contains(decl, "CLOB"), strings.Contains(decl, "TEXT"),
+		strings.Contains(decl, "DATE"), strings.Contains(decl, "TIME"):
+		return 'T'
+	case strings.Contains(decl, "BLOB"):
+		return 0
+	default:
+		// REAL and NUMERIC affinity
+		return 'R'
+	}
+}
+
+// valueType returns the schema type of a value returned by the driver.
+func valueType(v interface{}) byte {
+	switch v.(type) {
+	case int64, bool:
+		return 'I'
+	case float64:
+		return 'R'
+	case []byte:
+		return 'B'
+	default:
+		return 'T'
+	}
+}
+
+// formatValue renders a value returned by the driver for a column with the declared type given, with the formatter
+// given. The driver returns values of DATE, DATETIME and TIMESTAMP columns as time.Time values, which are rendered as
+// strings in the format they were most likely stored in, and BLOB values as byte slices, which are rendered in hex.
+func formatValue(f *logictest.ValueFormatter, v interface{}, decl string) string {
+	if b, ok := v.([]byte); ok {
+		return f.FormatBytes(b)
+	}
+	if t, ok := v.(time.Time); ok {
+		if strings.EqualFold(decl, "DATE") {
+			return f.FormatString(t.Format("2006-01-02"))
+		}
+		return f.FormatString(t.Format("2006-01-02 15:04:05"))
+	}
+	return f.FormatValue(v)
+}
@@ -0,0 +1,210 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSqliteHarness(t *testing.T) {
+	output := &bytes.Buffer{}
+	runner := logictest.NewRunner(NewSqliteHarness(":memory:"), logictest.WithOutput(output))
+	runner.RunTestFiles("testdata/basic.test")
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Contains(t, output.String(), " ok")
+}
+
+func TestSqliteHarnessInit(t *testing.T) {
+	ctx := context.Background()
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER)"))
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE VIEW v1 AS SELECT a FROM t1"))
+	require.NoError(t, h.Init())
+
+	schema, results, err := h.ExecuteQuery(ctx, "SELECT name FROM sqlite_master")
+	require.NoError(t, err)
+	assert.Equal(t, "T", schema)
+	assert.Empty(t, results)
+}
+
+func TestSqliteHarnessSchema(t *testing.T) {
+	ctx := context.Background()
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a BIGINT, b TEXT, c DOUBLE, d DECIMAL(10, 2), e BLOB)"))
+
+	// Declared types determine the schema even without rows
+	schema, _, err := h.ExecuteQuery(ctx, "SELECT a, b, c, d FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, "ITRR", schema)
+
+	// Columns without a declared type take the type of their first non-NULL value
+	require.NoError(t, h.ExecuteStatement(ctx, "INSERT INTO t1 VALUES (NULL, NULL, NULL, NULL, 'x'), (1, 'a', 2.5, 3, NULL)"))
+	schema, _, err = h.ExecuteQuery(ctx, "SELECT e, a * 2, c / 2, NULL FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, "TIRI", schema)
+
+	// BLOB values are rendered in hex
+	schema, results, err := h.ExecuteQuery(ctx, "SELECT X'0AFF', X''")
+	require.NoError(t, err)
+	assert.Equal(t, "BB", schema)
+	assert.Equal(t, []string{"0aff", "(empty)"}, results)
+}
+
+func TestSqliteHarnessBlobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blobs.test")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\nCREATE TABLE t1(a BLOB)\n\n"+
+		"statement ok\nINSERT INTO t1 VALUES (X'00FF'), (X''), (NULL)\n\n"+
+		"query B nosort\nSELECT a FROM t1 ORDER BY rowid\n----\n"), 0644))
+
+	// Generated results are in hex, and verify against the engine
+	logictest.NewRunner(NewSqliteHarness(":memory:"), logictest.WithOutput(&bytes.Buffer{}),
+		logictest.WithGenerateInPlace(true, false), logictest.WithRegenerateFailing(true)).GenerateTestFiles(path)
+	generated, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(generated), "----\n00ff\n(empty)\nNULL\n"), string(generated))
+
+	output := &bytes.Buffer{}
+	logictest.NewRunner(NewSqliteHarness(":memory:"), logictest.WithOutput(output)).RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Empty(t, logictest.ValidateTestFile(path))
+}
+
+func TestSqliteHarnessExplain(t *testing.T) {
+	ctx := context.Background()
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER)"))
+
+	plan, err := h.ExplainQuery(ctx, "SELECT a FROM t1")
+	require.NoError(t, err)
+	assert.Contains(t, plan, "SCAN t1")
+}
+
+func TestSqliteHarnessSnapshots(t *testing.T) {
+	output := &bytes.Buffer{}
+	runner := logictest.NewRunner(NewSqliteHarness(":memory:"), logictest.WithOutput(output))
+	runner.RunTestFiles("testdata/snapshot.test")
+	assert.NotContains(t, output.String(), "not ok")
+	assert.NotContains(t, output.String(), "skipped")
+
+	// Snapshots don't outlive the test file
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.Snapshot(context.Background(), "s1"))
+	require.NoError(t, h.Init())
+	assert.Error(t, h.Restore(context.Background(), "s1"))
+}
+
+func TestSqliteHarnessObjectCleanup(t *testing.T) {
+	h := NewSqliteHarness(":memory:")
+	output := &bytes.Buffer{}
+	path := filepath.Join(t.TempDir(), "cleanup.test")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\nCREATE TABLE t1(a INTEGER)\n\n"+
+		"statement ok\nCREATE INDEX i1 ON t1(a)\n\nstatement ok\nCREATE VIEW v1 AS SELECT a FROM t1\n"), 0644))
+
+	// Init only drops objects before each file, so without cleanup they would remain afterwards
+	runner := logictest.NewRunner(h, logictest.WithOutput(output), logictest.WithObjectCleanup(true))
+	runner.RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+
+	_, results, err := h.ExecuteQuery(context.Background(), "SELECT name FROM sqlite_master")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSqliteHarnessCatalog(t *testing.T) {
+	h := NewSqliteHarness(":memory:")
+	output := &bytes.Buffer{}
+	path := filepath.Join(t.TempDir(), "catalog.test")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\nCREATE TABLE t1(a INTEGER, b TEXT, PRIMARY KEY (a))\n\n"+
+		"statement ok\nALTER TABLE t1 ADD COLUMN c REAL\n\nstatement ok\nCREATE TABLE t2(a INTEGER)\n\n"+
+		"statement ok\nALTER TABLE t2 RENAME TO t3\n\nstatement ok\nCREATE VIEW v1 AS SELECT a FROM t1\n"), 0644))
+
+	runner := logictest.NewRunner(h, logictest.WithOutput(output), logictest.WithCatalogVerification(true))
+	runner.RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+
+	catalog, err := h.Catalog(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, logictest.Catalog{"t1": {"a", "b", "c"}, "t3": {"a"}}, catalog)
+}
+
+func TestSqliteHarnessImplicitTransactions(t *testing.T) {
+	h := NewSqliteHarness(":memory:")
+	output := &bytes.Buffer{}
+	path := filepath.Join(t.TempDir(), "implicit.test")
+	require.NoError(t, os.WriteFile(path, []byte("statement ok\nINSERT INTO t1 VALUES (2)\n\n"+
+		"query I nosort\nSELECT a FROM t1\n----\n1\n"), 0644))
+
+	// The INSERT is rolled back before the query, which sees only the fixed dataset
+	runner := logictest.NewRunner(h, logictest.WithOutput(output), logictest.WithImplicitTransactions(true),
+		logictest.WithSetupStatements("CREATE TABLE t1(a INTEGER)", "INSERT INTO t1 VALUES (1)"))
+	runner.RunTestFiles(path)
+	assert.NotContains(t, output.String(), "not ok")
+	assert.Contains(t, output.String(), " ok")
+}
+
+func TestSqliteHarnessColumnNames(t *testing.T) {
+	ctx := context.Background()
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER, b TEXT)"))
+
+	schema, columns, results, err := h.ExecuteQueryColumns(ctx, "SELECT a, b AS c, a + 1 FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, "ITI", schema)
+	assert.Equal(t, []string{"a", "c", "a + 1"}, columns)
+	assert.Empty(t, results)
+}
+
+func TestSqliteHarnessExecuteStatements(t *testing.T) {
+	ctx := context.Background()
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER PRIMARY KEY)"))
+	require.NoError(t, h.ExecuteStatements(ctx, []string{"INSERT INTO t1 VALUES (1)", "INSERT INTO t1 VALUES (2)"}))
+
+	// A batch with a failing statement has no effect
+	require.Error(t, h.ExecuteStatements(ctx, []string{"INSERT INTO t1 VALUES (3)", "INSERT INTO t1 VALUES (1)"}))
+	_, results, err := h.ExecuteQuery(ctx, "SELECT a FROM t1 ORDER BY a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, results)
+}
+
+func TestSqliteHarnessPreludes(t *testing.T) {
+	ctx := context.Background()
+	h := NewSqliteHarness(":memory:")
+	require.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1(a INTEGER)"))
+	require.NoError(t, h.ExecuteStatement(ctx, "INSERT INTO t1 VALUES (1)"))
+	require.NoError(t, h.SavePrelude(ctx, "t1"))
+
+	// Saved preludes survive Init
+	require.NoError(t, h.Init())
+	restored, err := h.RestorePrelude(ctx, "t1")
+	require.NoError(t, err)
+	assert.True(t, restored)
+	_, results, err := h.ExecuteQuery(ctx, "SELECT a FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, results)
+
+	restored, err = h.RestorePrelude(ctx, "t2")
+	require.NoError(t, err)
+	assert.False(t, restored)
+}
@@ -0,0 +1,92 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import "strings"
+
+// mysqlSQLStates map MySQL error numbers to the portable SQLSTATEs of the standard, as reported by Postgres for the
+// same errors. MySQL itself reports coarser SQLSTATEs for many of them, such as 23000 for every constraint violation.
+var mysqlSQLStates = map[string]string{
+	"1044": "42501", // ER_DBACCESS_DENIED_ERROR
+	"1045": "28000", // ER_ACCESS_DENIED_ERROR
+	"1048": "23502", // ER_BAD_NULL_ERROR
+	"1049": "3D000", // ER_BAD_DB_ERROR
+	"1050": "42P07", // ER_TABLE_EXISTS_ERROR
+	"1051": "42P01", // ER_BAD_TABLE_ERROR
+	"1054": "42703", // ER_BAD_FIELD_ERROR
+	"1060": "42701", // ER_DUP_FIELDNAME
+	"1061": "42P07", // ER_DUP_KEYNAME
+	"1062": "23505", // ER_DUP_ENTRY
+	"1064": "42601", // ER_PARSE_ERROR
+	"1142": "42501", // ER_TABLEACCESS_DENIED_ERROR
+	"1146": "42P01", // ER_NO_SUCH_TABLE
+	"1205": "55P03", // ER_LOCK_WAIT_TIMEOUT
+	"1213": "40P01", // ER_LOCK_DEADLOCK
+	"1235": "0A000", // ER_NOT_SUPPORTED_YET
+	"1264": "22003", // ER_WARN_DATA_OUT_OF_RANGE
+	"1305": "42883", // ER_SP_DOES_NOT_EXIST
+	"1365": "22012", // ER_DIVISION_BY_ZERO
+	"1406": "22001", // ER_DATA_TOO_LONG
+	"1451": "23503", // ER_ROW_IS_REFERENCED_2
+	"1452": "23503", // ER_NO_REFERENCED_ROW_2
+	"3819": "23514", // ER_CHECK_CONSTRAINT_VIOLATED
+}
+
+// sqliteSQLStates map SQLite's extended result codes to portable SQLSTATEs. SQLite reports most other errors, such as
+// syntax errors and missing tables, with the generic code SQLITE_ERROR.
+var sqliteSQLStates = map[string]string{
+	"3":    "42501", // SQLITE_PERM
+	"5":    "55P03", // SQLITE_BUSY
+	"6":    "55P03", // SQLITE_LOCKED
+	"8":    "25006", // SQLITE_READONLY
+	"18":   "22001", // SQLITE_TOOBIG
+	"19":   "23000", // SQLITE_CONSTRAINT
+	"20":   "42804", // SQLITE_MISMATCH
+	"23":   "42501", // SQLITE_AUTH
+	"275":  "23514", // SQLITE_CONSTRAINT_CHECK
+	"787":  "23503", // SQLITE_CONSTRAINT_FOREIGNKEY
+	"1299": "23502", // SQLITE_CONSTRAINT_NOTNULL
+	"1555": "23505", // SQLITE_CONSTRAINT_PRIMARYKEY
+	"2067": "23505", // SQLITE_CONSTRAINT_UNIQUE
+}
+
+// engineSQLStates map the names of engines, as returned by Harness.EngineStr, to the portable SQLSTATEs of their own
+// error codes. Engines that report standard SQLSTATEs themselves, such as Postgres, need no mapping.
+var engineSQLStates = map[string]map[string]string{
+	"mysql":  mysqlSQLStates,
+	"dolt":   mysqlSQLStates,
+	"vitess": mysqlSQLStates,
+	"sqlite": sqliteSQLStates,
+}
+
+// PortableSQLState returns the portable SQLSTATE of the error with the engine-specific code given, e.g. 23505 for
+// MySQL's error 1062, or the empty string if the built-in mapping doesn't know the code. The engine is named as by
+// Harness.EngineStr.
+func PortableSQLState(engine, code string) string {
+	return engineSQLStates[strings.ToLower(engine)][code]
+}
+
+// sqlStateMatches returns whether the SQLSTATE given is the one expected. An expected SQLSTATE whose subclass is 000,
+// such as 23000, names a whole class, and matches every SQLSTATE in the class, such as 23505.
+func sqlStateMatches(sqlState, expected string) bool {
+	if sqlState == "" {
+		return false
+	}
+	if strings.EqualFold(sqlState, expected) {
+		return true
+	}
+	return len(expected) == 5 && len(sqlState) == 5 && strings.HasSuffix(expected, "000") &&
+		strings.EqualFold(sqlState[:2], expected[:2])
+}
@@ -0,0 +1,77 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateKeyHarness is a MySQL harness that classifies every error as a duplicate key, which MySQL reports with the
+// coarse SQLSTATE 23000.
+type duplicateKeyHarness struct {
+	*fakeHarness
+}
+
+var _ ErrorClassifier = duplicateKeyHarness{}
+
+func (h duplicateKeyHarness) EngineStr() string {
+	return "mysql"
+}
+
+func (h duplicateKeyHarness) ClassifyError(err error) ErrorClass {
+	return ErrorClass{SQLState: "23000", Category: CategoryConstraint, Code: "1062"}
+}
+
+func TestPortableSQLState(t *testing.T) {
+	assert.Equal(t, "23505", PortableSQLState("mysql", "1062"))
+	assert.Equal(t, "23505", PortableSQLState("Dolt", "1062"))
+	assert.Equal(t, "23505", PortableSQLState("sqlite", "2067"))
+	assert.Equal(t, "42P01", PortableSQLState("mysql", "1146"))
+	assert.Empty(t, PortableSQLState("mysql", "9999"))
+	assert.Empty(t, PortableSQLState("postgresql", "23505"))
+}
+
+func TestSQLStateMatches(t *testing.T) {
+	assert.True(t, sqlStateMatches("23505", "23505"))
+	assert.True(t, sqlStateMatches("42p01", "42P01"))
+	assert.True(t, sqlStateMatches("23505", "23000"))
+	assert.False(t, sqlStateMatches("23000", "23505"))
+	assert.False(t, sqlStateMatches("42S02", "23000"))
+	assert.False(t, sqlStateMatches("", ""))
+}
+
+func TestPortableStatementErrors(t *testing.T) {
+	for _, expected := range []string{"23000", "23505", "constraint"} {
+		reporter := &collectingReporter{}
+		runner := NewRunner(duplicateKeyHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		runner.RunTestFiles(writeTestFile(t, "statement error "+expected+"\nINSERT INTO missing VALUES(1, 2)\n"))
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, Ok, reporter.entries[0].Result, expected)
+	}
+
+	reporter := &collectingReporter{}
+	runner := NewRunner(duplicateKeyHarness{newFakeHarness()}, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "statement error 23503\nINSERT INTO missing VALUES(1, 2)\n"))
+	})
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, ErrorMismatch, reporter.entries[0].FailureCode)
+	assert.Equal(t, "Expected error 23503 but got 23000/23505 (constraint): table not found: missing",
+		reporter.entries[0].ErrorMessage)
+}
@@ -0,0 +1,187 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A ResultsStore accumulates the results of runs in a directory, one JSON file per run, so that pass rates can be
+// tracked over time, e.g. across nightly runs of new versions of an engine.
+type ResultsStore struct {
+	dir string
+}
+
+// A StoredRun is the results of one run in a ResultsStore, with the engine and engine version it ran against.
+type StoredRun struct {
+	ID      string
+	Time    time.Time
+	Engine  string
+	Version string `json:",omitempty"`
+	Results []*ResultLogEntry
+}
+
+// OpenResultsStore opens the store in the directory given, creating it if it doesn't exist.
+func OpenResultsStore(dir string) (*ResultsStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ResultsStore{dir: dir}, nil
+}
+
+// AddRun adds the run given to the store. Runs without an ID are given one from their time and engine, and runs
+// without a time are given the current time.
+func (s *ResultsStore) AddRun(run *StoredRun) error {
+	if run.Time.IsZero() {
+		run.Time = time.Now()
+	}
+	if run.ID == "" {
+		run.ID = run.Time.UTC().Format("20060102T150405.000000000Z")
+		if run.Engine != "" {
+			run.ID += "-" + strings.Map(sanitizeIDRune, run.Engine)
+		}
+	}
+
+	path := filepath.Join(s.dir, run.ID+".json")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(run); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// sanitizeIDRune replaces the characters in an engine name that don't belong in a file name.
+func sanitizeIDRune(r rune) rune {
+	if r == '/' || r == '\\' || r == ' ' || r == ':' {
+		return '_'
+	}
+	return r
+}
+
+// Runs returns the runs in the store against the engine and version given, oldest first. An empty engine or version
+// matches any.
+func (s *ResultsStore) Runs(engine, version string) ([]*StoredRun, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*StoredRun
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		run := &StoredRun{}
+		if err := json.Unmarshal(contents, run); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if (engine == "" || run.Engine == engine) && (version == "" || run.Version == version) {
+			runs = append(runs, run)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Time.Before(runs[j].Time)
+	})
+	return runs, nil
+}
+
+// A TrendPoint is the outcome of one run in a trend: the number of records that passed, failed and were skipped, and
+// the total time they took.
+type TrendPoint struct {
+	RunID    string
+	Time     time.Time
+	Engine   string
+	Version  string
+	Passed   int
+	Failed   int
+	Skipped  int
+	Duration time.Duration
+}
+
+// PassRate returns the fraction of the records that ran that passed, or zero if none ran.
+func (p TrendPoint) PassRate() float64 {
+	if p.Passed+p.Failed == 0 {
+		return 0
+	}
+	return float64(p.Passed) / float64(p.Passed+p.Failed)
+}
+
+// Trend returns the outcome of each run in the store against the engine and version given, oldest first. An empty
+// engine or version matches any.
+func (s *ResultsStore) Trend(engine, version string) ([]TrendPoint, error) {
+	runs, err := s.Runs(engine, version)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]TrendPoint, len(runs))
+	for i, run := range runs {
+		points[i] = run.trendPoint()
+	}
+	return points, nil
+}
+
+func (r *StoredRun) trendPoint() TrendPoint {
+	point := TrendPoint{
+		RunID:   r.ID,
+		Time:    r.Time,
+		Engine:  r.Engine,
+		Version: r.Version,
+	}
+	for _, entry := range r.Results {
+		point.Duration += entry.Duration
+		switch outcomeOf(entry) {
+		case outcomePassed:
+			point.Passed++
+		case outcomeFailed:
+			point.Failed++
+		default:
+			point.Skipped++
+		}
+	}
+	return point
+}
+
+// PrintTrend writes the trend given to the writer given, one run per line.
+func PrintTrend(w io.Writer, points []TrendPoint) error {
+	for _, p := range points {
+		version := p.Version
+		if version == "" {
+			version = "-"
+		}
+		_, err := fmt.Fprintf(w, "%s %s %s %s: %.2f%% passed (%d passed, %d failed, %d skipped) in %s\n",
+			p.Time.Format(time.RFC3339), p.RunID, p.Engine, version, 100*p.PassRate(), p.Passed, p.Failed, p.Skipped,
+			p.Duration)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultsStore(t *testing.T) {
+	store, err := OpenResultsStore(t.TempDir())
+	require.NoError(t, err)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := []*StoredRun{
+		{Time: start.Add(2 * time.Hour), Engine: "sqlite", Version: "3.31", Results: []*ResultLogEntry{
+			resultEntry("a.test", 1, Ok, time.Millisecond),
+			resultEntry("a.test", 5, Ok, time.Millisecond),
+			resultEntry("a.test", 9, Ok, time.Millisecond),
+			resultEntry("a.test", 13, Skipped, 0),
+		}},
+		{Time: start, Engine: "sqlite", Version: "3.30", Results: []*ResultLogEntry{
+			resultEntry("a.test", 1, Ok, time.Millisecond),
+			resultEntry("a.test", 5, NotOk, time.Millisecond),
+			resultEntry("a.test", 9, Timeout, time.Millisecond),
+			resultEntry("a.test", 13, Ok, time.Millisecond),
+		}},
+		{Time: start.Add(time.Hour), Engine: "mysql", Results: []*ResultLogEntry{
+			resultEntry("a.test", 1, Ok, time.Millisecond),
+		}},
+	}
+	for _, run := range runs {
+		require.NoError(t, store.AddRun(run))
+		assert.NotEmpty(t, run.ID)
+	}
+	assert.Error(t, store.AddRun(runs[0]), "run IDs are unique")
+
+	stored, err := store.Runs("", "")
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	assert.Equal(t, []string{runs[1].ID, runs[2].ID, runs[0].ID}, []string{stored[0].ID, stored[1].ID, stored[2].ID})
+	assert.Equal(t, runs[0].Results, stored[2].Results)
+
+	trend, err := store.Trend("sqlite", "")
+	require.NoError(t, err)
+	require.Len(t, trend, 2)
+	assert.Equal(t, "3.30", trend[0].Version)
+	assert.Equal(t, []int{2, 2, 0}, []int{trend[0].Passed, trend[0].Failed, trend[0].Skipped})
+	assert.Equal(t, 0.5, trend[0].PassRate())
+	assert.Equal(t, 4*time.Millisecond, trend[0].Duration)
+	assert.Equal(t, []int{3, 0, 1}, []int{trend[1].Passed, trend[1].Failed, trend[1].Skipped})
+	assert.Equal(t, 1.0, trend[1].PassRate())
+
+	trend, err = store.Trend("sqlite", "3.31")
+	require.NoError(t, err)
+	require.Len(t, trend, 1)
+	assert.Equal(t, runs[0].ID, trend[0].RunID)
+
+	var out bytes.Buffer
+	require.NoError(t, PrintTrend(&out, trend))
+	assert.Contains(t, out.String(), "sqlite 3.31: 100.00% passed (3 passed, 0 failed, 1 skipped)")
+}
@@ -0,0 +1,132 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// executeStreamingQuery executes the query record given with a streaming harness, verifying its results incrementally
+// when possible. The outcome includes the results only when they had to be materialized.
+func (r *Runner) executeStreamingQuery(ctx context.Context, harness StreamingHarness, record *parser.Record) *R {
+	schema, rows, err := harness.ExecuteQueryStreaming(ctx, record.Query())
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+		return &R{err: err}
+	}
+	defer rows.Close()
+
+	// Only log one error per record, so if schema comparison fails don't bother with result comparison, unless the
+	// results are needed to regenerate the record
+	if err := r.verifySchema(ctx, record, schema); err != nil && !isGenerating(ctx) {
+		return &R{err: err}
+	}
+
+	if record.IsHashResult() && record.SortMode() == parser.NoSort && !isGenerating(ctx) {
+		return &R{schema: schema, err: r.verifyHashStreaming(ctx, record, rows)}
+	}
+
+	// Results larger than the spill threshold are verified from disk, except when they're needed to regenerate the
+	// record. Partially sorted results are always materialized, since their runs can't be merged in sorted order.
+	if r.config.SpillThreshold > 0 && record.SortMode() != parser.PartialSort && !isGenerating(ctx) {
+		return r.executeSpillingQuery(ctx, record, schema, rows)
+	}
+
+	var results []string
+	for {
+		row, err := rows.NextRow()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+			return &R{err: err}
+		}
+		results = append(results, row...)
+	}
+
+	return r.verifyQuery(ctx, record, schema, results)
+}
+
+// verifyHashStreaming verifies the hash of the rows given against the expected hash of the record given, without
+// materializing them, logging any failure. Checkpoint hashes are verified as the rows are read.
+func (r *Runner) verifyHashStreaming(ctx context.Context, record *parser.Record, rows RowIterator) error {
+	algorithm := HashAlgorithm(record.HashAlgorithm())
+	hasher, err := newResultHasher(algorithm)
+	if err != nil {
+		logFailure(ctx, UnexpectedError, "Error hashing results: %v", err)
+		return fmt.Errorf("error hashing results: %v", err)
+	}
+
+	var checkpoints []parser.HashCheckpoint
+	if r.config.BisectHashMismatches {
+		for _, checkpoint := range record.HashCheckpoints() {
+			if HashAlgorithm(checkpoint.Algorithm) == algorithm {
+				checkpoints = append(checkpoints, checkpoint)
+			}
+		}
+	}
+
+	schema := record.Schema()
+	numCols := record.NumCols()
+	divergence := ""
+	prevCheckpoint := 0
+	for {
+		row, err := rows.NextRow()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+			return err
+		}
+
+		for _, value := range row {
+			typ := schema[hasher.count%len(schema)]
+			hasher.add(r.config.normalizeValue(r.config.compactValue(value, typ), typ))
+
+			if len(checkpoints) > 0 && divergence == "" && hasher.count == checkpoints[0].NumValues {
+				if hasher.sum() != checkpoints[0].Hash {
+					divergence = describeRowRange(prevCheckpoint/numCols, (hasher.count+numCols-1)/numCols)
+				}
+				prevCheckpoint = hasher.count
+				checkpoints = checkpoints[1:]
+			}
+		}
+	}
+
+	if hasher.count != record.NumResults() {
+		logFailure(ctx, RowCountMismatch, "Incorrect number of results. Expected %v, got %v", record.NumResults(), hasher.count)
+		return fmt.Errorf("incorrect number of results. expected %v, got %v", record.NumResults(), hasher.count)
+	}
+
+	computedHash := hasher.sum()
+	if record.HashResult() != computedHash {
+		if divergence == "" && r.config.BisectHashMismatches && prevCheckpoint > 0 {
+			divergence = describeRowRange(prevCheckpoint/numCols, (hasher.count+numCols-1)/numCols)
+		}
+		if divergence != "" {
+			logFailure(ctx, HashMismatch, "Hash of results differ. Expected %v, got %v, %s", record.HashResult(), computedHash, divergence)
+			return fmt.Errorf("hash of results differ, expected %v, got %v, %s", record.HashResult(), computedHash, divergence)
+		}
+
+		logFailure(ctx, HashMismatch, "Hash of results differ. Expected %v, got %v", record.HashResult(), computedHash)
+		return fmt.Errorf("hash of results differ, expected %v, got %v", record.HashResult(), computedHash)
+	}
+
+	return nil
+}
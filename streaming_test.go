@@ -0,0 +1,118 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingHarness serves the results of a fakeHarness through the StreamingHarness interface.
+type streamingHarness struct {
+	*fakeHarness
+	streamed int
+}
+
+var _ StreamingHarness = &streamingHarness{}
+
+func (h *streamingHarness) ExecuteQueryStreaming(ctx context.Context, statement string) (string, RowIterator, error) {
+	schema, results, err := h.fakeHarness.ExecuteQuery(ctx, statement)
+	if err != nil {
+		return "", nil, err
+	}
+	h.streamed++
+	return schema, &sliceRowIterator{values: results, numCols: len(schema)}, nil
+}
+
+type sliceRowIterator struct {
+	values  []string
+	numCols int
+}
+
+func (i *sliceRowIterator) NextRow() ([]string, error) {
+	if len(i.values) == 0 {
+		return nil, io.EOF
+	}
+	row := i.values[:i.numCols]
+	i.values = i.values[i.numCols:]
+	return row, nil
+}
+
+func (i *sliceRowIterator) Close() error {
+	return nil
+}
+
+func TestStreamingHarness(t *testing.T) {
+	values := []string{"1", "a", "2", "b", "3", "c", "4", "d"}
+	harness := &streamingHarness{fakeHarness: newFakeHarness()}
+	harness.queryResults["SELECT * FROM t2"] = fakeResult{schema: "IT", results: values}
+
+	hash, err := hashResults(MD5, values)
+	require.NoError(t, err)
+	checkpoints, err := hashCheckpointLines(MD5, values, 2, 1)
+	require.NoError(t, err)
+	hashLines := fmt.Sprintf("8 values hashing to %s\n%s", hash, strings.Join(checkpoints, "\n"))
+
+	t.Run("hashed nosort", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		runner.RunTestFiles(writeTestFile(t, "query IT nosort\nSELECT * FROM t2\n----\n"+hashLines+"\n"))
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, Ok, reporter.entries[0].Result)
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		harness.queryResults["SELECT * FROM t2"] = fakeResult{schema: "IT", results: []string{"1", "a", "2", "x", "3", "c", "4", "d"}}
+		defer func() {
+			harness.queryResults["SELECT * FROM t2"] = fakeResult{schema: "IT", results: values}
+		}()
+
+		reporter := &collectingReporter{}
+		runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithHashBisection(true))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "query IT nosort\nSELECT * FROM t2\n----\n"+hashLines+"\n"))
+		})
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, HashMismatch, reporter.entries[0].FailureCode)
+		assert.Contains(t, reporter.entries[0].ErrorMessage, "first differing row is 1")
+	})
+
+	t.Run("row count mismatch", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		assert.Panics(t, func() {
+			runner.RunTestFiles(writeTestFile(t, "query IT nosort\nSELECT * FROM t2\n----\n10 values hashing to "+hash+"\n"))
+		})
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, RowCountMismatch, reporter.entries[0].FailureCode)
+	})
+
+	t.Run("materialized", func(t *testing.T) {
+		reporter := &collectingReporter{}
+		runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+		runner.RunTestFiles(writeTestFile(t, "query IT rowsort\nSELECT * FROM t2\n----\n"+hashLines+"\n"))
+		require.Len(t, reporter.entries, 1)
+		assert.Equal(t, Ok, reporter.entries[0].Result)
+	})
+
+	assert.Equal(t, 4, harness.streamed)
+}
@@ -0,0 +1,292 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package subprocess provides a harness for engines run as an external process, which exchanges statements and
+// results with the process over its stdin and stdout with a newline-delimited protocol.
+//
+// The harness writes one request per line, and the process answers each with a response:
+//
+//	ENGINE               answered with "OK <engine>"
+//	INIT                 answered with "OK" or "ERROR <message>"
+//	STATEMENT <sql>      answered with "OK" or "ERROR <message>"
+//	QUERY <sql>          answered with "OK <schema>", then "ROW <value>\t<value>..." per row, then "END";
+//	                     or with "ERROR <message>"
+//
+// SQL, values and messages are escaped so that each fits on one line: backslashes, newlines, carriage returns and tabs
+// are written as \\, \n, \r and \t. Lines the process writes to stderr are passed through to the harness's stderr.
+package subprocess
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+)
+
+// SubprocessHarness is a harness that executes records with an external process speaking the line protocol described
+// in the package documentation. The process is started by Init, and restarted if it exits or a request times out.
+type SubprocessHarness struct {
+	name string
+	args []string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	engine string
+}
+
+var _ logictest.Harness = &SubprocessHarness{}
+
+// NewSubprocessHarness returns a harness that runs the command given with the arguments given.
+func NewSubprocessHarness(name string, args ...string) *SubprocessHarness {
+	return &SubprocessHarness{name: name, args: args}
+}
+
+// See Harness.EngineStr. Returns the empty string if the process can't be started.
+func (h *SubprocessHarness) EngineStr() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.engine == "" {
+		lines, err := h.request(context.Background(), "ENGINE", "")
+		if err == nil {
+			h.engine = lines[0]
+		}
+	}
+	return h.engine
+}
+
+// See Harness.Init
+func (h *SubprocessHarness) Init() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.request(context.Background(), "INIT", "")
+	return err
+}
+
+// See Harness.ExecuteStatement
+func (h *SubprocessHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.request(ctx, "STATEMENT", statement)
+	return err
+}
+
+// See Harness.ExecuteQuery
+func (h *SubprocessHarness) ExecuteQuery(ctx context.Context, statement string) (schema string, results []string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	lines, err := h.request(ctx, "QUERY", statement)
+	if err != nil {
+		return "", nil, err
+	}
+
+	schema = lines[0]
+	for _, row := range lines[1:] {
+		for _, value := range strings.Split(row, "\t") {
+			results = append(results, unescape(value))
+		}
+	}
+	return schema, results, nil
+}
+
+// See Harness.GetTimeout
+func (h *SubprocessHarness) GetTimeout() int64 {
+	return 0
+}
+
+// Close stops the process, if it's running.
+func (h *SubprocessHarness) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stop()
+	return nil
+}
+
+// start starts the process.
+func (h *SubprocessHarness) start() error {
+	cmd := exec.Command(h.name, h.args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	h.cmd, h.stdin, h.stdout = cmd, stdin, bufio.NewReader(stdout)
+	return nil
+}
+
+// stop kills the process, so that the next request restarts it.
+func (h *SubprocessHarness) stop() {
+	if h.cmd == nil {
+		return
+	}
+	h.stdin.Close()
+	h.cmd.Process.Kill()
+	h.cmd.Wait()
+	h.cmd = nil
+}
+
+// request sends the command given to the process and returns the lines of its response, starting the process if it
+// isn't running. The first line is the argument of the OK response, which is followed by the values of any ROW lines
+// for queries. An ERROR response is returned as an error. If the context is done before the response is read, the
+// process is killed.
+func (h *SubprocessHarness) request(ctx context.Context, command string, arg string) ([]string, error) {
+	if h.cmd == nil {
+		if err := h.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	line := command
+	if arg != "" {
+		line += " " + escape(arg)
+	}
+
+	type response struct {
+		lines []string
+		err   error
+	}
+	rc := make(chan response, 1)
+	go func() {
+		if _, err := io.WriteString(h.stdin, line+"\n"); err != nil {
+			rc <- response{err: err}
+			return
+		}
+		lines, err := h.readResponse(command == "QUERY")
+		rc <- response{lines, err}
+	}()
+
+	select {
+	case res := <-rc:
+		var engineErr *engineError
+		if res.err != nil && !errors.As(res.err, &engineErr) {
+			h.stop()
+		}
+		return res.lines, res.err
+	case <-ctx.Done():
+		h.stop()
+		<-rc
+		return nil, ctx.Err()
+	}
+}
+
+// engineError is an error reported by the engine with an ERROR response, as opposed to a failure of the process.
+type engineError struct {
+	message string
+}
+
+func (e *engineError) Error() string {
+	return e.message
+}
+
+// readResponse reads a response from the process. Query responses include ROW lines up to a terminating END line.
+func (h *SubprocessHarness) readResponse(query bool) ([]string, error) {
+	line, err := h.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(line, "ERROR"):
+		return nil, &engineError{message: unescape(strings.TrimSpace(strings.TrimPrefix(line, "ERROR")))}
+	case line == "OK" || strings.HasPrefix(line, "OK "):
+	default:
+		return nil, fmt.Errorf("unexpected response from %s: %s", h.name, line)
+	}
+
+	lines := []string{unescape(strings.TrimPrefix(strings.TrimPrefix(line, "OK"), " "))}
+	if !query {
+		return lines, nil
+	}
+
+	for {
+		line, err := h.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "END" {
+			return lines, nil
+		}
+		if !strings.HasPrefix(line, "ROW ") {
+			return nil, fmt.Errorf("unexpected response from %s: %s", h.name, line)
+		}
+		lines = append(lines, strings.TrimPrefix(line, "ROW "))
+	}
+}
+
+// readLine reads a single line from the process, without its line ending.
+func (h *SubprocessHarness) readLine() (string, error) {
+	line, err := h.stdout.ReadString('\n')
+	if err == io.EOF {
+		return "", fmt.Errorf("%s exited unexpectedly", h.name)
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+var escaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r", "\t", "\\t")
+
+// escape escapes the string given to fit on a single line of the protocol.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// unescape reverses escape.
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	sb := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subprocess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// When this environment variable is set, the test binary acts as an engine speaking the line protocol instead of
+// running tests.
+const engineEnv = "SUBPROCESS_HARNESS_TEST_ENGINE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(engineEnv) != "" {
+		serveTestEngine()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveTestEngine answers requests from stdin: queries echo their SQL back as a single value, statements fail unless
+// they start with CREATE, and the statement SLEEP never responds.
+func serveTestEngine() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		command, arg := scanner.Text(), ""
+		if i := strings.Index(command, " "); i >= 0 {
+			command, arg = command[:i], command[i+1:]
+		}
+
+		switch command {
+		case "ENGINE":
+			fmt.Println("OK test")
+		case "INIT":
+			fmt.Println("OK")
+		case "STATEMENT":
+			if arg == "SLEEP" {
+				time.Sleep(time.Hour)
+			}
+			if strings.HasPrefix(arg, "CREATE") {
+				fmt.Println("OK")
+			} else {
+				fmt.Println("ERROR unsupported statement:\\n" + arg)
+			}
+		case "QUERY":
+			fmt.Println("OK TI")
+			fmt.Println("ROW " + arg + "\t1")
+			fmt.Println("ROW a\\tb\t2")
+			fmt.Println("END")
+		}
+	}
+}
+
+func newTestHarness(t *testing.T) *SubprocessHarness {
+	require.NoError(t, os.Setenv(engineEnv, "1"))
+	t.Cleanup(func() { os.Unsetenv(engineEnv) })
+
+	h := NewSubprocessHarness(os.Args[0])
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestSubprocessHarness(t *testing.T) {
+	h := newTestHarness(t)
+	ctx := context.Background()
+
+	require.NoError(t, h.Init())
+	assert.Equal(t, "test", h.EngineStr())
+
+	assert.NoError(t, h.ExecuteStatement(ctx, "CREATE TABLE t1 (a int)"))
+	assert.EqualError(t, h.ExecuteStatement(ctx, "DROP TABLE t1"), "unsupported statement:\nDROP TABLE t1")
+
+	schema, results, err := h.ExecuteQuery(ctx, "SELECT 'x\ty'\nFROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, "TI", schema)
+	assert.Equal(t, []string{"SELECT 'x\ty'\nFROM t1", "1", "a\tb", "2"}, results)
+}
+
+func TestSubprocessHarnessTimeout(t *testing.T) {
+	h := newTestHarness(t)
+	require.NoError(t, h.Init())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, h.ExecuteStatement(ctx, "SLEEP"))
+
+	// The process is restarted for the next request
+	assert.NoError(t, h.ExecuteStatement(context.Background(), "CREATE TABLE t1 (a int)"))
+}
+
+func TestEscape(t *testing.T) {
+	for _, s := range []string{"", "plain", "a\nb", "tab\there", "back\\slash\\n", "\r\n"} {
+		assert.Equal(t, s, unescape(escape(s)))
+		assert.NotContains(t, escape(s), "\n")
+	}
+}
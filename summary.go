@@ -0,0 +1,110 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A FileSummary summarizes the records of a test file, for auditing a corpus.
+type FileSummary struct {
+	// Path is the path of the test file.
+	Path string
+	// Records is the number of records in the file, of any type.
+	Records int
+	// Statements is the number of statement records.
+	Statements int
+	// Queries is the number of query records, which are either hashed or enumerated.
+	Queries int
+	// HashedQueries is the number of queries whose expected results are given as a hash.
+	HashedQueries int
+	// EnumeratedQueries is the number of queries whose expected results are given value by value.
+	EnumeratedQueries int
+	// Engines counts the records with skipif or onlyif conditions naming each engine.
+	Engines map[string]int
+}
+
+// Add adds the counts of the summary given to this one, e.g. to total the summaries of a corpus.
+func (s *FileSummary) Add(other FileSummary) {
+	s.Records += other.Records
+	s.Statements += other.Statements
+	s.Queries += other.Queries
+	s.HashedQueries += other.HashedQueries
+	s.EnumeratedQueries += other.EnumeratedQueries
+	for engine, n := range other.Engines {
+		if s.Engines == nil {
+			s.Engines = make(map[string]int)
+		}
+		s.Engines[engine] += n
+	}
+}
+
+// SummarizeTestFiles summarizes the test files found under any of the paths given, in the order RunTestFiles would
+// run them. Returns an error for the first file that can't be parsed.
+func SummarizeTestFiles(paths ...string) ([]FileSummary, error) {
+	files, err := findTestFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []FileSummary
+	for _, file := range files {
+		summary, err := SummarizeTestFile(file)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// SummarizeTestFile summarizes the test file at the path given.
+func SummarizeTestFile(path string) (FileSummary, error) {
+	records, err := parser.ParseTestFile(path)
+	if err != nil {
+		return FileSummary{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	summary := FileSummary{Path: path, Records: len(records)}
+	for _, record := range records {
+		switch record.Type() {
+		case parser.Statement:
+			summary.Statements++
+		case parser.Query:
+			summary.Queries++
+			if record.IsHashResult() {
+				summary.HashedQueries++
+			} else {
+				summary.EnumeratedQueries++
+			}
+		}
+
+		// A record naming an engine in several conditions counts once for it
+		seen := make(map[string]bool)
+		for _, condition := range record.Conditions() {
+			if seen[condition.Engine()] {
+				continue
+			}
+			seen[condition.Engine()] = true
+			if summary.Engines == nil {
+				summary.Engines = make(map[string]int)
+			}
+			summary.Engines[condition.Engine()]++
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeTestFile(t *testing.T) {
+	path := writeTestFile(t, `hash-threshold 2
+
+statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+skipif mysql
+skipif postgresql
+statement ok
+INSERT INTO t1 VALUES(1, 2)
+
+onlyif mysql
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+query II nosort
+SELECT a, b FROM t1
+----
+2 values hashing to fb1ba3e0a8cb7ea50e5a6e3d9ec4e4c9
+
+query I nosort
+SELECT a FROM t1 WHERE a > 1
+----
+
+halt
+`)
+
+	summary, err := SummarizeTestFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, FileSummary{
+		Path:              path,
+		Records:           6,
+		Statements:        2,
+		Queries:           3,
+		HashedQueries:     1,
+		EnumeratedQueries: 2,
+		Engines:           map[string]int{"mysql": 2, "postgresql": 1},
+	}, summary)
+
+	summaries, err := SummarizeTestFiles(path, path)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	total := FileSummary{}
+	for _, s := range summaries {
+		total.Add(s)
+	}
+	assert.Equal(t, 12, total.Records)
+	assert.Equal(t, 2, total.HashedQueries)
+	assert.Equal(t, map[string]int{"mysql": 4, "postgresql": 2}, total.Engines)
+
+	_, err = SummarizeTestFiles(writeTestFile(t, "statement ok\nCREATE TABLE t1(a INTEGER)\n\nconnection\n"))
+	assert.Error(t, err)
+}
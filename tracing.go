@@ -0,0 +1,70 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startFileSpan starts the span of the test file given, when the runner traces runs, and makes it the parent of the
+// spans of the file's records. The span returned must be ended when the file has run.
+func (r *Runner) startFileSpan(file string) trace.Span {
+	r.fileCtx = context.Background()
+	if r.config.Tracer == nil {
+		return trace.SpanFromContext(r.fileCtx)
+	}
+
+	var span trace.Span
+	r.fileCtx, span = r.config.Tracer.Start(r.fileCtx, "test file",
+		trace.WithAttributes(attribute.String("sqllogictest.file", testFilePath(file))))
+	return span
+}
+
+// startRecordSpan starts the span of the record given as a child of the current test file's span, when the runner
+// traces runs, and returns a context with the span and a cancel function that also ends it.
+func (r *Runner) startRecordSpan(ctx context.Context, cancel context.CancelFunc, testFile string, record *parser.Record) (context.Context, context.CancelFunc) {
+	if r.config.Tracer == nil {
+		return ctx, cancel
+	}
+
+	ctx, span := r.config.Tracer.Start(ctx, "record", trace.WithAttributes(
+		attribute.String("sqllogictest.file", testFilePath(testFile)),
+		attribute.Int("sqllogictest.line", record.LineNum()),
+		attribute.String("db.query.text", record.Query()),
+	))
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
+}
+
+// annotateRecordSpan records the result of a record on its span, if it has one.
+func annotateRecordSpan(ctx context.Context, entry *ResultLogEntry) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(attribute.String("sqllogictest.result", entry.Result.String()))
+	if entry.Result == NotOk {
+		span.SetAttributes(attribute.String("sqllogictest.failure_code", string(entry.FailureCode)))
+		span.SetStatus(codes.Error, entry.ErrorMessage)
+	}
+}
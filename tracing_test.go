@@ -0,0 +1,76 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+statement ok
+INSERT INTO missing VALUES(1, 2)
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+3
+`)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithTracer(provider.Tracer("test")),
+		WithSkipAfterSetupFailure(true)).RunTestFiles(path)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	file := spans[3]
+	assert.Equal(t, "test file", file.Name())
+	for _, span := range spans[:3] {
+		assert.Equal(t, "record", span.Name())
+		assert.Equal(t, file.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+
+	attrs := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+	create, insert, query := attrs(spans[0]), attrs(spans[1]), attrs(spans[2])
+	assert.Equal(t, int64(2), create["sqllogictest.line"].AsInt64())
+	assert.Equal(t, "ok", create["sqllogictest.result"].AsString())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "INSERT INTO missing VALUES(1, 2)", insert["db.query.text"].AsString())
+	assert.Equal(t, "not ok", insert["sqllogictest.result"].AsString())
+	assert.Equal(t, string(UnexpectedError), insert["sqllogictest.failure_code"].AsString())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Status().Description, "table not found: missing")
+
+	assert.Equal(t, "did not run", query["sqllogictest.result"].AsString())
+}
@@ -0,0 +1,51 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A Translator rewrites statements and queries before they're executed by a harness, e.g. to run test files written
+// for one SQL dialect against an engine using another. See the translate package for bundled translators.
+type Translator interface {
+	// Translate returns the statement or query given rewritten, or an error if it can't be translated.
+	Translate(query string) (string, error)
+}
+
+// TranslatorFunc adapts a function to the Translator interface.
+type TranslatorFunc func(query string) (string, error)
+
+// See Translator.Translate
+func (f TranslatorFunc) Translate(query string) (string, error) {
+	return f(query)
+}
+
+// translate returns the record given with its query normalized by the runner's query normalizers and then rewritten
+// by each of its translators in turn. Results are logged with the original query.
+func (r *Runner) translate(record *parser.Record) (*parser.Record, error) {
+	query := r.config.executableQuery(record)
+	if len(r.config.Translators) == 0 && query == record.Query() {
+		return record, nil
+	}
+
+	for _, t := range r.config.Translators {
+		var err error
+		if query, err = t.Translate(query); err != nil {
+			return nil, err
+		}
+	}
+	return record.WithQuery(query), nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package translate provides translators that rewrite the MySQL-flavored SQL common in sqllogictest corpora for other
+// dialects. Translations are textual and best-effort: they handle quoting and common functions, not the full grammar.
+package translate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+)
+
+// A rewrite replaces matches of a pattern in the unquoted parts of a query.
+type rewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// DialectTranslator translates MySQL queries for another dialect, rewriting identifier and string quoting and then
+// applying a list of pattern rewrites to the parts of the query outside quotes.
+type DialectTranslator struct {
+	// identifierQuote replaces the backticks that quote MySQL identifiers
+	identifierQuote byte
+	// doubleQuotedStrings converts MySQL's double-quoted string literals to single-quoted ones
+	doubleQuotedStrings bool
+	rewrites            []rewrite
+}
+
+var _ logictest.Translator = &DialectTranslator{}
+
+// MySQLToPostgres translates MySQL queries for PostgreSQL: identifiers quoted with backticks are quoted with double
+// quotes, double-quoted strings become single-quoted, and MySQL-specific functions, casts and LIMIT syntax are
+// rewritten.
+var MySQLToPostgres = &DialectTranslator{
+	identifierQuote:     '"',
+	doubleQuotedStrings: true,
+	rewrites: []rewrite{
+		{regexp.MustCompile(`(?i)\bIFNULL\s*\(`), "COALESCE("},
+		{regexp.MustCompile(`(?i)\bRAND\s*\(\s*\)`), "RANDOM()"},
+		{regexp.MustCompile(`(?i)\bAS\s+(UNSIGNED|SIGNED)(\s+INTEGER)?\b`), "AS BIGINT"},
+		{regexp.MustCompile(`(?i)\bLIMIT\s+(\d+)\s*,\s*(\d+)`), "LIMIT $2 OFFSET $1"},
+		{regexp.MustCompile(`(?i)\s+DIV\s+`), " / "},
+	},
+}
+
+// MySQLToSQLite translates MySQL queries for SQLite: identifiers quoted with backticks are quoted with double quotes,
+// and MySQL-specific functions and casts are rewritten.
+var MySQLToSQLite = &DialectTranslator{
+	identifierQuote: '"',
+	rewrites: []rewrite{
+		{regexp.MustCompile(`(?i)\bRAND\s*\(\s*\)`), "RANDOM()"},
+		{regexp.MustCompile(`(?i)\bIF\s*\(`), "IIF("},
+		{regexp.MustCompile(`(?i)\bAS\s+(UNSIGNED|SIGNED)(\s+INTEGER)?\b`), "AS INTEGER"},
+		{regexp.MustCompile(`(?i)\s+DIV\s+`), " / "},
+	},
+}
+
+// MySQLToDuckDB translates MySQL queries for DuckDB: identifiers quoted with backticks are quoted with double quotes,
+// double-quoted strings become single-quoted, and MySQL-specific functions, casts and LIMIT syntax are rewritten. Like
+// MySQL, DuckDB's / operator always divides as floats, so DIV is rewritten to its integer division operator.
+var MySQLToDuckDB = &DialectTranslator{
+	identifierQuote:     '"',
+	doubleQuotedStrings: true,
+	rewrites: []rewrite{
+		{regexp.MustCompile(`(?i)\bRAND\s*\(\s*\)`), "RANDOM()"},
+		{regexp.MustCompile(`(?i)\bAS\s+(UNSIGNED|SIGNED)(\s+INTEGER)?\b`), "AS BIGINT"},
+		{regexp.MustCompile(`(?i)\bLIMIT\s+(\d+)\s*,\s*(\d+)`), "LIMIT $2 OFFSET $1"},
+		{regexp.MustCompile(`(?i)\s+DIV\s+`), " // "},
+	},
+}
+
+// MySQLToClickHouse translates MySQL queries for ClickHouse, which accepts backtick-quoted identifiers: double-quoted
+// strings become single-quoted, casts and RAND() are rewritten to their ClickHouse equivalents, and UPDATE and DELETE
+// statements are rewritten as ALTER TABLE mutations. Other differences, such as nullability and table engines, are
+// left to the settings of the harness.
+var MySQLToClickHouse = &DialectTranslator{
+	identifierQuote:     '`',
+	doubleQuotedStrings: true,
+	rewrites: []rewrite{
+		{regexp.MustCompile(`(?i)\bRAND\s*\(\s*\)`), "randCanonical()"},
+		{regexp.MustCompile(`(?i)\bAS\s+UNSIGNED(\s+INTEGER)?\b`), "AS UInt64"},
+		{regexp.MustCompile(`(?i)\bAS\s+SIGNED(\s+INTEGER)?\b`), "AS Int64"},
+		{regexp.MustCompile(`(?i)^\s*UPDATE\s+(\S+)\s+SET\b`), "ALTER TABLE $1 UPDATE"},
+		{regexp.MustCompile(`(?i)^\s*DELETE\s+FROM\s+(\S+)\s+WHERE\b`), "ALTER TABLE $1 DELETE WHERE"},
+	},
+}
+
+// See Translator.Translate
+func (t *DialectTranslator) Translate(query string) (string, error) {
+	sb := strings.Builder{}
+	unquoted := strings.Builder{}
+	flush := func() {
+		s := unquoted.String()
+		for _, rw := range t.rewrites {
+			s = rw.pattern.ReplaceAllString(s, rw.replacement)
+		}
+		sb.WriteString(s)
+		unquoted.Reset()
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c != '\'' && c != '"' && c != '`' {
+			unquoted.WriteByte(c)
+			continue
+		}
+
+		flush()
+		end := closingQuote(query, i)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote at position %d", i)
+		}
+		body := query[i+1 : end]
+		switch {
+		case c == '`':
+			quote := string(t.identifierQuote)
+			sb.WriteString(quote + strings.ReplaceAll(strings.ReplaceAll(body, "``", "`"), quote, quote+quote) + quote)
+		case c == '"' && t.doubleQuotedStrings:
+			body = strings.ReplaceAll(body, `""`, `"`)
+			body = strings.ReplaceAll(body, `\"`, `"`)
+			sb.WriteString("'" + strings.ReplaceAll(body, "'", "''") + "'")
+		default:
+			sb.WriteString(query[i : end+1])
+		}
+		i = end
+	}
+	flush()
+
+	return sb.String(), nil
+}
+
+// closingQuote returns the index of the quote closing the quoted section starting at the index given, or -1 if it's
+// unterminated. Quotes are escaped by doubling them or, outside identifiers, with
+// backslashes.
+func closingQuote(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case query[i] == '\\' && quote != '`':
+			i++
+		case query[i] == quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
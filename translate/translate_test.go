@@ -0,0 +1,79 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package translate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMySQLToPostgres(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT `a` FROM `t1`", `SELECT "a" FROM "t1"`},
+		{`SELECT "it's", 'ifnull(' FROM t1`, `SELECT 'it''s', 'ifnull(' FROM t1`},
+		{"SELECT IFNULL(a, 0), rand() FROM t1 LIMIT 5, 10", "SELECT COALESCE(a, 0), RANDOM() FROM t1 LIMIT 10 OFFSET 5"},
+		{"SELECT CAST(a AS SIGNED), CAST(b AS UNSIGNED INTEGER) FROM t1", "SELECT CAST(a AS BIGINT), CAST(b AS BIGINT) FROM t1"},
+		{"SELECT a DIV 2 FROM t1", "SELECT a / 2 FROM t1"},
+		{`SELECT 'a\'b', "x""y"`, `SELECT 'a\'b', 'x"y'`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			translated, err := MySQLToPostgres.Translate(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, translated)
+		})
+	}
+
+	_, err := MySQLToPostgres.Translate("SELECT 'unterminated")
+	assert.Error(t, err)
+}
+
+func TestMySQLToSQLite(t *testing.T) {
+	translated, err := MySQLToSQLite.Translate("SELECT IF(`a` > 1, \"x\", 'y'), CAST(b AS SIGNED) FROM t1")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT IIF("a" > 1, "x", 'y'), CAST(b AS INTEGER) FROM t1`, translated)
+}
+
+func TestMySQLToDuckDB(t *testing.T) {
+	translated, err := MySQLToDuckDB.Translate("SELECT `a` DIV 2, \"x\", CAST(b AS SIGNED), RAND() FROM t1 LIMIT 1, 2")
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "a" // 2, 'x', CAST(b AS BIGINT), RANDOM() FROM t1 LIMIT 2 OFFSET 1`, translated)
+}
+
+func TestMySQLToClickHouse(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT `a`, \"x\", RAND() FROM t1", "SELECT `a`, 'x', randCanonical() FROM t1"},
+		{"SELECT CAST(a AS SIGNED), CAST(b AS UNSIGNED INTEGER) FROM t1", "SELECT CAST(a AS Int64), CAST(b AS UInt64) FROM t1"},
+		{"UPDATE t1 SET a = 'update' WHERE b = 1", "ALTER TABLE t1 UPDATE a = 'update' WHERE b = 1"},
+		{"DELETE FROM t1 WHERE a > 1", "ALTER TABLE t1 DELETE WHERE a > 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			translated, err := MySQLToClickHouse.Translate(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, translated)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// defaultConnection is the name of the connection that records execute on unless they name another.
+const defaultConnection = ""
+
+var (
+	// beginRegex matches statements that begin a transaction
+	beginRegex = regexp.MustCompile(`(?i)^\s*(BEGIN|START\s+TRANSACTION)\b`)
+	// endRegex matches statements that end the current transaction, but not ones that roll back to a savepoint
+	endRegex = regexp.MustCompile(`(?i)^\s*(COMMIT|ROLLBACK|END)(\s+(WORK|TRANSACTION))?\s*;?\s*$`)
+)
+
+// txnStatements are the statements executed for the txn record actions that control transactions.
+var txnStatements = map[parser.TxnAction]string{
+	parser.TxnBegin:    "BEGIN",
+	parser.TxnCommit:   "COMMIT",
+	parser.TxnRollback: "ROLLBACK",
+}
+
+// resetTransactions forgets the transaction state of all connections, for the start of a new test file.
+func (r *Runner) resetTransactions() {
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	r.activeTxns = make(map[string]bool)
+}
+
+// setTransactionActive records whether a transaction is active on the connection given.
+func (r *Runner) setTransactionActive(conn string, active bool) {
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	if r.activeTxns == nil {
+		r.activeTxns = make(map[string]bool)
+	}
+	r.activeTxns[conn] = active
+}
+
+// transactionActive returns whether a transaction is active on the connection given.
+func (r *Runner) transactionActive(conn string) bool {
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	return r.activeTxns[conn]
+}
+
+// trackTransaction updates the transaction state of the connection given after the statement record given executed
+// successfully, if it began or ended a transaction. Tracking is best-effort: statements that implicitly commit, such as
+// DDL in MySQL, aren't recognized.
+func (r *Runner) trackTransaction(conn string, record *parser.Record) {
+	switch {
+	case beginRegex.MatchString(record.Query()):
+		r.setTransactionActive(conn, true)
+	case endRegex.MatchString(record.Query()):
+		r.setTransactionActive(conn, false)
+	}
+}
+
+// executeTxn executes the txn record given, logging its result. Records that control transactions execute the
+// corresponding statement, on the reference harness too in differential mode. Records that assert the transaction
+// state compare it to the state tracked by the runner. The record is executed on the harness given, for the record's
+// connection.
+func (r *Runner) executeTxn(ctx context.Context, harness Harness, record *parser.Record) *R {
+	action := record.TxnAction()
+	conn := record.Connection()
+	if statement, ok := txnStatements[action]; ok {
+		if err := harness.ExecuteStatement(ctx, statement); err != nil {
+			logFailure(ctx, UnexpectedError, "Unexpected error %s", r.describeError(err))
+			return &R{cont: true, err: err}
+		}
+		if r.config.Reference != nil && !isGenerating(ctx) && conn == defaultConnection {
+			if err := r.config.Reference.ExecuteStatement(ctx, statement); err != nil {
+				logFailure(ctx, UnexpectedError, "Unexpected error from reference %v", err)
+				return &R{cont: true, err: err}
+			}
+		}
+
+		r.setTransactionActive(conn, action == parser.TxnBegin)
+		logResult(ctx, Ok, "")
+		return &R{cont: true}
+	}
+
+	expected := parser.TxnActive
+	if !r.transactionActive(conn) {
+		expected = parser.TxnInactive
+	}
+	if action != expected {
+		logFailure(ctx, TxnStateMismatch, "Expected transaction state %s but was %s", action, expected)
+		return &R{cont: true, err: fmt.Errorf("expected transaction state %s but was %s", action, expected)}
+	}
+
+	logResult(ctx, Ok, "")
+	return &R{cont: true}
+}
+
+// implicitTransaction returns whether the record given executes in an implicit transaction that's rolled back after
+// it, which is the case for statements and queries when RunConfig.ImplicitTransactions is set, unless the harness
+// doesn't declare transactions, the record's connection already has a transaction active, or the record controls
+// transactions itself. Async statements aren't wrapped, since they complete after later records begin.
+func (r *Runner) implicitTransaction(record *parser.Record) bool {
+	if !r.config.ImplicitTransactions || record.AsyncName() != "" ||
+		(record.Type() != parser.Statement && record.Type() != parser.Query) {
+		return false
+	}
+	if r.capabilities != nil && !r.capabilities[CapTransactions] {
+		return false
+	}
+	if beginRegex.MatchString(record.Query()) || endRegex.MatchString(record.Query()) {
+		return false
+	}
+	return !r.transactionActive(record.Connection())
+}
+
+// executeInImplicitTransaction executes the record given on the harness given between a BEGIN and a ROLLBACK, and on
+// the reference harness too in differential mode, so that the record can't change the data seen by later records.
+// Failing to roll back stops the run, even if the record was otherwise successful.
+func (r *Runner) executeInImplicitTransaction(ctx context.Context, harness Harness, record *parser.Record) *R {
+	harnesses := []Harness{harness}
+	if r.config.Reference != nil && !isGenerating(ctx) && record.Connection() == defaultConnection {
+		harnesses = append(harnesses, r.config.Reference)
+	}
+
+	for i, h := range harnesses {
+		if err := h.ExecuteStatement(ctx, txnStatements[parser.TxnBegin]); err != nil {
+			for _, begun := range harnesses[:i] {
+				begun.ExecuteStatement(ctx, txnStatements[parser.TxnRollback])
+			}
+			logFailure(ctx, UnexpectedError, "Unable to begin implicit transaction: %v", err)
+			return &R{cont: true, err: err}
+		}
+	}
+
+	res := r.executeOnConnection(ctx, harness, record)
+	for _, h := range harnesses {
+		if err := h.ExecuteStatement(ctx, txnStatements[parser.TxnRollback]); err != nil && res.err == nil {
+			res.err = fmt.Errorf("unable to roll back implicit transaction: %w", err)
+		}
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statementRecorder records the statements it executes.
+type statementRecorder struct {
+	*fakeHarness
+	mu         sync.Mutex
+	statements []string
+}
+
+func (h *statementRecorder) ExecuteStatement(ctx context.Context, statement string) error {
+	h.mu.Lock()
+	h.statements = append(h.statements, statement)
+	h.mu.Unlock()
+	return h.fakeHarness.ExecuteStatement(ctx, statement)
+}
+
+const txnTest = `txn inactive
+txn begin
+statement ok
+INSERT INTO t1 VALUES (1)
+
+txn active
+
+txn rollback
+txn inactive
+
+statement ok
+START TRANSACTION
+
+txn active
+
+statement ok
+ROLLBACK TO SAVEPOINT s1
+
+txn active
+
+statement ok
+COMMIT
+
+txn inactive
+`
+
+func TestTxnRecords(t *testing.T) {
+	harness := &statementRecorder{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, txnTest))
+
+	require.Len(t, reporter.entries, 12)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.Query)
+	}
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO t1 VALUES (1)", "ROLLBACK", "START TRANSACTION",
+		"ROLLBACK TO SAVEPOINT s1", "COMMIT"}, harness.statements)
+}
+
+func TestTxnStateMismatch(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, "statement ok\nBEGIN\n\ntxn inactive\n"))
+	})
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, TxnStateMismatch, reporter.entries[1].FailureCode)
+	assert.Equal(t, "Expected transaction state inactive but was active", reporter.entries[1].ErrorMessage)
+
+	// State doesn't carry over between files
+	reporter = &collectingReporter{}
+	runner = NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "txn begin\n"), writeTestFile(t, "txn inactive\n"))
+	require.Len(t, reporter.entries, 2)
+	assert.Equal(t, Ok, reporter.entries[1].Result)
+}
+
+func TestGenerateTxnRecords(t *testing.T) {
+	path := writeTestFile(t, txnTest)
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}))
+	runner.GenerateTestFiles(path)
+
+	generated, err := os.ReadFile(path + ".generated")
+	require.NoError(t, err)
+	assert.Equal(t, txnTest, string(generated))
+}
+
+const implicitTxnTest = `statement ok
+INSERT INTO t1 VALUES (1)
+
+query II
+SELECT a, b FROM t1
+----
+1
+2
+
+txn begin
+statement ok
+INSERT INTO t1 VALUES (2)
+
+txn commit
+statement ok
+START TRANSACTION
+
+statement ok
+COMMIT
+`
+
+func TestImplicitTransactions(t *testing.T) {
+	harness := &statementRecorder{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithImplicitTransactions(true))
+	runner.RunTestFiles(writeTestFile(t, implicitTxnTest))
+
+	require.Len(t, reporter.entries, 7)
+	for _, entry := range reporter.entries {
+		assert.Equal(t, Ok, entry.Result, entry.Query)
+	}
+	// Records in explicit transactions and records that control transactions aren't wrapped
+	assert.Equal(t, []string{"BEGIN", "INSERT INTO t1 VALUES (1)", "ROLLBACK", "BEGIN", "ROLLBACK", "BEGIN",
+		"INSERT INTO t1 VALUES (2)", "COMMIT", "START TRANSACTION", "COMMIT"}, harness.statements)
+
+	// A failure to roll back stops the run
+	harness = &statementRecorder{fakeHarness: newFakeHarness()}
+	harness.statementErrors["ROLLBACK"] = errors.New("no transaction")
+	runner = NewRunner(harness, WithOutput(&bytes.Buffer{}), WithImplicitTransactions(true))
+	assert.Panics(t, func() {
+		runner.RunTestFiles(writeTestFile(t, implicitTxnTest))
+	})
+
+	// Harnesses that don't declare transactions aren't wrapped
+	fake := newFakeHarness()
+	fake.statementErrors["BEGIN"] = errors.New("transactions unsupported")
+	reporter = &collectingReporter{}
+	runner = NewRunner(capabilityHarness{fake}, WithOutput(&bytes.Buffer{}), WithReporters(reporter),
+		WithImplicitTransactions(true))
+	runner.RunTestFiles(writeTestFile(t, "statement ok\nINSERT INTO t1 VALUES (1)\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}
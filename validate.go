@@ -0,0 +1,225 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A Problem is an inconsistency in a test file found by ValidateTestFile.
+type Problem struct {
+	// File is the path of the test file.
+	File string
+	// Line is the line of the record with the problem, or 0 for problems with the whole file, like parse errors.
+	Line int
+	// Message describes the problem.
+	Message string
+}
+
+// String returns the problem in the form path:line: message.
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// ValidateTestFiles validates the test files found under any of the paths given, as ValidateTestFile does, and returns
+// the problems found in all of them.
+func ValidateTestFiles(paths ...string) []Problem {
+	var problems []Problem
+	for _, file := range collectTestFiles(paths) {
+		problems = append(problems, ValidateTestFile(file)...)
+	}
+	return problems
+}
+
+// ValidateTestFile parses the test file given and checks its records for inconsistencies that would make them fail
+// on any engine, or pass without testing anything, without executing them:
+//   - the file can't be parsed
+//   - a statement or query has no SQL
+//   - a query's schema has types other than I, R, T and B, or its sort mode isn't nosort, rowsort, valuesort or
+//     partialsort
+//   - the number of expected results of a query, enumerated or hashed, isn't a multiple of its number of columns
+//   - the enumerated results of a sorted query without patterns aren't in sorted order, which the runner expects
+//   - an expected value is an invalid regular expression, or a value of a B-typed column isn't in lowercase hex
+//   - queries with the same label have different expected results
+//   - a restore record names a snapshot that no earlier record saved
+//   - an await record names an async statement that no earlier record started
+//
+// Returns nil if no problems are found.
+func ValidateTestFile(path string) []Problem {
+	records, err := parseTestFileSafely(path)
+	if err != nil {
+		return []Problem{{File: path, Message: err.Error()}}
+	}
+
+	v := &validator{
+		file:      path,
+		labels:    make(map[string]labeledResults),
+		snapshots: make(map[string]bool),
+		async:     make(map[string]bool),
+	}
+	for _, record := range records {
+		v.validate(record)
+	}
+	return v.problems
+}
+
+// parseTestFileSafely parses the test file given, returning an error rather than panicking for malformed records the
+// parser doesn't anticipate.
+func parseTestFileSafely(path string) (records []*parser.Record, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed test file: %v", r)
+		}
+	}()
+	return parser.ParseTestFile(path)
+}
+
+// labeledResults are the expected results of the first query with a label.
+type labeledResults struct {
+	line    int
+	results string
+}
+
+// validator accumulates the problems of the records of a test file, and the state needed to check each against the
+// records before it.
+type validator struct {
+	file      string
+	problems  []Problem
+	labels    map[string]labeledResults
+	snapshots map[string]bool
+	async     map[string]bool
+}
+
+func (v *validator) problem(record *parser.Record, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{File: v.file, Line: record.LineNum(), Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(record *parser.Record) {
+	switch record.Type() {
+	case parser.Statement:
+		if strings.TrimSpace(record.Query()) == "" {
+			v.problem(record, "statement has no SQL")
+		}
+		if record.AsyncName() != "" {
+			v.async[record.AsyncName()] = true
+		}
+	case parser.Query:
+		if strings.TrimSpace(record.Query()) == "" {
+			v.problem(record, "query has no SQL")
+		}
+		for _, set := range record.ResultSets() {
+			v.validateResults(set)
+		}
+		if record.Label() != "" && record.NumResultSets() == 1 {
+			v.validateLabel(record)
+		}
+	case parser.Snapshot:
+		v.snapshots[record.SnapshotName()] = true
+	case parser.Restore:
+		if !v.snapshots[record.SnapshotName()] {
+			v.problem(record, "restore of snapshot %s, which no earlier record saved", record.SnapshotName())
+		}
+	case parser.AwaitStatement:
+		v.validateAwait(record, record.AsyncName())
+	case parser.AwaitConflict:
+		for _, name := range record.AsyncNames() {
+			v.validateAwait(record, name)
+		}
+	}
+}
+
+// validateResults validates the schema, sort mode and expected results of a query, or of one result set of a query.
+func (v *validator) validateResults(record *parser.Record) {
+	schema := record.Schema()
+	if schema == "" || strings.Trim(schema, "IRTB") != "" {
+		v.problem(record, "invalid schema %q, expected only the types I, R, T and B", schema)
+		return
+	}
+
+	switch record.SortMode() {
+	case parser.NoSort, parser.Rowsort, parser.ValueSort, parser.PartialSort:
+	default:
+		v.problem(record, "invalid sort mode %q, expected nosort, rowsort, valuesort or partialsort", record.SortMode())
+		return
+	}
+
+	numCols := record.NumCols()
+	if record.IsHashResult() {
+		if record.NumResults()%numCols != 0 {
+			v.problem(record, "%d hashed values isn't a multiple of the %d columns of schema %s", record.NumResults(), numCols, schema)
+		}
+		return
+	}
+
+	results := record.Result()
+	if len(results)%numCols != 0 {
+		v.problem(record, "%d expected values isn't a multiple of the %d columns of schema %s", len(results), numCols, schema)
+		return
+	}
+	// Test files are validated without a run config, so binary values are checked against the default rendering of NULL
+	nullString := NewValueFormatter().NullString
+	hasPatterns := false
+	for i, value := range results {
+		if schema[i%numCols] == 'B' && !isPattern(value) && value != nullString && value != EmptyBytes &&
+			(len(value)%2 != 0 || strings.Trim(value, "0123456789abcdef") != "") {
+			v.problem(record, "binary value %s at value %d isn't in lowercase hex", value, i+1)
+		}
+		if !isPattern(value) {
+			continue
+		}
+		hasPatterns = true
+		if isRegex(value) {
+			if _, err := compileRegex(value); err != nil {
+				v.problem(record, "invalid pattern %s at value %d: %v", value, i+1, err)
+			}
+		}
+	}
+
+	// Patterns don't sort like the values they match, so the order of results with patterns isn't checked
+	if record.SortMode() != parser.NoSort && !hasPatterns {
+		sorted := sortLiterals(record, append([]string(nil), results...))
+		for i := range results {
+			if sorted[i] != results[i] {
+				v.problem(record, "expected results aren't in %s order, starting at value %d", record.SortString(), i+1)
+				break
+			}
+		}
+	}
+}
+
+// validateLabel checks that the expected results of a labeled query match those of the first query with its label.
+func (v *validator) validateLabel(record *parser.Record) {
+	results := strings.Join(record.Result(), "\n")
+	first, ok := v.labels[record.Label()]
+	if !ok {
+		v.labels[record.Label()] = labeledResults{line: record.LineNum(), results: results}
+		return
+	}
+	if first.results != results {
+		v.problem(record, "expected results differ from those of the query with the same label %s on line %d", record.Label(), first.line)
+	}
+}
+
+func (v *validator) validateAwait(record *parser.Record, name string) {
+	if !v.async[name] {
+		v.problem(record, "await of async statement %s, which no earlier record started", name)
+	}
+}
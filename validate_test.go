@@ -0,0 +1,110 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTestFile(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+CREATE TABLE t1(a INTEGER, b INTEGER)
+
+query II rowsort
+SELECT a, b FROM t1
+----
+1
+2
+3
+
+query I rowsort
+SELECT a FROM t1
+----
+2
+1
+
+query IX nosort
+SELECT a, b FROM t1
+----
+
+query II nosort
+SELECT a, b FROM t1
+----
+3 values hashing to fb1ba3e0a8cb7ea50e5a6e3d9ec4e4c9
+
+query I nosort label-1
+SELECT a FROM t1
+----
+2 values hashing to fb1ba3e0a8cb7ea50e5a6e3d9ec4e4c9
+
+query I nosort label-1
+SELECT a FROM t1 WHERE a > 0
+----
+2 values hashing to 00000000000000000000000000000000
+
+restore before
+
+awaitstatement missing
+`)
+
+	problems := ValidateTestFile(path)
+	var messages []string
+	for _, p := range problems {
+		assert.Equal(t, path, p.File)
+		messages = append(messages, p.String()[len(path)+1:])
+	}
+	assert.Equal(t, []string{
+		"5: 3 expected values isn't a multiple of the 2 columns of schema II",
+		"12: expected results aren't in rowsort order, starting at value 1",
+		"18: invalid schema \"IX\", expected only the types I, R, T and B",
+		"22: 3 hashed values isn't a multiple of the 2 columns of schema II",
+		"32: expected results differ from those of the query with the same label label-1 on line 27",
+		"36: restore of snapshot before, which no earlier record saved",
+		"38: await of async statement missing, which no earlier record started",
+	}, messages)
+}
+
+func TestValidateTestFileValid(t *testing.T) {
+	assert.Empty(t, ValidateTestFiles("testdata"))
+}
+
+func TestValidateTestFileParseErrors(t *testing.T) {
+	for _, contents := range []string{
+		"skipif\nstatement ok\nCREATE TABLE t1(a INTEGER)\n",
+		"hash-threshold many\n",
+		"query\nSELECT 1\n",
+		"statement\nCREATE TABLE t1(a INTEGER)\n",
+	} {
+		path := writeTestFile(t, contents)
+		problems := ValidateTestFile(path)
+		if assert.Len(t, problems, 1, contents) {
+			assert.Equal(t, 0, problems[0].Line)
+		}
+	}
+}
+
+func TestValidateBinaryValues(t *testing.T) {
+	path := writeTestFile(t, "query BT nosort\nSELECT a, b FROM t1\n----\n0aff\nx\n(empty)\ny\nNULL\nz\n0AFF\nw\nabc\nv\n")
+	var messages []string
+	for _, p := range ValidateTestFile(path) {
+		messages = append(messages, p.Message)
+	}
+	assert.Equal(t, []string{
+		"binary value 0AFF at value 7 isn't in lowercase hex",
+		"binary value abc at value 9 isn't in lowercase hex",
+	}, messages)
+}
@@ -0,0 +1,45 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/vitess"
+)
+
+// defaultDSN is the data source name used when SQLLOGICTEST_VITESS_DSN isn't set.
+const defaultDSN = "root@tcp(127.0.0.1:15306)/commerce"
+
+// Vitess test runner. Assumes a local vtgate serving the keyspace "commerce" to the root user without a password, as in
+// the Vitess local example, unless a different data source name is given in the SQLLOGICTEST_VITESS_DSN environment
+// variable. Will drop all tables in this keyspace routinely.
+//
+// The modes and options of the runner are those of logictest.RunEngineMain.
+func main() {
+	dsn := os.Getenv("SQLLOGICTEST_VITESS_DSN")
+	if dsn == "" {
+		dsn = defaultDSN
+	}
+	harness := vitess.NewVitessHarness(dsn)
+
+	if err := logictest.RunEngineMain(harness, os.Args[1:]); err != nil {
+		fmt.Println(err)
+		fmt.Println("Usage: go run main.go " + logictest.EngineMainUsage)
+		os.Exit(1)
+	}
+}
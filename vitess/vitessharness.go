@@ -0,0 +1,136 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vitess provides a harness for Vitess, and an example of a harness for a proxy layer that reports how queries
+// are routed. vtgate speaks the MySQL protocol, so the harness builds on the MySQL harness.
+package vitess
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+
+	logictest "github.com/andyyu2004/sqllogictest"
+	"github.com/andyyu2004/sqllogictest/mysql"
+)
+
+// dmlRegex matches the statements that VEXPLAIN QUERIES can execute.
+var dmlRegex = regexp.MustCompile(`(?i)^\s*(INSERT|UPDATE|DELETE|REPLACE)\b`)
+
+// VitessHarness is a harness for a vtgate. Routes are reported as keyspace/shard names, e.g. commerce/-80. To learn
+// their routes, DML statements are executed with VEXPLAIN QUERIES, which executes them and returns the queries sent to
+// each shard. Queries are explained the same way again after they execute, since they have no side effects. The routes
+// of other statements, such as DDL, and of DML executed as prepared statements can't be determined.
+type VitessHarness struct {
+	*mysql.MysqlHarness
+
+	mu sync.Mutex
+	// The last DML statement executed and its routes
+	lastStatement string
+	lastRoutes    []string
+}
+
+// compile check for interface compliance
+var _ logictest.Harness = &VitessHarness{}
+var _ logictest.RoutingHarness = &VitessHarness{}
+var _ logictest.MultiConnectionHarness = &VitessHarness{}
+
+// NewVitessHarness returns a new Vitess test harness for the data source name of a vtgate given, in the form accepted by
+// the MySQL driver. Panics if it cannot open a connection using the DSN.
+func NewVitessHarness(dsn string) *VitessHarness {
+	return &VitessHarness{MysqlHarness: mysql.NewMysqlHarness(dsn)}
+}
+
+// See MultiConnectionHarness.NewConnection. Each connection records the routes of its own statements.
+func (h *VitessHarness) NewConnection() (logictest.Harness, error) {
+	session, err := h.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	return &VitessHarness{MysqlHarness: session}, nil
+}
+
+// See Harness.EngineStr
+func (h *VitessHarness) EngineStr() string {
+	return "vitess"
+}
+
+// See Harness.ExecuteStatement
+func (h *VitessHarness) ExecuteStatement(ctx context.Context, statement string) error {
+	if !dmlRegex.MatchString(statement) {
+		return h.MysqlHarness.ExecuteStatement(ctx, statement)
+	}
+
+	routes, err := h.vexplainQueries(ctx, statement)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastStatement, h.lastRoutes = statement, routes
+	return err
+}
+
+// See WarningHarness.ExecuteStatementWarnings. The warnings of DML statements aren't determined, since they execute
+// with VEXPLAIN QUERIES to record their routes.
+func (h *VitessHarness) ExecuteStatementWarnings(ctx context.Context, statement string) ([]logictest.Warning, error) {
+	if dmlRegex.MatchString(statement) {
+		return nil, h.ExecuteStatement(ctx, statement)
+	}
+	return h.MysqlHarness.ExecuteStatementWarnings(ctx, statement)
+}
+
+// See RoutingHarness.Routes
+func (h *VitessHarness) Routes(ctx context.Context, query string) ([]string, error) {
+	if !dmlRegex.MatchString(query) {
+		return h.vexplainQueries(ctx, query)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if query != h.lastStatement {
+		return nil, fmt.Errorf("routes of %s weren't recorded when it executed", query)
+	}
+	return h.lastRoutes, nil
+}
+
+// vexplainQueries executes the statement given with VEXPLAIN QUERIES, and returns the keyspaces and shards of the
+// queries vtgate sent for it.
+func (h *VitessHarness) vexplainQueries(ctx context.Context, statement string) ([]string, error) {
+	rows, err := h.DB().QueryContext(ctx, "VEXPLAIN QUERIES "+statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return routes(rows)
+}
+
+// routes reads the routes from the output of VEXPLAIN QUERIES, which has a row with the columns #, keyspace, shard
+// and query for each query sent to a shard.
+func routes(rows *sql.Rows) ([]string, error) {
+	var routes []string
+	for rows.Next() {
+		var n, keyspace, shard, query sql.NullString
+		if err := rows.Scan(&n, &keyspace, &shard, &query); err != nil {
+			return nil, err
+		}
+		routes = append(routes, keyspace.String+"/"+shard.String)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return routes, nil
+}
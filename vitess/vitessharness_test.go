@@ -0,0 +1,42 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vitess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDMLRegex(t *testing.T) {
+	assert.True(t, dmlRegex.MatchString("INSERT INTO t1 VALUES (1)"))
+	assert.True(t, dmlRegex.MatchString("  update t1 SET a = 1"))
+	assert.True(t, dmlRegex.MatchString("DELETE FROM t1"))
+	assert.True(t, dmlRegex.MatchString("REPLACE INTO t1 VALUES (1)"))
+	assert.False(t, dmlRegex.MatchString("SELECT * FROM t1"))
+	assert.False(t, dmlRegex.MatchString("CREATE TABLE inserts (a INT)"))
+}
+
+func TestRoutesNotRecorded(t *testing.T) {
+	h := NewVitessHarness("root@tcp(127.0.0.1:15306)/commerce")
+	_, err := h.Routes(context.Background(), "INSERT INTO t1 VALUES (1)")
+	assert.Error(t, err)
+
+	h.lastStatement, h.lastRoutes = "INSERT INTO t1 VALUES (1)", []string{"commerce/-80"}
+	routes, err := h.Routes(context.Background(), "INSERT INTO t1 VALUES (1)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"commerce/-80"}, routes)
+}
@@ -0,0 +1,55 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// warmUp executes the configured warm-up queries on the harness, once it's initialized for a test file, discarding
+// their results and errors.
+func (r *Runner) warmUp() {
+	if len(r.config.WarmupQueries) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	for _, query := range r.config.WarmupQueries {
+		r.harness.ExecuteQuery(ctx, query)
+	}
+}
+
+// shouldWarmUp returns whether the record given should be warmed up before it's run, given the number of queries of
+// its test file warmed up so far. Only queries run on the default connection are warmed up.
+func (r *Runner) shouldWarmUp(record *parser.Record, warmedUp int) bool {
+	return warmedUp < r.config.WarmupRecords && record.Type() == parser.Query &&
+		record.Connection() == defaultConnection && r.shouldExecute(r.harness, record)
+}
+
+// warmUpQuery executes the query of the record given on the harness without verifying it, discarding its results and
+// any error, so that the record's own execution isn't penalized by e.g. the engine compiling or caching its plan.
+func (r *Runner) warmUpQuery(record *parser.Record) {
+	translated, err := r.translate(record)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.recordTimeout(record))
+	defer cancel()
+	r.harness.ExecuteQuery(ctx, translated.Query())
+}
@@ -0,0 +1,77 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryRecordingHarness records the queries it executes.
+type queryRecordingHarness struct {
+	*fakeHarness
+	queries []string
+}
+
+func (h *queryRecordingHarness) ExecuteQuery(ctx context.Context, statement string) (string, []string, error) {
+	h.queries = append(h.queries, statement)
+	return h.fakeHarness.ExecuteQuery(ctx, statement)
+}
+
+func TestWarmup(t *testing.T) {
+	path := writeTestFile(t, `statement ok
+INSERT INTO t1 VALUES (1)
+
+skipif fake
+query I nosort
+SELECT a FROM t1 WHERE a > 1
+----
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+
+query R nosort
+SELECT 1.0 / 3
+----
+0.333333333333333
+
+query II nosort
+SELECT a, b FROM t1
+----
+1
+2
+`)
+	harness := &queryRecordingHarness{fakeHarness: newFakeHarness()}
+	reporter := &collectingReporter{}
+	runner := NewRunner(harness, WithOutput(&bytes.Buffer{}), WithReporters(reporter), WithWarmupRecords(2),
+		WithWarmupQueries("SELECT 1"))
+	runner.RunTestFiles(path)
+
+	// The warm-up queries run first, and the first two queries that run are executed twice, without failing on the
+	// warm-up query that doesn't exist
+	assert.Equal(t, []string{"SELECT 1", "SELECT a, b FROM t1", "SELECT a, b FROM t1", "SELECT 1.0 / 3",
+		"SELECT 1.0 / 3", "SELECT a, b FROM t1"}, harness.queries)
+	require.Len(t, reporter.entries, 5)
+	for _, entry := range reporter.entries[2:] {
+		assert.Equal(t, Ok, entry.Result)
+	}
+}
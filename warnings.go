@@ -0,0 +1,96 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andyyu2004/sqllogictest/parser"
+)
+
+// A WarningHarness is a Harness that can report the warnings produced by the statements it executes. Harnesses that
+// implement this interface can run statements that assert their warnings, written e.g.:
+//
+//	warning 1265 Data truncated for column 'a' at row 1
+//	statement ok
+//	INSERT INTO t1 VALUES ('abc')
+//
+//	warnings 0
+//	statement ok
+//	INSERT INTO t1 VALUES ('a')
+//
+// A warning directive gives the code and optionally the message of each warning expected, in order, and a warnings
+// directive gives only their number. For harnesses that don't implement this interface, these directives are ignored.
+type WarningHarness interface {
+	Harness
+
+	// ExecuteStatementWarnings executes the statement given like ExecuteStatement, and returns the warnings it
+	// produced. It returns nil warnings if they can't be determined for the statement, in which case they aren't
+	// verified.
+	ExecuteStatementWarnings(ctx context.Context, statement string) ([]Warning, error)
+}
+
+// Warning is a warning produced by a statement.
+type Warning struct {
+	// Code is the engine's code for the warning.
+	Code string
+	// Message is the text of the warning.
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Code + " " + w.Message
+}
+
+// matches returns whether this warning is the one expected, which matches any message if it has none. Messages are
+// compared after applying the normalizer given to both.
+func (w Warning) matches(expected parser.Warning, normalize Normalizer) bool {
+	return strings.EqualFold(w.Code, expected.Code) &&
+		(expected.Message == "" || normalize(w.Message, MessageText) == normalize(expected.Message, MessageText))
+}
+
+// verifyWarnings verifies that the statement record given, which just executed successfully, produced the warnings
+// it expects, if it asserts any and they were determined, logging any failure.
+func (r *Runner) verifyWarnings(ctx context.Context, record *parser.Record, warnings []Warning) error {
+	if !record.ExpectsWarnings() || warnings == nil {
+		return nil
+	}
+
+	if len(warnings) != record.WarningCount() {
+		logFailure(ctx, WarningMismatch, "Expected warnings count %d but got %d: %s", record.WarningCount(),
+			len(warnings), describeWarnings(warnings))
+		return fmt.Errorf("expected warnings count %d but got %d", record.WarningCount(), len(warnings))
+	}
+
+	for i, expected := range record.Warnings() {
+		if !warnings[i].matches(expected, r.config.normalize) {
+			logFailure(ctx, WarningMismatch, "Expected warnings %s but got %s", describeWarnings(record.Warnings()),
+				describeWarnings(warnings))
+			return fmt.Errorf("expected warning %s but got %s", expected, warnings[i])
+		}
+	}
+	return nil
+}
+
+// describeWarnings returns a description of the warnings given for failure messages.
+func describeWarnings[W fmt.Stringer](warnings []W) string {
+	descriptions := make([]string, len(warnings))
+	for i, w := range warnings {
+		descriptions[i] = w.String()
+	}
+	return "[" + strings.Join(descriptions, "; ") + "]"
+}
@@ -0,0 +1,111 @@
+// Copyright 2019-2020 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logictest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// warningHarness reports fixed warnings for some statements.
+type warningHarness struct {
+	*fakeHarness
+	warnings map[string][]Warning
+}
+
+var _ WarningHarness = warningHarness{}
+
+func (h warningHarness) ExecuteStatementWarnings(ctx context.Context, statement string) ([]Warning, error) {
+	if err := h.ExecuteStatement(ctx, statement); err != nil {
+		return nil, err
+	}
+	return append([]Warning{}, h.warnings[statement]...), nil
+}
+
+func newWarningHarness() warningHarness {
+	return warningHarness{fakeHarness: newFakeHarness(), warnings: map[string][]Warning{
+		"INSERT INTO t1 VALUES ('abc')": {
+			{Code: "1265", Message: "Data truncated for column 'a' at row 1"},
+			{Code: "1264", Message: "Out of range value for column 'b' at row 1"},
+		},
+	}}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		message  string
+	}{
+		{
+			name:     "warnings",
+			contents: "warning 1265 Data truncated for column 'a' at row 1\nwarning 1264\nstatement ok\nINSERT INTO t1 VALUES ('abc')\n",
+		},
+		{
+			name:     "count",
+			contents: "warnings 2\nstatement ok\nINSERT INTO t1 VALUES ('abc')\n",
+		},
+		{
+			name:     "no warnings",
+			contents: "warnings 0\nstatement ok\nINSERT INTO t1 VALUES (1)\n",
+		},
+		{
+			name:     "count mismatch",
+			contents: "warnings 0\nstatement ok\nINSERT INTO t1 VALUES ('abc')\n",
+			message: "Expected warnings count 0 but got 2: [1265 Data truncated for column 'a' at row 1; " +
+				"1264 Out of range value for column 'b' at row 1]",
+		},
+		{
+			name:     "message mismatch",
+			contents: "warning 1265 Data truncated for column 'b' at row 1\nwarning 1264\nstatement ok\nINSERT INTO t1 VALUES ('abc')\n",
+			message: "Expected warnings [1265 Data truncated for column 'b' at row 1; 1264] but got " +
+				"[1265 Data truncated for column 'a' at row 1; 1264 Out of range value for column 'b' at row 1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter := &collectingReporter{}
+			runner := NewRunner(newWarningHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+			run := func() { runner.RunTestFiles(writeTestFile(t, tt.contents)) }
+			if tt.message == "" {
+				run()
+			} else {
+				assert.Panics(t, run)
+			}
+
+			require.Len(t, reporter.entries, 1)
+			if tt.message == "" {
+				assert.Equal(t, Ok, reporter.entries[0].Result)
+			} else {
+				assert.Equal(t, WarningMismatch, reporter.entries[0].FailureCode)
+				assert.Equal(t, tt.message, reporter.entries[0].ErrorMessage)
+				assert.Equal(t, WarningMismatch, classifyFailure(reporter.entries[0].ErrorMessage))
+			}
+		})
+	}
+}
+
+func TestWarningsIgnoredWithoutWarningHarness(t *testing.T) {
+	reporter := &collectingReporter{}
+	runner := NewRunner(newFakeHarness(), WithOutput(&bytes.Buffer{}), WithReporters(reporter))
+	runner.RunTestFiles(writeTestFile(t, "warnings 3\nstatement ok\nINSERT INTO t1 VALUES (1)\n"))
+	require.Len(t, reporter.entries, 1)
+	assert.Equal(t, Ok, reporter.entries[0].Result)
+}